@@ -0,0 +1,138 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"gorm.io/gorm"
+)
+
+type Kind string
+
+const (
+	KindBadRequest   Kind = "bad-request"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not-found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindInternal     Kind = "internal"
+
+	KindPreconditionFailed   Kind = "precondition-failed"
+	KindPreconditionRequired Kind = "precondition-required"
+	KindPaymentFailed        Kind = "payment-failed"
+)
+
+// Error is a domain error that knows which HTTP status it maps to.
+// Handlers return it and the central error handler renders it as
+// application/problem+json.
+type Error struct {
+	Kind   Kind
+	Status int
+	Detail string
+	Fields []models.FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Detail, e.Err)
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Title is the short, human-readable summary of the problem type.
+func (e *Error) Title() string {
+	return http.StatusText(e.Status)
+}
+
+// Type is the problem type URI reported to clients. Errors without a
+// specific kind use "about:blank" as RFC 7807 recommends.
+func (e *Error) Type() string {
+	if e.Kind == "" {
+		return "about:blank"
+	}
+	return "/problems/" + string(e.Kind)
+}
+
+func New(kind Kind, status int, detail string) *Error {
+	return &Error{Kind: kind, Status: status, Detail: detail}
+}
+
+func BadRequest(detail string) *Error {
+	return New(KindBadRequest, http.StatusBadRequest, detail)
+}
+
+func Unauthorized(detail string) *Error {
+	return New(KindUnauthorized, http.StatusUnauthorized, detail)
+}
+
+func Forbidden(detail string) *Error {
+	return New(KindForbidden, http.StatusForbidden, detail)
+}
+
+// NotFound reports that the named resource (e.g. "Tour") does not exist.
+func NotFound(resource string) *Error {
+	return New(KindNotFound, http.StatusNotFound, resource+" not found")
+}
+
+func Conflict(detail string) *Error {
+	return New(KindConflict, http.StatusConflict, detail)
+}
+
+// PreconditionFailed reports that a conditional request (If-Match) no longer
+// matches the current state of the resource.
+func PreconditionFailed(detail string) *Error {
+	return New(KindPreconditionFailed, http.StatusPreconditionFailed, detail)
+}
+
+// PreconditionRequired reports that a write was sent without If-Match.
+func PreconditionRequired(detail string) *Error {
+	return New(KindPreconditionRequired, http.StatusPreconditionRequired, detail)
+}
+
+// PaymentFailed reports that the payment provider declined a charge. The
+// detail comes from the provider and is meant for the customer.
+func PaymentFailed(detail string) *Error {
+	return New(KindPaymentFailed, http.StatusPaymentRequired, detail)
+}
+
+// Validation reports invalid input together with the offending fields.
+func Validation(detail string, fields ...models.FieldError) *Error {
+	e := New(KindValidation, http.StatusBadRequest, detail)
+	e.Fields = fields
+	return e
+}
+
+// Internal wraps an unexpected error. Only detail is shown to clients.
+func Internal(detail string, err error) *Error {
+	e := New(KindInternal, http.StatusInternalServerError, detail)
+	e.Err = err
+	return e
+}
+
+// FromDB maps a database error for the named resource onto a domain error.
+// It returns nil when err is nil and passes domain errors through unchanged.
+func FromDB(err error, resource string) error {
+	var appErr *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return NotFound(resource)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return Conflict(resource + " already exists")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return Conflict(resource + " is referenced by other records")
+	default:
+		return Internal("Failed to process "+resource, err)
+	}
+}
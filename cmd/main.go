@@ -5,9 +5,11 @@ import (
 
 	"github.com/Twisac-Solutions/tours-backend/config"
 	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/middlewares"
 
 	// _ "github.com/Twisac-Solutions/tours-backend/docs"
 	"github.com/Twisac-Solutions/tours-backend/routes"
+	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/garrettladley/fiberpaginate/v2"
 	"github.com/gofiber/fiber/v2"
@@ -22,22 +24,48 @@ import (
 // @BasePath /
 func main() {
 	config.InitConfig()
+	if !utils.IsCurrencyCode(config.BaseCurrency) {
+		log.Fatalf("BASE_CURRENCY %q is not an ISO 4217 currency code", config.BaseCurrency)
+	}
+	if config.DataEncryptionKey == "" {
+		log.Fatal("DATA_ENCRYPTION_KEY is required: 32 random bytes, base64-encoded (openssl rand -base64 32)")
+	}
+	if err := utils.InitEncryption(config.DataEncryptionKey); err != nil {
+		log.Fatalf("Invalid DATA_ENCRYPTION_KEY: %v", err)
+	}
 	database.ConnectDB()
 	database.SeedSuperAdmin()
 	database.MigrateDB()
+	services.StartRatingReconciler(config.RatingReconcileInterval)
 
 	err := utils.InitCloudinary()
 	if err != nil {
 		log.Fatalf("Failed to initialize Cloudinary: %v", err)
 	}
+	if err := services.InitPayments(); err != nil {
+		log.Fatalf("Failed to initialize payments: %v", err)
+	}
+	services.StartWebhookRetrier(config.WebhookRetryInterval)
+	if err := services.InitNotifications(); err != nil {
+		log.Fatalf("Failed to initialize notifications: %v", err)
+	}
+	services.StartNotificationDispatcher(config.NotificationDispatchInterval)
+	services.StartWaitlistExpirer(config.WaitlistExpiryInterval)
+	services.StartHoldSweeper(config.HoldSweepInterval)
+	services.StartBookingCompleter(config.BookingCompletionInterval)
+	services.StartDataExporter(config.DataExportInterval)
+	services.StartAccountPurger(config.AccountPurgeInterval)
 
 	app := fiber.New(fiber.Config{
-		BodyLimit: 10 * 1024 * 1024, // 10MB limit
+		BodyLimit:    10 * 1024 * 1024, // 10MB limit
+		ErrorHandler: middlewares.ErrorHandler,
 	})
+	app.Use(middlewares.Recover())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000,https://tours-dashboard-pi.vercel.app", // or your Next.js URL
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, If-Match, If-None-Match, Idempotency-Key",
+		ExposeHeaders:    "ETag",
 		AllowCredentials: true,
 	}))
 	// app.Get("/swagger/*", fiberSwagger.WrapHandler)
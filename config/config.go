@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -19,6 +22,82 @@ var (
 
 	// JWT secret key
 	JWTSecret string
+	// Key encrypting personal data at rest: 32 bytes, base64-encoded
+	// (required, e.g. from `openssl rand -base64 32`)
+	DataEncryptionKey string
+
+	// Number of reports that queues a review for moderation (default 3)
+	ReviewFlagThreshold int
+
+	// How often stored ratings are checked against the reviews (default 1h,
+	// 0 disables the check)
+	RatingReconcileInterval time.Duration
+
+	// Currency exchange rates are quoted against (default USD)
+	BaseCurrency string
+
+	// Payment gateway: "mock" (default) or "stripe"
+	PaymentProvider string
+	// Stripe API secret key and base URL (default the live Stripe API)
+	StripeSecretKey string
+	StripeAPIURL    string
+	// Secret used to verify payment webhook signatures
+	PaymentWebhookSecret string
+	// How often failed payment webhooks are retried (default 1m, 0 disables
+	// retries)
+	WebhookRetryInterval time.Duration
+
+	// Notification channel: "log" (default) or "smtp"
+	NotificationChannel string
+	// SMTP relay and the address notifications are sent from
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+	// How often pending notifications are delivered (default 30s, 0
+	// disables delivery)
+	NotificationDispatchInterval time.Duration
+
+	// How long waitlist offers hold seats (default 24h)
+	WaitlistHoldWindow time.Duration
+	// How often lapsed waitlist offers are expired (default 1m, 0 disables
+	// expiry)
+	WaitlistExpiryInterval time.Duration
+
+	// How long an unpaid booking holds its seats (default 15m)
+	SeatHoldDuration time.Duration
+	// How often expired seat holds are released (default 1m, 0 disables
+	// the sweeper)
+	HoldSweepInterval time.Duration
+	// How often confirmed bookings whose trip is over are completed
+	// (default 1h, 0 disables completion)
+	BookingCompletionInterval time.Duration
+
+	// The business named on invoices and its tax registration
+	InvoiceSellerName    string
+	InvoiceSellerAddress string
+	InvoiceSellerTaxID   string
+	// Percentage of tax included in prices, broken out on invoices
+	// (default 0)
+	InvoiceTaxRate float64
+
+	// How long users wait between username changes (default 720h)
+	UsernameChangeCooldown time.Duration
+
+	// Directory data exports are written to (default "exports")
+	DataExportDir string
+	// How long a data export can be downloaded (default 168h)
+	DataExportTTL time.Duration
+	// How often requested data exports are built and lapsed ones removed
+	// (default 30s, 0 disables the exporter)
+	DataExportInterval time.Duration
+	// How long a deleted account can still be restored before its personal
+	// data is purged (default 720h)
+	AccountDeletionGracePeriod time.Duration
+	// How often accounts due for deletion are purged (default 1h, 0
+	// disables purging)
+	AccountPurgeInterval time.Duration
 )
 
 func InitConfig() {
@@ -42,4 +121,109 @@ func InitConfig() {
 	if JWTSecret == "" {
 		JWTSecret = "secret"
 	}
+	DataEncryptionKey = os.Getenv("DATA_ENCRYPTION_KEY")
+
+	ReviewFlagThreshold = 3
+	if v, err := strconv.Atoi(os.Getenv("REVIEW_FLAG_THRESHOLD")); err == nil && v > 0 {
+		ReviewFlagThreshold = v
+	}
+
+	RatingReconcileInterval = time.Hour
+	if v, err := time.ParseDuration(os.Getenv("RATING_RECONCILE_INTERVAL")); err == nil && v >= 0 {
+		RatingReconcileInterval = v
+	}
+
+	BaseCurrency = strings.ToUpper(os.Getenv("BASE_CURRENCY"))
+	if BaseCurrency == "" {
+		BaseCurrency = "USD"
+	}
+
+	PaymentProvider = os.Getenv("PAYMENT_PROVIDER")
+	if PaymentProvider == "" {
+		PaymentProvider = "mock"
+	}
+	StripeSecretKey = os.Getenv("STRIPE_SECRET_KEY")
+	StripeAPIURL = os.Getenv("STRIPE_API_URL")
+	PaymentWebhookSecret = os.Getenv("PAYMENT_WEBHOOK_SECRET")
+
+	WebhookRetryInterval = time.Minute
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_INTERVAL")); err == nil && v >= 0 {
+		WebhookRetryInterval = v
+	}
+
+	NotificationChannel = os.Getenv("NOTIFICATION_CHANNEL")
+	if NotificationChannel == "" {
+		NotificationChannel = "log"
+	}
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = 587
+	if v, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && v > 0 {
+		SMTPPort = v
+	}
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	MailFrom = os.Getenv("MAIL_FROM")
+
+	NotificationDispatchInterval = 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("NOTIFICATION_DISPATCH_INTERVAL")); err == nil && v >= 0 {
+		NotificationDispatchInterval = v
+	}
+
+	WaitlistHoldWindow = 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("WAITLIST_HOLD_WINDOW")); err == nil && v > 0 {
+		WaitlistHoldWindow = v
+	}
+	WaitlistExpiryInterval = time.Minute
+	if v, err := time.ParseDuration(os.Getenv("WAITLIST_EXPIRY_INTERVAL")); err == nil && v >= 0 {
+		WaitlistExpiryInterval = v
+	}
+
+	SeatHoldDuration = 15 * time.Minute
+	if v, err := time.ParseDuration(os.Getenv("SEAT_HOLD_DURATION")); err == nil && v > 0 {
+		SeatHoldDuration = v
+	}
+	HoldSweepInterval = time.Minute
+	if v, err := time.ParseDuration(os.Getenv("HOLD_SWEEP_INTERVAL")); err == nil && v >= 0 {
+		HoldSweepInterval = v
+	}
+	BookingCompletionInterval = time.Hour
+	if v, err := time.ParseDuration(os.Getenv("BOOKING_COMPLETION_INTERVAL")); err == nil && v >= 0 {
+		BookingCompletionInterval = v
+	}
+
+	InvoiceSellerName = os.Getenv("INVOICE_SELLER_NAME")
+	if InvoiceSellerName == "" {
+		InvoiceSellerName = "Twisac Solutions"
+	}
+	InvoiceSellerAddress = os.Getenv("INVOICE_SELLER_ADDRESS")
+	InvoiceSellerTaxID = os.Getenv("INVOICE_SELLER_TAX_ID")
+	if v, err := strconv.ParseFloat(os.Getenv("INVOICE_TAX_RATE"), 64); err == nil && v >= 0 && v < 100 {
+		InvoiceTaxRate = v
+	}
+
+	UsernameChangeCooldown = 30 * 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("USERNAME_CHANGE_COOLDOWN")); err == nil && v >= 0 {
+		UsernameChangeCooldown = v
+	}
+
+	DataExportDir = os.Getenv("DATA_EXPORT_DIR")
+	if DataExportDir == "" {
+		DataExportDir = "exports"
+	}
+	DataExportTTL = 7 * 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("DATA_EXPORT_TTL")); err == nil && v > 0 {
+		DataExportTTL = v
+	}
+	DataExportInterval = 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("DATA_EXPORT_INTERVAL")); err == nil && v >= 0 {
+		DataExportInterval = v
+	}
+	AccountDeletionGracePeriod = 30 * 24 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")); err == nil && v >= 0 {
+		AccountDeletionGracePeriod = v
+	}
+	AccountPurgeInterval = time.Hour
+	if v, err := time.ParseDuration(os.Getenv("ACCOUNT_PURGE_INTERVAL")); err == nil && v >= 0 {
+		AccountPurgeInterval = v
+	}
 }
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/responses"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestMyDataExport godoc
+// @Summary      Download my data
+// @Description  Asks for a ZIP of JSON files with the logged-in user's profile, bookings, reviews, favourites and
+// @Description  login sessions. It is built in the background; the user is notified with the download link when it
+// @Description  is ready, and it can be downloaded for 7 days by default.
+// @Tags         user_account
+// @Produce      json
+// @Success      202  {object}  responses.DataExportResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/data-exports [post]
+func RequestMyDataExport(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	export, err := services.RequestDataExport(userID, userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(responses.ToDataExportResponse(*export, myDataExportURL(export)))
+}
+
+// GetMyDataExports godoc
+// @Summary      List my data exports
+// @Description  Returns the logged-in user's data exports, newest first
+// @Tags         user_account
+// @Produce      json
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Success      200  {object}  object{data=[]responses.DataExportResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      401  {object}  models.ErrorResponse
+// @Router       /api/user/data-exports [get]
+func GetMyDataExports(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	exports, total, err := services.GetUserDataExports(c, userID)
+	if err != nil {
+		return apperrors.FromDB(err, "Data export")
+	}
+	response := make([]responses.DataExportResponse, len(exports))
+	for i := range exports {
+		response[i] = responses.ToDataExportResponse(exports[i], myDataExportURL(&exports[i]))
+	}
+	return c.JSON(utils.PaginationResponse(c, response, total))
+}
+
+// GetMyDataExport godoc
+// @Summary      Get one of my data exports
+// @Description  Returns a data export of the logged-in user, with its download link once it is ready
+// @Tags         user_account
+// @Produce      json
+// @Param        id   path      string  true  "Data export ID"
+// @Success      200  {object}  responses.DataExportResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/data-exports/{id} [get]
+func GetMyDataExport(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	export, err := services.GetUserDataExport(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(responses.ToDataExportResponse(*export, myDataExportURL(export)))
+}
+
+// DownloadMyDataExport godoc
+// @Summary      Download one of my data exports
+// @Description  Downloads the ZIP of a ready data export of the logged-in user
+// @Tags         user_account
+// @Produce      application/zip
+// @Param        id   path  string  true  "Data export ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/data-exports/{id}/download [get]
+func DownloadMyDataExport(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	return sendDataExport(c, userID, id)
+}
+
+// DeleteMyAccount godoc
+// @Summary      Delete my account
+// @Description  Schedules the logged-in user's account for deletion after a grace period (30 days by default) and
+// @Description  logs the user out everywhere. Logging in again, the user can cancel the deletion until then.
+// @Description  Afterwards personal data is erased, reviews stay up anonymously and bookings, payments and invoices
+// @Description  are kept as financial records. Accounts with a password must confirm it.
+// @Tags         user_account
+// @Accept       json
+// @Produce      json
+// @Param        confirmation  body      requests.DeleteAccountRequest  true  "Password confirmation"
+// @Success      202           {object}  object{user=responses.UserResponse}
+// @Failure      400           {object}  models.ErrorResponse
+// @Failure      401           {object}  models.ErrorResponse
+// @Failure      409           {object}  models.ErrorResponse
+// @Router       /api/user/account/deletion [post]
+func DeleteMyAccount(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	user, err := services.GetUserByID(userID.String())
+	if err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+	if user.Password != "" && !utils.CheckPasswordHash(req.Password, user.Password) {
+		return apperrors.Validation("Validation failed", models.FieldError{Field: "password", Message: "is incorrect"})
+	}
+	user, err = services.ScheduleAccountDeletion(userID, config.AccountDeletionGracePeriod)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+// CancelMyAccountDeletion godoc
+// @Summary      Keep my account
+// @Description  Cancels the scheduled deletion of the logged-in user's account
+// @Tags         user_account
+// @Produce      json
+// @Success      200  {object}  object{user=responses.UserResponse}
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/account/deletion [delete]
+func CancelMyAccountDeletion(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	user, err := services.CancelAccountDeletion(userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+func myDataExportURL(export *models.DataExport) string {
+	return "/api/user/data-exports/" + export.ID.String() + "/download"
+}
+
+// sendDataExport sends the ZIP of a ready data export of the user.
+func sendDataExport(c *fiber.Ctx, userID, id uuid.UUID) error {
+	export, err := services.GetDataExportDownload(userID, id)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, services.DataExportFilename(export)))
+	if err := c.SendFile(export.FilePath); err != nil {
+		return apperrors.Internal("Failed to send data export", err)
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	return nil
+}
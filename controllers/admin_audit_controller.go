@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAuditLogs godoc
+// @Summary      List audit logs
+// @Description  Returns admin write operations, newest first (super admin only)
+// @Tags         admin_audit
+// @Produce      json
+// @Param        page         query  integer  false  "Page number (default: 1)"
+// @Param        limit        query  integer  false  "Limit per page (default: 10)"
+// @Param        actor_id     query  string   false  "Filter by actor ID"
+// @Param        action       query  string   false  "Filter by action (create, update, delete)"
+// @Param        entity_type  query  string   false  "Filter by entity type (tours, users, ...)"
+// @Param        entity_id    query  string   false  "Filter by entity ID"
+// @Param        from         query  string   false  "Only entries at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param        to           query  string   false  "Only entries at or before this date (YYYY-MM-DD or RFC 3339)"
+// @Success      200  {object}  object{data=[]models.AuditLog,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/audit-logs [get]
+func GetAuditLogs(c *fiber.Ctx) error {
+	logs, totalCount, err := services.GetAuditLogs(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve audit logs", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, logs, totalCount))
+}
+
+// ExportAuditLogs godoc
+// @Summary      Export audit logs as CSV
+// @Description  Streams every audit log entry matching the filters as CSV (super admin only)
+// @Tags         admin_audit
+// @Produce      text/csv
+// @Param        actor_id     query  string  false  "Filter by actor ID"
+// @Param        action       query  string  false  "Filter by action (create, update, delete)"
+// @Param        entity_type  query  string  false  "Filter by entity type (tours, users, ...)"
+// @Param        entity_id    query  string  false  "Filter by entity ID"
+// @Param        from         query  string  false  "Only entries at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param        to           query  string  false  "Only entries at or before this date (YYYY-MM-DD or RFC 3339)"
+// @Success      200  {string}  string  "CSV file"
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/audit-logs/export [get]
+func ExportAuditLogs(c *fiber.Ctx) error {
+	logs, err := services.ExportAuditLogs(c)
+	if err != nil {
+		return apperrors.Internal("Failed to export audit logs", err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="audit-logs-%s.csv"`, time.Now().Format("20060102-150405")))
+
+	w := csv.NewWriter(c)
+	w.Write([]string{"id", "created_at", "actor_id", "actor_role", "action", "entity_type", "entity_id", "method", "path", "ip", "changes"})
+	for _, entry := range logs {
+		w.Write([]string{
+			entry.ID.String(),
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.ActorID.String(),
+			entry.ActorRole,
+			string(entry.Action),
+			entry.EntityType,
+			entry.EntityID,
+			entry.Method,
+			entry.Path,
+			entry.IP,
+			entry.Changes,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -1,16 +1,13 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
-type AdminLoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
-
 // AdminLoginResponse represents the admin login response payload.
 type AdminLoginResponse struct {
 	ID             string `json:"id"`
@@ -27,29 +24,34 @@ type AdminLoginResponse struct {
 // @Tags         admin_auth
 // @Accept       json
 // @Produce      json
-// @Param        credentials  body      AdminLoginRequest  true  "Admin login credentials"
+// @Param        credentials  body      requests.AdminLoginRequest  true  "Admin login credentials"
 // @Success      200  {object}  AdminLoginResponse
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      401  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/login [post]
 func AdminLogin(c *fiber.Ctx) error {
-	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
+	var input requests.AdminLoginRequest
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+		return apperrors.BadRequest("Invalid request")
+	}
+	if err := requests.Validate(&input); err != nil {
+		return err
 	}
 
 	admin, err := services.FindUserByEmail(input.Email)
 	if err != nil || !utils.CheckPasswordHash(input.Password, admin.Password) {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid credentials"})
+		return apperrors.Unauthorized("Invalid credentials")
 	}
 
-	token, err := utils.GenerateJWTRole(admin.ID.String(), "admin")
+	// Super admins keep their role in the token so they can reach superadmin-only routes
+	role := "admin"
+	if admin.Role == "superadmin" {
+		role = admin.Role
+	}
+	token, err := utils.GenerateJWTRole(admin.ID.String(), role)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
+		return apperrors.Internal("Failed to generate token", err)
 	}
 
 	return c.JSON(fiber.Map{"token": token, "user": &AdminLoginResponse{
@@ -1,43 +1,48 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 // UpdateAdminPassword allows the current admin to update their password.
 func UpdateAdminPassword(c *fiber.Ctx) error {
-	type Request struct {
-		OldPassword string `json:"oldPassword"`
-		NewPassword string `json:"newPassword"`
-	}
-
-	var body Request
+	var body requests.UpdatePasswordRequest
 	if err := c.BodyParser(&body); err != nil {
-		return fiber.ErrBadRequest
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&body); err != nil {
+		return err
 	}
 
-	admin := c.Locals("admin").(*models.User)
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	admin, err := services.GetUserByID(adminID.String())
+	if err != nil {
+		return apperrors.FromDB(err, "Admin")
+	}
 
 	if !utils.CheckPasswordHash(body.OldPassword, admin.Password) {
-		return fiber.NewError(fiber.StatusUnauthorized, "Old password is incorrect")
+		return apperrors.Unauthorized("Old password is incorrect")
 	}
 
 	hashed := utils.HashPassword(body.NewPassword)
 	admin.Password = hashed
 
-	database.DB.Save(&admin)
+	if err := database.DB.Save(admin).Error; err != nil {
+		return apperrors.Internal("Failed to update password", err)
+	}
 
 	return c.JSON(fiber.Map{"message": "Password updated"})
 }
 
-type UpdateAdminRequest struct {
-	Name  string `json:"name,omitempty"`
-	Email string `json:"email,omitempty"`
-}
-
 // ListAdmins godoc
 // @Summary      List all admins
 // @Description  Lists all admin users (super admin only)
@@ -47,7 +52,9 @@ type UpdateAdminRequest struct {
 // @Router       /admin [get]
 func ListAdmins(c *fiber.Ctx) error {
 	var admins []models.User
-	database.DB.Where("role = ?", "admin").Find(&admins)
+	if err := database.DB.Where("role = ?", "admin").Find(&admins).Error; err != nil {
+		return apperrors.Internal("Failed to retrieve admins", err)
+	}
 	return c.JSON(admins)
 }
 
@@ -57,22 +64,31 @@ func ListAdmins(c *fiber.Ctx) error {
 // @Tags         admins
 // @Accept       json
 // @Produce      json
-// @Param        body  body      models.User  true  "Admin user object"
+// @Param        body  body      requests.CreateAdminRequest  true  "Admin user object"
 // @Success      200   {object}  models.User
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin [post]
 func CreateAdmin(c *fiber.Ctx) error {
-	var data models.User
-	if err := c.BodyParser(&data); err != nil {
-		return fiber.ErrBadRequest
+	var req requests.CreateAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	data := models.User{
+		ID:       utils.GenerateUUID(),
+		Email:    req.Email,
+		Name:     req.Name,
+		Username: utils.GenerateUsername(req.Name),
+		Password: utils.HashPassword(req.Password),
+		Role:     "admin",
 	}
-	data.ID = utils.GenerateUUID()
-	data.Role = "admin"
-	data.Password = utils.HashPassword(data.Password)
 
 	if err := database.DB.Create(&data).Error; err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return apperrors.FromDB(err, "Admin")
 	}
 	return c.JSON(data)
 }
@@ -84,31 +100,39 @@ func CreateAdmin(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string  true  "Admin ID"
-// @Param        body  body      UpdateAdminRequest  true  "Fields to update"
+// @Param        body  body      requests.UpdateAdminRequest  true  "Fields to update"
 // @Success      200   {object}  models.User
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      404   {object}  models.ErrorResponse
 // @Router       /admin/{id} [put]
 func UpdateAdmin(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
 	var data models.User
 	if err := database.DB.First(&data, "id = ? AND role = ?", id, "admin").Error; err != nil {
-		return fiber.ErrNotFound
+		return apperrors.FromDB(err, "Admin")
 	}
 
-	var body map[string]string
+	var body requests.UpdateAdminRequest
 	if err := c.BodyParser(&body); err != nil {
-		return fiber.ErrBadRequest
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&body); err != nil {
+		return err
 	}
 
-	if name, ok := body["name"]; ok {
-		data.Name = name
+	if body.Name != nil {
+		data.Name = *body.Name
 	}
-	if email, ok := body["email"]; ok {
-		data.Email = email
+	if body.Email != nil {
+		data.Email = *body.Email
 	}
 
-	database.DB.Save(&data)
+	if err := database.DB.Save(&data).Error; err != nil {
+		return apperrors.FromDB(err, "Admin")
+	}
 	return c.JSON(data)
 }
 
@@ -119,12 +143,16 @@ func UpdateAdmin(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Admin ID"
 // @Success      204  "No Content"
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/{id} [delete]
 func DeleteAdmin(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
 	if err := database.DB.Where("id = ? AND role = ?", id, "admin").Delete(&models.User{}).Error; err != nil {
-		return fiber.ErrInternalServerError
+		return apperrors.Internal("Failed to delete admin", err)
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
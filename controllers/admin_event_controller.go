@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
@@ -18,7 +20,7 @@ import (
 func GetAllEvents(c *fiber.Ctx) error {
 	events, err := services.GetAllEvents()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve events"})
+		return apperrors.Internal("Failed to retrieve events", err)
 	}
 	return c.JSON(events)
 }
@@ -29,16 +31,22 @@ func GetAllEvents(c *fiber.Ctx) error {
 // @Tags         admin_events
 // @Produce      json
 // @Param        id   path      string  true  "Event ID"
+// @Param        If-None-Match  header  string  false  "ETag of the cached copy"
 // @Success      200  {object}  models.Event
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /admin/events/{id} [get]
 func GetEventByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
 	event, err := services.GetEventByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+		return apperrors.FromDB(err, "Event")
 	}
-	return c.JSON(event)
+	return sendWithETag(c, event.Version, event)
 }
 
 // CreateEvent godoc
@@ -47,16 +55,65 @@ func GetEventByID(c *fiber.Ctx) error {
 // @Tags         admin_events
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        event  body      models.Event  true  "Event object"
+// @Param        event  body      requests.CreateEventRequest  true  "Event object"
 // @Param        coverImage formData file false "Cover image file"
 // @Success      200   {object}  models.Event
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/events [post]
 func CreateEvent(c *fiber.Ctx) error {
-	var event models.Event
-	if err := c.BodyParser(&event); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	var req requests.CreateEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	price, err := minorPrice(req.TicketPrice, req.Currency, "ticketPrice")
+	if err != nil {
+		return err
+	}
+	policyID, err := cancellationPolicyField(req.CancellationPolicyID)
+	if err != nil {
+		return err
+	}
+
+	destinationID, err := parseUUIDField(req.DestinationID, "destinationId")
+	if err != nil {
+		return err
+	}
+	categoryID, err := parseUUIDField(req.CategoryID, "categoryId")
+	if err != nil {
+		return err
+	}
+
+	event := models.Event{
+		Title:            req.Title,
+		Slug:             req.Slug,
+		DestinationID:    destinationID,
+		CategoryID:       categoryID,
+		ShortDesc:        req.ShortDesc,
+		FullDesc:         req.FullDesc,
+		EventDate:        req.EventDate,
+		DurationHours:    req.DurationHours,
+		TicketPriceMinor: price,
+		TicketPrice:      req.TicketPrice,
+		Currency:         req.Currency,
+		Capacity:         req.Capacity,
+		Availability:     req.Availability,
+		IsFeatured:       req.IsFeatured,
+		Inclusions:       req.Inclusions,
+		Exclusions:       req.Exclusions,
+		Tags:             req.Tags,
+
+		CancellationPolicyID: policyID,
+		MeetingPoint:         req.MeetingPoint,
+	}
+	if event.Availability == 0 {
+		event.Availability = event.Capacity
+	}
+	if userID, err := currentUserID(c); err == nil {
+		event.CreatedBy = userID
 	}
 	form, err := c.MultipartForm()
 	if err == nil && form != nil {
@@ -64,7 +121,7 @@ func CreateEvent(c *fiber.Ctx) error {
 	}
 	err = services.CreateEvent(&event)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create event"})
+		return apperrors.Internal("Failed to create event", err)
 	}
 	return c.JSON(event)
 }
@@ -76,27 +133,152 @@ func CreateEvent(c *fiber.Ctx) error {
 // @Accept       multipart/form-data
 // @Produce      json
 // @Param        id    path      string      true  "Event ID"
-// @Param        event body      models.Event true  "Event object"
+// @Param        event body      requests.UpdateEventRequest true  "Fields to update"
 // @Param        coverImage formData file false "Cover image file"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200   {object}  models.Event
 // @Failure      400   {object}  models.ErrorResponse
+// @Failure      412   {object}  models.ErrorResponse
+// @Failure      428   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/events/{id} [put]
 func UpdateEvent(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var updated models.Event
-	if err := c.BodyParser(&updated); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	current, err := services.GetEventByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+	if err := checkIfMatch(c, current.Version); err != nil {
+		return err
+	}
+
+	var req requests.UpdateEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = current.Currency
+	}
+	price, err := minorPrice(req.TicketPrice, currency, "ticketPrice")
+	if err != nil {
+		return err
+	}
+	policyID, err := cancellationPolicyField(req.CancellationPolicyID)
+	if err != nil {
+		return err
+	}
+
+	updated := models.Event{
+		Title:            req.Title,
+		Slug:             req.Slug,
+		ShortDesc:        req.ShortDesc,
+		FullDesc:         req.FullDesc,
+		EventDate:        req.EventDate,
+		DurationHours:    req.DurationHours,
+		TicketPriceMinor: price,
+		Currency:         req.Currency,
+		Capacity:         req.Capacity,
+		Availability:     req.Availability,
+		IsFeatured:       req.IsFeatured,
+		Inclusions:       req.Inclusions,
+		Exclusions:       req.Exclusions,
+		Tags:             req.Tags,
+
+		CancellationPolicyID: policyID,
+		MeetingPoint:         req.MeetingPoint,
+	}
+	if userID, err := currentUserID(c); err == nil {
+		updated.UpdatedBy = &userID
+	}
+	if req.DestinationID != "" {
+		if updated.DestinationID, err = parseUUIDField(req.DestinationID, "destinationId"); err != nil {
+			return err
+		}
+	}
+	if req.CategoryID != "" {
+		if updated.CategoryID, err = parseUUIDField(req.CategoryID, "categoryId"); err != nil {
+			return err
+		}
 	}
 	form, _ := c.MultipartForm()
 	if form != nil {
 		updated.CoverImage.URL, _ = utils.SaveFile(form.File["coverImage"])
 	}
-	err := services.UpdateEvent(id, &updated)
+	err = services.UpdateEvent(id, current.Version, &updated)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update event"})
+		return apperrors.FromDB(err, "Event")
 	}
-	return c.JSON(updated)
+	event, err := services.GetEventByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+	setETag(c, event.Version)
+	return c.JSON(event)
+}
+
+// PatchEvent godoc
+// @Summary      Partially update an event
+// @Description  Applies a JSON Merge Patch (RFC 7386); null resets a field, absent fields are kept
+// @Tags         admin_events
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string  true  "Event ID"
+// @Param        patch  body  requests.PatchEventRequest  true  "Merge patch"
+// @Param        If-Match  header  string  true  "ETag from the last read"
+// @Success      200  {object}  models.Event
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/events/{id} [patch]
+func PatchEvent(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	event, err := services.GetEventByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+	if err := checkIfMatch(c, event.Version); err != nil {
+		return err
+	}
+	req := requests.NewPatchEventRequest(*event)
+	columns, err := requests.ApplyMergePatch(c.Body(), &req)
+	if err != nil {
+		return err
+	}
+	if err := patchPrice(columns, "ticket_price_minor", req.TicketPrice, req.Currency, "ticketPrice"); err != nil {
+		return err
+	}
+	if err := patchCancellationPolicy(columns); err != nil {
+		return err
+	}
+	columns["updated_by"] = userUUID
+
+	if err := services.PatchEvent(id, event.Version, columns); err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+
+	event, err = services.GetEventByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated event", err)
+	}
+	setETag(c, event.Version)
+	return c.JSON(event)
 }
 
 // DeleteEvent godoc
@@ -105,14 +287,28 @@ func UpdateEvent(c *fiber.Ctx) error {
 // @Tags         admin_events
 // @Produce      json
 // @Param        id   path      string  true  "Event ID"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/events/{id} [delete]
 func DeleteEvent(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := services.DeleteEvent(id)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	event, err := services.GetEventByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+	if err := checkIfMatch(c, event.Version); err != nil {
+		return err
+	}
+	err = services.DeleteEvent(id, event.Version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete event"})
+		return apperrors.FromDB(err, "Event")
 	}
 	return c.JSON(fiber.Map{"message": "Event deleted"})
 }
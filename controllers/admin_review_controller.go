@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // GetAllReviews godoc
@@ -17,7 +20,7 @@ import (
 func GetAllReviews(c *fiber.Ctx) error {
 	reviews, err := services.GetAllReviews()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve reviews"})
+		return apperrors.Internal("Failed to retrieve reviews", err)
 	}
 	return c.JSON(reviews)
 }
@@ -29,41 +32,64 @@ func GetAllReviews(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Review ID"
 // @Success      200  {object}  models.Review
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /admin/reviews/{id} [get]
 func GetReviewByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
 	review, err := services.GetReviewByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Review not found"})
+		return apperrors.FromDB(err, "Review")
 	}
 	return c.JSON(review)
 }
 
 // CreateReview godoc
 // @Summary      Create a new review
-// @Description  Creates a new review for a tour
+// @Description  Creates an approved review of a tour, event or destination
 // @Tags         admin_reviews
 // @Accept       json
 // @Produce      json
-// @Param        review  body      models.Review  true  "Review object"
+// @Param        review  body      requests.AdminReviewRequest  true  "Review object"
 // @Success      200   {object}  models.Review
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/reviews [post]
 func CreateReview(c *fiber.Ctx) error {
-	var review models.Review
-	if err := c.BodyParser(&review); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	var req requests.AdminReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	targetType, targetID, err := adminReviewTarget(req)
+	if err != nil {
+		return err
+	}
+	userID, err := parseUUIDField(req.UserID, "userId")
+	if err != nil {
+		return err
 	}
 
-	// Validate rating
-	if review.Rating < 1 || review.Rating > 5 {
-		return c.Status(400).JSON(fiber.Map{"error": "Rating must be between 1 and 5"})
+	review := models.Review{
+		UserID:             userID,
+		TargetType:         targetType,
+		TargetID:           targetID,
+		Rating:             req.Rating,
+		Comment:            req.Comment,
+		GuideRating:        optionalRating(req.GuideRating),
+		ValueRating:        optionalRating(req.ValueRating),
+		OrganisationRating: optionalRating(req.OrganisationRating),
+		SafetyRating:       optionalRating(req.SafetyRating),
+		Status:             models.ReviewApproved,
 	}
 
-	if err := services.CreateTourReview(&review); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create review"})
+	if err := services.CreateTargetReview(&review); err != nil {
+		return apperrors.FromDB(err, "Review")
 	}
 	return c.JSON(review)
 }
@@ -75,27 +101,51 @@ func CreateReview(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string      true  "Review ID"
-// @Param        review body      models.Review true  "Review object"
+// @Param        review body      requests.AdminReviewRequest true  "Review object"
 // @Success      200   {object}  models.Review
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/reviews/{id} [put]
 func UpdateReview(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var updated models.Review
-	if err := c.BodyParser(&updated); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
-
-	// Validate rating
-	if updated.Rating < 1 || updated.Rating > 5 {
-		return c.Status(400).JSON(fiber.Map{"error": "Rating must be between 1 and 5"})
+	var req requests.AdminReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	targetType, targetID, err := adminReviewTarget(req)
+	if err != nil {
+		return err
+	}
+	userID, err := parseUUIDField(req.UserID, "userId")
+	if err != nil {
+		return err
 	}
 
+	updated := models.Review{
+		UserID:             userID,
+		TargetType:         targetType,
+		TargetID:           targetID,
+		Rating:             req.Rating,
+		Comment:            req.Comment,
+		GuideRating:        optionalRating(req.GuideRating),
+		ValueRating:        optionalRating(req.ValueRating),
+		OrganisationRating: optionalRating(req.OrganisationRating),
+		SafetyRating:       optionalRating(req.SafetyRating),
+	}
 	if err := services.UpdateTourReview(id, &updated); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update review"})
+		return apperrors.FromDB(err, "Review")
+	}
+	review, err := services.GetReviewByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
 	}
-	return c.JSON(updated)
+	return c.JSON(review)
 }
 
 // DeleteReview godoc
@@ -105,12 +155,27 @@ func UpdateReview(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Review ID"
 // @Success      200  {object}  models.MessageResponse
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/reviews/{id} [delete]
 func DeleteReview(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
 	if err := services.DeleteTourReview(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete review"})
+		return apperrors.FromDB(err, "Review")
 	}
 	return c.JSON(fiber.Map{"message": "Review deleted"})
 }
+
+// adminReviewTarget returns the target named by an admin review request,
+// falling back to the legacy tourId field.
+func adminReviewTarget(req requests.AdminReviewRequest) (models.ReviewTargetType, uuid.UUID, error) {
+	if req.TargetID == "" {
+		id, err := parseUUIDField(req.TourID, "tourId")
+		return models.ReviewTargetTour, id, err
+	}
+	id, err := parseUUIDField(req.TargetID, "targetId")
+	return models.ReviewTargetType(req.TargetType), id, err
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/responses"
 	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
@@ -34,7 +37,7 @@ func GetCurrentAdminProfile(c *fiber.Ctx) error {
 func GetAllUsers(c *fiber.Ctx) error {
 	users, total, err := services.GetAllUsers(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve users"})
+		return apperrors.Internal("Failed to retrieve users", err)
 	}
 	return c.JSON(utils.PaginationResponse(c, users, total))
 }
@@ -47,40 +50,40 @@ func GetAllUsers(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id  path  string  true  "User ID"
 // @Success      200  {object} responses.UserResponse
+// @Failure      400  {object} models.ErrorResponse
 // @Failure      404  {object} models.ErrorResponse
 // @Router       /admin/user/{id} [get]
 func GetUserByID(c *fiber.Ctx) error {
-	user, err := services.GetUserByID(c.Params("id"))
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	user, err := services.GetUserByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+		return apperrors.FromDB(err, "User")
 	}
 	return c.JSON(responses.ToUserResponse(*user))
 }
 
 /* ---------- POST /admin/user ---------- */
-type createUserRequest struct {
-	Email    string  `json:"email" validate:"required,email"`
-	Name     string  `json:"name"  validate:"required"`
-	Password string  `json:"password" validate:"required,min=6"`
-	Role     string  `json:"role"  validate:"required,oneof=user admin superadmin"`
-	Username *string `json:"username,omitempty"`
-}
-
 // CreateUser godoc
 // @Summary      Create user
 // @Description  Admin creates a new user
 // @Tags         admin_users
 // @Accept       json
 // @Produce      json
-// @Param        body  body  createUserRequest  true  "User data"
+// @Param        body  body  requests.CreateUserRequest  true  "User data"
 // @Success      201  {object} responses.UserResponse
 // @Failure      400  {object} models.ErrorResponse
 // @Failure      500  {object} models.ErrorResponse
 // @Router       /admin/user [post]
 func CreateUser(c *fiber.Ctx) error {
-	var req createUserRequest
+	var req requests.CreateUserRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid payload"})
+		return apperrors.BadRequest("Invalid payload")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
 	user := models.User{
@@ -91,40 +94,44 @@ func CreateUser(c *fiber.Ctx) error {
 		Password: utils.HashPassword(req.Password),
 		Role:     req.Role,
 	}
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
 	if err := services.CreateUser(&user); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return apperrors.FromDB(err, "User")
 	}
 	return c.Status(201).JSON(responses.ToUserResponse(user))
 }
 
 /* ---------- PUT /admin/user/:id ---------- */
-type updateUserRequest struct {
-	Email *string `json:"email,omitempty"` // pointer = optional
-	Name  *string `json:"name,omitempty"`
-	Role  *string `json:"role,omitempty" validate:"omitempty,oneof=user admin superadmin"`
-}
-
 // UpdateUser godoc
 // @Summary      Update user
 // @Description  Admin updates an existing user
 // @Tags         admin_users
 // @Accept       json
 // @Produce      json
-// @Param        id    path  string             true  "User ID"
-// @Param        body  body  updateUserRequest  true  "Fields to update"
+// @Param        id    path  string                      true  "User ID"
+// @Param        body  body  requests.UpdateUserRequest  true  "Fields to update"
 // @Success      200   {object} responses.UserResponse
 // @Failure      400  {object} models.ErrorResponse
 // @Failure      404  {object} models.ErrorResponse
 // @Router       /admin/user/{id} [put]
 func UpdateUser(c *fiber.Ctx) error {
-	var req updateUserRequest
+	var req requests.UpdateUserRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid payload"})
+		return apperrors.BadRequest("Invalid payload")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
-	user, err := services.GetUserByID(c.Params("id"))
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	user, err := services.GetUserByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+		return apperrors.FromDB(err, "User")
 	}
 
 	if req.Email != nil {
@@ -136,9 +143,58 @@ func UpdateUser(c *fiber.Ctx) error {
 	if req.Role != nil {
 		user.Role = *req.Role
 	}
+	if editorID, err := currentUserID(c); err == nil {
+		user.UpdatedBy = &editorID
+	}
 
 	if err := services.UpdateUser(user.ID.String(), user); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return apperrors.FromDB(err, "User")
+	}
+	return c.JSON(responses.ToUserResponse(*user))
+}
+
+/* ---------- PATCH /admin/users/:id ---------- */
+// PatchUser godoc
+// @Summary      Partially update an user
+// @Description  Applies a JSON Merge Patch (RFC 7386); null resets a field, absent fields are kept
+// @Tags         admin_users
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string  true  "User ID"
+// @Param        patch  body  requests.PatchUserRequest  true  "Merge patch"
+// @Success      200  {object}  responses.UserResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/users/{id} [patch]
+func PatchUser(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := services.GetUserByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+	req := requests.NewPatchUserRequest(*user)
+	columns, err := requests.ApplyMergePatch(c.Body(), &req)
+	if err != nil {
+		return err
+	}
+	columns["updated_by"] = userUUID
+
+	if err := services.PatchUser(id, columns); err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+
+	user, err = services.GetUserByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated user", err)
 	}
 	return c.JSON(responses.ToUserResponse(*user))
 }
@@ -150,11 +206,170 @@ func UpdateUser(c *fiber.Ctx) error {
 // @Tags         admin_users
 // @Param        id  path  string  true  "User ID"
 // @Success      204 "No Content"
+// @Failure      400  {object} models.ErrorResponse
 // @Failure      404  {object} models.ErrorResponse
 // @Router       /admin/user/{id} [delete]
 func DeleteUser(c *fiber.Ctx) error {
-	if err := services.DeleteUser(c.Params("id")); err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := services.DeleteUser(id); err != nil {
+		return apperrors.FromDB(err, "User")
 	}
 	return c.SendStatus(204)
 }
+
+/* ---------- POST /admin/users/:id/data-exports ---------- */
+// RequestUserDataExport godoc
+// @Summary      Export a user's data
+// @Description  Asks for a ZIP of JSON files with the user's profile, bookings, reviews, favourites and login sessions,
+// @Description  built in the background
+// @Tags         admin_users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      202  {object}  responses.DataExportResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /admin/users/{id}/data-exports [post]
+func RequestUserDataExport(c *fiber.Ctx) error {
+	userID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	export, err := services.RequestDataExport(userID, adminID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(responses.ToDataExportResponse(*export, userDataExportURL(export)))
+}
+
+/* ---------- GET /admin/users/:id/data-exports ---------- */
+// GetUserDataExports godoc
+// @Summary      List a user's data exports
+// @Description  Returns the user's data exports, newest first, with download links once ready
+// @Tags         admin_users
+// @Produce      json
+// @Param        id     path   string   true   "User ID"
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Success      200  {object}  object{data=[]responses.DataExportResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Router       /admin/users/{id}/data-exports [get]
+func GetUserDataExports(c *fiber.Ctx) error {
+	userID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	exports, total, err := services.GetUserDataExports(c, userID)
+	if err != nil {
+		return apperrors.FromDB(err, "Data export")
+	}
+	response := make([]responses.DataExportResponse, len(exports))
+	for i := range exports {
+		response[i] = responses.ToDataExportResponse(exports[i], userDataExportURL(&exports[i]))
+	}
+	return c.JSON(utils.PaginationResponse(c, response, total))
+}
+
+/* ---------- GET /admin/users/:id/data-exports/:exportId/download ---------- */
+// DownloadUserDataExport godoc
+// @Summary      Download a user's data export
+// @Description  Downloads the ZIP of a ready data export of the user
+// @Tags         admin_users
+// @Produce      application/zip
+// @Param        id        path  string  true  "User ID"
+// @Param        exportId  path  string  true  "Data export ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /admin/users/{id}/data-exports/{exportId}/download [get]
+func DownloadUserDataExport(c *fiber.Ctx) error {
+	userID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	id, err := paramUUID(c, "exportId")
+	if err != nil {
+		return err
+	}
+	return sendDataExport(c, userID, id)
+}
+
+/* ---------- POST /admin/users/:id/deletion ---------- */
+// DeleteUserAccount godoc
+// @Summary      Delete a user's account
+// @Description  Schedules the user's account for deletion after the grace period and logs the user out everywhere,
+// @Description  or with immediate erases their personal data right away. Reviews stay up anonymously; bookings,
+// @Description  payments and invoices are kept. Accounts with unfinished bookings can't be deleted.
+// @Tags         admin_users
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                              true   "User ID"
+// @Param        deletion  body      requests.AdminDeleteAccountRequest  false  "Deletion options"
+// @Success      202       {object}  responses.UserResponse
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Failure      409       {object}  models.ErrorResponse
+// @Router       /admin/users/{id}/deletion [post]
+func DeleteUserAccount(c *fiber.Ctx) error {
+	userID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	var req requests.AdminDeleteAccountRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperrors.BadRequest("Invalid request body: " + err.Error())
+		}
+	}
+	if !req.Immediate {
+		user, err := services.ScheduleAccountDeletion(userID, config.AccountDeletionGracePeriod)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusAccepted).JSON(responses.ToUserResponse(*user))
+	}
+	if err := services.PurgeAccount(userID); err != nil {
+		return err
+	}
+	user, err := services.GetUserByID(userID.String())
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve deleted user", err)
+	}
+	return c.JSON(responses.ToUserResponse(*user))
+}
+
+/* ---------- DELETE /admin/users/:id/deletion ---------- */
+// CancelUserAccountDeletion godoc
+// @Summary      Keep a user's account
+// @Description  Cancels the scheduled deletion of the user's account
+// @Tags         admin_users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  responses.UserResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /admin/users/{id}/deletion [delete]
+func CancelUserAccountDeletion(c *fiber.Ctx) error {
+	userID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	user, err := services.CancelAccountDeletion(userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(responses.ToUserResponse(*user))
+}
+
+func userDataExportURL(export *models.DataExport) string {
+	return "/admin/users/" + export.UserID.String() + "/data-exports/" + export.ID.String() + "/download"
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTourAvailability godoc
+// @Summary      Get the availability calendar of a tour
+// @Description  Returns one entry per day with the seats left, lowest adult fare and status (available, limited, sold_out, cancelled
+// @Description  or unavailable) of the tour's departures that day, and the departures themselves. Ranges can span up to 366 days.
+// @Tags         tours
+// @Produce      json
+// @Param        id    path      string  true   "Tour ID"
+// @Param        from  query     string  false  "First day, YYYY-MM-DD (default: today)"
+// @Param        to    query     string  false  "Last day, YYYY-MM-DD (default: 30 days after from)"
+// @Success      200   {object}  models.Availability
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      404   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /api/tours/{id}/availability [get]
+func GetTourAvailability(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	from, err := parseDateQuery(c.Query("from"), "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQuery(c.Query("to"), "to")
+	if err != nil {
+		return err
+	}
+	availability, err := services.GetTourAvailability(tourID, from, to)
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	return c.JSON(availability)
+}
+
+// GetDestinationAvailability godoc
+// @Summary      Get the availability calendar of a destination
+// @Description  Sums up the departures of all tours to a destination by day: tours departing, seats left, lowest adult fare in the
+// @Description  requested currency and status. Ranges can span up to 366 days.
+// @Tags         destinations
+// @Produce      json
+// @Param        id        path      string  true   "Destination ID"
+// @Param        from      query     string  false  "First day, YYYY-MM-DD (default: today)"
+// @Param        to        query     string  false  "Last day, YYYY-MM-DD (default: 30 days after from)"
+// @Param        currency  query     string  false  "ISO 4217 code to show prices in (default: base currency)"
+// @Success      200       {object}  models.Availability
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Failure      500       {object}  models.ErrorResponse
+// @Router       /api/destinations/{id}/availability [get]
+func GetDestinationAvailability(c *fiber.Ctx) error {
+	destinationID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	from, err := parseDateQuery(c.Query("from"), "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDateQuery(c.Query("to"), "to")
+	if err != nil {
+		return err
+	}
+	availability, err := services.GetDestinationAvailability(destinationID, from, to, c.Query("currency"))
+	if err != nil {
+		return apperrors.FromDB(err, "Destination")
+	}
+	return c.JSON(availability)
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CreateBooking godoc
+// @Summary      Book a tour or an event
+// @Description  Books a departure of a tour (tourId) for the logged-in user's party, priced like GET /api/tours/{id}/quote. The departure is
+// @Description  picked by departureId, else by travelDate, else the tour's next departure is booked. The party's adults and children take
+// @Description  seats on it. With eventId instead, the party's adults and children each get a ticket for the event at its ticket price.
+// @Description  The booking stays pending until it is paid. Its seats are held until holdExpiresAt; if it is not paid by then the
+// @Description  booking expires and the seats are released.
+// @Tags         user_bookings
+// @Accept       json
+// @Produce      json
+// @Param        booking  body      requests.CreateBookingRequest  true  "Booking"
+// @Success      201      {object}  models.Booking
+// @Failure      400      {object}  models.ErrorResponse
+// @Failure      401      {object}  models.ErrorResponse
+// @Failure      404      {object}  models.ErrorResponse
+// @Failure      409      {object}  models.ErrorResponse
+// @Failure      500      {object}  models.ErrorResponse
+// @Router       /api/user/bookings [post]
+func CreateBooking(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.CreateBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	if req.TourID != "" && req.EventID != "" {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "eventId",
+			Message: "give either tourId or eventId",
+		})
+	}
+	tourID, err := optionalUUIDField(req.TourID, "tourId")
+	if err != nil {
+		return err
+	}
+	eventID, err := optionalUUIDField(req.EventID, "eventId")
+	if err != nil {
+		return err
+	}
+	departureID, err := optionalUUIDField(req.DepartureID, "departureId")
+	if err != nil {
+		return err
+	}
+	if eventID != nil {
+		if err := checkEventBookingRequest(&req); err != nil {
+			return err
+		}
+	}
+
+	adults := req.Adults
+	if adults == 0 {
+		adults = req.Travelers
+	}
+	booking := models.Booking{
+		UserID:        userID,
+		TourID:        tourID,
+		EventID:       eventID,
+		DepartureID:   departureID,
+		Adults:        adults,
+		Children:      req.Children,
+		Infants:       req.Infants,
+		TravelDate:    req.TravelDate,
+		PromotionCode: req.PromoCode,
+	}
+	if err := services.CreateBooking(&booking); err != nil {
+		return apperrors.FromDB(err, "Booking")
+	}
+	return c.Status(fiber.StatusCreated).JSON(booking)
+}
+
+// checkEventBookingRequest rejects the tour-only fields on a booking of
+// event tickets.
+func checkEventBookingRequest(req *requests.CreateBookingRequest) error {
+	var fields []models.FieldError
+	if req.DepartureID != "" {
+		fields = append(fields, models.FieldError{Field: "departureId", Message: "can only be given for tours"})
+	}
+	if req.TravelDate != nil {
+		fields = append(fields, models.FieldError{Field: "travelDate", Message: "can only be given for tours"})
+	}
+	if req.PromoCode != "" {
+		fields = append(fields, models.FieldError{Field: "promoCode", Message: "can only be used for tours"})
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
+
+// GetMyBookings godoc
+// @Summary      List my bookings
+// @Description  Returns the logged-in user's bookings, newest first
+// @Tags         user_bookings
+// @Produce      json
+// @Success      200  {array}   models.Booking
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/bookings [get]
+func GetMyBookings(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	bookings, err := services.GetUserBookings(userID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve bookings", err)
+	}
+	return c.JSON(bookings)
+}
+
+// GetMyBooking godoc
+// @Summary      Get one of my bookings
+// @Description  Returns one of the logged-in user's bookings with its payments
+// @Tags         user_bookings
+// @Produce      json
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  models.Booking
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id} [get]
+func GetMyBooking(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	booking, err := services.GetUserBooking(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(booking)
+}
+
+// GetMyBookingCancellation godoc
+// @Summary      Preview cancelling one of my bookings
+// @Description  Returns the tour's cancellation policy in text and what cancelling the booking now would refund, to show before
+// @Description  the customer confirms with POST /api/user/bookings/{id}/cancel
+// @Tags         user_bookings
+// @Produce      json
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  models.CancellationQuote
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/cancellation [get]
+func GetMyBookingCancellation(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	quote, err := services.QuoteCancellation(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(quote)
+}
+
+// CancelMyBooking godoc
+// @Summary      Cancel one of my bookings
+// @Description  Cancels a pending or confirmed booking before departure. Its seats are released and the refund the tour's
+// @Description  cancellation policy gives (see GET /api/user/bookings/{id}/cancellation) is paid back to the original payment.
+// @Tags         user_bookings
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string                         true   "Booking ID"
+// @Param        cancellation  body      requests.CancelBookingRequest  false  "Reason"
+// @Success      200           {object}  models.Booking
+// @Failure      400           {object}  models.ErrorResponse
+// @Failure      401           {object}  models.ErrorResponse
+// @Failure      404           {object}  models.ErrorResponse
+// @Failure      409           {object}  models.ErrorResponse
+// @Failure      500           {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/cancel [post]
+func CancelMyBooking(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.CancelBookingRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperrors.BadRequest("Invalid request body")
+		}
+		if err := requests.Validate(&req); err != nil {
+			return err
+		}
+	}
+	booking, err := services.CancelBooking(userID, id, req.Reason)
+	if err != nil {
+		return err
+	}
+	return c.JSON(booking)
+}
+
+// AdminCancelBooking godoc
+// @Summary      Cancel a booking
+// @Description  Cancels a customer's pending or confirmed booking, also after departure. Its seats are released and the customer is
+// @Description  refunded what the tour's cancellation policy gives, or refundPercent of what was paid when it is set.
+// @Tags         admin_bookings
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string                              true  "Booking ID"
+// @Param        cancellation  body      requests.AdminCancelBookingRequest  true  "Reason and refund"
+// @Success      200           {object}  models.Booking
+// @Failure      400           {object}  models.ErrorResponse
+// @Failure      404           {object}  models.ErrorResponse
+// @Failure      409           {object}  models.ErrorResponse
+// @Failure      500           {object}  models.ErrorResponse
+// @Router       /admin/bookings/{id}/cancel [post]
+func AdminCancelBooking(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.AdminCancelBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	booking, err := services.AdminCancelBooking(adminID, id, req.Reason, req.RefundPercent)
+	if err != nil {
+		return err
+	}
+	return c.JSON(booking)
+}
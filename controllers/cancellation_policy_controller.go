@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetCancellationPolicies godoc
+// @Summary      List cancellation policies
+// @Description  Lists cancellation policies by name, with their tiers spelled out in text
+// @Tags         admin_cancellation_policies
+// @Produce      json
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Param        name   query  string   false  "Only names containing this text"
+// @Success      200  {object}  object{data=[]models.CancellationPolicy,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/cancellation-policies [get]
+func GetCancellationPolicies(c *fiber.Ctx) error {
+	policies, totalCount, err := services.GetCancellationPolicies(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve cancellation policies", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, policies, totalCount))
+}
+
+// GetCancellationPolicy godoc
+// @Summary      Get a cancellation policy
+// @Tags         admin_cancellation_policies
+// @Produce      json
+// @Param        id   path      string  true  "Cancellation policy ID"
+// @Success      200  {object}  models.CancellationPolicy
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /admin/cancellation-policies/{id} [get]
+func GetCancellationPolicy(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	policy, err := services.GetCancellationPolicy(id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(policy)
+}
+
+// CreateCancellationPolicy godoc
+// @Summary      Create a cancellation policy
+// @Description  Creates a policy of refund tiers by days before departure, which tours and events can then use via cancellationPolicyId.
+// @Description  Refunds may not grow as departure gets closer.
+// @Tags         admin_cancellation_policies
+// @Accept       json
+// @Produce      json
+// @Param        policy  body      requests.CancellationPolicyRequest  true  "Cancellation policy"
+// @Success      201     {object}  models.CancellationPolicy
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /admin/cancellation-policies [post]
+func CreateCancellationPolicy(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	policy, err := parseCancellationPolicy(c)
+	if err != nil {
+		return err
+	}
+	policy.CreatedBy = adminID
+	if err := services.CreateCancellationPolicy(policy); err != nil {
+		return apperrors.FromDB(err, "Cancellation policy")
+	}
+	return c.Status(fiber.StatusCreated).JSON(policy)
+}
+
+// UpdateCancellationPolicy godoc
+// @Summary      Update a cancellation policy
+// @Description  Replaces a cancellation policy; it applies to later cancellations on every tour and event using it
+// @Tags         admin_cancellation_policies
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                              true  "Cancellation policy ID"
+// @Param        policy  body      requests.CancellationPolicyRequest  true  "Cancellation policy"
+// @Success      200     {object}  models.CancellationPolicy
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /admin/cancellation-policies/{id} [put]
+func UpdateCancellationPolicy(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	policy, err := parseCancellationPolicy(c)
+	if err != nil {
+		return err
+	}
+	policy.ID = id
+	policy.UpdatedBy = &adminID
+	if err := services.UpdateCancellationPolicy(policy); err != nil {
+		return apperrors.FromDB(err, "Cancellation policy")
+	}
+	return c.JSON(policy)
+}
+
+// DeleteCancellationPolicy godoc
+// @Summary      Delete a cancellation policy
+// @Description  Deletes a cancellation policy no tour or event uses
+// @Tags         admin_cancellation_policies
+// @Produce      json
+// @Param        id   path      string  true  "Cancellation policy ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/cancellation-policies/{id} [delete]
+func DeleteCancellationPolicy(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := services.DeleteCancellationPolicy(id); err != nil {
+		return apperrors.FromDB(err, "Cancellation policy")
+	}
+	return c.JSON(fiber.Map{"message": "Cancellation policy deleted"})
+}
+
+func parseCancellationPolicy(c *fiber.Ctx) (*models.CancellationPolicy, error) {
+	var req requests.CancellationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return nil, err
+	}
+	return &models.CancellationPolicy{
+		Name:        req.Name,
+		Description: req.Description,
+		Tiers:       req.Tiers,
+	}, nil
+}
+
+// cancellationPolicyField parses and checks the cancellation policy a tour
+// or event is given; empty means none.
+func cancellationPolicyField(value string) (*uuid.UUID, error) {
+	id, err := optionalUUIDField(value, "cancellationPolicyId")
+	if err != nil {
+		return nil, err
+	}
+	if err := services.CheckCancellationPolicy(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// patchCancellationPolicy checks the cancellation policy set by a merge
+// patch; null removes it.
+func patchCancellationPolicy(columns map[string]interface{}) error {
+	value, ok := columns["cancellation_policy_id"]
+	if !ok {
+		return nil
+	}
+	id, err := cancellationPolicyField(value.(string))
+	if err != nil {
+		return err
+	}
+	columns["cancellation_policy_id"] = id
+	return nil
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,7 +19,7 @@ import (
 func GetAllCategories(c *fiber.Ctx) error {
 	categories, err := services.GetAllCategories()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve categories"})
+		return apperrors.Internal("Failed to retrieve categories", err)
 	}
 	return c.JSON(categories)
 }
@@ -28,14 +30,24 @@ func GetAllCategories(c *fiber.Ctx) error {
 // @Tags         categories
 // @Produce      json
 // @Param        id   path      string  true  "Category ID"
+// @Param        If-None-Match  header  string  false  "ETag of the cached copy"
 // @Success      200  {object}  models.Category
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /api/categories/{id} [get]
 func GetCategoryByID(c *fiber.Ctx) error {
-	id := c.Params("id")
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
 	category, err := services.GetCategoryByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Category not found"})
+		return apperrors.FromDB(err, "Category")
+	}
+	setETag(c, category.Version)
+	if notModified(c, category.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 	return c.JSON(category)
 }
@@ -46,27 +58,28 @@ func GetCategoryByID(c *fiber.Ctx) error {
 // @Tags         admin_categories
 // @Accept       json
 // @Produce      json
-// @Param        category  body      models.Category  true  "Category object"
+// @Param        category  body      requests.CreateCategoryRequest  true  "Category object"
 // @Success      200   {object}  models.Category
 // @Failure      400   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/categories [post]
 func CreateCategory(c *fiber.Ctx) error {
-	var category models.Category
-	if err := c.BodyParser(&category); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	var req requests.CreateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
 	}
-
-	// Validate required fields
-	if category.Name == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
-	if category.Icon == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Icon is required"})
+
+	category := models.Category{
+		Name:        req.Name,
+		Description: req.Description,
+		Icon:        req.Icon,
 	}
 
 	if err := services.CreateCategory(&category); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create category"})
+		return apperrors.Internal("Failed to create category", err)
 	}
 	return c.JSON(category)
 }
@@ -78,23 +91,105 @@ func CreateCategory(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string      true  "Category ID"
-// @Param        category body      models.Category true  "Category object"
-// @Param        coverImage formData file false "Cover image file"
+// @Param        category body      requests.UpdateCategoryRequest true  "Fields to update"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200   {object}  models.Category
 // @Failure      400   {object}  models.ErrorResponse
+// @Failure      404   {object}  models.ErrorResponse
+// @Failure      412   {object}  models.ErrorResponse
+// @Failure      428   {object}  models.ErrorResponse
 // @Failure      500   {object}  models.ErrorResponse
 // @Router       /admin/categories/{id} [put]
 func UpdateCategory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var updated models.Category
-	if err := c.BodyParser(&updated); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	current, err := services.GetCategoryByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+	if err := checkIfMatch(c, current.Version); err != nil {
+		return err
+	}
+
+	var req requests.UpdateCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
-	if err := services.UpdateCategory(id, &updated); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update category"})
+	updated := models.Category{
+		Name:        req.Name,
+		Description: req.Description,
+		Icon:        req.Icon,
+	}
+	if userID, err := currentUserID(c); err == nil {
+		updated.UpdatedBy = &userID
 	}
-	return c.JSON(updated)
+	if err := services.UpdateCategory(id, current.Version, &updated); err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+	category, err := services.GetCategoryByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+	setETag(c, category.Version)
+	return c.JSON(category)
+}
+
+// PatchCategory godoc
+// @Summary      Partially update a category
+// @Description  Applies a JSON Merge Patch (RFC 7386); null resets a field, absent fields are kept
+// @Tags         admin_categories
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string  true  "Category ID"
+// @Param        patch  body  requests.PatchCategoryRequest  true  "Merge patch"
+// @Param        If-Match  header  string  true  "ETag from the last read"
+// @Success      200  {object}  models.Category
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/categories/{id} [patch]
+func PatchCategory(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	category, err := services.GetCategoryByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		return err
+	}
+	req := requests.NewPatchCategoryRequest(*category)
+	columns, err := requests.ApplyMergePatch(c.Body(), &req)
+	if err != nil {
+		return err
+	}
+	columns["updated_by"] = userUUID
+
+	if err := services.PatchCategory(id, category.Version, columns); err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+
+	category, err = services.GetCategoryByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated category", err)
+	}
+	setETag(c, category.Version)
+	return c.JSON(category)
 }
 
 // DeleteCategory godoc
@@ -103,13 +198,27 @@ func UpdateCategory(c *fiber.Ctx) error {
 // @Tags         admin_categories
 // @Produce      json
 // @Param        id   path      string  true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  models.MessageResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/categories/{id} [delete]
 func DeleteCategory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if err := services.DeleteCategory(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete category"})
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	category, err := services.GetCategoryByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Category")
+	}
+	if err := checkIfMatch(c, category.Version); err != nil {
+		return err
+	}
+	if err := services.DeleteCategory(id, category.Version); err != nil {
+		return apperrors.FromDB(err, "Category")
 	}
 	return c.JSON(fiber.Map{"message": "Category deleted"})
 }
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetTourDepartures godoc
+// @Summary      List upcoming departures of a tour
+// @Description  Lists the departures of a tour that haven't left and aren't cancelled, by start date
+// @Tags         tours
+// @Produce      json
+// @Param        id     path   string   true   "Tour ID"
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Param        from   query  string   false  "First start date, YYYY-MM-DD"
+// @Param        to     query  string   false  "Last start date, YYYY-MM-DD"
+// @Success      200  {object}  object{data=[]models.TourDeparture,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/tours/{id}/departures [get]
+func GetTourDepartures(c *fiber.Ctx) error {
+	return listTourDepartures(c, false)
+}
+
+// GetUpcomingDepartures godoc
+// @Summary      List upcoming departures
+// @Description  Lists the departures of all tours that haven't left and aren't cancelled, by start date, with their tour
+// @Tags         tours
+// @Produce      json
+// @Param        page            query  integer  false  "Page number (default: 1)"
+// @Param        limit           query  integer  false  "Limit per page (default: 10)"
+// @Param        from            query  string   false  "First start date, YYYY-MM-DD"
+// @Param        to              query  string   false  "Last start date, YYYY-MM-DD"
+// @Param        destination_id  query  string   false  "Filter by destination ID"
+// @Param        category_id     query  string   false  "Filter by category ID"
+// @Success      200  {object}  object{data=[]models.TourDeparture,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/departures [get]
+func GetUpcomingDepartures(c *fiber.Ctx) error {
+	departures, totalCount, err := services.GetUpcomingDepartures(c)
+	if err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	return c.JSON(utils.PaginationResponse(c, departures, totalCount))
+}
+
+// GetAdminTourDepartures godoc
+// @Summary      List the departures of a tour
+// @Description  Lists all departures of a tour by start date, including past and cancelled ones
+// @Tags         admin_departures
+// @Produce      json
+// @Param        id      path   string   true   "Tour ID"
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        status  query  string   false  "scheduled, guaranteed or cancelled"
+// @Param        from    query  string   false  "First start date, YYYY-MM-DD"
+// @Param        to      query  string   false  "Last start date, YYYY-MM-DD"
+// @Success      200  {object}  object{data=[]models.TourDeparture,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures [get]
+func GetAdminTourDepartures(c *fiber.Ctx) error {
+	return listTourDepartures(c, true)
+}
+
+func listTourDepartures(c *fiber.Ctx, admin bool) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departures, totalCount, err := services.GetTourDepartures(c, tourID, admin)
+	if err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	return c.JSON(utils.PaginationResponse(c, departures, totalCount))
+}
+
+// GetDeparture godoc
+// @Summary      Get a departure
+// @Description  Returns one departure of a tour with its booked seats
+// @Tags         admin_departures
+// @Produce      json
+// @Param        id           path      string  true  "Tour ID"
+// @Param        departureId  path      string  true  "Departure ID"
+// @Success      200          {object}  models.TourDeparture
+// @Failure      400          {object}  models.ErrorResponse
+// @Failure      404          {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/{departureId} [get]
+func GetDeparture(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departureID, err := paramUUID(c, "departureId")
+	if err != nil {
+		return err
+	}
+	departure, err := services.GetDeparture(tourID, departureID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(departure)
+}
+
+// CreateDeparture godoc
+// @Summary      Add a departure to a tour
+// @Description  Schedules one departure. endDate defaults to the tour's duration, capacity to its default capacity;
+// @Description  priceOverride replaces the adult fare on this departure. A tour can't have two departures starting at the same time.
+// @Tags         admin_departures
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                     true  "Tour ID"
+// @Param        departure  body      requests.DepartureRequest  true  "Departure"
+// @Success      201        {object}  models.TourDeparture
+// @Failure      400        {object}  models.ErrorResponse
+// @Failure      404        {object}  models.ErrorResponse
+// @Failure      409        {object}  models.ErrorResponse
+// @Failure      500        {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures [post]
+func CreateDeparture(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departure, err := parseDeparture(c, tourID)
+	if err != nil {
+		return err
+	}
+	if err := services.CreateDeparture(departure); err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	return c.Status(fiber.StatusCreated).JSON(departure)
+}
+
+// UpdateDeparture godoc
+// @Summary      Replace a departure
+// @Description  Replaces the dates, capacity, price override and status of a departure. The capacity can't drop below the seats
+// @Description  already booked. Cancelling it cancels its bookings with a full refund and notifies the customers.
+// @Tags         admin_departures
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                     true  "Tour ID"
+// @Param        departureId  path      string                     true  "Departure ID"
+// @Param        departure    body      requests.DepartureRequest  true  "Departure"
+// @Success      200          {object}  models.TourDeparture
+// @Failure      400          {object}  models.ErrorResponse
+// @Failure      404          {object}  models.ErrorResponse
+// @Failure      409          {object}  models.ErrorResponse
+// @Failure      500          {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/{departureId} [put]
+func UpdateDeparture(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departureID, err := paramUUID(c, "departureId")
+	if err != nil {
+		return err
+	}
+	departure, err := parseDeparture(c, tourID)
+	if err != nil {
+		return err
+	}
+	departure.ID = departureID
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := services.UpdateDeparture(departure, adminID); err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	return c.JSON(departure)
+}
+
+// DeleteDeparture godoc
+// @Summary      Delete a departure
+// @Description  Removes a departure that has never been booked; booked departures can only be cancelled
+// @Tags         admin_departures
+// @Produce      json
+// @Param        id           path      string  true  "Tour ID"
+// @Param        departureId  path      string  true  "Departure ID"
+// @Success      200          {object}  map[string]string
+// @Failure      400          {object}  models.ErrorResponse
+// @Failure      404          {object}  models.ErrorResponse
+// @Failure      409          {object}  models.ErrorResponse
+// @Failure      500          {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/{departureId} [delete]
+func DeleteDeparture(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departureID, err := paramUUID(c, "departureId")
+	if err != nil {
+		return err
+	}
+	if err := services.DeleteDeparture(tourID, departureID); err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	return c.JSON(fiber.Map{"message": "Departure deleted"})
+}
+
+// GenerateDepartures godoc
+// @Summary      Generate departures from a recurrence rule
+// @Description  Creates the departures produced by an RFC 5545 RRULE (FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL, COUNT, UNTIL,
+// @Description  BYDAY and BYMONTHDAY), with startDate as the first, at its time of day, up to two years ahead and 366 departures.
+// @Description  Start times that already have a departure are skipped, so running a schedule again extends it.
+// @Tags         admin_departures
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                              true  "Tour ID"
+// @Param        schedule  body      requests.GenerateDeparturesRequest  true  "Schedule"
+// @Success      201       {object}  object{created=[]models.TourDeparture,skipped=integer}
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Failure      500       {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/generate [post]
+func GenerateDepartures(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	var req requests.GenerateDeparturesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	tour, err := services.GetTourByID(tourID.String())
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+
+	schedule := services.DepartureSchedule{
+		RRule:    req.RRule,
+		Start:    req.StartDate,
+		Until:    req.Until,
+		Capacity: req.Capacity,
+		Status:   models.DepartureStatus(req.Status),
+	}
+	if req.PriceOverride != nil {
+		price, err := minorPrice(*req.PriceOverride, tour.Currency, "priceOverride")
+		if err != nil {
+			return err
+		}
+		schedule.PriceOverrideMinor = &price
+	}
+	created, skipped, err := services.GenerateDepartures(tour, schedule)
+	if err != nil {
+		return apperrors.FromDB(err, "Departure")
+	}
+	if created == nil {
+		created = []models.TourDeparture{}
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"created": created, "skipped": skipped})
+}
+
+// parseDeparture reads a departure of a tour from the request body, filling
+// in the tour's defaults and converting the price override to minor units
+// of the tour's currency.
+func parseDeparture(c *fiber.Ctx, tourID uuid.UUID) (*models.TourDeparture, error) {
+	var req requests.DepartureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return nil, err
+	}
+	tour, err := services.GetTourByID(tourID.String())
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Tour")
+	}
+
+	departure := &models.TourDeparture{
+		TourID:    tourID,
+		StartDate: req.StartDate,
+		EndDate:   req.StartDate.AddDate(0, 0, max(tour.DurationDays, 1)-1),
+		Capacity:  req.Capacity,
+		Status:    models.DepartureStatus(req.Status),
+	}
+	if req.EndDate != nil {
+		departure.EndDate = *req.EndDate
+	}
+	if departure.Capacity == 0 {
+		departure.Capacity = tour.DefaultCapacity
+	}
+	if departure.Status == "" {
+		departure.Status = models.DepartureScheduled
+	}
+	if req.PriceOverride != nil {
+		price, err := minorPrice(*req.PriceOverride, tour.Currency, "priceOverride")
+		if err != nil {
+			return nil, err
+		}
+		departure.PriceOverrideMinor = &price
+	}
+	departure.SetPriceOverride(tour.Currency)
+	return departure, nil
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/responses"
@@ -26,13 +27,16 @@ import (
 func GetAllDestinations(c *fiber.Ctx) error {
 	destinations, totalCount, err := services.GetAllDestinations(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve destinations"})
+		return apperrors.Internal("Failed to retrieve destinations", err)
 	}
 
 	response := make([]responses.DestinationResponse, len(destinations))
 	for i, d := range destinations {
 		response[i] = responses.ToDestinationResponse(d)
 	}
+	if err := markDestinationFavorites(c, response); err != nil {
+		return err
+	}
 
 	return c.JSON(utils.PaginationResponse(c, response, totalCount))
 }
@@ -43,16 +47,28 @@ func GetAllDestinations(c *fiber.Ctx) error {
 // @Tags         destinations
 // @Produce      json
 // @Param        id   path      string  true  "Destination ID"
+// @Param        If-None-Match  header  string  false  "ETag of the cached copy"
 // @Success      200  {object}  responses.DestinationResponse
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /api/destinations/{id} [get]
 func GetDestinationByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	destination, err := services.GetDestinationByID(id)
+	id, err := paramUUID(c, "id")
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Destination not found"})
+		return err
 	}
-	return c.JSON(responses.ToDestinationResponse(*destination))
+	destination, err := services.GetDestinationByID(id.String())
+	if err != nil {
+		return apperrors.FromDB(err, "Destination")
+	}
+	response := []responses.DestinationResponse{responses.ToDestinationResponse(*destination)}
+	if err := markDestinationFavorites(c, response); err != nil {
+		return err
+	}
+	// Ratings and favourites change without a version bump, so the ETag
+	// follows the rendered body
+	return sendWithETag(c, destination.Version, response[0])
 }
 
 // CreateDestination godoc
@@ -73,23 +89,23 @@ func GetDestinationByID(c *fiber.Ctx) error {
 func CreateDestination(c *fiber.Ctx) error {
 	var req requests.CreateDestinationRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
 	// Get user ID from context
-	userID, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "User ID not found in context"})
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	destinationID := uuid.New()
-	userUUID := uuid.MustParse(userID)
 	// Get the user
-	user, err := services.GetUserByID(userID)
+	user, err := services.GetUserByID(userUUID.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get user details"})
+		return apperrors.Internal("Failed to get user details", err)
 	}
 	destination := models.Destination{
 		ID:          destinationID,
@@ -107,7 +123,7 @@ func CreateDestination(c *fiber.Ctx) error {
 		log.Println("Destination Cover Image Found manually:", file.Filename, file.Size)
 		fileURL, err := utils.UploadImageToCloudinary(file, "destinations")
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to save cover image"})
+			return apperrors.Internal("Failed to save cover image", err)
 		}
 		destination.CoverImage = models.MediaDestination{
 			ID:            uint(time.Now().Unix()), // or use auto-increment
@@ -119,12 +135,12 @@ func CreateDestination(c *fiber.Ctx) error {
 	}
 	err = services.CreateDestination(&destination) // Changed from := to =
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create destination"})
+		return apperrors.Internal("Failed to create destination", err)
 	}
 
 	createdDestination, err := services.GetDestinationByID(destination.ID.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve created destination"})
+		return apperrors.Internal("Failed to retrieve created destination", err)
 	}
 
 	return c.JSON(responses.ToDestinationResponse(*createdDestination))
@@ -142,36 +158,41 @@ func CreateDestination(c *fiber.Ctx) error {
 // @Param        region      formData    string  true   "Destination region"
 // @Param        country     formData    string  true   "Destination country"
 // @Param        coverImage  formData    file    false  "Cover image file"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  responses.DestinationResponse
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/destinations/{id} [put]
 func UpdateDestination(c *fiber.Ctx) error {
-	id := c.Params("id")
+	destinationID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	id := destinationID.String()
 	var req requests.UpdateDestinationRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
 	// Get user ID from context
-	userID, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "User ID not found in context"})
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
 	}
 
 	// Get existing destination
 	destination, err := services.GetDestinationByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Destination not found"})
+		return apperrors.FromDB(err, "Destination")
 	}
-
-	// Get the user
-	user, err := services.GetUserByID(userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get user details"})
+	if err := checkIfMatch(c, destination.Version); err != nil {
+		return err
 	}
 
 	// Update fields
@@ -179,7 +200,7 @@ func UpdateDestination(c *fiber.Ctx) error {
 	destination.Description = req.Description
 	destination.Region = req.Region
 	destination.Country = req.Country
-	destination.User = *user // Update the User relationship
+	destination.UpdatedBy = &userUUID
 
 	// Handle cover image if provided
 	file, err := c.FormFile("coverImage")
@@ -187,22 +208,76 @@ func UpdateDestination(c *fiber.Ctx) error {
 		log.Println("Destination Cover Image Found manually:", file.Filename, file.Size)
 		fileURL, err := utils.UploadImageToCloudinary(file, "destinations")
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to save cover image"})
+			return apperrors.Internal("Failed to save cover image", err)
 		}
 		destination.CoverImage = models.MediaDestination{
 			ID:            uint(time.Now().Unix()),
 			DestinationID: destination.ID,
-			UserID:        uuid.MustParse(userID),
+			UserID:        userUUID,
 			URL:           fileURL,
 			Type:          destination.CoverImage.Type,
 		}
 	}
 
-	err = services.UpdateDestination(id, destination)
+	err = services.UpdateDestination(id, destination.Version, destination)
+	if err != nil {
+		return apperrors.FromDB(err, "Destination")
+	}
+
+	destination.Version++
+	setETag(c, destination.Version)
+	return c.JSON(responses.ToDestinationResponse(*destination))
+}
+
+// PatchDestination godoc
+// @Summary      Partially update a destination
+// @Description  Applies a JSON Merge Patch (RFC 7386); null resets a field, absent fields are kept
+// @Tags         admin_destinations
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string                            true  "Destination ID"
+// @Param        patch  body  requests.PatchDestinationRequest  true  "Merge patch"
+// @Param        If-Match  header  string  true  "ETag from the last read"
+// @Success      200  {object}  responses.DestinationResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/destinations/{id} [patch]
+func PatchDestination(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	destination, err := services.GetDestinationByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Destination")
+	}
+	if err := checkIfMatch(c, destination.Version); err != nil {
+		return err
+	}
+	req := requests.NewPatchDestinationRequest(*destination)
+	columns, err := requests.ApplyMergePatch(c.Body(), &req)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update destination"})
+		return err
+	}
+	columns["updated_by"] = userUUID
+
+	if err := services.PatchDestination(id, destination.Version, columns); err != nil {
+		return apperrors.FromDB(err, "Destination")
 	}
 
+	destination, err = services.GetDestinationByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated destination", err)
+	}
+	setETag(c, destination.Version)
 	return c.JSON(responses.ToDestinationResponse(*destination))
 }
 
@@ -212,14 +287,78 @@ func UpdateDestination(c *fiber.Ctx) error {
 // @Tags         admin_destinations
 // @Produce      json
 // @Param        id   path      string  true  "Destination ID"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/destinations/{id} [delete]
 func DeleteDestination(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := services.DeleteDestination(id)
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	destination, err := services.GetDestinationByID(id.String())
+	if err != nil {
+		return apperrors.FromDB(err, "Destination")
+	}
+	if err := checkIfMatch(c, destination.Version); err != nil {
+		return err
+	}
+	err = services.DeleteDestination(id.String(), destination.Version)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete destination"})
+		return apperrors.FromDB(err, "Destination")
 	}
 	return c.JSON(fiber.Map{"message": "Destination deleted"})
 }
+
+// GetDestinationReviews godoc
+// @Summary      Get reviews for a destination
+// @Description  Retrieves the approved reviews of a destination
+// @Tags         destinations
+// @Produce      json
+// @Param        id    path      string  true   "Destination ID"
+// @Param        sort  query     string  false  "newest (default), helpful, rating or rating_asc"
+// @Success      200   {array}   models.Review
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /api/destinations/{id}/reviews [get]
+func GetDestinationReviews(c *fiber.Ctx) error {
+	return listTargetReviews(c, models.ReviewTargetDestination)
+}
+
+// GetDestinationReviewSummary godoc
+// @Summary      Get the review summary of a destination
+// @Description  Returns the rating histogram, average aspect ratings and most helpful reviews of a destination, including those of its tours and events
+// @Tags         destinations
+// @Produce      json
+// @Param        id   path      string  true  "Destination ID"
+// @Success      200  {object}  models.ReviewSummary
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/destinations/{id}/reviews/summary [get]
+func GetDestinationReviewSummary(c *fiber.Ctx) error {
+	return targetReviewSummary(c, models.ReviewTargetDestination)
+}
+
+// CreateDestinationReview godoc
+// @Summary      Create a review for a destination
+// @Description  Creates the logged-in user's review for a destination. Each user can review a destination once;
+// @Description  the review is published once a moderator approves it.
+// @Tags         destinations
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                        true  "Destination ID"
+// @Param        review  body      requests.CreateReviewRequest  true  "Review"
+// @Success      201     {object}  models.Review
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/destinations/{id}/reviews [post]
+func CreateDestinationReview(c *fiber.Ctx) error {
+	return createTargetReview(c, models.ReviewTargetDestination)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetMyBookingVoucher godoc
+// @Summary      Download the voucher of one of my bookings
+// @Description  Returns a PDF voucher with the booking reference, a QR code identifying the booking, the dates, travellers,
+// @Description  meeting point and itinerary. Only confirmed and completed bookings have a voucher.
+// @Tags         user_bookings
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/voucher [get]
+func GetMyBookingVoucher(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	booking, err := services.GetBookingVoucher(userID, id)
+	if err != nil {
+		return err
+	}
+	return sendPDF(c, services.VoucherFilename(booking), services.RenderBookingVoucher(booking))
+}
+
+// GetMyBookingInvoice godoc
+// @Summary      Download the invoice of one of my bookings
+// @Description  Returns the booking's tax invoice as a PDF, issuing it with the next invoice number the first time. Prices
+// @Description  include tax; the invoice shows the net amount and the tax. Invoices are issued for confirmed and completed
+// @Description  bookings and stay available after a cancellation.
+// @Tags         user_bookings
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/invoice [get]
+func GetMyBookingInvoice(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	invoice, err := services.GetBookingInvoice(userID, id)
+	if err != nil {
+		return err
+	}
+	return sendPDF(c, services.InvoiceFilename(invoice), services.RenderInvoice(invoice))
+}
+
+// GetMyEventTicket godoc
+// @Summary      Download the ticket of a claimed event place
+// @Description  Returns the PDF ticket, with a QR code, of the booking an event waitlist offer was claimed into, once that booking is paid.
+// @Description  It is the same document as GET /api/user/bookings/{id}/voucher.
+// @Tags         user_waitlist
+// @Produce      application/pdf
+// @Param        id   path      string  true  "Waitlist entry ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/waitlist/{id}/ticket [get]
+func GetMyEventTicket(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	booking, err := services.GetEventTicket(userID, id)
+	if err != nil {
+		return err
+	}
+	return sendPDF(c, services.VoucherFilename(booking), services.RenderBookingVoucher(booking))
+}
+
+func sendPDF(c *fiber.Ctx, filename string, data []byte) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(data)
+}
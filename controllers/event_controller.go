@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetEventReviews godoc
+// @Summary      Get reviews for an event
+// @Description  Retrieves the approved reviews of an event
+// @Tags         events
+// @Produce      json
+// @Param        id    path      string  true   "Event ID"
+// @Param        sort  query     string  false  "newest (default), helpful, rating or rating_asc"
+// @Success      200   {array}   models.Review
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /api/events/{id}/reviews [get]
+func GetEventReviews(c *fiber.Ctx) error {
+	return listTargetReviews(c, models.ReviewTargetEvent)
+}
+
+// GetEventReviewSummary godoc
+// @Summary      Get the review summary of an event
+// @Description  Returns the rating histogram, average aspect ratings and most helpful reviews of an event
+// @Tags         events
+// @Produce      json
+// @Param        id   path      string  true  "Event ID"
+// @Success      200  {object}  models.ReviewSummary
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/events/{id}/reviews/summary [get]
+func GetEventReviewSummary(c *fiber.Ctx) error {
+	return targetReviewSummary(c, models.ReviewTargetEvent)
+}
+
+// CreateEventReview godoc
+// @Summary      Create a review for an event
+// @Description  Creates the logged-in user's review for an event. Each user can review an event once;
+// @Description  the review is published once a moderator approves it.
+// @Tags         events
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                        true  "Event ID"
+// @Param        review  body      requests.CreateReviewRequest  true  "Review"
+// @Success      201     {object}  models.Review
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/events/{id}/reviews [post]
+func CreateEventReview(c *fiber.Ctx) error {
+	return createTargetReview(c, models.ReviewTargetEvent)
+}
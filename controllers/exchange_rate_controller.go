@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"io"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRateFileSize caps exchange rate imports at 1 MiB.
+const maxRateFileSize = 1 << 20
+
+// GetExchangeRates godoc
+// @Summary      List exchange rates
+// @Description  Lists how many units of each currency one unit of the base currency buys
+// @Tags         currencies
+// @Produce      json
+// @Success      200  {object}  object{base=string,rates=[]models.ExchangeRate}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/exchange-rates [get]
+func GetExchangeRates(c *fiber.Ctx) error {
+	rates, err := services.GetExchangeRates()
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve exchange rates", err)
+	}
+	return c.JSON(fiber.Map{"base": config.BaseCurrency, "rates": rates})
+}
+
+// SetExchangeRate godoc
+// @Summary      Set an exchange rate
+// @Description  Creates or replaces the rate of a currency against the base currency
+// @Tags         admin_currencies
+// @Accept       json
+// @Produce      json
+// @Param        currency  path      string                           true  "ISO 4217 currency code"
+// @Param        rate      body      requests.SetExchangeRateRequest  true  "Rate"
+// @Success      200       {object}  models.ExchangeRate
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      500       {object}  models.ErrorResponse
+// @Router       /admin/exchange-rates/{currency} [put]
+func SetExchangeRate(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.SetExchangeRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	rate, err := services.SetExchangeRate(c.Params("currency"), req.Rate, &adminID)
+	if err != nil {
+		return apperrors.FromDB(err, "Exchange rate")
+	}
+	return c.JSON(rate)
+}
+
+// DeleteExchangeRate godoc
+// @Summary      Delete an exchange rate
+// @Description  Removes the rate of a currency; prices in it can no longer be converted
+// @Tags         admin_currencies
+// @Produce      json
+// @Param        currency  path      string  true  "ISO 4217 currency code"
+// @Success      200       {object}  map[string]string
+// @Failure      404       {object}  models.ErrorResponse
+// @Failure      500       {object}  models.ErrorResponse
+// @Router       /admin/exchange-rates/{currency} [delete]
+func DeleteExchangeRate(c *fiber.Ctx) error {
+	if err := services.DeleteExchangeRate(c.Params("currency")); err != nil {
+		return apperrors.FromDB(err, "Exchange rate")
+	}
+	return c.JSON(fiber.Map{"message": "Exchange rate deleted"})
+}
+
+// ImportExchangeRates godoc
+// @Summary      Import exchange rates
+// @Description  Creates or replaces rates from a file. CSV files hold "currency,rate" rows against the base currency, optionally with a header;
+// @Description  JSON files look like {"base": "EUR", "rates": {"USD": 1.08}} and are converted when their base differs. Nothing is imported if any row is invalid.
+// @Tags         admin_currencies
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "CSV or JSON rate file (max 1 MiB)"
+// @Success      200   {object}  object{imported=integer}
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /admin/exchange-rates/import [post]
+func ImportExchangeRates(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return apperrors.BadRequest("A rate file is required")
+	}
+	if header.Size > maxRateFileSize {
+		return apperrors.BadRequest("The rate file must not exceed 1 MiB")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return apperrors.Internal("Failed to read the rate file", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxRateFileSize))
+	if err != nil {
+		return apperrors.Internal("Failed to read the rate file", err)
+	}
+
+	imported, err := services.ImportExchangeRates(header.Filename, data, &adminID)
+	if err != nil {
+		return apperrors.FromDB(err, "Exchange rate")
+	}
+	return c.JSON(fiber.Map{"imported": imported})
+}
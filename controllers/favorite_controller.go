@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/responses"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetMyFavorites godoc
+// @Summary      List my favourites
+// @Description  Returns the tours, events and destinations the logged-in user saved, newest first
+// @Tags         user_favorites
+// @Produce      json
+// @Param        type   query  string   false  "Only favourites of this type: tour, event or destination"
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Success      200  {object}  object{data=[]responses.FavoriteResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Router       /api/user/favorites [get]
+func GetMyFavorites(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	favorites, total, err := services.GetUserFavorites(c, userID)
+	if err != nil {
+		return apperrors.FromDB(err, "Favorite")
+	}
+	response := make([]responses.FavoriteResponse, len(favorites))
+	for i, favorite := range favorites {
+		response[i] = responses.ToFavoriteResponse(favorite)
+	}
+	return c.JSON(utils.PaginationResponse(c, response, total))
+}
+
+// AddFavorite godoc
+// @Summary      Save a favourite
+// @Description  Saves a tour, event or destination for the logged-in user. Saving one again returns the existing favourite
+// @Description  with status 200.
+// @Tags         user_favorites
+// @Accept       json
+// @Produce      json
+// @Param        favorite  body      requests.AddFavoriteRequest  true  "What to save"
+// @Success      201       {object}  models.Favorite
+// @Success      200       {object}  models.Favorite
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      401       {object}  models.ErrorResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Router       /api/user/favorites [post]
+func AddFavorite(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.AddFavoriteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	targetID, err := parseUUIDField(req.TargetID, "targetId")
+	if err != nil {
+		return err
+	}
+	favorite, created, err := services.AddFavorite(userID, models.FavoriteTarget(req.TargetType), targetID)
+	if err != nil {
+		return apperrors.FromDB(err, "Favorite")
+	}
+	if created {
+		c.Status(fiber.StatusCreated)
+	}
+	return c.JSON(favorite)
+}
+
+// RemoveFavorite godoc
+// @Summary      Remove a favourite
+// @Description  Removes a tour, event or destination from the logged-in user's favourites
+// @Tags         user_favorites
+// @Param        type      path  string  true  "tour, event or destination"
+// @Param        targetId  path  string  true  "ID of the tour, event or destination"
+// @Success      204
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/favorites/{type}/{targetId} [delete]
+func RemoveFavorite(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	targetType, err := services.ParseFavoriteTarget(c.Params("type"), "type")
+	if err != nil {
+		return err
+	}
+	targetID, err := paramUUID(c, "targetId")
+	if err != nil {
+		return err
+	}
+	if err := services.RemoveFavorite(userID, targetType, targetID); err != nil {
+		return apperrors.FromDB(err, "Favorite")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// markTourFavorites sets isFavorite on tour responses for logged-in users.
+func markTourFavorites(c *fiber.Ctx, tours []responses.TourResponse) error {
+	userID := optionalUserID(c)
+	if userID == nil || len(tours) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(tours))
+	for i := range tours {
+		id, err := uuid.Parse(tours[i].ID)
+		if err != nil {
+			return apperrors.Internal("Failed to load favourites", err)
+		}
+		ids[i] = id
+	}
+	saved, err := services.FavoriteSet(*userID, models.FavoriteTour, ids)
+	if err != nil {
+		return apperrors.Internal("Failed to load favourites", err)
+	}
+	for i := range tours {
+		isFavorite := saved[ids[i]]
+		tours[i].IsFavorite = &isFavorite
+	}
+	return nil
+}
+
+// markDestinationFavorites sets isFavorite on destination responses for
+// logged-in users.
+func markDestinationFavorites(c *fiber.Ctx, destinations []responses.DestinationResponse) error {
+	userID := optionalUserID(c)
+	if userID == nil || len(destinations) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(destinations))
+	for i := range destinations {
+		id, err := uuid.Parse(destinations[i].ID)
+		if err != nil {
+			return apperrors.Internal("Failed to load favourites", err)
+		}
+		ids[i] = id
+	}
+	saved, err := services.FavoriteSet(*userID, models.FavoriteDestination, ids)
+	if err != nil {
+		return apperrors.Internal("Failed to load favourites", err)
+	}
+	for i := range destinations {
+		isFavorite := saved[ids[i]]
+		destinations[i].IsFavorite = &isFavorite
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// paramUUID parses a UUID path parameter, rejecting malformed values with a 400.
+func paramUUID(c *fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, apperrors.BadRequest("Malformed " + name)
+	}
+	return id, nil
+}
+
+// paramID validates a UUID path parameter and returns it in string form,
+// which is what the service layer takes.
+func paramID(c *fiber.Ctx, name string) (string, error) {
+	id, err := paramUUID(c, name)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// parseUUIDField parses a UUID supplied in a request body field.
+func parseUUIDField(value, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   field,
+			Message: "must be a valid UUID",
+		})
+	}
+	return id, nil
+}
+
+// optionalUUIDField is parseUUIDField for optional fields; empty values
+// give nil.
+func optionalUUIDField(value, field string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := parseUUIDField(value, field)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// currentUserID returns the authenticated user's ID set by the auth middleware.
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return uuid.Nil, apperrors.Unauthorized("Unauthorized")
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, apperrors.Unauthorized("Invalid token subject")
+	}
+	return id, nil
+}
+
+// optionalUserID returns the user identified by middlewares.OptionalJWT, or
+// nil for anonymous requests.
+func optionalUserID(c *fiber.Ctx) *uuid.UUID {
+	id, err := currentUserID(c)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// setETag advertises the version of the resource in the response.
+func setETag(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, utils.VersionETag(version))
+}
+
+// notModified reports whether the client's cached copy, named by
+// If-None-Match, is still the current version.
+func notModified(c *fiber.Ctx, version int) bool {
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && utils.MatchETag(header, utils.VersionETag(version), true)
+}
+
+// sendWithETag renders body as JSON under a weak ETag derived from the
+// rendered bytes, answering 304 when If-None-Match names it. Use it where the
+// response carries fields that change without a version bump.
+func sendWithETag(c *fiber.Ctx, version int, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	etag := utils.BodyETag(version, data)
+	c.Set(fiber.HeaderETag, etag)
+	c.Vary(fiber.HeaderAuthorization)
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" && utils.MatchETag(header, etag, true) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
+
+// checkIfMatch requires an If-Match header naming the current version of the
+// resource, so concurrent admin edits can't silently overwrite each other.
+func checkIfMatch(c *fiber.Ctx, version int) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return apperrors.PreconditionRequired("If-Match header is required; send the ETag from your last read")
+	}
+	if !utils.MatchVersion(header, version) {
+		return apperrors.PreconditionFailed("The resource was modified since it was read; reload and retry")
+	}
+	return nil
+}
+
+// optionalRating treats an aspect rating of 0 as not given.
+func optionalRating(rating *int) *int {
+	if rating == nil || *rating == 0 {
+		return nil
+	}
+	return rating
+}
+
+// idempotencyKey returns the client's Idempotency-Key header, which is
+// required on requests that move money.
+func idempotencyKey(c *fiber.Ctx) (string, error) {
+	key := c.Get("Idempotency-Key")
+	if key == "" {
+		return "", apperrors.BadRequest("Idempotency-Key header is required")
+	}
+	if len(key) > 255 {
+		return "", apperrors.BadRequest("Idempotency-Key must be at most 255 characters")
+	}
+	return key, nil
+}
+
+// minorPrice converts a price given in major units to the currency's minor
+// units, rejecting amounts finer than the currency allows (e.g. 10.5 RWF).
+func minorPrice(amount float64, currency, field string) (int64, error) {
+	minor, ok := utils.ExactMinorUnits(amount, currency)
+	if !ok {
+		return 0, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   field,
+			Message: "has more decimals than " + currency + " allows",
+		})
+	}
+	return minor, nil
+}
+
+// patchPrice rewrites the price member of a merge patch, which clients send
+// in major units, as minor units of the tour's or event's currency. It also
+// runs when only the currency changes, since the same amount then means a
+// different number of minor units.
+func patchPrice(columns map[string]interface{}, column string, amount float64, currency, field string) error {
+	_, priceSet := columns[column]
+	_, currencySet := columns["currency"]
+	if !priceSet && !currencySet {
+		return nil
+	}
+	minor, err := minorPrice(amount, currency, field)
+	if err != nil {
+		return err
+	}
+	columns[column] = minor
+	return nil
+}
+
+// parseDateQuery parses an optional date query parameter given as
+// YYYY-MM-DD or RFC 3339.
+func parseDateQuery(value, field string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date, nil
+		}
+	}
+	return nil, apperrors.Validation("Validation failed", models.FieldError{
+		Field:   field,
+		Message: "must be a date as YYYY-MM-DD",
+	})
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/pdf"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetDepartureManifest godoc
+// @Summary      Export a departure manifest
+// @Description  Lists every traveller on the departure's confirmed bookings with their participant details, as JSON, CSV or PDF.
+// @Description  Travellers the customer hasn't described yet get a row too; missing lists the details still owed.
+// @Tags         admin_departures
+// @Produce      json,text/csv,application/pdf
+// @Param        id           path      string  true   "Tour ID"
+// @Param        departureId  path      string  true   "Departure ID"
+// @Param        format       query     string  false  "json (default), csv or pdf"
+// @Success      200          {object}  models.Manifest
+// @Failure      400          {object}  models.ErrorResponse
+// @Failure      404          {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/{departureId}/manifest [get]
+func GetDepartureManifest(c *fiber.Ctx) error {
+	return sendManifest(c, nil)
+}
+
+// GetVendorTours godoc
+// @Summary      List the tours I run
+// @Description  Returns the tours assigned to the logged-in vendor, newest first
+// @Tags         vendor
+// @Produce      json
+// @Param        page   query  integer  false  "Page number (default: 1)"
+// @Param        limit  query  integer  false  "Limit per page (default: 10)"
+// @Success      200  {object}  object{data=[]responses.TourResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Router       /api/vendor/tours [get]
+func GetVendorTours(c *fiber.Ctx) error {
+	vendorID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	tours, total, err := services.GetVendorTours(c, vendorID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve tours", err)
+	}
+	tourResponses, err := toTourResponses(c, tours)
+	if err != nil {
+		return err
+	}
+	return c.JSON(utils.PaginationResponse(c, tourResponses, total))
+}
+
+// GetVendorDepartureManifest godoc
+// @Summary      Export a manifest for a departure I run
+// @Description  Same as GET /admin/tours/{id}/departures/{departureId}/manifest, for departures of tours assigned to the
+// @Description  logged-in vendor
+// @Tags         vendor
+// @Produce      json,text/csv,application/pdf
+// @Param        id           path      string  true   "Tour ID"
+// @Param        departureId  path      string  true   "Departure ID"
+// @Param        format       query     string  false  "json (default), csv or pdf"
+// @Success      200          {object}  models.Manifest
+// @Failure      400          {object}  models.ErrorResponse
+// @Failure      401          {object}  models.ErrorResponse
+// @Failure      403          {object}  models.ErrorResponse
+// @Failure      404          {object}  models.ErrorResponse
+// @Router       /api/vendor/tours/{id}/departures/{departureId}/manifest [get]
+func GetVendorDepartureManifest(c *fiber.Ctx) error {
+	vendorID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	return sendManifest(c, &vendorID)
+}
+
+// sendManifest answers with the manifest of the departure in the path in
+// the format asked for.
+func sendManifest(c *fiber.Ctx, vendorID *uuid.UUID) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departureID, err := paramUUID(c, "departureId")
+	if err != nil {
+		return err
+	}
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "format",
+			Message: "must be one of json csv pdf",
+		})
+	}
+
+	manifest, err := services.GetDepartureManifest(tourID, departureID, vendorID)
+	if err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("manifest-%s-%s", manifest.StartDate.Format("20060102"), manifest.DepartureID.String()[:8])
+	switch format {
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		return writeManifestCSV(c, manifest)
+	case "pdf":
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+		return c.Send(manifestPDF(manifest))
+	}
+	return c.JSON(manifest)
+}
+
+func writeManifestCSV(c *fiber.Ctx, manifest *models.Manifest) error {
+	w := csv.NewWriter(c)
+	w.Write([]string{"booking_id", "booked_by", "booked_by_email", "position", "full_name", "date_of_birth", "nationality",
+		"passport_number", "passport_expiry", "dietary_requirements", "emergency_contact_name", "emergency_contact_phone", "missing"})
+	for _, row := range manifest.Rows {
+		p := row.Details()
+		position := ""
+		if p.Position > 0 {
+			position = strconv.Itoa(p.Position)
+		}
+		w.Write([]string{
+			row.BookingID.String(),
+			row.BookedBy,
+			row.BookedByEmail,
+			position,
+			p.FullName,
+			string(p.DateOfBirth),
+			p.Nationality,
+			string(p.PassportNumber),
+			string(p.PassportExpiry),
+			string(p.DietaryRequirements),
+			string(p.EmergencyContactName),
+			string(p.EmergencyContactPhone),
+			strings.Join(row.Missing, " "),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// manifestColumn is one column of the PDF manifest table.
+type manifestColumn struct {
+	title string
+	width float64
+	value func(row models.ManifestRow) string
+}
+
+var manifestColumns = []manifestColumn{
+	{"#", 22, nil},
+	{"Name", 120, func(row models.ManifestRow) string { return row.Details().FullName }},
+	{"Born", 58, func(row models.ManifestRow) string { return string(row.Details().DateOfBirth) }},
+	{"Nationality", 62, func(row models.ManifestRow) string { return row.Details().Nationality }},
+	{"Passport", 92, func(row models.ManifestRow) string {
+		p := row.Details()
+		if p.PassportExpiry == "" {
+			return string(p.PassportNumber)
+		}
+		return fmt.Sprintf("%s (exp. %s)", p.PassportNumber, p.PassportExpiry)
+	}},
+	{"Dietary needs", 96, func(row models.ManifestRow) string { return string(row.Details().DietaryRequirements) }},
+	{"Emergency contact", 120, func(row models.ManifestRow) string {
+		p := row.Details()
+		return strings.TrimSpace(fmt.Sprintf("%s %s", p.EmergencyContactName, p.EmergencyContactPhone))
+	}},
+	{"Booked by", 110, func(row models.ManifestRow) string { return row.BookedBy }},
+	{"Missing", 101, func(row models.ManifestRow) string { return strings.Join(row.Missing, ", ") }},
+}
+
+// manifestPDF lays the manifest out as a table on landscape A4 pages.
+func manifestPDF(manifest *models.Manifest) []byte {
+	const (
+		margin    = 30.0
+		rowHeight = 16.0
+		fontSize  = 8.0
+	)
+	doc := pdf.New(pdf.A4Height, pdf.A4Width)
+	doc.SetTitle("Manifest: " + manifest.TourTitle)
+
+	page := 0
+	y := 0.0
+	startPage := func() {
+		doc.AddPage()
+		page++
+		doc.Text(margin, margin+14, pdf.HelveticaBold, 14, pdf.Truncate(pdf.HelveticaBold, 14, manifest.TourTitle, doc.Width()-2*margin-80))
+		doc.TextRight(doc.Width()-margin, margin+14, pdf.Helvetica, 9, fmt.Sprintf("Page %d", page))
+		doc.Text(margin, margin+30, pdf.Helvetica, 9, fmt.Sprintf("Departure %s to %s  ·  %d travellers  ·  generated %s UTC",
+			manifest.StartDate.Format("2 Jan 2006"), manifest.EndDate.Format("2 Jan 2006"),
+			manifest.Travelers, manifest.GeneratedAt.Format("2 Jan 2006 15:04")))
+
+		y = margin + 42
+		doc.FillRect(margin, y, doc.Width()-2*margin, rowHeight, 0.85)
+		x := margin
+		for _, column := range manifestColumns {
+			doc.Text(x+3, y+11, pdf.HelveticaBold, fontSize, column.title)
+			x += column.width
+		}
+		y += rowHeight
+	}
+
+	startPage()
+	for i, row := range manifest.Rows {
+		if y+rowHeight > doc.Height()-margin {
+			startPage()
+		}
+		if i%2 == 1 {
+			doc.FillRect(margin, y, doc.Width()-2*margin, rowHeight, 0.96)
+		}
+		x := margin
+		for _, column := range manifestColumns {
+			text := strconv.Itoa(i + 1)
+			if column.value != nil {
+				text = column.value(row)
+			}
+			doc.Text(x+3, y+11, pdf.Helvetica, fontSize, pdf.Truncate(pdf.Helvetica, fontSize, text, column.width-6))
+			x += column.width
+		}
+		y += rowHeight
+	}
+	if len(manifest.Rows) == 0 {
+		doc.Text(margin+3, y+13, pdf.Helvetica, 9, "No confirmed bookings on this departure.")
+	}
+	doc.Line(margin, y, doc.Width()-margin, y, 0.5)
+	return doc.Bytes()
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetMyNotifications godoc
+// @Summary      List my notifications
+// @Description  Lists the logged-in user's notifications, newest first. They are also sent by email.
+// @Tags         user_notifications
+// @Produce      json
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        unread  query  boolean  false  "Only unread notifications"
+// @Success      200  {object}  object{data=[]models.Notification,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/notifications [get]
+func GetMyNotifications(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	notifications, totalCount, err := services.GetUserNotifications(c, userID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve notifications", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, notifications, totalCount))
+}
+
+// MarkNotificationRead godoc
+// @Summary      Mark a notification as read
+// @Tags         user_notifications
+// @Produce      json
+// @Param        id   path      string  true  "Notification ID"
+// @Success      200  {object}  models.Notification
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/notifications/{id}/read [post]
+func MarkNotificationRead(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	notification, err := services.MarkNotificationRead(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(notification)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary      Mark all my notifications as read
+// @Tags         user_notifications
+// @Produce      json
+// @Success      200  {object}  object{updated=integer}
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/notifications/read-all [post]
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	updated, err := services.MarkAllNotificationsRead(userID)
+	if err != nil {
+		return apperrors.Internal("Failed to update notifications", err)
+	}
+	return c.JSON(fiber.Map{"updated": updated})
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetMyBookingParticipants godoc
+// @Summary      List the travellers on one of my bookings
+// @Description  Returns the participant details given for one of the logged-in user's bookings, in party order
+// @Tags         user_bookings
+// @Produce      json
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {array}   models.Participant
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/participants [get]
+func GetMyBookingParticipants(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	participants, err := services.GetBookingParticipants(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(participants)
+}
+
+// SetMyBookingParticipants godoc
+// @Summary      Give the travellers on one of my bookings
+// @Description  Replaces the participant details of a pending or confirmed booking. There must be one participant per adult,
+// @Description  child and infant on the booking, each with the details the tour's participantFields require: dateOfBirth,
+// @Description  nationality, passport (number and expiry), dietaryRequirements, emergencyContact (name and phone).
+// @Description  Dates are YYYY-MM-DD. Everything but the name and nationality is stored encrypted.
+// @Tags         user_bookings
+// @Accept       json
+// @Produce      json
+// @Param        id            path      string                        true  "Booking ID"
+// @Param        participants  body      requests.ParticipantsRequest  true  "Travellers"
+// @Success      200           {array}   models.Participant
+// @Failure      400           {object}  models.ErrorResponse
+// @Failure      401           {object}  models.ErrorResponse
+// @Failure      404           {object}  models.ErrorResponse
+// @Failure      409           {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/participants [put]
+func SetMyBookingParticipants(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.ParticipantsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.ValidateParticipants(&req); err != nil {
+		return err
+	}
+	participants := make([]models.Participant, len(req.Participants))
+	for i, p := range req.Participants {
+		prefix := fmt.Sprintf("participants[%d].", i)
+		dateOfBirth, err := participantDate(p.DateOfBirth, prefix+"dateOfBirth")
+		if err != nil {
+			return err
+		}
+		if dateOfBirth != "" && dateOfBirth > time.Now().UTC().Format(time.DateOnly) {
+			return apperrors.Validation("Validation failed", models.FieldError{
+				Field:   prefix + "dateOfBirth",
+				Message: "must not be in the future",
+			})
+		}
+		passportExpiry, err := participantDate(p.PassportExpiry, prefix+"passportExpiry")
+		if err != nil {
+			return err
+		}
+		participants[i] = models.Participant{
+			FullName:              p.FullName,
+			DateOfBirth:           models.EncryptedString(dateOfBirth),
+			Nationality:           p.Nationality,
+			PassportNumber:        models.EncryptedString(p.PassportNumber),
+			PassportExpiry:        models.EncryptedString(passportExpiry),
+			DietaryRequirements:   models.EncryptedString(p.DietaryRequirements),
+			EmergencyContactName:  models.EncryptedString(p.EmergencyContactName),
+			EmergencyContactPhone: models.EncryptedString(p.EmergencyContactPhone),
+		}
+	}
+
+	saved, err := services.SetBookingParticipants(userID, id, participants)
+	if err != nil {
+		return err
+	}
+	return c.JSON(saved)
+}
+
+// participantDate checks an optional YYYY-MM-DD date.
+func participantDate(value, field string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return "", apperrors.Validation("Validation failed", models.FieldError{
+			Field:   field,
+			Message: "must be a date as YYYY-MM-DD",
+		})
+	}
+	return value, nil
+}
+
+// participantFieldList checks the participant details a tour requires.
+func participantFieldList(values []string) (models.StringList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return services.CheckParticipantFields(values)
+}
+
+// vendorField parses and checks the vendor a tour is assigned to.
+func vendorField(value string) (*uuid.UUID, error) {
+	id, err := optionalUUIDField(value, "vendorId")
+	if err != nil {
+		return nil, err
+	}
+	if err := services.CheckVendor(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// patchTourOperations checks the vendor and participant details set by a
+// tour merge patch; null removes them.
+func patchTourOperations(columns map[string]interface{}) error {
+	if value, ok := columns["vendor_id"]; ok {
+		id, err := vendorField(value.(string))
+		if err != nil {
+			return err
+		}
+		columns["vendor_id"] = id
+	}
+	if value, ok := columns["participant_fields"]; ok {
+		fields, err := services.CheckParticipantFields(value.([]string))
+		if err != nil {
+			return err
+		}
+		columns["participant_fields"] = fields
+	}
+	return nil
+}
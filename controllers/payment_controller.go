@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PayBooking godoc
+// @Summary      Start paying for a booking
+// @Description  Creates a payment for one of the logged-in user's pending bookings and returns the client secret needed to complete it. Repeating the Idempotency-Key returns the original payment with 200 instead of charging twice.
+// @Tags         user_payments
+// @Produce      json
+// @Param        id               path      string  true  "Booking ID"
+// @Param        Idempotency-Key  header    string  true  "Client-chosen key, unique per payment attempt"
+// @Success      201              {object}  models.Payment
+// @Success      200              {object}  models.Payment
+// @Failure      400              {object}  models.ErrorResponse
+// @Failure      401              {object}  models.ErrorResponse
+// @Failure      404              {object}  models.ErrorResponse
+// @Failure      409              {object}  models.ErrorResponse
+// @Failure      500              {object}  models.ErrorResponse
+// @Router       /api/user/bookings/{id}/payments [post]
+func PayBooking(c *fiber.Ctx) error {
+	bookingID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	key, err := idempotencyKey(c)
+	if err != nil {
+		return err
+	}
+
+	payment, created, err := services.CreateBookingPayment(userID, bookingID, key)
+	if err != nil {
+		return apperrors.FromDB(err, "Payment")
+	}
+	if created {
+		c.Status(fiber.StatusCreated)
+	}
+	return c.JSON(payment)
+}
+
+// ConfirmMyPayment godoc
+// @Summary      Confirm a payment
+// @Description  Charges one of the logged-in user's payments with the given payment method. A successful payment confirms the booking; a declined one answers 402 and can be retried with another method.
+// @Tags         user_payments
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true  "Payment ID"
+// @Param        payment  body      requests.ConfirmPaymentRequest  true  "Payment method"
+// @Success      200      {object}  models.Payment
+// @Failure      400      {object}  models.ErrorResponse
+// @Failure      401      {object}  models.ErrorResponse
+// @Failure      402      {object}  models.ErrorResponse
+// @Failure      404      {object}  models.ErrorResponse
+// @Failure      409      {object}  models.ErrorResponse
+// @Failure      500      {object}  models.ErrorResponse
+// @Router       /api/user/payments/{id}/confirm [post]
+func ConfirmMyPayment(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.ConfirmPaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	payment, err := services.ConfirmPayment(userID, id, req.PaymentMethod)
+	if err != nil {
+		return apperrors.FromDB(err, "Payment")
+	}
+	return c.JSON(payment)
+}
+
+// GetAllPayments godoc
+// @Summary      List payments
+// @Description  Lists payments, newest first
+// @Tags         admin_payments
+// @Produce      json
+// @Param        page        query  integer  false  "Page number (default: 1)"
+// @Param        limit       query  integer  false  "Limit per page (default: 10)"
+// @Param        status      query  string   false  "Only payments in this state (pending, succeeded, failed, partially_refunded, refunded, cancelled)"
+// @Param        booking_id  query  string   false  "Only payments for this booking"
+// @Param        user_id     query  string   false  "Only payments by this user"
+// @Success      200  {object}  object{data=[]models.Payment,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/payments [get]
+func GetAllPayments(c *fiber.Ctx) error {
+	paymentList, totalCount, err := services.GetAllPayments(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve payments", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, paymentList, totalCount))
+}
+
+// GetPaymentByID godoc
+// @Summary      Get a payment
+// @Description  Returns a payment with its refunds
+// @Tags         admin_payments
+// @Produce      json
+// @Param        id   path      string  true  "Payment ID"
+// @Success      200  {object}  models.Payment
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /admin/payments/{id} [get]
+func GetPaymentByID(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	payment, err := services.GetPaymentByID(id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(payment)
+}
+
+// RefundPayment godoc
+// @Summary      Refund a payment
+// @Description  Returns part or all of a succeeded payment to the customer. Repeating the Idempotency-Key returns the original refund.
+// @Tags         admin_payments
+// @Accept       json
+// @Produce      json
+// @Param        id               path      string                         true  "Payment ID"
+// @Param        Idempotency-Key  header    string                         true  "Client-chosen key, unique per refund"
+// @Param        refund           body      requests.RefundPaymentRequest  false  "Amount in minor units and reason"
+// @Success      200              {object}  models.PaymentRefund
+// @Failure      400              {object}  models.ErrorResponse
+// @Failure      404              {object}  models.ErrorResponse
+// @Failure      409              {object}  models.ErrorResponse
+// @Failure      500              {object}  models.ErrorResponse
+// @Router       /admin/payments/{id}/refund [post]
+func RefundPayment(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	key, err := idempotencyKey(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.RefundPaymentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperrors.BadRequest("Invalid request body")
+		}
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	var adminID *uuid.UUID
+	if userID, err := currentUserID(c); err == nil {
+		adminID = &userID
+	}
+
+	refund, err := services.RefundPayment(id, req.Amount, req.Reason, key, adminID)
+	if err != nil {
+		return apperrors.FromDB(err, "Refund")
+	}
+	return c.JSON(refund)
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetPricingRules godoc
+// @Summary      List the pricing rules of a tour
+// @Description  Lists the fare, season, group and early-bird rules of a tour
+// @Tags         admin_pricing
+// @Produce      json
+// @Param        id   path      string  true  "Tour ID"
+// @Success      200  {array}   models.PricingRule
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/pricing-rules [get]
+func GetPricingRules(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	rules, err := services.GetPricingRules(tourID)
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	return c.JSON(rules)
+}
+
+// CreatePricingRule godoc
+// @Summary      Add a pricing rule to a tour
+// @Description  fare: child or infant price, as an amount or a percent of the adult fare.
+// @Description  season: between startDate and endDate the adult fare becomes amount, or changes by percent.
+// @Description  group: parties of at least minTravelers get percent off, or amount off per traveler.
+// @Description  early_bird: bookings at least daysBefore days ahead get percent off, or amount off per traveler.
+// @Tags         admin_pricing
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                       true  "Tour ID"
+// @Param        rule  body      requests.PricingRuleRequest  true  "Pricing rule"
+// @Success      201   {object}  models.PricingRule
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      404   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/pricing-rules [post]
+func CreatePricingRule(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	rule, err := parsePricingRule(c, tourID)
+	if err != nil {
+		return err
+	}
+	if err := services.CreatePricingRule(rule); err != nil {
+		return apperrors.FromDB(err, "Pricing rule")
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdatePricingRule godoc
+// @Summary      Replace a pricing rule
+// @Description  Replaces a pricing rule of a tour; see the create endpoint for the kinds of rule
+// @Tags         admin_pricing
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                       true  "Tour ID"
+// @Param        ruleId  path      string                       true  "Pricing rule ID"
+// @Param        rule    body      requests.PricingRuleRequest  true  "Pricing rule"
+// @Success      200     {object}  models.PricingRule
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/pricing-rules/{ruleId} [put]
+func UpdatePricingRule(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	ruleID, err := paramUUID(c, "ruleId")
+	if err != nil {
+		return err
+	}
+	rule, err := parsePricingRule(c, tourID)
+	if err != nil {
+		return err
+	}
+	rule.ID = ruleID
+	if err := services.UpdatePricingRule(rule); err != nil {
+		return apperrors.FromDB(err, "Pricing rule")
+	}
+	return c.JSON(rule)
+}
+
+// DeletePricingRule godoc
+// @Summary      Delete a pricing rule
+// @Description  Removes a pricing rule from a tour; existing bookings keep their price
+// @Tags         admin_pricing
+// @Produce      json
+// @Param        id      path      string  true  "Tour ID"
+// @Param        ruleId  path      string  true  "Pricing rule ID"
+// @Success      200     {object}  map[string]string
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/pricing-rules/{ruleId} [delete]
+func DeletePricingRule(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	ruleID, err := paramUUID(c, "ruleId")
+	if err != nil {
+		return err
+	}
+	if err := services.DeletePricingRule(tourID, ruleID); err != nil {
+		return apperrors.FromDB(err, "Pricing rule")
+	}
+	return c.JSON(fiber.Map{"message": "Pricing rule deleted"})
+}
+
+// parsePricingRule reads a pricing rule of a tour from the request body,
+// converting its amount to minor units of the tour's currency.
+func parsePricingRule(c *fiber.Ctx, tourID uuid.UUID) (*models.PricingRule, error) {
+	var req requests.PricingRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return nil, err
+	}
+	tour, err := services.GetTourByID(tourID.String())
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Tour")
+	}
+
+	rule := &models.PricingRule{
+		TourID:       tourID,
+		Kind:         models.PricingRuleKind(req.Kind),
+		Name:         req.Name,
+		TravelerType: models.TravelerType(req.TravelerType),
+		MinTravelers: req.MinTravelers,
+		DaysBefore:   req.DaysBefore,
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		Percent:      req.Percent,
+	}
+	if req.Amount != nil {
+		amount, err := minorPrice(*req.Amount, tour.Currency, "amount")
+		if err != nil {
+			return nil, err
+		}
+		rule.AmountMinor = &amount
+	}
+	return rule, nil
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ValidatePromotion godoc
+// @Summary      Validate a promotion code
+// @Description  Checks a promotion code against a tour, party and departure and returns the quote with the discount applied.
+// @Description  The per-user limit is checked when a bearer token is sent. Invalid codes fail with a validation error on promoCode giving the reason.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Param        promotion  body      requests.ValidatePromotionRequest  true  "Code, tour and party"
+// @Success      200        {object}  models.Quote
+// @Failure      400        {object}  models.ErrorResponse
+// @Failure      404        {object}  models.ErrorResponse
+// @Failure      500        {object}  models.ErrorResponse
+// @Router       /api/promotions/validate [post]
+func ValidatePromotion(c *fiber.Ctx) error {
+	var req requests.ValidatePromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	tourID, err := parseUUIDField(req.TourID, "tourId")
+	if err != nil {
+		return err
+	}
+	departureID, err := optionalUUIDField(req.DepartureID, "departureId")
+	if err != nil {
+		return err
+	}
+
+	party := models.Party{Adults: req.Adults, Children: req.Children, Infants: req.Infants}
+	quote, err := services.QuoteTour(tourID, party, departureID, req.Date, req.PromoCode, optionalUserID(c))
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	return c.JSON(quote)
+}
+
+// GetPromotions godoc
+// @Summary      List promotions
+// @Description  Lists promotions, newest first
+// @Tags         admin_promotions
+// @Produce      json
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        active  query  boolean  false  "Only active (true) or inactive (false) promotions"
+// @Param        code    query  string   false  "Only codes containing this text"
+// @Success      200  {object}  object{data=[]models.Promotion,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/promotions [get]
+func GetPromotions(c *fiber.Ctx) error {
+	promotions, totalCount, err := services.GetPromotions(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve promotions", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, promotions, totalCount))
+}
+
+// GetPromotionByID godoc
+// @Summary      Get a promotion
+// @Description  Returns a promotion with its redemption count
+// @Tags         admin_promotions
+// @Produce      json
+// @Param        id   path      string  true  "Promotion ID"
+// @Success      200  {object}  models.Promotion
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /admin/promotions/{id} [get]
+func GetPromotionByID(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	promotion, err := services.GetPromotionByID(id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(promotion)
+}
+
+// CreatePromotion godoc
+// @Summary      Create a promotion
+// @Description  Creates a discount code; codes are case-insensitive and stored in upper case
+// @Tags         admin_promotions
+// @Accept       json
+// @Produce      json
+// @Param        promotion  body      requests.PromotionRequest  true  "Promotion"
+// @Success      201        {object}  models.Promotion
+// @Failure      400        {object}  models.ErrorResponse
+// @Failure      409        {object}  models.ErrorResponse
+// @Failure      500        {object}  models.ErrorResponse
+// @Router       /admin/promotions [post]
+func CreatePromotion(c *fiber.Ctx) error {
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	promotion, err := parsePromotion(c)
+	if err != nil {
+		return err
+	}
+	promotion.CreatedBy = adminID
+	if err := services.CreatePromotion(promotion); err != nil {
+		return apperrors.FromDB(err, "Promotion")
+	}
+	return c.Status(fiber.StatusCreated).JSON(promotion)
+}
+
+// UpdatePromotion godoc
+// @Summary      Update a promotion
+// @Description  Replaces the terms of a promotion; its redemption count is kept
+// @Tags         admin_promotions
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                     true  "Promotion ID"
+// @Param        promotion  body      requests.PromotionRequest  true  "Promotion"
+// @Success      200        {object}  models.Promotion
+// @Failure      400        {object}  models.ErrorResponse
+// @Failure      404        {object}  models.ErrorResponse
+// @Failure      409        {object}  models.ErrorResponse
+// @Failure      500        {object}  models.ErrorResponse
+// @Router       /admin/promotions/{id} [put]
+func UpdatePromotion(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	adminID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	promotion, err := parsePromotion(c)
+	if err != nil {
+		return err
+	}
+	promotion.ID = id
+	promotion.UpdatedBy = &adminID
+	if err := services.UpdatePromotion(promotion); err != nil {
+		return apperrors.FromDB(err, "Promotion")
+	}
+	return c.JSON(promotion)
+}
+
+// DeletePromotion godoc
+// @Summary      Delete a promotion
+// @Description  Deletes a promotion that was never redeemed; redeemed promotions can only be deactivated
+// @Tags         admin_promotions
+// @Produce      json
+// @Param        id   path      string  true  "Promotion ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/promotions/{id} [delete]
+func DeletePromotion(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := services.DeletePromotion(id); err != nil {
+		return apperrors.FromDB(err, "Promotion")
+	}
+	return c.JSON(fiber.Map{"message": "Promotion deleted"})
+}
+
+// parsePromotion reads a promotion from the request body, converting its
+// amounts to minor units of its currency.
+func parsePromotion(c *fiber.Ctx) (*models.Promotion, error) {
+	var req requests.PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	promotion := &models.Promotion{
+		Code:           req.Code,
+		Description:    req.Description,
+		Type:           models.PromotionType(req.Type),
+		Percent:        req.Percent,
+		Currency:       req.Currency,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		Active:         req.Active == nil || *req.Active,
+	}
+	var err error
+	if promotion.AmountMinor, err = minorPrice(req.Amount, req.Currency, "amount"); err != nil {
+		return nil, err
+	}
+	if promotion.MinSpendMinor, err = minorPrice(req.MinSpend, req.Currency, "minSpend"); err != nil {
+		return nil, err
+	}
+	if promotion.TourIDs, err = uuidList(req.TourIDs, "tourIds"); err != nil {
+		return nil, err
+	}
+	if promotion.CategoryIDs, err = uuidList(req.CategoryIDs, "categoryIds"); err != nil {
+		return nil, err
+	}
+	if promotion.DestinationIDs, err = uuidList(req.DestinationIDs, "destinationIds"); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// uuidList checks and normalises a list of UUIDs from a request body.
+func uuidList(values []string, field string) (models.StringList, error) {
+	list := make(models.StringList, 0, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, apperrors.Validation("Validation failed", models.FieldError{
+				Field:   field,
+				Message: "must only contain valid UUIDs",
+			})
+		}
+		list = append(list, id.String())
+	}
+	return list, nil
+}
@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// listTargetReviews serves the public review list of a tour, event or
+// destination.
+func listTargetReviews(c *fiber.Ctx, targetType models.ReviewTargetType) error {
+	targetID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	reviews, err := services.GetTargetReviews(targetType, targetID, c.Query("sort"))
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(reviews)
+}
+
+// targetReviewSummary serves the review summary of a tour, event or
+// destination.
+func targetReviewSummary(c *fiber.Ctx, targetType models.ReviewTargetType) error {
+	targetID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	summary, err := services.GetReviewSummary(targetType, targetID)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(summary)
+}
+
+// createTargetReview creates the logged-in user's review of a tour, event or
+// destination.
+func createTargetReview(c *fiber.Ctx, targetType models.ReviewTargetType) error {
+	targetID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req requests.CreateReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	review := models.Review{
+		TargetType:         targetType,
+		TargetID:           targetID,
+		UserID:             userID,
+		Rating:             req.Rating,
+		Comment:            req.Comment,
+		GuideRating:        optionalRating(req.GuideRating),
+		ValueRating:        optionalRating(req.ValueRating),
+		OrganisationRating: optionalRating(req.OrganisationRating),
+		SafetyRating:       optionalRating(req.SafetyRating),
+	}
+	if err := services.CreateTargetReview(&review); err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.Status(fiber.StatusCreated).JSON(review)
+}
+
+// GetMyReviews godoc
+// @Summary      List my reviews
+// @Description  Returns the reviews written by the logged-in user, newest first
+// @Tags         user_reviews
+// @Produce      json
+// @Success      200  {array}   models.Review
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/reviews [get]
+func GetMyReviews(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	reviews, err := services.GetUserReviews(userID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve reviews", err)
+	}
+	return c.JSON(reviews)
+}
+
+// UpdateMyReview godoc
+// @Summary      Edit my review
+// @Description  Changes the ratings and comment of one of the logged-in user's reviews. The edit goes back to moderation.
+// @Tags         user_reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                        true  "Review ID"
+// @Param        review  body      requests.UpdateReviewRequest  true  "Review"
+// @Success      200     {object}  models.Review
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/user/reviews/{id} [put]
+func UpdateMyReview(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.UpdateReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	review, err := services.UpdateUserReview(userID, id, models.Review{
+		Rating:             req.Rating,
+		Comment:            req.Comment,
+		GuideRating:        optionalRating(req.GuideRating),
+		ValueRating:        optionalRating(req.ValueRating),
+		OrganisationRating: optionalRating(req.OrganisationRating),
+		SafetyRating:       optionalRating(req.SafetyRating),
+	})
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(review)
+}
+
+// DeleteMyReview godoc
+// @Summary      Delete my review
+// @Description  Deletes one of the logged-in user's reviews
+// @Tags         user_reviews
+// @Produce      json
+// @Param        id   path      string  true  "Review ID"
+// @Success      200  {object}  models.MessageResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/reviews/{id} [delete]
+func DeleteMyReview(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := services.DeleteUserReview(userID, id); err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(fiber.Map{"message": "Review deleted"})
+}
+
+// maxReviewPhotoSize caps the size of a single review photo upload.
+const maxReviewPhotoSize = 5 * 1024 * 1024
+
+// AddMyReviewPhotos godoc
+// @Summary      Add photos to my review
+// @Description  Uploads photos to one of the logged-in user's reviews (at most 5 per review, 5MB each).
+// @Description  The review goes back to moderation.
+// @Tags         user_reviews
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id      path      string  true  "Review ID"
+// @Param        photos  formData  file    true  "Photo files"
+// @Success      200     {object}  models.Review
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/user/reviews/{id}/photos [post]
+func AddMyReviewPhotos(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["photos"]) == 0 {
+		return apperrors.Validation("Validation failed", models.FieldError{Field: "photos", Message: "This field is required"})
+	}
+	files := form.File["photos"]
+	for _, file := range files {
+		if !strings.HasPrefix(file.Header.Get("Content-Type"), "image/") {
+			return apperrors.Validation("Validation failed", models.FieldError{Field: "photos", Message: "must be images"})
+		}
+		if file.Size > maxReviewPhotoSize {
+			return apperrors.Validation("Validation failed", models.FieldError{Field: "photos", Message: "must be at most 5MB each"})
+		}
+	}
+
+	// Check ownership and the photo limit before uploading anything
+	review, err := services.GetUserReview(userID, id)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	if len(review.Photos)+len(files) > services.MaxReviewPhotos {
+		return apperrors.BadRequest(fmt.Sprintf("A review can have at most %d photos", services.MaxReviewPhotos))
+	}
+
+	urls := make([]string, len(files))
+	for i, file := range files {
+		urls[i], err = utils.UploadImageToCloudinary(file, "reviews")
+		if err != nil {
+			return apperrors.Internal("Failed to upload photo to Cloudinary", err)
+		}
+	}
+	review, err = services.AddReviewPhotos(userID, id, urls)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(review)
+}
+
+// DeleteMyReviewPhoto godoc
+// @Summary      Remove a photo from my review
+// @Description  Deletes a photo from one of the logged-in user's reviews
+// @Tags         user_reviews
+// @Produce      json
+// @Param        id       path      string   true  "Review ID"
+// @Param        photoId  path      integer  true  "Photo ID"
+// @Success      200      {object}  models.MessageResponse
+// @Failure      400      {object}  models.ErrorResponse
+// @Failure      401      {object}  models.ErrorResponse
+// @Failure      404      {object}  models.ErrorResponse
+// @Failure      500      {object}  models.ErrorResponse
+// @Router       /api/user/reviews/{id}/photos/{photoId} [delete]
+func DeleteMyReviewPhoto(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	photoID, err := c.ParamsInt("photoId")
+	if err != nil || photoID <= 0 {
+		return apperrors.BadRequest("Malformed photoId")
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := services.DeleteReviewPhoto(userID, id, uint(photoID)); err != nil {
+		return apperrors.FromDB(err, "Photo")
+	}
+	return c.JSON(fiber.Map{"message": "Photo deleted"})
+}
+
+// VoteReview godoc
+// @Summary      Vote on a review
+// @Description  Marks a review as helpful or unhelpful. Voting again replaces the earlier vote.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                      true  "Review ID"
+// @Param        vote  body      requests.ReviewVoteRequest  true  "Vote"
+// @Success      200   {object}  models.Review
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      401   {object}  models.ErrorResponse
+// @Failure      403   {object}  models.ErrorResponse
+// @Failure      404   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /api/reviews/{id}/vote [post]
+func VoteReview(c *fiber.Ctx) error {
+	reviewID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.ReviewVoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	review, err := services.VoteReview(reviewID, userID, *req.Helpful)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(review)
+}
+
+// DeleteReviewVote godoc
+// @Summary      Withdraw my vote on a review
+// @Description  Removes the logged-in user's helpful/unhelpful vote from a review
+// @Tags         reviews
+// @Produce      json
+// @Param        id   path      string  true  "Review ID"
+// @Success      200  {object}  models.Review
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/reviews/{id}/vote [delete]
+func DeleteReviewVote(c *fiber.Ctx) error {
+	reviewID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	review, err := services.DeleteReviewVote(reviewID, userID)
+	if err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.JSON(review)
+}
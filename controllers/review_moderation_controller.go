@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ReportReview godoc
+// @Summary      Report a review
+// @Description  Reports an approved review as inappropriate. Enough reports queue it for a moderator.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                        true  "Review ID"
+// @Param        report  body      requests.ReportReviewRequest  true  "Report"
+// @Success      201     {object}  models.MessageResponse
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/reviews/{id}/report [post]
+func ReportReview(c *fiber.Ctx) error {
+	reviewID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.ReportReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	report := models.ReviewReport{
+		ReviewID: reviewID,
+		UserID:   userID,
+		Reason:   req.Reason,
+		Details:  req.Details,
+		IP:       c.IP(),
+	}
+	if err := services.ReportReview(&report); err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "Review reported"})
+}
+
+// GetModerationQueue godoc
+// @Summary      Review moderation queue
+// @Description  Lists reviews awaiting moderation (pending, flagged and often-reported by default), most reported first
+// @Tags         admin_reviews
+// @Produce      json
+// @Param        page     query  integer  false  "Page number (default: 1)"
+// @Param        limit    query  integer  false  "Limit per page (default: 10)"
+// @Param        status   query  string   false  "Only reviews in this state (pending, approved, rejected, flagged)"
+// @Param        target_type  query  string  false  "Only reviews of this kind of target (tour, event, destination)"
+// @Param        target_id    query  string  false  "Only reviews of this tour, event or destination"
+// @Param        tour_id      query  string  false  "Only reviews of this tour (legacy)"
+// @Success      200  {object}  object{data=[]models.Review,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/reviews/moderation [get]
+func GetModerationQueue(c *fiber.Ctx) error {
+	reviews, totalCount, err := services.GetModerationQueue(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve moderation queue", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, reviews, totalCount))
+}
+
+// ApproveReviews godoc
+// @Summary      Bulk approve reviews
+// @Description  Publishes the given reviews and counts them in their target's rating
+// @Tags         admin_reviews
+// @Accept       json
+// @Produce      json
+// @Param        body  body      requests.ModerateReviewsRequest  true  "Review IDs"
+// @Success      200   {object}  object{updated=integer}
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /admin/reviews/moderation/approve [post]
+func ApproveReviews(c *fiber.Ctx) error {
+	return moderateReviews(c, models.ReviewApproved)
+}
+
+// RejectReviews godoc
+// @Summary      Bulk reject reviews
+// @Description  Hides the given reviews and removes them from their target's rating
+// @Tags         admin_reviews
+// @Accept       json
+// @Produce      json
+// @Param        body  body      requests.ModerateReviewsRequest  true  "Review IDs"
+// @Success      200   {object}  object{updated=integer}
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /admin/reviews/moderation/reject [post]
+func RejectReviews(c *fiber.Ctx) error {
+	return moderateReviews(c, models.ReviewRejected)
+}
+
+func moderateReviews(c *fiber.Ctx, status models.ReviewStatus) error {
+	var req requests.ModerateReviewsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	ids := make([]uuid.UUID, len(req.IDs))
+	for i, raw := range req.IDs {
+		id, err := parseUUIDField(raw, "ids")
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+
+	moderatorID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	updated, err := services.ModerateReviews(ids, status, moderatorID, req.Note)
+	if err != nil {
+		return apperrors.Internal("Failed to moderate reviews", err)
+	}
+	return c.JSON(fiber.Map{"updated": updated})
+}
+
+// ReplyToReview godoc
+// @Summary      Reply to a review
+// @Description  Creates or replaces the owner's public reply to a review of their tour, event or destination (one per review)
+// @Tags         admin_reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string                       true  "Review ID"
+// @Param        reply  body      requests.ReviewReplyRequest  true  "Reply"
+// @Success      200    {object}  models.ReviewReply
+// @Failure      400    {object}  models.ErrorResponse
+// @Failure      403    {object}  models.ErrorResponse
+// @Failure      404    {object}  models.ErrorResponse
+// @Failure      500    {object}  models.ErrorResponse
+// @Router       /admin/reviews/{id}/reply [put]
+func ReplyToReview(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	var req requests.ReviewReplyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+
+	ownerID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	superAdmin := c.Locals("userRole") == "superadmin"
+	reply, err := services.SetReviewReply(id, ownerID, superAdmin, req.Body)
+	if err != nil {
+		return apperrors.FromDB(err, "Reply")
+	}
+	return c.JSON(reply)
+}
+
+// DeleteReviewReply godoc
+// @Summary      Delete a review reply
+// @Description  Removes the owner's reply to a review
+// @Tags         admin_reviews
+// @Produce      json
+// @Param        id   path      string  true  "Review ID"
+// @Success      200  {object}  models.MessageResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/reviews/{id}/reply [delete]
+func DeleteReviewReply(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	ownerID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	superAdmin := c.Locals("userRole") == "superadmin"
+	if err := services.DeleteReviewReply(id, ownerID, superAdmin); err != nil {
+		return apperrors.FromDB(err, "Reply")
+	}
+	return c.JSON(fiber.Map{"message": "Reply deleted"})
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/responses"
@@ -13,28 +14,33 @@ import (
 	"github.com/google/uuid"
 )
 
+// defaultTourCapacity is the seats on a new tour's departures when the
+// request doesn't say.
+const defaultTourCapacity = 20
+
 // GetAllTours godoc
 // @Summary      Get all tours
 // @Description  Retrieves a list of all tours
 // @Tags         tours
 // @Produce      json
-// @Param        page   query    integer  false  "Page number (default: 1)"
-// @Param        limit  query    integer  false  "Limit per page (default: 10)"
+// @Param        page      query    integer  false  "Page number (default: 1)"
+// @Param        limit     query    integer  false  "Limit per page (default: 10)"
+// @Param        currency  query    string   false  "ISO 4217 code to show prices in"
+// @Param        sort      query    string   false  "newest (default) or popular (most saved as favourites)"
 // @Success      200  {object}   object{data=[]responses.TourResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /api/tours [get]
 func GetAllTours(c *fiber.Ctx) error {
 	tours, totalCount, err := services.GetAllTours(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve tours"})
+		return apperrors.FromDB(err, "Tour")
 	}
 
-	// Convert tours to response format
-	tourResponses := make([]responses.TourResponse, len(tours))
-	for i, tour := range tours {
-		tourResponses[i] = responses.ToTourResponse(tour)
+	tourResponses, err := toTourResponses(c, tours)
+	if err != nil {
+		return err
 	}
-
 	return c.JSON(utils.PaginationResponse(c, tourResponses, totalCount))
 }
 
@@ -43,36 +49,61 @@ func GetAllTours(c *fiber.Ctx) error {
 // @Description  Retrieves a tour by its ID
 // @Tags         tours
 // @Produce      json
-// @Param        id   path      string  true  "Tour ID"
+// @Param        id        path   string  true   "Tour ID"
+// @Param        currency  query  string  false  "ISO 4217 code to show the price in"
+// @Param        If-None-Match  header  string  false  "ETag of the cached copy"
 // @Success      200  {object}  responses.TourResponse
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /api/tours/{id} [get]
 func GetTourByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	tour, err := services.GetTourByID(id)
+	id, err := paramUUID(c, "id")
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Tour not found"})
+		return err
 	}
-	return c.JSON(responses.ToTourResponse(*tour))
+	tour, err := services.GetTourByID(id.String())
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	converter, err := services.NewPriceConverter(c.Query("currency"))
+	if err != nil {
+		return err
+	}
+	response := []responses.TourResponse{responses.ToTourResponse(*tour)}
+	convertTourPrice(converter, &response[0], tour)
+	if err := markTourFavorites(c, response); err != nil {
+		return err
+	}
+	// Ratings, favourites, seats and converted prices change without a
+	// version bump, so the ETag follows the rendered body
+	return sendWithETag(c, tour.Version, response[0])
 }
 
 // CreateTour godoc
 // @Summary      Create a new tour
-// @Description  Creates a new tour
+// @Description  Creates a new tour. Sent as JSON, the body may also carry the day-by-day itinerary
+// @Description  ([{day, title, description, image}]); otherwise PATCH it afterwards.
 // @Tags         admin_tours
-// @Accept       multipart/form-data
+// @Accept       multipart/form-data,json
 // @Produce      json
 // @Param        title          formData    string  true   "Tour title"
 // @Param        destinationId  formData    string  true   "Destination ID"
 // @Param        categoryId     formData    string  true   "Category ID"
 // @Param        description           formData    string  true   "Tour description"
 // @Param        about              formData    string  true   "Tour about"
-// @Param        startDate      formData    string  true   "Start date"
-// @Param        endDate        formData    string  true   "End date"
+// @Param        durationDays   formData    integer true   "Days each departure lasts (optional with startDate and endDate)"
+// @Param        defaultCapacity formData   integer false  "Seats on new departures (default: 20)"
+// @Param        startDate      formData    string  false  "Deprecated: start of a first departure"
+// @Param        endDate        formData    string  false  "Deprecated: end of a first departure"
 // @Param        pricePerPerson formData    number  true   "Price per person"
 // @Param        currency       formData    string  true   "Currency"
 // @Param        isFeatured     formData    boolean false  "Is featured"
 // @Param        coverImage     formData    file    false  "Cover image"
+// @Param        cancellationPolicyId  formData  string  false  "Cancellation policy ID"
+// @Param        vendorId              formData  string  false  "ID of the vendor running the tour"
+// @Param        participantFields     formData  []string  false  "Traveller details bookings must give (dateOfBirth, nationality, passport, dietaryRequirements, emergencyContact)"
+// @Param        meetingPoint          formData  string  false  "Where travellers meet, printed on vouchers"
 // @Success      200  {object}  responses.TourResponse
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
@@ -91,49 +122,90 @@ func CreateTour(c *fiber.Ctx) error {
 	}
 	var req requests.CreateTourRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
-	validationResult := requests.ValidateCreateTourRequest(req)
-	if !validationResult.Valid {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Validation failed",
-			"details": validationResult.Errors,
-		})
+	destinationID, err := parseUUIDField(req.DestinationID, "destinationId")
+	if err != nil {
+		return err
+	}
+	categoryID, err := parseUUIDField(req.CategoryID, "categoryId")
+	if err != nil {
+		return err
+	}
+	price, err := minorPrice(req.PricePerPerson, req.Currency, "pricePerPerson")
+	if err != nil {
+		return err
+	}
+	policyID, err := cancellationPolicyField(req.CancellationPolicyID)
+	if err != nil {
+		return err
+	}
+	vendorID, err := vendorField(req.VendorID)
+	if err != nil {
+		return err
+	}
+	participantFields, err := participantFieldList(req.ParticipantFields)
+	if err != nil {
+		return err
+	}
+	if err := requests.ValidateItinerary(req.Itinerary); err != nil {
+		return err
 	}
 
 	// Get user ID from context
-	userID, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "User ID not found in context"})
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
 	}
-	userUUID := uuid.MustParse(userID)
-	user, err := services.GetUserByID(userID)
+	user, err := services.GetUserByID(userUUID.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get user details"})
+		return apperrors.Internal("Failed to get user details", err)
 	}
-	destination, err := services.GetDestinationByID(req.DestinationID)
+	destination, err := services.GetDestinationByID(destinationID.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get Destination"})
+		return apperrors.FromDB(err, "Destination")
 	}
 
 	tourID := uuid.New()
 	tour := models.Tour{
-		ID:             tourID,
-		Title:          req.Title,
-		DestinationID:  uuid.MustParse(req.DestinationID),
-		Destination:    *destination,
-		Category:       uuid.MustParse(req.CategoryID),
-		Description:    req.Description,
-		About:          req.About,
-		StartDate:      req.StartDate,
-		EndDate:        req.EndDate,
-		PricePerPerson: req.PricePerPerson,
-		Currency:       req.Currency,
-		IsFeatured:     req.IsFeatured,
-		CreatedBy:      userUUID,
-		User:           *user,
+		ID:                  tourID,
+		Title:               req.Title,
+		DestinationID:       destinationID,
+		Destination:         *destination,
+		Category:            categoryID,
+		Description:         req.Description,
+		About:               req.About,
+		DurationDays:        req.DurationDays,
+		DefaultCapacity:     req.DefaultCapacity,
+		PricePerPersonMinor: price,
+		Currency:            req.Currency,
+		IsFeatured:          req.IsFeatured,
+		CreatedBy:           userUUID,
+		User:                *user,
+
+		CancellationPolicyID: policyID,
+		VendorID:             vendorID,
+		ParticipantFields:    participantFields,
+		MeetingPoint:         req.MeetingPoint,
+		Itinerary:            req.Itinerary,
+	}
+
+	if tour.DefaultCapacity == 0 {
+		tour.DefaultCapacity = defaultTourCapacity
+	}
+	// Clients that still send startDate and endDate get a first departure
+	var firstDeparture *models.TourDeparture
+	if req.StartDate != nil && req.EndDate != nil {
+		tour.DurationDays = int(req.EndDate.Sub(*req.StartDate).Hours()/24) + 1
+		firstDeparture = &models.TourDeparture{
+			TourID:    tourID,
+			StartDate: *req.StartDate,
+			EndDate:   *req.EndDate,
+			Capacity:  tour.DefaultCapacity,
+		}
 	}
 
 	// Handle cover image separately from the request body parsing
@@ -142,12 +214,11 @@ func CreateTour(c *fiber.Ctx) error {
 		log.Println("Cover Image Found manually:", file.Filename, file.Size)
 		fileURL, err := utils.UploadImageToCloudinary(file, "tours")
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to upload cover image to Cloudinary"})
+			return apperrors.Internal("Failed to upload cover image to Cloudinary", err)
 		}
-		tourID := uuid.New()
 		tour.CoverImage = models.MediaTour{
 			TourID:    tourID,
-			UserID:    uuid.MustParse(userID),
+			UserID:    userUUID,
 			URL:       fileURL,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
@@ -156,13 +227,13 @@ func CreateTour(c *fiber.Ctx) error {
 		log.Println("Error getting cover image:", err)
 	}
 
-	err = services.CreateTour(&tour)
+	err = services.CreateTour(&tour, firstDeparture)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create tour"})
+		return apperrors.Internal("Failed to create tour", err)
 	}
 	createdTour, err := services.GetTourByID(tour.ID.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve created tour"})
+		return apperrors.Internal("Failed to retrieve created tour", err)
 	}
 
 	return c.JSON(responses.ToTourResponse(*createdTour))
@@ -180,94 +251,230 @@ func CreateTour(c *fiber.Ctx) error {
 // @Param        categoryId     formData    string  true   "Category ID"
 // @Param        description    formData    string  true   "Tour description"
 // @Param        about          formData    string  true   "Tour about"
-// @Param        startDate      formData    string  true   "Start date"
-// @Param        endDate        formData    string  true   "End date"
+// @Param        durationDays   formData    integer false  "Days each departure lasts (default: unchanged)"
+// @Param        defaultCapacity formData   integer false  "Seats on new departures (default: unchanged)"
 // @Param        pricePerPerson formData    number  true   "Price per person"
 // @Param        currency       formData    string  true   "Currency"
 // @Param        isFeatured     formData    boolean false  "Is featured"
 // @Param        coverImage     formData    file    false  "Cover image"
+// @Param        cancellationPolicyId  formData  string  false  "Cancellation policy ID"
+// @Param        vendorId              formData  string  false  "ID of the vendor running the tour"
+// @Param        participantFields     formData  []string  false  "Traveller details bookings must give (dateOfBirth, nationality, passport, dietaryRequirements, emergencyContact)"
+// @Param        meetingPoint          formData  string  false  "Where travellers meet, printed on vouchers"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  responses.TourResponse
 // @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/tours/{id} [put]
 func UpdateTour(c *fiber.Ctx) error {
-	id := c.Params("id")
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	id := tourID.String()
 	var req requests.UpdateTourRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid request body: " + err.Error(),
-		})
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	destinationID, err := parseUUIDField(req.DestinationID, "destinationId")
+	if err != nil {
+		return err
+	}
+	categoryID, err := parseUUIDField(req.CategoryID, "categoryId")
+	if err != nil {
+		return err
+	}
+	price, err := minorPrice(req.PricePerPerson, req.Currency, "pricePerPerson")
+	if err != nil {
+		return err
+	}
+	policyID, err := cancellationPolicyField(req.CancellationPolicyID)
+	if err != nil {
+		return err
+	}
+	vendorID, err := vendorField(req.VendorID)
+	if err != nil {
+		return err
+	}
+	participantFields, err := participantFieldList(req.ParticipantFields)
+	if err != nil {
+		return err
 	}
 
 	// Get user ID from context
-	userID, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(500).JSON(fiber.Map{"error": "User ID not found in context"})
-	}
-	userUUID := uuid.MustParse(userID)
-	user, err := services.GetUserByID(userID)
+	userUUID, err := currentUserID(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get user details"})
+		return err
 	}
 
 	// Get existing tour
 	tour, err := services.GetTourByID(id)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Tour not found"})
+		return apperrors.FromDB(err, "Tour")
+	}
+	if err := checkIfMatch(c, tour.Version); err != nil {
+		return err
 	}
 
 	// Update tour fields
 	tour.Title = req.Title
-	tour.DestinationID = uuid.MustParse(req.DestinationID)
-	tour.Category = uuid.MustParse(req.CategoryID)
+	tour.DestinationID = destinationID
+	tour.Category = categoryID
 	tour.Description = req.Description
 	tour.About = req.About
-	tour.StartDate = req.StartDate
-	tour.EndDate = req.EndDate
-	tour.PricePerPerson = req.PricePerPerson
+	if req.DurationDays != 0 {
+		tour.DurationDays = req.DurationDays
+	}
+	if req.DefaultCapacity != 0 {
+		tour.DefaultCapacity = req.DefaultCapacity
+	}
+	tour.PricePerPersonMinor = price
 	tour.Currency = req.Currency
 	tour.IsFeatured = req.IsFeatured
-	tour.CreatedBy = userUUID
-	tour.User = *user
+	if policyID != nil {
+		tour.CancellationPolicyID = policyID
+		tour.CancellationPolicy = nil
+	}
+	if vendorID != nil {
+		tour.VendorID = vendorID
+	}
+	if participantFields != nil {
+		tour.ParticipantFields = participantFields
+	}
+	if req.MeetingPoint != "" {
+		tour.MeetingPoint = req.MeetingPoint
+	}
+	tour.UpdatedBy = &userUUID
 
 	// Handle cover image if provided
 	if req.CoverImage != nil {
 		fileURL, err := utils.UploadImageToCloudinary(req.CoverImage, "tours")
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Failed to save cover image"})
+			return apperrors.Internal("Failed to save cover image", err)
 		}
 		tour.CoverImage = models.MediaTour{
-			TourID:    uuid.MustParse(id),
-			UserID:    uuid.MustParse(userID),
+			TourID:    tourID,
+			UserID:    userUUID,
 			URL:       fileURL,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 	}
 
-	err = services.UpdateTour(id, tour)
+	err = services.UpdateTour(id, tour.Version, tour)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update tour"})
+		return apperrors.FromDB(err, "Tour")
 	}
 
+	updatedTour, err := services.GetTourByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated tour", err)
+	}
+	setETag(c, updatedTour.Version)
+	return c.JSON(responses.ToTourResponse(*updatedTour))
+}
+
+// PatchTour godoc
+// @Summary      Partially update a tour
+// @Description  Applies a JSON Merge Patch (RFC 7386); null resets a field, absent fields are kept
+// @Tags         admin_tours
+// @Accept       json
+// @Produce      json
+// @Param        id     path  string                     true  "Tour ID"
+// @Param        patch  body  requests.PatchTourRequest  true  "Merge patch"
+// @Param        If-Match  header  string  true  "ETag from the last read"
+// @Success      200  {object}  responses.TourResponse
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/tours/{id} [patch]
+func PatchTour(c *fiber.Ctx) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	tour, err := services.GetTourByID(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	if err := checkIfMatch(c, tour.Version); err != nil {
+		return err
+	}
+	req := requests.NewPatchTourRequest(*tour)
+	columns, err := requests.ApplyMergePatch(c.Body(), &req)
+	if err != nil {
+		return err
+	}
+	if err := patchPrice(columns, "price_per_person_minor", req.PricePerPerson, req.Currency, "pricePerPerson"); err != nil {
+		return err
+	}
+	if err := patchCancellationPolicy(columns); err != nil {
+		return err
+	}
+	if err := patchTourOperations(columns); err != nil {
+		return err
+	}
+	if _, ok := columns["itinerary"]; ok {
+		if err := requests.ValidateItinerary(req.Itinerary); err != nil {
+			return err
+		}
+	}
+	columns["updated_by"] = userUUID
+
+	if err := services.PatchTour(id, tour.Version, columns); err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+
+	tour, err = services.GetTourByID(id)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve updated tour", err)
+	}
+	setETag(c, tour.Version)
 	return c.JSON(responses.ToTourResponse(*tour))
 }
 
 // DeleteTour godoc
 // @Summary      Delete a tour
-// @Description  Deletes a tour by ID
+// @Description  Deletes a tour by ID; tours with bookings are refused
 // @Tags         admin_tours
 // @Produce      json
 // @Param        id   path      string  true  "Tour ID"
+// @Param        If-Match  header  string  true  "ETag from the last read"
 // @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      412  {object}  models.ErrorResponse
+// @Failure      428  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /admin/tours/{id} [delete]
 func DeleteTour(c *fiber.Ctx) error {
-	id := c.Params("id")
-	err := services.DeleteTour(id)
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	tour, err := services.GetTourByID(id.String())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete"})
+		return apperrors.FromDB(err, "Tour")
+	}
+	if err := checkIfMatch(c, tour.Version); err != nil {
+		return err
+	}
+	err = services.DeleteTour(id.String(), tour.Version)
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
 	}
 	return c.JSON(fiber.Map{"message": "Tour deleted"})
 }
@@ -277,23 +484,24 @@ func DeleteTour(c *fiber.Ctx) error {
 // @Description  Retrieves a list of featured tours
 // @Tags         tours
 // @Produce      json
-// @Param        page   query    integer  false  "Page number (default: 1)"
-// @Param        limit  query    integer  false  "Limit per page (default: 10)"
+// @Param        page      query    integer  false  "Page number (default: 1)"
+// @Param        limit     query    integer  false  "Limit per page (default: 10)"
+// @Param        currency  query    string   false  "ISO 4217 code to show prices in"
+// @Param        sort      query    string   false  "newest (default) or popular (most saved as favourites)"
 // @Success      200  {object}   object{data=[]responses.TourResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /api/tours/featured [get]
 func GetFeaturedTours(c *fiber.Ctx) error {
 	tours, totalCount, err := services.GetFeaturedTours(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve featured tours"})
+		return apperrors.FromDB(err, "Tour")
 	}
 
-	// Convert tours to response format
-	tourResponses := make([]responses.TourResponse, len(tours))
-	for i, tour := range tours {
-		tourResponses[i] = responses.ToTourResponse(tour)
+	tourResponses, err := toTourResponses(c, tours)
+	if err != nil {
+		return err
 	}
-
 	return c.JSON(utils.PaginationResponse(c, tourResponses, totalCount))
 }
 
@@ -308,82 +516,149 @@ func GetFeaturedTours(c *fiber.Ctx) error {
 // @Param        featured       query    boolean  false  "Filter by featured tours (true/false)"
 // @Param        destination_id query    string   false  "Filter by destination ID"
 // @Param        category_id    query    string   false  "Filter by category ID"
-// @Param        min_price      query    number   false  "Filter by minimum price"
-// @Param        max_price      query    number   false  "Filter by maximum price"
+// @Param        min_price      query    number   false  "Filter by minimum price, in the requested currency"
+// @Param        max_price      query    number   false  "Filter by maximum price, in the requested currency"
+// @Param        currency       query    string   false  "ISO 4217 code to show and filter prices in (default: base currency)"
+// @Param        sort           query    string   false  "newest (default) or popular (most saved as favourites)"
 // @Success      200  {object}   object{data=[]responses.TourResponse,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /api/tours/filter [get]
 func GetFilteredTours(c *fiber.Ctx) error {
 	tours, totalCount, err := services.GetFilteredTours(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve filtered tours"})
+		return apperrors.FromDB(err, "Tour")
 	}
 
-	// Convert tours to response format
-	tourResponses := make([]responses.TourResponse, len(tours))
-	for i, tour := range tours {
-		tourResponses[i] = responses.ToTourResponse(tour)
+	tourResponses, err := toTourResponses(c, tours)
+	if err != nil {
+		return err
 	}
-
 	return c.JSON(utils.PaginationResponse(c, tourResponses, totalCount))
 }
 
+// GetTourQuote godoc
+// @Summary      Quote a tour
+// @Description  Returns the itemised price of a tour for a party on one of its departures, after child and infant fares,
+// @Description  departure price overrides or seasonal prices, group and early-bird discounts and an optional promotion code. Amounts are in minor units of the tour's currency.
+// @Description  A promotion's per-user limit is checked when a bearer token is sent.
+// @Tags         tours
+// @Produce      json
+// @Param        id        path      string   true   "Tour ID"
+// @Param        departureId query   string   false  "Departure ID"
+// @Param        adults    query     integer  true   "Number of adults"
+// @Param        children  query     integer  false  "Number of children"
+// @Param        infants   query     integer  false  "Number of infants"
+// @Param        date      query     string   false  "Departure date, YYYY-MM-DD, when departureId isn't given (default: the next departure)"
+// @Param        promoCode query     string   false  "Promotion code to apply"
+// @Success      200       {object}  models.Quote
+// @Failure      400       {object}  models.ErrorResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Failure      409       {object}  models.ErrorResponse
+// @Failure      500       {object}  models.ErrorResponse
+// @Router       /api/tours/{id}/quote [get]
+func GetTourQuote(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	var req requests.QuoteRequest
+	if err := c.QueryParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid query parameters")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	departureID, err := optionalUUIDField(req.DepartureID, "departureId")
+	if err != nil {
+		return err
+	}
+	date, err := parseDateQuery(req.Date, "date")
+	if err != nil {
+		return err
+	}
+
+	party := models.Party{Adults: req.Adults, Children: req.Children, Infants: req.Infants}
+	quote, err := services.QuoteTour(id, party, departureID, date, req.PromoCode, optionalUserID(c))
+	if err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+	return c.JSON(quote)
+}
+
 // GetTourReviews godoc
 // @Summary      Get reviews for a tour
-// @Description  Retrieves all reviews for a specific tour
+// @Description  Retrieves the approved reviews of a tour
+// @Tags         tours
+// @Produce      json
+// @Param        id    path      string  true   "Tour ID"
+// @Param        sort  query     string  false  "newest (default), helpful, rating or rating_asc"
+// @Success      200   {array}   models.Review
+// @Failure      400   {object}  models.ErrorResponse
+// @Failure      404   {object}  models.ErrorResponse
+// @Failure      500   {object}  models.ErrorResponse
+// @Router       /api/tours/{id}/reviews [get]
+func GetTourReviews(c *fiber.Ctx) error {
+	return listTargetReviews(c, models.ReviewTargetTour)
+}
+
+// GetTourReviewSummary godoc
+// @Summary      Get the review summary of a tour
+// @Description  Returns the rating histogram, average aspect ratings and most helpful reviews of a tour
 // @Tags         tours
 // @Produce      json
 // @Param        id   path      string  true  "Tour ID"
-// @Success      200  {array}   models.Review
+// @Success      200  {object}  models.ReviewSummary
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      404  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
-// @Router       /api/tours/{id}/reviews [get]
-func GetTourReviews(c *fiber.Ctx) error {
-	tourID := c.Params("id")
-	reviews, err := services.GetTourReviews(tourID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to retrieve reviews"})
-	}
-	return c.JSON(reviews)
+// @Router       /api/tours/{id}/reviews/summary [get]
+func GetTourReviewSummary(c *fiber.Ctx) error {
+	return targetReviewSummary(c, models.ReviewTargetTour)
 }
 
 // CreateTourReview godoc
 // @Summary      Create a review for a tour
-// @Description  Creates a new review for a tour
+// @Description  Creates the logged-in user's review for a tour. Each user can review a tour once;
+// @Description  the review is marked verified when the user has a completed booking for the tour.
 // @Tags         tours
 // @Accept       json
 // @Produce      json
-// @Param        id      path      string          true  "Tour ID"
-// @Param        review  body      models.Review  true  "Review object"
-// @Success      200     {object}  models.Review
+// @Param        id      path      string                        true  "Tour ID"
+// @Param        review  body      requests.CreateReviewRequest  true  "Review"
+// @Success      201     {object}  models.Review
 // @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      404     {object}  models.ErrorResponse
+// @Failure      409     {object}  models.ErrorResponse
 // @Failure      500     {object}  models.ErrorResponse
 // @Router       /api/tours/{id}/reviews [post]
 func CreateTourReview(c *fiber.Ctx) error {
-	tourID := c.Params("id")
+	return createTargetReview(c, models.ReviewTargetTour)
+}
 
-	var review models.Review
-	if err := c.BodyParser(&review); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+// toTourResponses maps tours to their response format, with prices in the
+// currency asked for with ?currency= and isFavorite for logged-in users.
+func toTourResponses(c *fiber.Ctx, tours []models.Tour) ([]responses.TourResponse, error) {
+	converter, err := services.NewPriceConverter(c.Query("currency"))
+	if err != nil {
+		return nil, err
 	}
-
-	// Validate rating
-	if review.Rating < 1 || review.Rating > 5 {
-		return c.Status(400).JSON(fiber.Map{"error": "Rating must be between 1 and 5"})
+	tourResponses := make([]responses.TourResponse, len(tours))
+	for i := range tours {
+		tourResponses[i] = responses.ToTourResponse(tours[i])
+		convertTourPrice(converter, &tourResponses[i], &tours[i])
 	}
+	return tourResponses, markTourFavorites(c, tourResponses)
+}
 
-	// Get user ID from context
-	userID, ok := c.Locals("userID").(string)
-	if !ok {
-		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+// convertTourPrice converts the price of a tour response; prices in a
+// currency without an exchange rate are left as stored.
+func convertTourPrice(converter *services.PriceConverter, response *responses.TourResponse, tour *models.Tour) {
+	if converter == nil || tour.Currency == converter.Currency {
+		return
 	}
-
-	review.TourID = uuid.MustParse(tourID)
-	review.UserID = uuid.MustParse(userID)
-
-	if err := services.CreateTourReview(&review); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to create review"})
+	if amount, ok := converter.Convert(tour.PricePerPersonMinor, tour.Currency); ok {
+		response.ConvertPrice(amount, converter.Currency)
 	}
-
-	return c.JSON(review)
 }
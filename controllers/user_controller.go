@@ -1,10 +1,223 @@
 package controllers
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/responses"
 	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
+// maxAvatarSize caps the size of a profile picture upload.
+const maxAvatarSize = 5 * 1024 * 1024
+
 func GetUserProfile(c *fiber.Ctx) error {
 	return services.GetUserProfile(c)
 }
+
+// UpdateMyProfile godoc
+// @Summary      Update my profile
+// @Description  Replaces the logged-in user's profile. Usernames are lowercased, must be free and can be changed
+// @Description  once per cooldown period (30 days by default).
+// @Tags         user_profile
+// @Accept       json
+// @Produce      json
+// @Param        profile  body      requests.UpdateProfileRequest  true  "Profile"
+// @Success      200      {object}  object{user=responses.UserResponse}
+// @Failure      400      {object}  models.ErrorResponse
+// @Failure      401      {object}  models.ErrorResponse
+// @Failure      409      {object}  models.ErrorResponse
+// @Router       /api/user/profile [put]
+func UpdateMyProfile(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req requests.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body: " + err.Error())
+	}
+	req.Normalize()
+	if err := requests.ValidateProfile(&req); err != nil {
+		return err
+	}
+	user, err := services.UpdateProfile(userID, req.Columns())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+// PatchMyProfile godoc
+// @Summary      Partially update my profile
+// @Description  Applies a JSON Merge Patch (RFC 7386) to the logged-in user's profile; null resets a field, absent
+// @Description  fields are kept. socialLinks is merged link by link. Username changes follow the rules of PUT.
+// @Tags         user_profile
+// @Accept       json
+// @Produce      json
+// @Param        patch  body      requests.PatchProfileRequest  true  "Merge patch"
+// @Success      200    {object}  object{user=responses.UserResponse}
+// @Failure      400    {object}  models.ErrorResponse
+// @Failure      401    {object}  models.ErrorResponse
+// @Failure      409    {object}  models.ErrorResponse
+// @Router       /api/user/profile [patch]
+func PatchMyProfile(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	user, err := services.GetUserByID(userID.String())
+	if err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+	profile, links := requests.NewPatchProfileRequest(*user)
+	columns, err := requests.ApplyProfilePatch(c.Body(), &profile, &links)
+	if err != nil {
+		return err
+	}
+	user, err = services.UpdateProfile(userID, columns)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+// UploadMyAvatar godoc
+// @Summary      Upload my profile picture
+// @Description  Uploads a JPEG, PNG or GIF profile picture (5MB at most). The square avatar and its thumbnail are
+// @Description  cut from the crop rectangle, in pixels of the uploaded picture, or around the face without one.
+// @Tags         user_profile
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        avatar  formData  file     true   "Picture"
+// @Param        x       formData  integer  false  "Left edge of the crop"
+// @Param        y       formData  integer  false  "Top edge of the crop"
+// @Param        width   formData  integer  false  "Width of the crop"
+// @Param        height  formData  integer  false  "Height of the crop"
+// @Success      200     {object}  object{user=responses.UserResponse}
+// @Failure      400     {object}  models.ErrorResponse
+// @Failure      401     {object}  models.ErrorResponse
+// @Failure      500     {object}  models.ErrorResponse
+// @Router       /api/user/profile/avatar [post]
+func UploadMyAvatar(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return apperrors.Validation("Validation failed", models.FieldError{Field: "avatar", Message: "This field is required"})
+	}
+	if file.Size > maxAvatarSize {
+		return apperrors.Validation("Validation failed", models.FieldError{Field: "avatar", Message: "must be at most 5MB"})
+	}
+	width, height, err := utils.ImageSize(file)
+	if err != nil || !strings.HasPrefix(file.Header.Get("Content-Type"), "image/") {
+		return apperrors.Validation("Validation failed", models.FieldError{Field: "avatar", Message: "must be a JPEG, PNG or GIF image"})
+	}
+	crop, err := avatarCrop(c, width, height)
+	if err != nil {
+		return err
+	}
+
+	url, thumbnailURL, publicID, err := utils.UploadAvatarToCloudinary(file, crop)
+	if err != nil {
+		return apperrors.Internal("Failed to upload avatar to Cloudinary", err)
+	}
+	user, err := services.SetProfileImage(userID, models.ProfileImage{URL: url, ThumbnailURL: thumbnailURL, PublicID: publicID})
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+// avatarCrop reads the crop rectangle of an avatar upload, which is either
+// left out or given in full and must lie inside the picture.
+func avatarCrop(c *fiber.Ctx, width, height int) (*utils.ImageCrop, error) {
+	names := []string{"x", "y", "width", "height"}
+	given := 0
+	for _, name := range names {
+		if c.FormValue(name) != "" {
+			given++
+		}
+	}
+	if given == 0 {
+		return nil, nil
+	}
+
+	values := make([]int, len(names))
+	var fields []models.FieldError
+	for i, name := range names {
+		min := 0
+		if name == "width" || name == "height" {
+			min = 1
+		}
+		value := c.FormValue(name)
+		n, err := strconv.Atoi(value)
+		switch {
+		case value == "":
+			fields = append(fields, models.FieldError{Field: name, Message: "This field is required"})
+		case err != nil || n < min:
+			fields = append(fields, models.FieldError{Field: name, Message: fmt.Sprintf("must be a whole number of at least %d", min)})
+		}
+		values[i] = n
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Validation failed", fields...)
+	}
+
+	crop := &utils.ImageCrop{X: values[0], Y: values[1], Width: values[2], Height: values[3]}
+	if crop.X+crop.Width > width {
+		fields = append(fields, models.FieldError{Field: "width", Message: fmt.Sprintf("must fit inside the picture, which is %d pixels wide", width)})
+	}
+	if crop.Y+crop.Height > height {
+		fields = append(fields, models.FieldError{Field: "height", Message: fmt.Sprintf("must fit inside the picture, which is %d pixels high", height)})
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Validation failed", fields...)
+	}
+	return crop, nil
+}
+
+// DeleteMyAvatar godoc
+// @Summary      Remove my profile picture
+// @Description  Removes the logged-in user's profile picture
+// @Tags         user_profile
+// @Produce      json
+// @Success      200  {object}  object{user=responses.UserResponse}
+// @Failure      401  {object}  models.ErrorResponse
+// @Router       /api/user/profile/avatar [delete]
+func DeleteMyAvatar(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	user, err := services.SetProfileImage(userID, models.ProfileImage{})
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(*user)})
+}
+
+// GetPublicProfile godoc
+// @Summary      Get a user's public profile
+// @Description  Returns what anyone can see of a user, looked up by username ignoring case
+// @Tags         users
+// @Produce      json
+// @Param        username  path      string  true  "Username"
+// @Success      200       {object}  responses.PublicProfileResponse
+// @Failure      404       {object}  models.ErrorResponse
+// @Router       /api/users/{username} [get]
+func GetPublicProfile(c *fiber.Ctx) error {
+	user, reviews, err := services.GetPublicProfile(c.Params("username"))
+	if err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+	return c.JSON(responses.ToPublicProfileResponse(*user, reviews))
+}
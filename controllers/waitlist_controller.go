@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// JoinWaitlist godoc
+// @Summary      Join the waitlist of a sold-out departure or event
+// @Description  Puts the logged-in user's party in line for a departure (departureId) or event (eventId) that has too few seats left for it.
+// @Description  When seats free up they are offered in line order and held for the customer, who claims them with POST /api/user/waitlist/{id}/claim.
+// @Description  The response has the entry's place in line.
+// @Tags         user_waitlist
+// @Accept       json
+// @Produce      json
+// @Param        entry  body      requests.JoinWaitlistRequest  true  "Waitlist entry"
+// @Success      201    {object}  models.WaitlistEntry
+// @Failure      400    {object}  models.ErrorResponse
+// @Failure      401    {object}  models.ErrorResponse
+// @Failure      404    {object}  models.ErrorResponse
+// @Failure      409    {object}  models.ErrorResponse
+// @Failure      500    {object}  models.ErrorResponse
+// @Router       /api/user/waitlist [post]
+func JoinWaitlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req requests.JoinWaitlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperrors.BadRequest("Invalid request body")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
+	}
+	if req.DepartureID != "" && req.EventID != "" {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "eventId",
+			Message: "give either departureId or eventId",
+		})
+	}
+
+	entry := models.WaitlistEntry{
+		UserID:   userID,
+		Adults:   req.Adults,
+		Children: req.Children,
+		Infants:  req.Infants,
+	}
+	if req.EventID != "" {
+		entry.TargetType = models.WaitlistEvent
+		entry.TargetID, err = parseUUIDField(req.EventID, "eventId")
+	} else {
+		entry.TargetType = models.WaitlistDeparture
+		entry.TargetID, err = parseUUIDField(req.DepartureID, "departureId")
+	}
+	if err != nil {
+		return err
+	}
+	if err := services.JoinWaitlist(&entry); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// GetMyWaitlist godoc
+// @Summary      List my waitlist entries
+// @Description  Lists the logged-in user's waitlist entries, newest first; waiting entries have their place in line
+// @Tags         user_waitlist
+// @Produce      json
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        status  query  string   false  "waiting, offered, claimed, expired or left"
+// @Success      200  {object}  object{data=[]models.WaitlistEntry,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/waitlist [get]
+func GetMyWaitlist(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	entries, totalCount, err := services.GetUserWaitlist(c, userID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve waitlist", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, entries, totalCount))
+}
+
+// GetMyWaitlistEntry godoc
+// @Summary      Get one of my waitlist entries
+// @Description  Returns one of the logged-in user's waitlist entries with its place in line or its offer
+// @Tags         user_waitlist
+// @Produce      json
+// @Param        id   path      string  true  "Waitlist entry ID"
+// @Success      200  {object}  models.WaitlistEntry
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /api/user/waitlist/{id} [get]
+func GetMyWaitlistEntry(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	entry, err := services.GetUserWaitlistEntry(userID, id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(entry)
+}
+
+// LeaveWaitlist godoc
+// @Summary      Leave a waitlist
+// @Description  Withdraws one of the logged-in user's waiting or offered entries; seats held for an offer go to the next in line
+// @Tags         user_waitlist
+// @Param        id   path  string  true  "Waitlist entry ID"
+// @Success      204
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /api/user/waitlist/{id} [delete]
+func LeaveWaitlist(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := services.LeaveWaitlist(userID, id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ClaimWaitlistOffer godoc
+// @Summary      Claim a waitlist offer
+// @Description  Books the seats or tickets held for an unexpired offer. This creates a booking priced like POST /api/user/bookings,
+// @Description  which waits for payment; its ID is in bookingId. The places are only the customer's once the booking is paid.
+// @Tags         user_waitlist
+// @Produce      json
+// @Param        id   path      string  true  "Waitlist entry ID"
+// @Success      200  {object}  models.WaitlistEntry
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      401  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /api/user/waitlist/{id}/claim [post]
+func ClaimWaitlistOffer(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	entry, err := services.ClaimWaitlistOffer(userID, id)
+	if err != nil {
+		return apperrors.FromDB(err, "Booking")
+	}
+	return c.JSON(entry)
+}
+
+// GetDepartureWaitlist godoc
+// @Summary      List the waitlist of a departure
+// @Description  Lists the open entries of a departure's waitlist with their customers: offers first, then waiting entries in line order
+// @Tags         admin_departures
+// @Produce      json
+// @Param        id           path   string   true   "Tour ID"
+// @Param        departureId  path   string   true   "Departure ID"
+// @Param        page         query  integer  false  "Page number (default: 1)"
+// @Param        limit        query  integer  false  "Limit per page (default: 10)"
+// @Param        status       query  string   false  "waiting, offered, claimed, expired or left (default: waiting and offered)"
+// @Success      200  {object}  object{data=[]models.WaitlistEntry,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/tours/{id}/departures/{departureId}/waitlist [get]
+func GetDepartureWaitlist(c *fiber.Ctx) error {
+	tourID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	departureID, err := paramUUID(c, "departureId")
+	if err != nil {
+		return err
+	}
+	if _, err := services.GetDeparture(tourID, departureID); err != nil {
+		return err
+	}
+	return listWaitlist(c, models.WaitlistDeparture, departureID)
+}
+
+// GetEventWaitlist godoc
+// @Summary      List the waitlist of an event
+// @Description  Lists the open entries of an event's waitlist with their customers: offers first, then waiting entries in line order
+// @Tags         admin_events
+// @Produce      json
+// @Param        id      path   string   true   "Event ID"
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        status  query  string   false  "waiting, offered, claimed, expired or left (default: waiting and offered)"
+// @Success      200  {object}  object{data=[]models.WaitlistEntry,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/events/{id}/waitlist [get]
+func GetEventWaitlist(c *fiber.Ctx) error {
+	eventID, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if _, err := services.GetEventByID(eventID.String()); err != nil {
+		return apperrors.FromDB(err, "Event")
+	}
+	return listWaitlist(c, models.WaitlistEvent, eventID)
+}
+
+func listWaitlist(c *fiber.Ctx, targetType models.WaitlistTarget, targetID uuid.UUID) error {
+	entries, totalCount, err := services.GetWaitlist(c, targetType, targetID)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve waitlist", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, entries, totalCount))
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PaymentWebhook godoc
+// @Summary      Payment provider webhook
+// @Description  Receives signed event notifications from the payment provider. Events are stored, de-duplicated by event ID and applied to payments and bookings; events that fail to apply are retried from the inbox.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        Stripe-Signature  header    string  true  "Signature header, t=<unix>,v1=<hex HMAC-SHA256>"
+// @Success      200               {object}  object{received=boolean,duplicate=boolean}
+// @Failure      400               {object}  models.ErrorResponse
+// @Router       /api/webhooks/payments [post]
+func PaymentWebhook(c *fiber.Ctx) error {
+	_, duplicate, err := services.ReceivePaymentWebhook(c.Body(), c.Get(payments.SignatureHeader))
+	if err != nil {
+		return apperrors.FromDB(err, "Webhook event")
+	}
+	return c.JSON(fiber.Map{"received": true, "duplicate": duplicate})
+}
+
+// GetWebhookEvents godoc
+// @Summary      List webhook events
+// @Description  Lists the payment webhook inbox, newest first
+// @Tags         admin_webhooks
+// @Produce      json
+// @Param        page    query  integer  false  "Page number (default: 1)"
+// @Param        limit   query  integer  false  "Limit per page (default: 10)"
+// @Param        status  query  string   false  "Only events in this state (pending, processed, ignored, failed)"
+// @Param        type    query  string   false  "Only events of this type, e.g. payment_intent.succeeded"
+// @Success      200  {object}  object{data=[]models.WebhookEvent,meta=object{page=integer,limit=integer,total=integer,total_pages=integer}}
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/webhooks [get]
+func GetWebhookEvents(c *fiber.Ctx) error {
+	events, totalCount, err := services.GetWebhookEvents(c)
+	if err != nil {
+		return apperrors.Internal("Failed to retrieve webhook events", err)
+	}
+	return c.JSON(utils.PaginationResponse(c, events, totalCount))
+}
+
+// GetWebhookEventByID godoc
+// @Summary      Get a webhook event
+// @Description  Returns one event from the payment webhook inbox with its payload
+// @Tags         admin_webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Webhook event ID"
+// @Success      200  {object}  models.WebhookEvent
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /admin/webhooks/{id} [get]
+func GetWebhookEventByID(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	event, err := services.GetWebhookEventByID(id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(event)
+}
+
+// ReplayWebhookEvent godoc
+// @Summary      Replay a webhook event
+// @Description  Applies a stored webhook event again, whatever its state. Applying an event twice is harmless.
+// @Tags         admin_webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Webhook event ID"
+// @Success      200  {object}  models.WebhookEvent
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /admin/webhooks/{id}/replay [post]
+func ReplayWebhookEvent(c *fiber.Ctx) error {
+	id, err := paramUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	event, err := services.ReplayWebhookEvent(id)
+	if err != nil {
+		return apperrors.FromDB(err, "Webhook event")
+	}
+	return c.JSON(event)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"log"
 	"os"
+	"strings"
 
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"gorm.io/driver/postgres"
@@ -24,24 +25,65 @@ func ConnectDB() {
 		if dsn == "" {
 			dsn = "tour.db"
 		}
+		// Take the write lock when a transaction begins, so two transactions
+		// that read before writing wait for each other instead of failing
+		// with "database is locked".
+		if !strings.Contains(dsn, "?") {
+			dsn += "?_txlock=immediate&_busy_timeout=5000"
+		}
 		dialector = sqlite.Open(dsn)
 	}
 
 	var err error
-	DB, err = gorm.Open(dialector, &gorm.Config{})
+	DB, err = gorm.Open(dialector, &gorm.Config{
+		// Surface duplicate-key and foreign-key failures as gorm errors
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatalf("cannot connect to database: %v", err)
 	}
 
+	// Must run before the unique (user_id, tour_id) index is created
+	removeDuplicateReviews()
+	// Must run before the not-null user_id column is added
+	dropAnonymousReviewReports()
+
 	if err := DB.AutoMigrate(
 		&models.User{},
 		&models.Category{},
+		&models.CancellationPolicy{},
 		&models.Destination{},
 		&models.Tour{},
+		&models.TourDeparture{},
+		&models.Event{},
 		&models.Review{},
+		&models.ReviewReply{},
+		&models.ReviewReport{},
+		&models.ReviewPhoto{},
+		&models.ReviewVote{},
+		&models.Booking{},
+		&models.Participant{},
+		&models.Invoice{},
+		&models.Payment{},
+		&models.PaymentRefund{},
+		&models.WebhookEvent{},
+		&models.ExchangeRate{},
+		&models.PricingRule{},
+		&models.Promotion{},
+		&models.PromotionRedemption{},
+		&models.WaitlistEntry{},
+		&models.Notification{},
+		&models.Favorite{},
+		&models.Session{},
+		&models.DataExport{},
 		&models.MediaDestination{},
 		&models.MediaTour{},
+		&models.AuditLog{},
 	); err != nil {
 		log.Fatalf("auto-migrate failed: %v", err)
 	}
+	migrateReviewTargets()
+	migrateMoneyColumns()
+	migrateBookingParties()
+	migrateTourDepartures()
 }
@@ -3,6 +3,13 @@ package database
 import (
 	"fmt"
 	"log"
+	"strings"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // MigrateDB runs all database migrations
@@ -90,3 +97,280 @@ func addRatingFieldsToTours() {
 
 	fmt.Println("✅ Added rating fields to tours table")
 }
+
+// removeDuplicateReviews keeps only the latest review of each user for each
+// tour, so the one-review-per-user-per-tour index can be created, and
+// recomputes the rating of the tours that lost reviews.
+func removeDuplicateReviews() {
+	// Only databases from before reviews had polymorphic targets need this
+	if !DB.Migrator().HasTable("reviews") || !DB.Migrator().HasColumn("reviews", "tour_id") {
+		return
+	}
+
+	var tourIDs []string
+	err := DB.Raw(`SELECT DISTINCT tour_id FROM reviews GROUP BY user_id, tour_id HAVING COUNT(*) > 1`).
+		Scan(&tourIDs).Error
+	if err != nil {
+		log.Printf("Error looking for duplicate reviews: %v", err)
+		return
+	}
+	if len(tourIDs) == 0 {
+		return
+	}
+
+	result := DB.Exec(`DELETE FROM reviews WHERE EXISTS (
+		SELECT 1 FROM reviews newer
+		WHERE newer.user_id = reviews.user_id AND newer.tour_id = reviews.tour_id
+		  AND (newer.created_at > reviews.created_at
+		       OR (newer.created_at = reviews.created_at AND newer.id > reviews.id)))`)
+	if result.Error != nil {
+		log.Printf("Error removing duplicate reviews: %v", result.Error)
+		return
+	}
+
+	if err := DB.Exec(`UPDATE tours SET
+		review_count = (SELECT COUNT(*) FROM reviews WHERE reviews.tour_id = tours.id),
+		average_rating = COALESCE((SELECT AVG(rating) FROM reviews WHERE reviews.tour_id = tours.id), 0)
+		WHERE id IN ?`, tourIDs).Error; err != nil {
+		log.Printf("Error recomputing tour ratings: %v", err)
+	}
+
+	fmt.Printf("✅ Removed %d duplicate reviews\n", result.RowsAffected)
+}
+
+// dropAnonymousReviewReports drops review reports from before reporting
+// needed a login. They can't be tied to a user, and the reviews keep their
+// report counts.
+func dropAnonymousReviewReports() {
+	migrator := DB.Migrator()
+	if !migrator.HasTable("review_reports") || migrator.HasColumn("review_reports", "user_id") {
+		return
+	}
+	if err := migrator.DropTable("review_reports"); err != nil {
+		log.Printf("Error dropping anonymous review reports: %v", err)
+		return
+	}
+	fmt.Println("✅ Dropped anonymous review reports")
+}
+
+// migrateReviewTargets moves reviews from the tour_id and event_id columns to
+// the polymorphic target_type/target_id pair and drops the old columns and
+// their indexes and constraint. It runs after AutoMigrate has added the new columns.
+func migrateReviewTargets() {
+	migrator := DB.Migrator()
+	if !migrator.HasColumn("reviews", "tour_id") {
+		return
+	}
+
+	if migrator.HasColumn("reviews", "event_id") {
+		if err := DB.Exec(`UPDATE reviews SET target_type = 'event', target_id = event_id
+			WHERE target_id IS NULL AND event_id IS NOT NULL`).Error; err != nil {
+			log.Printf("Error migrating event reviews: %v", err)
+			return
+		}
+	}
+	result := DB.Exec(`UPDATE reviews SET target_type = 'tour', target_id = tour_id WHERE target_id IS NULL`)
+	if result.Error != nil {
+		log.Printf("Error migrating tour reviews: %v", result.Error)
+		return
+	}
+
+	if migrator.HasConstraint(&models.Review{}, "fk_reviews_tour") {
+		if err := migrator.DropConstraint(&models.Review{}, "fk_reviews_tour"); err != nil {
+			log.Printf("Error dropping constraint fk_reviews_tour: %v", err)
+			return
+		}
+	}
+	for _, index := range []string{"idx_reviews_user_tour", "idx_reviews_event_id"} {
+		if migrator.HasIndex("reviews", index) {
+			if err := migrator.DropIndex(&models.Review{}, index); err != nil {
+				log.Printf("Error dropping index %s: %v", index, err)
+				return
+			}
+		}
+	}
+	for _, column := range []string{"tour_id", "event_id"} {
+		if migrator.HasColumn("reviews", column) {
+			if err := migrator.DropColumn(&models.Review{}, column); err != nil {
+				log.Printf("Error dropping reviews.%s: %v", column, err)
+				return
+			}
+		}
+	}
+
+	// SQLite rebuilds the table to drop columns, losing its indexes
+	if err := DB.AutoMigrate(&models.Review{}); err != nil {
+		log.Printf("Error recreating review indexes: %v", err)
+		return
+	}
+
+	fmt.Printf("✅ Moved %d reviews to polymorphic targets\n", result.RowsAffected)
+}
+
+// migrateMoneyColumns moves prices stored as decimals to integer minor units.
+// It runs after AutoMigrate has added the new columns.
+func migrateMoneyColumns() {
+	migrateMinorUnits(&models.Tour{}, "tours", "price_per_person", "price_per_person_minor")
+	migrateMinorUnits(&models.Event{}, "events", "ticket_price", "ticket_price_minor")
+	migrateMinorUnits(&models.Booking{}, "bookings", "total_price", "total_price_minor")
+}
+
+// migrateMinorUnits converts a decimal amount column into minor units of each
+// row's currency, upper-casing the currency code on the way, and drops the
+// old column. Rows with an unknown currency are converted with two decimals
+// and logged so they can be fixed by hand.
+func migrateMinorUnits(model interface{}, table, oldColumn, newColumn string) {
+	migrator := DB.Migrator()
+	if !migrator.HasColumn(table, oldColumn) {
+		return
+	}
+
+	var rows []struct {
+		ID       string
+		Amount   float64
+		Currency string
+	}
+	err := DB.Table(table).
+		Select("id, COALESCE(" + oldColumn + ", 0) AS amount, COALESCE(currency, '') AS currency").
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Error reading %s.%s: %v", table, oldColumn, err)
+		return
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			currency := strings.ToUpper(strings.TrimSpace(row.Currency))
+			if !utils.IsCurrencyCode(currency) {
+				log.Printf("⚠️ %s %s has unknown currency %q; assuming two decimals", table, row.ID, row.Currency)
+			}
+			err := tx.Table(table).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				newColumn:  utils.ToMinorUnits(row.Amount, currency),
+				"currency": currency,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error converting %s.%s to minor units: %v", table, oldColumn, err)
+		return
+	}
+
+	if err := migrator.DropColumn(model, oldColumn); err != nil {
+		log.Printf("Error dropping %s.%s: %v", table, oldColumn, err)
+		return
+	}
+	// SQLite rebuilds the table to drop columns, losing its indexes
+	if err := DB.AutoMigrate(model); err != nil {
+		log.Printf("Error recreating %s indexes: %v", table, err)
+		return
+	}
+
+	fmt.Printf("✅ Converted %d %s prices to minor units\n", len(rows), table)
+}
+
+// migrateBookingParties books the travelers of bookings made before parties
+// were recorded as adults. It is idempotent.
+func migrateBookingParties() {
+	result := DB.Exec(`UPDATE bookings SET adults = travelers
+		WHERE adults = 0 AND children = 0 AND infants = 0`)
+	if result.Error != nil {
+		log.Printf("Error migrating booking parties: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("✅ Recorded the party of %d bookings\n", result.RowsAffected)
+	}
+}
+
+// migrateTourDepartures moves the single start and end date of each tour
+// onto a first departure, which takes over the tour's bookings and their
+// seats, and drops the old columns. It runs after AutoMigrate has created
+// the departures table.
+func migrateTourDepartures() {
+	migrator := DB.Migrator()
+	if !migrator.HasColumn("tours", "start_date") {
+		return
+	}
+
+	var tours []struct {
+		ID              uuid.UUID
+		StartDate       *time.Time
+		EndDate         *time.Time
+		DefaultCapacity int
+	}
+	if err := DB.Table("tours").Select("id, start_date, end_date, default_capacity").Scan(&tours).Error; err != nil {
+		log.Printf("Error reading tour dates: %v", err)
+		return
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, tour := range tours {
+			if tour.StartDate == nil || tour.StartDate.IsZero() {
+				continue
+			}
+			// A run that failed to drop the old columns already moved this tour
+			var moved int64
+			if err := tx.Model(&models.TourDeparture{}).Where("tour_id = ?", tour.ID).Count(&moved).Error; err != nil {
+				return err
+			}
+			if moved > 0 {
+				continue
+			}
+			end := *tour.StartDate
+			if tour.EndDate != nil && !tour.EndDate.Before(end) {
+				end = *tour.EndDate
+			}
+			duration := int(end.Sub(*tour.StartDate).Hours()/24) + 1
+			if err := tx.Table("tours").Where("id = ?", tour.ID).Update("duration_days", duration).Error; err != nil {
+				return err
+			}
+
+			var seats int
+			err := tx.Model(&models.Booking{}).
+				Where("tour_id = ? AND status <> ?", tour.ID, models.BookingCancelled).
+				Select("COALESCE(SUM(adults + children), 0)").Scan(&seats).Error
+			if err != nil {
+				return err
+			}
+			departure := models.TourDeparture{
+				TourID:      tour.ID,
+				StartDate:   *tour.StartDate,
+				EndDate:     end,
+				Capacity:    max(tour.DefaultCapacity, seats),
+				SeatsBooked: seats,
+			}
+			if err := tx.Create(&departure).Error; err != nil {
+				return err
+			}
+			err = tx.Model(&models.Booking{}).
+				Where("tour_id = ? AND departure_id IS NULL", tour.ID).
+				Update("departure_id", departure.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error moving tour dates to departures: %v", err)
+		return
+	}
+
+	for _, column := range []string{"start_date", "end_date"} {
+		if err := migrator.DropColumn(&models.Tour{}, column); err != nil {
+			log.Printf("Error dropping tours.%s: %v", column, err)
+			return
+		}
+	}
+	// SQLite rebuilds the table to drop columns, losing its indexes
+	if err := DB.AutoMigrate(&models.Tour{}); err != nil {
+		log.Printf("Error recreating tours indexes: %v", err)
+		return
+	}
+
+	fmt.Printf("✅ Moved the dates of %d tours to departures\n", len(tours))
+}
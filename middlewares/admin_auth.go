@@ -3,6 +3,7 @@ package middlewares
 import (
 	"log"
 
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/utils" // replace with your actual project import path
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,7 +19,7 @@ func AdminOnly(c *fiber.Ctx) error {
 	userID, role, err := utils.VerifyJWTRole(c)
 	if err != nil {
 		log.Printf("JWT verification failed: %v", err)
-		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+		return apperrors.Unauthorized("Unauthorized")
 	}
 
 	// Set the role in locals for potential use by other middlewares
@@ -27,7 +28,7 @@ func AdminOnly(c *fiber.Ctx) error {
 	log.Printf("User %s role from JWT: %s", userID, role)
 
 	if role != "admin" && role != "superadmin" {
-		return c.Status(403).JSON(fiber.Map{"error": "Forbidden"})
+		return apperrors.Forbidden("Forbidden")
 	}
 	return c.Next()
 }
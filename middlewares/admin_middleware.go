@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/Twisac-Solutions/tours-backend/utils"
@@ -11,12 +12,12 @@ func AdminAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userId, role, err := utils.VerifyJWTRole(c)
 		if err != nil || (role != "admin" && role != "superadmin") {
-			return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized")
+			return apperrors.Unauthorized("Unauthorized")
 		}
 
 		var user models.User
 		if err := database.DB.First(&user, "id = ?", userId).Error; err != nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "Admin not found")
+			return apperrors.Unauthorized("Admin not found")
 		}
 		c.Locals("admin", &user)
 		return c.Next()
@@ -25,9 +26,13 @@ func AdminAuth() fiber.Handler {
 
 func SuperAdminOnly() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		admin := c.Locals("admin").(*models.User)
-		if admin.Role != "superadmin" {
-			return fiber.NewError(fiber.StatusForbidden, "Super Admins only")
+		// AdminAuth stores the admin record; AdminOnly only stores the token role
+		role, _ := c.Locals("userRole").(string)
+		if admin, ok := c.Locals("admin").(*models.User); ok {
+			role = admin.Role
+		}
+		if role != "superadmin" {
+			return apperrors.Forbidden("Super Admins only")
 		}
 		return c.Next()
 	}
@@ -0,0 +1,179 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var auditActions = map[string]models.AuditAction{
+	fiber.MethodPost:   models.AuditCreate,
+	fiber.MethodPut:    models.AuditUpdate,
+	fiber.MethodPatch:  models.AuditUpdate,
+	fiber.MethodDelete: models.AuditDelete,
+}
+
+// auditAliases maps admin route segments onto the entity they modify.
+var auditAliases = map[string]string{
+	"managers": "users",
+	"me":       "users",
+}
+
+// auditSubResources maps a parent/child route pair onto the entity type the
+// child path writes. Other child paths (a user's password or deletion, a
+// booking's cancellation, a webhook's replay) update the parent itself.
+var auditSubResources = map[string]string{
+	"tours/pricing-rules": "pricing-rules",
+	"tours/departures":    "departures",
+	"reviews/reply":       "review-replies",
+	"payments/refund":     "refunds",
+	"users/data-exports":  "data-exports",
+}
+
+// auditCollectionActions are path segments naming a bulk action on a
+// collection rather than the ID of one entity.
+var auditCollectionActions = map[string]bool{
+	"generate": true,
+	"import":   true,
+}
+
+// auditBulkWrites are the bulk writes whose request body lists the IDs of the
+// entities they update; each gets its own entry.
+var auditBulkWrites = map[string]string{
+	"/admin/reviews/moderation/approve": "reviews",
+	"/admin/reviews/moderation/reject":  "reviews",
+}
+
+// AuditLog records every successful write under /admin together with a
+// before/after diff of the affected entity. It must run after AdminOnly so
+// the actor is known.
+func AuditLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		action, ok := auditActions[c.Method()]
+		if !ok || c.Path() == "/admin/login" {
+			return c.Next()
+		}
+
+		actorID, _ := c.Locals("userID").(string)
+		actorRole, _ := c.Locals("userRole").(string)
+		entityType, entityID, action := auditTarget(action, c.Path(), actorID)
+		entityIDs := []string{entityID}
+		bulk := false
+		if bulkType, ok := auditBulkWrites[c.Path()]; ok {
+			// An unreadable body is rejected by the handler and never logged
+			var body struct {
+				IDs []string `json:"ids"`
+			}
+			_ = json.Unmarshal(c.Body(), &body)
+			entityType, entityIDs, action, bulk = bulkType, body.IDs, models.AuditUpdate, true
+		}
+
+		befores := make([]map[string]interface{}, len(entityIDs))
+		for i, id := range entityIDs {
+			before, err := services.SnapshotEntity(entityType, id)
+			if err != nil {
+				log.Printf("audit: failed to snapshot %s %s: %v", entityType, id, err)
+			}
+			befores[i] = before
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() >= fiber.StatusBadRequest {
+			return nil
+		}
+
+		// Creates only learn their ID from the response body
+		if !bulk && entityIDs[0] == "" {
+			var created struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(c.Response().Body(), &created) == nil {
+				entityIDs[0] = created.ID
+			}
+		}
+
+		actorUUID, _ := uuid.Parse(actorID)
+		for i, id := range entityIDs {
+			var after map[string]interface{}
+			if action != models.AuditDelete {
+				var err error
+				after, err = services.SnapshotEntity(entityType, id)
+				if err != nil {
+					log.Printf("audit: failed to snapshot %s %s: %v", entityType, id, err)
+				}
+			}
+			changes := services.DiffSnapshots(befores[i], after)
+			// Reviews a bulk write left alone (unknown or already in that
+			// state) weren't modified
+			if bulk && len(changes) == 0 {
+				continue
+			}
+
+			entry := &models.AuditLog{
+				ActorID:    actorUUID,
+				ActorRole:  actorRole,
+				Action:     action,
+				EntityType: entityType,
+				EntityID:   id,
+				Method:     c.Method(),
+				Path:       c.Path(),
+				IP:         c.IP(),
+			}
+			if err := services.RecordAudit(entry, changes); err != nil {
+				log.Printf("audit: failed to record %s on %s %s: %v", action, entityType, id, err)
+			}
+		}
+		return nil
+	}
+}
+
+// auditTarget derives the entity type, ID and action of a write to an admin
+// path such as /admin/tours/:id, /admin/tours/:id/departures/:departureId or
+// /admin/me/password. A write to a child path that isn't a sub-resource of
+// its own (e.g. a password) is an update of its parent. A sub-resource
+// without an ID of its own in the path is created under its parent, or, for
+// other methods, is the parent's only one (a review's reply) and keyed by the
+// parent's ID.
+func auditTarget(action models.AuditAction, path, actorID string) (string, string, models.AuditAction) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/admin"), "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return "", "", action
+	}
+
+	entityType := segments[0]
+	if alias, ok := auditAliases[entityType]; ok {
+		entityType = alias
+	}
+	if segments[0] == "me" {
+		return entityType, actorID, models.AuditUpdate
+	}
+	if len(segments) == 1 {
+		return entityType, "", action
+	}
+
+	entityID := segments[1]
+	if len(segments) > 2 {
+		child, ok := auditSubResources[segments[0]+"/"+segments[2]]
+		if !ok {
+			return entityType, entityID, models.AuditUpdate
+		}
+		entityType = child
+		switch {
+		case len(segments) > 3:
+			entityID = segments[3]
+		case action == models.AuditCreate:
+			entityID = ""
+		}
+	}
+	if auditCollectionActions[entityID] {
+		entityID = ""
+	}
+	return entityType, entityID, action
+}
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+func TestAuditTarget(t *testing.T) {
+	const actor = "actor-id"
+	tests := []struct {
+		action     models.AuditAction
+		path       string
+		entityType string
+		entityID   string
+		want       models.AuditAction
+	}{
+		{models.AuditCreate, "/admin/tours", "tours", "", models.AuditCreate},
+		{models.AuditUpdate, "/admin/tours/t1", "tours", "t1", models.AuditUpdate},
+		{models.AuditCreate, "/admin/tours/t1/departures", "departures", "", models.AuditCreate},
+		{models.AuditCreate, "/admin/tours/t1/departures/generate", "departures", "", models.AuditCreate},
+		{models.AuditDelete, "/admin/tours/t1/departures/d1", "departures", "d1", models.AuditDelete},
+		{models.AuditUpdate, "/admin/tours/t1/pricing-rules/r1", "pricing-rules", "r1", models.AuditUpdate},
+		{models.AuditUpdate, "/admin/reviews/v1/reply", "review-replies", "v1", models.AuditUpdate},
+		{models.AuditDelete, "/admin/reviews/v1/reply", "review-replies", "v1", models.AuditDelete},
+		{models.AuditCreate, "/admin/payments/p1/refund", "refunds", "", models.AuditCreate},
+		{models.AuditCreate, "/admin/users/u1/data-exports", "data-exports", "", models.AuditCreate},
+		{models.AuditCreate, "/admin/bookings/b1/cancel", "bookings", "b1", models.AuditUpdate},
+		{models.AuditCreate, "/admin/webhooks/w1/replay", "webhooks", "w1", models.AuditUpdate},
+		{models.AuditDelete, "/admin/users/u1/deletion", "users", "u1", models.AuditUpdate},
+		{models.AuditCreate, "/admin/exchange-rates/import", "exchange-rates", "", models.AuditCreate},
+		{models.AuditUpdate, "/admin/exchange-rates/USD", "exchange-rates", "USD", models.AuditUpdate},
+		{models.AuditUpdate, "/admin/me/password", "users", actor, models.AuditUpdate},
+		{models.AuditUpdate, "/admin/managers/m1", "users", "m1", models.AuditUpdate},
+	}
+	for _, tt := range tests {
+		entityType, entityID, action := auditTarget(tt.action, tt.path, actor)
+		if entityType != tt.entityType || entityID != tt.entityID || action != tt.want {
+			t.Errorf("auditTarget(%s, %q) = %q, %q, %s; want %q, %q, %s",
+				tt.action, tt.path, entityType, entityID, action, tt.entityType, tt.entityID, tt.want)
+		}
+	}
+}
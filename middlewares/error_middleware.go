@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+const problemContentType = "application/problem+json"
+
+// ErrorHandler renders every error returned by a handler as an RFC 7807
+// problem document. It is installed as fiber.Config.ErrorHandler.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var appErr *apperrors.Error
+	var fiberErr *fiber.Error
+
+	switch {
+	case errors.As(err, &appErr):
+	case errors.As(err, &fiberErr):
+		appErr = apperrors.New(kindForStatus(fiberErr.Code), fiberErr.Code, fiberErr.Message)
+	default:
+		appErr = apperrors.Internal("Internal server error", err)
+	}
+
+	if appErr.Kind == apperrors.KindInternal {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), appErr)
+	}
+
+	return c.Status(appErr.Status).JSON(models.ErrorResponse{
+		Type:     appErr.Type(),
+		Title:    appErr.Title(),
+		Status:   appErr.Status,
+		Detail:   appErr.Detail,
+		Instance: c.OriginalURL(),
+		Errors:   appErr.Fields,
+		Error:    appErr.Detail,
+	}, problemContentType)
+}
+
+// Recover turns panics into 500s so a single bad request cannot take the
+// handler down. Input must be validated before it can panic; a panic is
+// always a server bug.
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			log.Printf("panic recovered on %s %s: %v\n%s", c.Method(), c.Path(), r, debug.Stack())
+			err = apperrors.Internal("Internal server error", fmt.Errorf("panic: %v", r))
+		}()
+		return c.Next()
+	}
+}
+
+func kindForStatus(status int) apperrors.Kind {
+	switch status {
+	case fiber.StatusBadRequest:
+		return apperrors.KindBadRequest
+	case fiber.StatusUnauthorized:
+		return apperrors.KindUnauthorized
+	case fiber.StatusForbidden:
+		return apperrors.KindForbidden
+	case fiber.StatusNotFound:
+		return apperrors.KindNotFound
+	case fiber.StatusConflict:
+		return apperrors.KindConflict
+	case fiber.StatusPreconditionFailed:
+		return apperrors.KindPreconditionFailed
+	case fiber.StatusPreconditionRequired:
+		return apperrors.KindPreconditionRequired
+	case fiber.StatusUnprocessableEntity:
+		return apperrors.KindValidation
+	}
+	if status >= fiber.StatusInternalServerError {
+		return apperrors.KindInternal
+	}
+	return ""
+}
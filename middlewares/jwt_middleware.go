@@ -1,17 +1,31 @@
 package middlewares
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/services"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 func JWTProtected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId, err := utils.VerifyJWT(c)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		userId, sessionID, err := utils.VerifySessionJWT(c)
+		if err != nil || (sessionID != "" && !services.SessionActive(userId, sessionID)) {
+			return apperrors.Unauthorized("Unauthorized")
+		}
+		c.Locals("userID", userId)
+		return c.Next()
+	}
+}
+
+// OptionalJWT identifies the user like JWTProtected when a valid token is
+// sent, and lets anonymous requests through.
+func OptionalJWT() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, sessionID, err := utils.VerifySessionJWT(c)
+		if err == nil && (sessionID == "" || services.SessionActive(userId, sessionID)) {
+			c.Locals("userID", userId)
 		}
-		c.Locals("userId", userId)
 		return c.Next()
 	}
 }
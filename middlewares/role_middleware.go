@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -9,7 +10,7 @@ func RequireRole(role string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		userRole := c.Locals("userRole") // set in your JWT middleware
 		if userRole != role {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+			return apperrors.Forbidden("Forbidden")
 		}
 		return c.Next()
 	}
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VendorOnly lets through users whose role is vendor. Customer tokens don't
+// carry the role, so it is looked up; it must run after JWTProtected.
+func VendorOnly(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return apperrors.Unauthorized("Unauthorized")
+	}
+	user, err := services.GetUserByID(userID)
+	if err != nil {
+		return apperrors.Unauthorized("Unauthorized")
+	}
+	if user.Role != "vendor" {
+		return apperrors.Forbidden("Forbidden")
+	}
+	c.Locals("userRole", user.Role)
+	return c.Next()
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditLog records a single write performed through the admin API.
+type AuditLog struct {
+	ID         uuid.UUID   `gorm:"type:text;primaryKey" json:"id"`
+	ActorID    uuid.UUID   `gorm:"type:text;index" json:"actorId"`
+	ActorRole  string      `gorm:"type:varchar(50)" json:"actorRole"`
+	Action     AuditAction `gorm:"type:varchar(20);index" json:"action"`
+	EntityType string      `gorm:"type:varchar(50);index:idx_audit_entity" json:"entityType"`
+	EntityID   string      `gorm:"type:text;index:idx_audit_entity" json:"entityId"`
+	Changes    string      `gorm:"type:text" json:"changes"` // JSON object of field -> {before, after}
+	Method     string      `gorm:"type:varchar(10)" json:"method"`
+	Path       string      `gorm:"type:text" json:"path"`
+	IP         string      `gorm:"type:varchar(64)" json:"ip"`
+	CreatedAt  time.Time   `gorm:"index" json:"createdAt"`
+}
+
+// AuditChange is the before/after pair stored for every changed field.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return
+}
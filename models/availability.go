@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AvailabilityStatus string
+
+const (
+	// AvailabilityAvailable means seats are left on the day's departures
+	AvailabilityAvailable AvailabilityStatus = "available"
+	// AvailabilityLimited means only a few seats are left
+	AvailabilityLimited AvailabilityStatus = "limited"
+	// AvailabilitySoldOut means every departure of the day is full
+	AvailabilitySoldOut AvailabilityStatus = "sold_out"
+	// AvailabilityCancelled means every departure of the day is cancelled
+	AvailabilityCancelled AvailabilityStatus = "cancelled"
+	// AvailabilityUnavailable means nothing departs that day, or it is past
+	AvailabilityUnavailable AvailabilityStatus = "unavailable"
+)
+
+// Availability is a calendar of what can be booked, one entry per day from
+// From to To (YYYY-MM-DD). Prices are adult fares in minor units of
+// Currency.
+type Availability struct {
+	TourID        *uuid.UUID        `json:"tourId,omitempty"`
+	DestinationID *uuid.UUID        `json:"destinationId,omitempty"`
+	Currency      string            `json:"currency"`
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	Days          []DayAvailability `json:"days"`
+}
+
+// DayAvailability sums up the departures starting on one day. PriceMinor is
+// the lowest adult fare among them, preferring departures with seats left.
+// Tours counts the tours departing; tour calendars list the departures too.
+type DayAvailability struct {
+	Date       string                  `json:"date"`
+	Status     AvailabilityStatus      `json:"status"`
+	SeatsLeft  int                     `json:"seatsLeft"`
+	Capacity   int                     `json:"capacity"`
+	PriceMinor *int64                  `json:"priceMinor"`
+	Price      *float64                `json:"price"`
+	Guaranteed bool                    `json:"guaranteed"`
+	Tours      int                     `json:"tours"`
+	Departures []DepartureAvailability `json:"departures,omitempty"`
+}
+
+// DepartureAvailability is one departure in a tour's calendar.
+type DepartureAvailability struct {
+	ID         uuid.UUID       `json:"id"`
+	StartDate  time.Time       `json:"startDate"`
+	Status     DepartureStatus `json:"status"`
+	SeatsLeft  int             `json:"seatsLeft"`
+	PriceMinor *int64          `json:"priceMinor"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"
+	BookingConfirmed BookingStatus = "confirmed"
+	BookingCompleted BookingStatus = "completed"
+	BookingCancelled BookingStatus = "cancelled"
+	// BookingExpired bookings were not paid before their seat hold ran out
+	BookingExpired BookingStatus = "expired"
+)
+
+// Booking is a customer's reservation on a tour or, with EventID instead of
+// TourID, their tickets for an event. A completed tour booking is what makes
+// the customer's review of that tour "verified". The total is in the
+// currency's minor units; PriceLines is the quote it was priced from. A
+// pending booking holds its seats until HoldExpiresAt; paying for it in time
+// confirms it, otherwise it expires and the seats are released.
+type Booking struct {
+	ID              uuid.UUID     `gorm:"type:text;primaryKey" json:"id"`
+	UserID          uuid.UUID     `gorm:"type:text;not null;index" json:"userId"`
+	TourID          *uuid.UUID    `gorm:"type:text;index" json:"tourId,omitempty"`
+	DepartureID     *uuid.UUID    `gorm:"type:text;index" json:"departureId"`
+	EventID         *uuid.UUID    `gorm:"type:text;index" json:"eventId,omitempty"`
+	Status          BookingStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	Travelers       int           `gorm:"not null;default:1" json:"travelers"`
+	Adults          int           `gorm:"not null;default:0" json:"adults"`
+	Children        int           `gorm:"not null;default:0" json:"children"`
+	Infants         int           `gorm:"not null;default:0" json:"infants"`
+	TravelDate      *time.Time    `json:"travelDate"`
+	TotalPriceMinor int64         `gorm:"not null;default:0" json:"totalPriceMinor"`
+	Currency        string        `gorm:"type:varchar(3)" json:"currency"`
+	PriceLines      QuoteLines    `gorm:"type:text" json:"priceLines"`
+	PromotionCode   string        `gorm:"type:varchar(50)" json:"promotionCode,omitempty"`
+	HoldExpiresAt   *time.Time    `gorm:"index" json:"holdExpiresAt,omitempty"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+
+	// Set when the booking is cancelled; RefundMinor is what the tour's
+	// cancellation policy (or the admin cancelling) gave back
+	CancelledAt        *time.Time `json:"cancelledAt,omitempty"`
+	CancelledBy        *uuid.UUID `gorm:"type:text" json:"cancelledBy,omitempty"`
+	CancellationReason string     `gorm:"type:varchar(500)" json:"cancellationReason,omitempty"`
+	RefundMinor        int64      `gorm:"not null;default:0" json:"refundMinor"`
+
+	Tour      *Tour          `gorm:"foreignKey:TourID;constraint:-" json:"tour,omitempty"`
+	Departure *TourDeparture `gorm:"foreignKey:DepartureID;constraint:-" json:"departure,omitempty"`
+	Event     *Event         `gorm:"foreignKey:EventID;constraint:-" json:"event,omitempty"`
+	Payments  []Payment      `gorm:"foreignKey:BookingID" json:"payments,omitempty"`
+
+	User         *User         `gorm:"foreignKey:UserID;constraint:-" json:"user,omitempty"`
+	Participants []Participant `gorm:"foreignKey:BookingID;constraint:OnDelete:CASCADE" json:"participants,omitempty"`
+}
+
+func (b *Booking) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return
+}
+
+// Reference is the short code customers quote for the booking, printed on
+// vouchers and invoices.
+func (b *Booking) Reference() string {
+	return BookingReference(b.ID)
+}
+
+// BookingReference is the Reference of the booking with the given ID.
+func BookingReference(id uuid.UUID) string {
+	return "BK-" + strings.ToUpper(id.String()[:8])
+}
+
+// Party returns who travels on the booking.
+func (b *Booking) Party() Party {
+	return Party{Adults: b.Adults, Children: b.Children, Infants: b.Infants}
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CancellationTier refunds RefundPercent of what was paid when a booking is
+// cancelled at least DaysBefore days before departure.
+type CancellationTier struct {
+	DaysBefore    int `json:"daysBefore"`
+	RefundPercent int `json:"refundPercent"`
+}
+
+// CancellationTiers is stored as a JSON array in a text column, like
+// QuoteLines, ordered from the earliest cancellation to the latest.
+type CancellationTiers []CancellationTier
+
+// Value implements driver.Valuer.
+func (t CancellationTiers) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]CancellationTier(t))
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (t *CancellationTiers) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	case []byte:
+		return json.Unmarshal(v, t)
+	default:
+		return fmt.Errorf("cannot scan %T into CancellationTiers", src)
+	}
+}
+
+// CancellationPolicy decides how much of a booking is refunded when it is
+// cancelled, by how many days before departure that happens. Tours and
+// events point at the policy that applies to them; cancelling later than
+// every tier is never refunded. Text is the tiers spelled out for
+// customers.
+type CancellationPolicy struct {
+	ID          uuid.UUID         `gorm:"type:text;primaryKey" json:"id"`
+	Name        string            `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Description string            `gorm:"type:text" json:"description"`
+	Tiers       CancellationTiers `gorm:"type:text" json:"tiers"`
+	Text        string            `gorm:"-" json:"text"`
+	CreatedBy   uuid.UUID         `gorm:"type:text" json:"createdBy"`
+	UpdatedBy   *uuid.UUID        `gorm:"type:text" json:"updatedBy"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+func (p *CancellationPolicy) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+func (p *CancellationPolicy) BeforeSave(tx *gorm.DB) (err error) {
+	p.SortTiers()
+	p.Text = p.Summary()
+	return
+}
+
+func (p *CancellationPolicy) AfterFind(tx *gorm.DB) (err error) {
+	p.Text = p.Summary()
+	return
+}
+
+// SortTiers orders the tiers from the earliest cancellation to the latest.
+func (p *CancellationPolicy) SortTiers() {
+	sort.SliceStable(p.Tiers, func(i, j int) bool {
+		return p.Tiers[i].DaysBefore > p.Tiers[j].DaysBefore
+	})
+}
+
+// RefundPercent returns the share of the price refunded when cancelling
+// daysBefore days before departure.
+func (p *CancellationPolicy) RefundPercent(daysBefore int) int {
+	for _, tier := range p.Tiers {
+		if daysBefore >= tier.DaysBefore {
+			return tier.RefundPercent
+		}
+	}
+	return 0
+}
+
+// Summary spells out the tiers, e.g. "Full refund if cancelled 30 or more
+// days before departure. No refund if cancelled less than 30 days before
+// departure."
+func (p *CancellationPolicy) Summary() string {
+	if len(p.Tiers) == 0 {
+		return "Non-refundable."
+	}
+	var sentences []string
+	for i, tier := range p.Tiers {
+		var when string
+		switch {
+		case i == 0 && tier.DaysBefore == 0:
+			when = "at any time before departure"
+		case i == 0:
+			when = fmt.Sprintf("%s or more before departure", dayCount(tier.DaysBefore))
+		default:
+			last := p.Tiers[i-1].DaysBefore - 1
+			if last == 0 {
+				when = "on the day of departure"
+			} else if last == tier.DaysBefore {
+				when = fmt.Sprintf("%s before departure", dayCount(last))
+			} else {
+				when = fmt.Sprintf("%d to %s before departure", tier.DaysBefore, dayCount(last))
+			}
+		}
+		sentences = append(sentences, fmt.Sprintf("%s if cancelled %s.", refundName(tier.RefundPercent), when))
+	}
+	if last := p.Tiers[len(p.Tiers)-1]; last.DaysBefore > 0 {
+		sentences = append(sentences, fmt.Sprintf("No refund if cancelled less than %s before departure.", dayCount(last.DaysBefore)))
+	}
+	return strings.Join(sentences, " ")
+}
+
+func refundName(percent int) string {
+	switch percent {
+	case 100:
+		return "Full refund"
+	case 0:
+		return "No refund"
+	}
+	return fmt.Sprintf("%d%% refund", percent)
+}
+
+func dayCount(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CancellationQuote is what cancelling a booking now would refund under its
+// tour's cancellation policy, shown to the customer before they confirm.
+// RefundedMinor is what was already refunded before. Amounts are in the booking currency's minor units; Refund is the refund
+// in major units.
+type CancellationQuote struct {
+	BookingID           uuid.UUID           `json:"bookingId"`
+	Policy              *CancellationPolicy `json:"policy"`
+	PolicyText          string              `json:"policyText"`
+	DepartureDate       time.Time           `json:"departureDate"`
+	DaysBeforeDeparture int                 `json:"daysBeforeDeparture"`
+	RefundPercent       int                 `json:"refundPercent"`
+	PaidMinor           int64               `json:"paidMinor"`
+	RefundedMinor       int64               `json:"refundedMinor"`
+	RefundMinor         int64               `json:"refundMinor"`
+	Refund              float64             `json:"refund"`
+	Currency            string              `json:"currency"`
+}
@@ -8,17 +8,22 @@ import (
 )
 
 type Category struct {
-	ID          uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Icon        string    `json:"icon"` // UI icon
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uuid.UUID  `gorm:"type:text;primaryKey" json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"` // UI icon
+	UpdatedBy   *uuid.UUID `gorm:"type:text" json:"updatedBy"`
+	Version     int        `gorm:"not null;default:1" json:"version"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
 }
 
 func (m *Category) BeforeCreate(tx *gorm.DB) (err error) {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
 	return
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending DataExportStatus = "pending"
+	DataExportReady   DataExportStatus = "ready"
+	DataExportFailed  DataExportStatus = "failed"
+	// DataExportExpired exports were ready but their file has been removed
+	DataExportExpired DataExportStatus = "expired"
+)
+
+// DataExport is a ZIP of JSON files holding everything stored about a user,
+// asked for by the user or an admin. It is built in the background and can
+// be downloaded until ExpiresAt, after which the file is removed.
+type DataExport struct {
+	ID          uuid.UUID        `gorm:"type:text;primaryKey" json:"id"`
+	UserID      uuid.UUID        `gorm:"type:text;not null;index" json:"userId"`
+	RequestedBy uuid.UUID        `gorm:"type:text;not null" json:"requestedBy"`
+	Status      DataExportStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	FilePath    string           `gorm:"type:varchar(500)" json:"-"`
+	SizeBytes   int64            `gorm:"not null;default:0" json:"sizeBytes"`
+	Attempts    int              `gorm:"not null;default:0" json:"-"`
+	LastError   string           `gorm:"type:text" json:"-"`
+	CompletedAt *time.Time       `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time       `gorm:"index" json:"expiresAt,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.Status == "" {
+		e.Status = DataExportPending
+	}
+	return
+}
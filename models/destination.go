@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Destination struct {
@@ -16,8 +17,22 @@ type Destination struct {
 	// Gallery     []string  `gorm:"type:text[]" json:"gallery"`
 	// Tours     []string  `gorm:"type:text[]" json:"tours"`
 	// Events    []string  `gorm:"type:text[]" json:"events"`
-	CreatedBy uuid.UUID `gorm:"type:text;not null" json:"createdBy"`
-	User      User      `gorm:"foreignKey:CreatedBy" json:"user"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	AverageRating float64    `gorm:"type:decimal(3,2);default:0.00" json:"averageRating"`
+	ReviewCount   int        `gorm:"default:0" json:"reviewCount"`
+	CreatedBy     uuid.UUID  `gorm:"type:text;not null" json:"createdBy"`
+	UpdatedBy     *uuid.UUID `gorm:"type:text" json:"updatedBy"`
+	Version       int        `gorm:"not null;default:1" json:"version"`
+	User          User       `gorm:"foreignKey:CreatedBy" json:"user"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (m *Destination) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	return
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/utils"
+)
+
+// EncryptedString is a string stored encrypted at rest (see utils.Encrypt)
+// and read back as plain text. Empty strings are stored as they are, so
+// "not given" stays queryable.
+type EncryptedString string
+
+// Value implements driver.Valuer.
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	return utils.Encrypt(string(s))
+}
+
+// Scan implements sql.Scanner.
+func (s *EncryptedString) Scan(src interface{}) error {
+	var stored string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EncryptedString", src)
+	}
+	if stored == "" {
+		*s = ""
+		return nil
+	}
+	plaintext, err := utils.Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
@@ -3,7 +3,9 @@ package models
 import (
 	"time"
 
+	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ScheduleItem struct {
@@ -22,19 +24,48 @@ type Event struct {
 	FullDesc      string    `json:"fullDescription"`
 	EventDate     time.Time `json:"eventDate"`
 	DurationHours int       `json:"durationHours"`
-	TicketPrice   float64   `json:"ticketPrice"`
-	Currency      string    `json:"currency"`
-	Capacity      int       `json:"capacity"`
-	Availability  int       `json:"availability"`
-	IsFeatured    bool      `json:"isFeatured"`
-	Inclusions    []string  `gorm:"type:text[]" json:"inclusions"`
-	Exclusions    []string  `gorm:"type:text[]" json:"exclusions"`
-	CoverImage    Media     `gorm:"embedded" json:"coverImage"`
-	Gallery       []string  `gorm:"type:text[]" json:"gallery"`
-	Schedule      []string  `gorm:"type:text[]" json:"schedule"`
-	Tags          []string  `gorm:"type:text[]" json:"tags"`
-	Reviews       []string  `gorm:"type:text[]" json:"reviews"`
-	CreatedBy     uuid.UUID `json:"createdBy"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	// Ticket price in the currency's minor units; TicketPrice is the same
+	// amount in major units, filled in when the event is loaded
+	TicketPriceMinor int64      `gorm:"not null;default:0" json:"ticketPriceMinor"`
+	TicketPrice      float64    `gorm:"-" json:"ticketPrice"`
+	Currency         string     `gorm:"type:varchar(3)" json:"currency"`
+	Capacity         int        `json:"capacity"`
+	Availability     int        `json:"availability"`
+	IsFeatured       bool       `json:"isFeatured"`
+	Inclusions       StringList `gorm:"type:text" json:"inclusions"`
+	Exclusions       StringList `gorm:"type:text" json:"exclusions"`
+	CoverImage       Media      `gorm:"embedded" json:"coverImage"`
+	Gallery          StringList `gorm:"type:text" json:"gallery"`
+	Schedule         StringList `gorm:"type:text" json:"schedule"`
+	Tags             StringList `gorm:"type:text" json:"tags"`
+	AverageRating    float64    `gorm:"type:decimal(3,2);default:0.00" json:"averageRating"`
+	ReviewCount      int        `gorm:"default:0" json:"reviewCount"`
+	CreatedBy        uuid.UUID  `json:"createdBy"`
+	UpdatedBy        *uuid.UUID `gorm:"type:text" json:"updatedBy"`
+	Version          int        `gorm:"not null;default:1" json:"version"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+
+	// CancellationPolicy decides refunds when tickets are cancelled; events
+	// without one are non-refundable
+	CancellationPolicyID *uuid.UUID          `gorm:"type:text;index" json:"cancellationPolicyId"`
+	CancellationPolicy   *CancellationPolicy `gorm:"foreignKey:CancellationPolicyID;constraint:-" json:"cancellationPolicy,omitempty"`
+
+	// Where guests should go, printed on tickets
+	MeetingPoint string `gorm:"type:varchar(500)" json:"meetingPoint"`
+}
+
+func (m *Event) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	return
+}
+
+func (m *Event) AfterFind(tx *gorm.DB) (err error) {
+	m.TicketPrice = utils.FromMinorUnits(m.TicketPriceMinor, m.Currency)
+	return
 }
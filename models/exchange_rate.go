@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate says how many units of Currency one unit of the base currency
+// buys. The base currency itself has no row; its rate is always 1.
+type ExchangeRate struct {
+	Currency  string     `gorm:"type:varchar(3);primaryKey" json:"currency"`
+	Rate      float64    `gorm:"not null" json:"rate"`
+	Source    string     `gorm:"type:varchar(20);not null;default:manual" json:"source"`
+	UpdatedBy *uuid.UUID `gorm:"type:text" json:"updatedBy"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
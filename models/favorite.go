@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FavoriteTarget string
+
+const (
+	FavoriteTour        FavoriteTarget = "tour"
+	FavoriteEvent       FavoriteTarget = "event"
+	FavoriteDestination FavoriteTarget = "destination"
+)
+
+// Favorite is a tour, event or destination a user saved for later. A user
+// saves each at most once. The saved item is filled in by listings.
+type Favorite struct {
+	ID         uuid.UUID      `gorm:"type:text;primaryKey" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:text;not null;uniqueIndex:idx_favorites_target" json:"userId"`
+	TargetType FavoriteTarget `gorm:"type:varchar(20);not null;uniqueIndex:idx_favorites_target;index:idx_favorites_of" json:"targetType"`
+	TargetID   uuid.UUID      `gorm:"type:text;not null;uniqueIndex:idx_favorites_target;index:idx_favorites_of" json:"targetId"`
+	CreatedAt  time.Time      `gorm:"index" json:"createdAt"`
+
+	Tour        *Tour        `gorm:"-" json:"-"`
+	Event       *Event       `gorm:"-" json:"-"`
+	Destination *Destination `gorm:"-" json:"-"`
+}
+
+func (f *Favorite) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Invoice is the tax invoice for a booking, issued once when it is first
+// asked for. Numbers run on per year (INV-2026-000001) without gaps. The
+// seller, buyer and lines are copied in when the invoice is issued so it
+// never changes afterwards. Prices include tax: TotalMinor is what the
+// booking cost and NetMinor + TaxMinor add up to it, in minor units of
+// Currency.
+type Invoice struct {
+	ID        uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
+	Number    string    `gorm:"type:varchar(30);not null;uniqueIndex" json:"number"`
+	BookingID uuid.UUID `gorm:"type:text;not null;uniqueIndex" json:"bookingId"`
+	UserID    uuid.UUID `gorm:"type:text;not null;index" json:"userId"`
+	IssuedAt  time.Time `gorm:"not null" json:"issuedAt"`
+	Currency  string    `gorm:"type:varchar(3);not null" json:"currency"`
+	// Description names what was bought, e.g. the tour and its departure
+	Description string     `gorm:"type:varchar(500)" json:"description"`
+	Lines       QuoteLines `gorm:"type:text" json:"lines"`
+	// TaxRate is the percentage of tax included in the prices
+	TaxRate    float64 `gorm:"not null;default:0" json:"taxRate"`
+	NetMinor   int64   `gorm:"not null" json:"netMinor"`
+	TaxMinor   int64   `gorm:"not null" json:"taxMinor"`
+	TotalMinor int64   `gorm:"not null" json:"totalMinor"`
+
+	SellerName    string `gorm:"type:varchar(255)" json:"sellerName"`
+	SellerAddress string `gorm:"type:varchar(500)" json:"sellerAddress"`
+	SellerTaxID   string `gorm:"type:varchar(50)" json:"sellerTaxId"`
+	BuyerName     string `gorm:"type:varchar(255)" json:"buyerName"`
+	BuyerEmail    string `gorm:"type:varchar(255)" json:"buyerEmail"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Manifest lists everyone travelling on a departure for the people running
+// it: one row per traveller on its confirmed bookings.
+type Manifest struct {
+	TourID            uuid.UUID     `json:"tourId"`
+	TourTitle         string        `json:"tourTitle"`
+	DepartureID       uuid.UUID     `json:"departureId"`
+	StartDate         time.Time     `json:"startDate"`
+	EndDate           time.Time     `json:"endDate"`
+	ParticipantFields []string      `json:"participantFields"`
+	Travelers         int           `json:"travelers"`
+	Rows              []ManifestRow `json:"rows"`
+	GeneratedAt       time.Time     `json:"generatedAt"`
+}
+
+// ManifestRow is one traveller. Travellers the customer hasn't described
+// yet have no Participant; Missing lists the details still owed.
+type ManifestRow struct {
+	BookingID     uuid.UUID    `json:"bookingId"`
+	BookedBy      string       `json:"bookedBy"`
+	BookedByEmail string       `json:"bookedByEmail"`
+	Participant   *Participant `json:"participant"`
+	Missing       []string     `json:"missing"`
+}
+
+// Details returns the traveller's participant details, empty when the
+// customer hasn't given them.
+func (r ManifestRow) Details() Participant {
+	if r.Participant == nil {
+		return Participant{}
+	}
+	return *r.Participant
+}
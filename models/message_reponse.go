@@ -1,9 +1,22 @@
 package models
 
-// ErrorResponse represents a standard error response.
+// ErrorResponse is the RFC 7807 problem document returned for every error.
+// Error mirrors Detail for clients written against the older {"error": ...} body.
 // swagger:model
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// FieldError describes a single invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type MessageResponse struct {
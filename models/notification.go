@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationWaitlistJoined   NotificationType = "waitlist_joined"
+	NotificationWaitlistOffer    NotificationType = "waitlist_offer"
+	NotificationWaitlistExpired  NotificationType = "waitlist_expired"
+	NotificationBookingCancelled NotificationType = "booking_cancelled"
+	NotificationBookingConfirmed NotificationType = "booking_confirmed"
+	NotificationDataExportReady  NotificationType = "data_export_ready"
+	NotificationAccountDeletion  NotificationType = "account_deletion_scheduled"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySent    DeliveryStatus = "sent"
+	DeliveryFailed  DeliveryStatus = "failed"
+)
+
+// Notification is a message to a user. It shows in the user's in-app inbox
+// and is also delivered by email from an outbox: rows are written in the
+// transaction that caused them and sent afterwards by the dispatcher, which
+// retries failed deliveries a few times.
+type Notification struct {
+	ID          uuid.UUID        `gorm:"type:text;primaryKey" json:"id"`
+	UserID      uuid.UUID        `gorm:"type:text;not null;index" json:"userId"`
+	Type        NotificationType `gorm:"type:varchar(50);not null" json:"type"`
+	Title       string           `gorm:"type:varchar(255);not null" json:"title"`
+	Body        string           `gorm:"type:text;not null" json:"body"`
+	Link        string           `gorm:"type:varchar(500)" json:"link,omitempty"`
+	ReadAt      *time.Time       `json:"readAt"`
+	Delivery    DeliveryStatus   `gorm:"type:varchar(20);not null;default:pending;index" json:"delivery"`
+	Attempts    int              `gorm:"not null;default:0" json:"-"`
+	LastError   string           `gorm:"type:text" json:"-"`
+	DeliveredAt *time.Time       `json:"deliveredAt"`
+	CreatedAt   time.Time        `gorm:"index" json:"createdAt"`
+
+	// BookingID is the booking the notification is about; confirmation
+	// emails carry its voucher and invoice
+	BookingID *uuid.UUID `gorm:"type:text" json:"bookingId,omitempty"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.Delivery == "" {
+		n.Delivery = DeliveryPending
+	}
+	return
+}
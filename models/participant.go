@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Participant details a tour may require besides the traveller's name.
+const (
+	ParticipantDateOfBirth      = "dateOfBirth"
+	ParticipantNationality      = "nationality"
+	ParticipantPassport         = "passport"
+	ParticipantDietary          = "dietaryRequirements"
+	ParticipantEmergencyContact = "emergencyContact"
+)
+
+// ParticipantFields are the details a tour's ParticipantFields may list.
+var ParticipantFields = []string{
+	ParticipantDateOfBirth,
+	ParticipantNationality,
+	ParticipantPassport,
+	ParticipantDietary,
+	ParticipantEmergencyContact,
+}
+
+// Participant is one traveller on a booking, as listed on the departure's
+// manifest. Everything but the name and nationality is encrypted at rest;
+// dates are kept as YYYY-MM-DD.
+type Participant struct {
+	ID                    uuid.UUID       `gorm:"type:text;primaryKey" json:"id"`
+	BookingID             uuid.UUID       `gorm:"type:text;not null;index" json:"bookingId"`
+	Position              int             `gorm:"not null;default:0" json:"position"`
+	FullName              string          `gorm:"type:varchar(200);not null" json:"fullName"`
+	DateOfBirth           EncryptedString `gorm:"type:text" json:"dateOfBirth"`
+	Nationality           string          `gorm:"type:varchar(100)" json:"nationality"`
+	PassportNumber        EncryptedString `gorm:"type:text" json:"passportNumber"`
+	PassportExpiry        EncryptedString `gorm:"type:text" json:"passportExpiry"`
+	DietaryRequirements   EncryptedString `gorm:"type:text" json:"dietaryRequirements"`
+	EmergencyContactName  EncryptedString `gorm:"type:text" json:"emergencyContactName"`
+	EmergencyContactPhone EncryptedString `gorm:"type:text" json:"emergencyContactPhone"`
+	CreatedAt             time.Time       `json:"createdAt"`
+	UpdatedAt             time.Time       `json:"updatedAt"`
+}
+
+func (p *Participant) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// Missing returns which of the required details the participant lacks.
+func (p *Participant) Missing(required []string) []string {
+	var missing []string
+	for _, field := range required {
+		var given bool
+		switch field {
+		case ParticipantDateOfBirth:
+			given = p.DateOfBirth != ""
+		case ParticipantNationality:
+			given = p.Nationality != ""
+		case ParticipantPassport:
+			given = p.PassportNumber != "" && p.PassportExpiry != ""
+		case ParticipantDietary:
+			given = p.DietaryRequirements != ""
+		case ParticipantEmergencyContact:
+			given = p.EmergencyContactName != "" && p.EmergencyContactPhone != ""
+		default:
+			given = true
+		}
+		if !given {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
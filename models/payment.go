@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPending           PaymentStatus = "pending"
+	PaymentSucceeded         PaymentStatus = "succeeded"
+	PaymentFailed            PaymentStatus = "failed"
+	PaymentPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentRefunded          PaymentStatus = "refunded"
+	PaymentCancelled         PaymentStatus = "cancelled"
+)
+
+// Payment is one attempt to pay for a booking through a payment provider.
+// Amounts are in the currency's minor units. The idempotency key is chosen
+// by the client and is unique per user, so a retried request returns the
+// original payment instead of charging twice.
+type Payment struct {
+	ID             uuid.UUID     `gorm:"type:text;primaryKey" json:"id"`
+	BookingID      uuid.UUID     `gorm:"type:text;not null;index" json:"bookingId"`
+	UserID         uuid.UUID     `gorm:"type:text;not null;uniqueIndex:idx_payments_idempotency" json:"userId"`
+	IdempotencyKey string        `gorm:"type:varchar(255);not null;uniqueIndex:idx_payments_idempotency" json:"-"`
+	Provider       string        `gorm:"type:varchar(20);not null" json:"provider"`
+	ProviderRef    *string       `gorm:"type:varchar(255);uniqueIndex" json:"providerRef"`
+	ClientSecret   string        `gorm:"type:varchar(255)" json:"clientSecret,omitempty"`
+	Status         PaymentStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	Amount         int64         `gorm:"not null" json:"amount"`
+	RefundedAmount int64         `gorm:"not null;default:0" json:"refundedAmount"`
+	Currency       string        `gorm:"type:varchar(3);not null" json:"currency"`
+	FailureReason  string        `gorm:"type:text" json:"failureReason,omitempty"`
+	PaidAt         *time.Time    `json:"paidAt"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+
+	Refunds []PaymentRefund `gorm:"foreignKey:PaymentID" json:"refunds,omitempty"`
+}
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
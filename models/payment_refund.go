@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// PaymentRefund is money returned from a payment. Its amount is reserved on
+// the payment before the provider is called, so concurrent refunds can never
+// return more than was paid.
+type PaymentRefund struct {
+	ID             uuid.UUID    `gorm:"type:text;primaryKey" json:"id"`
+	PaymentID      uuid.UUID    `gorm:"type:text;not null;uniqueIndex:idx_payment_refunds_idempotency" json:"paymentId"`
+	IdempotencyKey string       `gorm:"type:varchar(255);not null;uniqueIndex:idx_payment_refunds_idempotency" json:"-"`
+	ProviderRef    *string      `gorm:"type:varchar(255)" json:"providerRef"`
+	Amount         int64        `gorm:"not null" json:"amount"`
+	Reason         string       `gorm:"type:varchar(255)" json:"reason"`
+	Status         RefundStatus `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	FailureReason  string       `gorm:"type:text" json:"failureReason,omitempty"`
+	CreatedBy      *uuid.UUID   `gorm:"type:text" json:"createdBy"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+func (r *PaymentRefund) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PricingRuleKind string
+
+const (
+	// PricingFare prices children or infants: AmountMinor per person, or
+	// Percent of the adult fare.
+	PricingFare PricingRuleKind = "fare"
+	// PricingSeason applies between StartDate and EndDate: the adult fare
+	// becomes AmountMinor, or changes by Percent (negative for a discount).
+	PricingSeason PricingRuleKind = "season"
+	// PricingGroup discounts parties of at least MinTravelers: Percent off
+	// the fares, or AmountMinor off per traveler.
+	PricingGroup PricingRuleKind = "group"
+	// PricingEarlyBird discounts bookings made at least DaysBefore days
+	// ahead: Percent off the fares, or AmountMinor off per traveler.
+	PricingEarlyBird PricingRuleKind = "early_bird"
+)
+
+type TravelerType string
+
+const (
+	TravelerAdult  TravelerType = "adult"
+	TravelerChild  TravelerType = "child"
+	TravelerInfant TravelerType = "infant"
+)
+
+// PricingRule adjusts the price of a tour for some parties or dates. Which
+// fields apply depends on Kind; amounts are in minor units of the tour's
+// currency. The adult fare is the tour's price per person.
+type PricingRule struct {
+	ID           uuid.UUID       `gorm:"type:text;primaryKey" json:"id"`
+	TourID       uuid.UUID       `gorm:"type:text;not null;index" json:"tourId"`
+	Kind         PricingRuleKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Name         string          `gorm:"type:varchar(100)" json:"name"`
+	TravelerType TravelerType    `gorm:"type:varchar(10)" json:"travelerType,omitempty"`
+	MinTravelers int             `gorm:"not null;default:0" json:"minTravelers,omitempty"`
+	DaysBefore   int             `gorm:"not null;default:0" json:"daysBefore,omitempty"`
+	StartDate    *time.Time      `json:"startDate,omitempty"`
+	EndDate      *time.Time      `json:"endDate,omitempty"`
+	AmountMinor  *int64          `json:"amountMinor,omitempty"`
+	Percent      *float64        `json:"percent,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
+
+func (r *PricingRule) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
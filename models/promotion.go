@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromotionType string
+
+const (
+	PromotionPercent PromotionType = "percent"
+	PromotionFixed   PromotionType = "fixed"
+)
+
+// Promotion is a discount code. A fixed discount (AmountMinor) and a minimum
+// spend are in Currency, so such promotions only apply to tours priced in
+// it. Zero limits mean unlimited. With no tour, category or destination IDs
+// the promotion applies to every tour; otherwise to tours matching any of
+// them.
+type Promotion struct {
+	ID              uuid.UUID     `gorm:"type:text;primaryKey" json:"id"`
+	Code            string        `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"`
+	Description     string        `gorm:"type:varchar(255)" json:"description"`
+	Type            PromotionType `gorm:"type:varchar(10);not null" json:"type"`
+	Percent         float64       `gorm:"not null;default:0" json:"percent,omitempty"`
+	AmountMinor     int64         `gorm:"not null;default:0" json:"amountMinor,omitempty"`
+	Currency        string        `gorm:"type:varchar(3)" json:"currency,omitempty"`
+	MinSpendMinor   int64         `gorm:"not null;default:0" json:"minSpendMinor"`
+	StartsAt        *time.Time    `json:"startsAt"`
+	EndsAt          *time.Time    `json:"endsAt"`
+	MaxRedemptions  int           `gorm:"not null;default:0" json:"maxRedemptions"`
+	MaxPerUser      int           `gorm:"not null;default:0" json:"maxPerUser"`
+	RedemptionCount int           `gorm:"not null;default:0" json:"redemptionCount"`
+	TourIDs         StringList    `gorm:"type:text" json:"tourIds"`
+	CategoryIDs     StringList    `gorm:"type:text" json:"categoryIds"`
+	DestinationIDs  StringList    `gorm:"type:text" json:"destinationIds"`
+	Active          bool          `gorm:"not null" json:"active"`
+	CreatedBy       uuid.UUID     `gorm:"type:text" json:"createdBy"`
+	UpdatedBy       *uuid.UUID    `gorm:"type:text" json:"updatedBy"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+}
+
+func (p *Promotion) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
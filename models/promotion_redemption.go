@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PromotionRedemption records a promotion used on a booking; a booking can
+// use one promotion.
+type PromotionRedemption struct {
+	ID            uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
+	PromotionID   uuid.UUID `gorm:"type:text;not null;index:idx_promotion_redemptions_user" json:"promotionId"`
+	UserID        uuid.UUID `gorm:"type:text;not null;index:idx_promotion_redemptions_user" json:"userId"`
+	BookingID     uuid.UUID `gorm:"type:text;not null;uniqueIndex" json:"bookingId"`
+	DiscountMinor int64     `gorm:"not null" json:"discountMinor"`
+	Currency      string    `gorm:"type:varchar(3)" json:"currency"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func (r *PromotionRedemption) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
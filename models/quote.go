@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Party is who travels on a booking.
+type Party struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+	Infants  int `json:"infants"`
+}
+
+// Travelers is the size of the party.
+func (p Party) Travelers() int {
+	return p.Adults + p.Children + p.Infants
+}
+
+// Seats is how many seats the party takes; infants travel on a lap.
+func (p Party) Seats() int {
+	return p.Adults + p.Children
+}
+
+// Quote is the itemised price of a tour for a party on a date. Amounts are
+// in minor units of Currency.
+type Quote struct {
+	TourID        uuid.UUID  `json:"tourId"`
+	DepartureID   uuid.UUID  `json:"departureId"`
+	Date          time.Time  `json:"date"`
+	Party         Party      `json:"party"`
+	Currency      string     `json:"currency"`
+	PromotionCode string     `json:"promotionCode,omitempty"`
+	Lines         QuoteLines `json:"lines"`
+	SubtotalMinor int64      `json:"subtotalMinor"`
+	DiscountMinor int64      `json:"discountMinor"`
+	TotalMinor    int64      `json:"totalMinor"`
+}
+
+// QuoteLine is one fare or discount of a quote. Discounts have a negative
+// amount.
+type QuoteLine struct {
+	Kind         string       `json:"kind"`
+	Description  string       `json:"description"`
+	TravelerType TravelerType `json:"travelerType,omitempty"`
+	Quantity     int          `json:"quantity"`
+	UnitMinor    int64        `json:"unitMinor"`
+	AmountMinor  int64        `json:"amountMinor"`
+	RuleID       *uuid.UUID   `json:"ruleId,omitempty"`
+}
+
+// QuoteLines is stored as a JSON array in a text column, like StringList.
+type QuoteLines []QuoteLine
+
+// Value implements driver.Valuer.
+func (l QuoteLines) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]QuoteLine(l))
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (l *QuoteLines) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("cannot scan %T into QuoteLines", src)
+	}
+}
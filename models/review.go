@@ -4,17 +4,76 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+	ReviewFlagged  ReviewStatus = "flagged"
+)
+
+// ReviewTargetType is the kind of thing a review is about.
+type ReviewTargetType string
+
+const (
+	ReviewTargetTour        ReviewTargetType = "tour"
+	ReviewTargetEvent       ReviewTargetType = "event"
+	ReviewTargetDestination ReviewTargetType = "destination"
+)
+
+// Review is a customer's rating of a tour, event or destination, named by
+// TargetType and TargetID. Each user can review a target once; Verified is
+// set when the user has a completed booking for the tour. Only approved
+// reviews are public and count towards the target's rating.
 type Review struct {
-	ID        uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
-	UserID    uuid.UUID `json:"userId"`
-	TourID    uuid.UUID `json:"tourId"`                        // Changed from PackageID to TourID for clarity
-	Rating    int       `json:"rating" validate:"min=1,max=5"` // 1 to 5
-	Comment   string    `json:"comment"`
+	ID         uuid.UUID        `gorm:"type:text;primaryKey" json:"id"`
+	UserID     uuid.UUID        `gorm:"uniqueIndex:idx_reviews_user_target" json:"userId"`
+	TargetType ReviewTargetType `gorm:"type:varchar(20);not null;default:tour;uniqueIndex:idx_reviews_user_target;index:idx_reviews_target" json:"targetType"`
+	TargetID   uuid.UUID        `gorm:"type:text;uniqueIndex:idx_reviews_user_target;index:idx_reviews_target" json:"targetId"`
+	Rating     int              `json:"rating" validate:"min=1,max=5"` // 1 to 5
+	Comment    string           `json:"comment"`
+	Verified   bool             `gorm:"not null;default:false" json:"verified"`
+
+	// Optional aspect ratings, 1 to 5
+	GuideRating        *int `json:"guideRating"`
+	ValueRating        *int `json:"valueRating"`
+	OrganisationRating *int `json:"organisationRating"`
+	SafetyRating       *int `json:"safetyRating"`
+
+	// Votes from other users on whether the review was useful
+	HelpfulCount   int `gorm:"not null;default:0;index" json:"helpfulCount"`
+	UnhelpfulCount int `gorm:"not null;default:0" json:"unhelpfulCount"`
+
+	// Moderation
+	Status         ReviewStatus `gorm:"type:varchar(20);not null;default:approved;index" json:"status"`
+	ReportCount    int          `gorm:"not null;default:0" json:"reportCount"`
+	ModeratedBy    *uuid.UUID   `gorm:"type:text" json:"moderatedBy,omitempty"`
+	ModeratedAt    *time.Time   `json:"moderatedAt,omitempty"`
+	ModerationNote string       `json:"moderationNote,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID" json:"user"`
-	Tour Tour `gorm:"foreignKey:TourID" json:"tour"`
+	// Only the one matching TargetType is loaded
+	Tour        *Tour         `gorm:"foreignKey:TargetID;constraint:-" json:"tour,omitempty"`
+	Event       *Event        `gorm:"foreignKey:TargetID;constraint:-" json:"event,omitempty"`
+	Destination *Destination  `gorm:"foreignKey:TargetID;constraint:-" json:"destination,omitempty"`
+	Reply       *ReviewReply  `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE" json:"reply,omitempty"`
+	Photos      []ReviewPhoto `gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE" json:"photos"`
+}
+
+func (r *Review) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.Status == "" {
+		r.Status = ReviewPending
+	}
+	return
 }
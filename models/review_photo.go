@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewPhoto is a picture a reviewer attached to their review.
+type ReviewPhoto struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ReviewID  uuid.UUID `gorm:"type:text;not null;index" json:"reviewId"`
+	UserID    uuid.UUID `gorm:"type:text;not null" json:"createdBy"`
+	URL       string    `gorm:"type:varchar(255);not null" json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
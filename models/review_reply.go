@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewReply is the owner's public answer to a review of their tour, event
+// or destination. A review has at most one reply.
+type ReviewReply struct {
+	ID        uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
+	ReviewID  uuid.UUID `gorm:"type:text;not null;uniqueIndex" json:"reviewId"`
+	UserID    uuid.UUID `gorm:"type:text;not null" json:"userId"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (r *ReviewReply) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewReport records a signed-in user reporting a review as
+// inappropriate. Each user counts once per review.
+type ReviewReport struct {
+	ID        uuid.UUID `gorm:"type:text;primaryKey" json:"id"`
+	ReviewID  uuid.UUID `gorm:"type:text;not null;uniqueIndex:idx_review_reports_user" json:"reviewId"`
+	UserID    uuid.UUID `gorm:"type:text;not null;uniqueIndex:idx_review_reports_user" json:"userId"`
+	Reason    string    `gorm:"type:varchar(50);not null" json:"reason"`
+	Details   string    `gorm:"type:text" json:"details"`
+	IP        string    `gorm:"type:varchar(64)" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (r *ReviewReport) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
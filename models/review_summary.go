@@ -0,0 +1,25 @@
+package models
+
+import "github.com/google/uuid"
+
+// ReviewSummary aggregates the approved reviews of a tour, event or
+// destination.
+type ReviewSummary struct {
+	TargetType    ReviewTargetType `json:"targetType"`
+	TargetID      uuid.UUID        `json:"targetId"`
+	AverageRating float64          `json:"averageRating"`
+	ReviewCount   int64            `json:"reviewCount"`
+	// Histogram counts the reviews per star rating, "1" to "5".
+	Histogram   map[int]int64    `json:"histogram"`
+	SubRatings  ReviewSubRatings `json:"subRatings"`
+	MostHelpful []Review         `json:"mostHelpful"`
+}
+
+// ReviewSubRatings holds the average aspect ratings of a tour. An aspect
+// nobody rated is null.
+type ReviewSubRatings struct {
+	Guide        *float64 `json:"guide"`
+	Value        *float64 `json:"value"`
+	Organisation *float64 `json:"organisation"`
+	Safety       *float64 `json:"safety"`
+}
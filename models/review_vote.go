@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewVote records whether a user found a review helpful. Each user has at
+// most one vote per review; voting again changes it.
+type ReviewVote struct {
+	ReviewID  uuid.UUID `gorm:"type:text;primaryKey" json:"reviewId"`
+	UserID    uuid.UUID `gorm:"type:text;primaryKey" json:"userId"`
+	Helpful   bool      `gorm:"not null" json:"helpful"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
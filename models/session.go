@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is a login: every token issued to a user belongs to one. Tokens
+// of a revoked session are refused even before they expire.
+type Session struct {
+	ID        uuid.UUID  `gorm:"type:text;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:text;not null;index" json:"userId"`
+	IPAddress string     `gorm:"type:varchar(45)" json:"ipAddress"`
+	UserAgent string     `gorm:"type:varchar(500)" json:"userAgent"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
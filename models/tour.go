@@ -3,7 +3,9 @@ package models
 import (
 	"time"
 
+	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Tour struct {
@@ -15,26 +17,65 @@ type Tour struct {
 	// ShortDesc     string    `json:"shortDescription"`
 	Description string `gorm:"type:text" json:"description"`
 	About       string `gorm:"type:text" json:"about"`
-	// DurationDays   int       `json:"durationDays"`
-	StartDate      time.Time `json:"startDate"`
-	EndDate        time.Time `json:"endDate"`
-	PricePerPerson float64   `json:"pricePerPerson"`
-	Currency       string    `json:"currency"`
-	AverageRating  float64   `gorm:"type:decimal(3,2);default:0.00" json:"averageRating"`
-	ReviewCount    int       `gorm:"default:0" json:"reviewCount"`
+	// Dates and seats are per departure (see TourDeparture); new departures
+	// last DurationDays days and get DefaultCapacity seats unless told otherwise
+	DurationDays    int `gorm:"not null;default:1" json:"durationDays"`
+	DefaultCapacity int `gorm:"not null;default:20" json:"defaultCapacity"`
+	// NextDeparture is the first bookable departure, filled in by listings
+	NextDeparture *TourDeparture `gorm:"-" json:"nextDeparture,omitempty"`
+	// Price in the currency's minor units; PricePerPerson is the same amount
+	// in major units, filled in when the tour is loaded
+	PricePerPersonMinor int64   `gorm:"not null;default:0" json:"pricePerPersonMinor"`
+	PricePerPerson      float64 `gorm:"-" json:"pricePerPerson"`
+	Currency            string  `gorm:"type:varchar(3)" json:"currency"`
+	AverageRating       float64 `gorm:"type:decimal(3,2);default:0.00" json:"averageRating"`
+	ReviewCount         int     `gorm:"default:0" json:"reviewCount"`
+	// FavoriteCount is how many users saved the tour, for the popular sort
+	FavoriteCount int `gorm:"not null;default:0" json:"favoriteCount"`
 	// GroupSize      int       `json:"groupSize"`
 	// Availability   bool       `json:"availability"`
 	IsFeatured bool `json:"isFeatured"`
+	// CancellationPolicy decides refunds when bookings are cancelled; tours
+	// without one are non-refundable
+	CancellationPolicyID *uuid.UUID          `gorm:"type:text;index" json:"cancellationPolicyId"`
+	CancellationPolicy   *CancellationPolicy `gorm:"foreignKey:CancellationPolicyID;constraint:-" json:"cancellationPolicy,omitempty"`
+	// Vendor is the user (role vendor) who runs the tour and may export its
+	// departure manifests
+	VendorID *uuid.UUID `gorm:"type:text;index" json:"vendorId"`
+	// ParticipantFields lists the details (see models.ParticipantFields)
+	// customers must give for each traveller besides the name
+	ParticipantFields StringList `gorm:"type:text" json:"participantFields"`
+
+	// Where travellers gather and the day-by-day plan, printed on vouchers
+	MeetingPoint string      `gorm:"type:varchar(500)" json:"meetingPoint"`
+	Itinerary    Itineraries `gorm:"type:text" json:"itinerary"`
+
 	// Inclusions     []string  `gorm:"type:text[]" json:"inclusions"`
 	// Exclusions     []string  `gorm:"type:text[]" json:"exclusions"`
 	CoverImage MediaTour `gorm:"foreignKey:TourID" json:"coverImage"`
 	// Gallery        []string  `gorm:"type:text[]" json:"gallery"`
-	// Itinerary      []string  `gorm:"type:text[]" json:"itinerary"`
 	// Tags           []string  `gorm:"type:text[]" json:"tags"`
 	// Reviews        []string  `gorm:"type:text[]" json:"reviews"`
 	CreatedBy   uuid.UUID   `json:"createdBy"`
+	UpdatedBy   *uuid.UUID  `gorm:"type:text" json:"updatedBy"`
+	Version     int         `gorm:"not null;default:1" json:"version"`
 	Destination Destination `gorm:"foreignKey:DestinationID" json:"destination"`
 	User        User        `gorm:"foreignKey:CreatedBy" json:"user"`
 	CreatedAt   time.Time   `json:"createdAt"`
 	UpdatedAt   time.Time   `json:"updatedAt"`
 }
+
+func (m *Tour) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	return
+}
+
+func (m *Tour) AfterFind(tx *gorm.DB) (err error) {
+	m.PricePerPerson = utils.FromMinorUnits(m.PricePerPersonMinor, m.Currency)
+	return
+}
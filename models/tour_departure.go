@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DepartureStatus string
+
+const (
+	DepartureScheduled  DepartureStatus = "scheduled"
+	DepartureGuaranteed DepartureStatus = "guaranteed"
+	DepartureCancelled  DepartureStatus = "cancelled"
+)
+
+// TourDeparture is one run of a tour. SeatsBooked counts the adults and
+// children booked on it and never exceeds Capacity. PriceOverrideMinor,
+// when set, replaces the tour's adult fare for this departure.
+type TourDeparture struct {
+	ID                 uuid.UUID       `gorm:"type:text;primaryKey" json:"id"`
+	TourID             uuid.UUID       `gorm:"type:text;not null;uniqueIndex:idx_tour_departures_start" json:"tourId"`
+	StartDate          time.Time       `gorm:"not null;uniqueIndex:idx_tour_departures_start;index" json:"startDate"`
+	EndDate            time.Time       `gorm:"not null" json:"endDate"`
+	Capacity           int             `gorm:"not null" json:"capacity"`
+	SeatsBooked        int             `gorm:"not null;default:0" json:"seatsBooked"`
+	PriceOverrideMinor *int64          `json:"priceOverrideMinor"`
+	PriceOverride      *float64        `gorm:"-" json:"priceOverride"`
+	Status             DepartureStatus `gorm:"type:varchar(20);not null;default:scheduled;index" json:"status"`
+	CreatedAt          time.Time       `json:"createdAt"`
+	UpdatedAt          time.Time       `json:"updatedAt"`
+
+	Tour *Tour `gorm:"foreignKey:TourID;constraint:OnDelete:CASCADE" json:"tour,omitempty"`
+}
+
+func (d *TourDeparture) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	if d.Status == "" {
+		d.Status = DepartureScheduled
+	}
+	return
+}
+
+// SeatsLeft is how many more adults and children can book the departure.
+func (d *TourDeparture) SeatsLeft() int {
+	return max(0, d.Capacity-d.SeatsBooked)
+}
+
+// SetPriceOverride fills in PriceOverride from PriceOverrideMinor in the
+// tour's currency, which the departure doesn't store itself.
+func (d *TourDeparture) SetPriceOverride(currency string) {
+	d.PriceOverride = nil
+	if d.PriceOverrideMinor != nil {
+		price := utils.FromMinorUnits(*d.PriceOverrideMinor, currency)
+		d.PriceOverride = &price
+	}
+}
@@ -1,8 +1,42 @@
 package models
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
 type Itinerary struct {
 	Day         int    `json:"day"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Image       string `json:"image"`
 }
+
+// Itineraries is a tour's day-by-day plan, stored as a JSON array in a text
+// column like StringList.
+type Itineraries []Itinerary
+
+// Value implements driver.Valuer.
+func (l Itineraries) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]Itinerary(l))
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (l *Itineraries) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	default:
+		return fmt.Errorf("cannot scan %T into Itineraries", src)
+	}
+}
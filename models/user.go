@@ -6,8 +6,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// ProfileImage is the user's avatar: a square crop of the uploaded picture
+// and a small thumbnail of it. PublicID names the uploaded picture at
+// Cloudinary, so it can be deleted with the avatar.
 type ProfileImage struct {
-	URL string `json:"url"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	PublicID     string `gorm:"type:varchar(255)" json:"-"`
 }
 
 type SocialLinks struct {
@@ -33,6 +38,18 @@ type User struct {
 	IsVerified      bool         `json:"isVerified"`
 	EmailVerifiedAt time.Time    `json:"emailVerifiedAt"`
 	SocialLinks     SocialLinks  `gorm:"embedded" json:"socialLinks"`
+	UpdatedBy       *uuid.UUID   `gorm:"type:text" json:"updatedBy"`
 	CreatedAt       time.Time    `json:"createdAt"`
 	UpdatedAt       time.Time    `json:"updatedAt"`
+
+	// UsernameChangedAt is when the user last picked a new username
+	UsernameChangedAt *time.Time `json:"usernameChangedAt,omitempty"`
+
+	// A user who deletes their account keeps it until DeletionScheduledAt
+	// and may change their mind until then. Afterwards their personal data
+	// is purged and AnonymizedAt set; the row stays behind, anonymous, for
+	// their reviews and financial records.
+	DeletionRequestedAt *time.Time `json:"deletionRequestedAt,omitempty"`
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletionScheduledAt,omitempty"`
+	AnonymizedAt        *time.Time `json:"anonymizedAt,omitempty"`
 }
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistTarget string
+
+const (
+	WaitlistDeparture WaitlistTarget = "departure"
+	WaitlistEvent     WaitlistTarget = "event"
+)
+
+type WaitlistStatus string
+
+const (
+	// WaitlistWaiting entries wait in line for seats
+	WaitlistWaiting WaitlistStatus = "waiting"
+	// WaitlistOffered entries hold seats until OfferExpiresAt
+	WaitlistOffered WaitlistStatus = "offered"
+	// WaitlistClaimed entries turned their offer into a booking
+	WaitlistClaimed WaitlistStatus = "claimed"
+	// WaitlistExpired entries let their offer lapse
+	WaitlistExpired WaitlistStatus = "expired"
+	// WaitlistLeft entries were withdrawn by the customer
+	WaitlistLeft WaitlistStatus = "left"
+)
+
+// WaitlistEntry is a customer's place in line for a sold-out departure or
+// event. The line is first come, first served; when seats free up the
+// first entries that fit are offered them, and the seats are held for the
+// customer until the offer expires. A user has at most one open (waiting
+// or offered) entry per departure or event.
+type WaitlistEntry struct {
+	ID             uuid.UUID      `gorm:"type:text;primaryKey" json:"id"`
+	TargetType     WaitlistTarget `gorm:"type:varchar(20);not null;index:idx_waitlist_entries_target;uniqueIndex:idx_waitlist_entries_open,where:status = 'waiting' OR status = 'offered'" json:"targetType"`
+	TargetID       uuid.UUID      `gorm:"type:text;not null;index:idx_waitlist_entries_target;uniqueIndex:idx_waitlist_entries_open,where:status = 'waiting' OR status = 'offered'" json:"targetId"`
+	UserID         uuid.UUID      `gorm:"type:text;not null;index;uniqueIndex:idx_waitlist_entries_open,where:status = 'waiting' OR status = 'offered'" json:"userId"`
+	Adults         int            `gorm:"not null;default:1" json:"adults"`
+	Children       int            `gorm:"not null;default:0" json:"children"`
+	Infants        int            `gorm:"not null;default:0" json:"infants"`
+	Status         WaitlistStatus `gorm:"type:varchar(20);not null;default:waiting;index" json:"status"`
+	Position       int            `gorm:"-" json:"position,omitempty"`
+	OfferedAt      *time.Time     `json:"offeredAt"`
+	OfferExpiresAt *time.Time     `gorm:"index" json:"offerExpiresAt"`
+	BookingID      *uuid.UUID     `gorm:"type:text" json:"bookingId"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:-" json:"user,omitempty"`
+}
+
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	if w.Status == "" {
+		w.Status = WaitlistWaiting
+	}
+	return
+}
+
+// Party is who the entry waits for.
+func (w *WaitlistEntry) Party() Party {
+	return Party{Adults: w.Adults, Children: w.Children, Infants: w.Infants}
+}
+
+// Seats is how many seats the entry needs: infants ride on a lap on tours,
+// but every event guest needs a ticket.
+func (w *WaitlistEntry) Seats() int {
+	if w.TargetType == WaitlistEvent {
+		return w.Party().Travelers()
+	}
+	return w.Party().Seats()
+}
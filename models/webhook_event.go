@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookStatus string
+
+const (
+	WebhookPending   WebhookStatus = "pending"
+	WebhookProcessed WebhookStatus = "processed"
+	WebhookIgnored   WebhookStatus = "ignored"
+	WebhookFailed    WebhookStatus = "failed"
+)
+
+// WebhookEvent is a verified provider notification kept in the inbox. The
+// provider's event ID is unique, so a redelivered event is recognised and
+// not applied twice. Events that fail to process are retried later with
+// backoff, and admins can replay any event.
+type WebhookEvent struct {
+	ID            uuid.UUID     `gorm:"type:text;primaryKey" json:"id"`
+	Provider      string        `gorm:"type:varchar(20);not null;uniqueIndex:idx_webhook_events_event" json:"provider"`
+	EventID       string        `gorm:"type:varchar(255);not null;uniqueIndex:idx_webhook_events_event" json:"eventId"`
+	Type          string        `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload       string        `gorm:"type:text;not null" json:"payload"`
+	Status        WebhookStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	Attempts      int           `gorm:"not null;default:0" json:"attempts"`
+	LastError     string        `gorm:"type:text" json:"lastError,omitempty"`
+	NextAttemptAt *time.Time    `gorm:"index" json:"nextAttemptAt"`
+	ProcessedAt   *time.Time    `json:"processedAt"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+}
+
+func (w *WebhookEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return
+}
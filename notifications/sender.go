@@ -0,0 +1,73 @@
+// Package notifications delivers messages to customers outside the app.
+// Each channel is wrapped in a Sender so callers don't care how a message
+// travels.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Message is an email-style message to one recipient.
+type Message struct {
+	To          string
+	Name        string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent along with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender delivers messages.
+type Sender interface {
+	// Name identifies the channel in logs.
+	Name() string
+	// Send delivers one message; errors are retried by the caller.
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTPConfig is how to reach an SMTP relay.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// New returns the sender with the given name.
+func New(name string, smtp SMTPConfig) (Sender, error) {
+	switch name {
+	case "", "log":
+		return LogSender{}, nil
+	case "smtp":
+		if smtp.Host == "" || smtp.From == "" {
+			return nil, errors.New("smtp sender needs a host and a from address")
+		}
+		return NewSMTP(smtp), nil
+	default:
+		return nil, fmt.Errorf("unknown notification channel %q", name)
+	}
+}
+
+// LogSender writes messages to the log instead of sending them, for
+// development.
+type LogSender struct{}
+
+func (LogSender) Name() string { return "log" }
+
+func (LogSender) Send(ctx context.Context, message Message) error {
+	log.Printf("notification to %s: %s\n%s", message.To, message.Subject, message.Body)
+	for _, attachment := range message.Attachments {
+		log.Printf("notification to %s: attached %s (%s, %d bytes)", message.To, attachment.Filename, attachment.ContentType, len(attachment.Data))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// SMTP sends messages through an SMTP relay, with STARTTLS when the relay
+// offers it.
+type SMTP struct {
+	config SMTPConfig
+}
+
+func NewSMTP(config SMTPConfig) *SMTP {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &SMTP{config: config}
+}
+
+func (s *SMTP) Name() string { return "smtp" }
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.config.From, []string{message.To}, s.render(message))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// render builds the RFC 5322 message.
+func (s *SMTP) render(message Message) []byte {
+	to := mail.Address{Name: message.Name, Address: message.To}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", s.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	if len(message.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		buf.WriteString(message.Body)
+		return buf.Bytes()
+	}
+
+	// The body and each attachment are parts of a multipart/mixed message;
+	// attachments are base64-encoded in lines of 76 characters
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", parts.Boundary())
+	part, _ := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	io.WriteString(part, message.Body)
+	for _, attachment := range message.Attachments {
+		part, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachment.ContentType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(part, encoded+"\r\n")
+	}
+	parts.Close()
+	return buf.Bytes()
+}
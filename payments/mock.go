@@ -0,0 +1,157 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test payment methods understood by the mock provider. Any other method
+// succeeds like MockCardOK.
+const (
+	MockCardOK       = "pm_card_visa"
+	MockCardDeclined = "pm_card_chargeDeclined"
+	MockCardNoFunds  = "pm_card_chargeDeclinedInsufficientFunds"
+)
+
+// Mock is an in-memory provider for local development and tests. It keeps
+// the same idempotency and state rules as Stripe, so the booking flow can be
+// exercised without network access.
+type Mock struct {
+	webhookSecret string
+
+	mu       sync.Mutex
+	intents  map[string]*Intent
+	refunded map[string]int64
+	// results of earlier calls by idempotency key
+	intentKeys map[string]*Intent
+	refundKeys map[string]*Refund
+}
+
+// NewMock returns an empty mock provider.
+func NewMock(webhookSecret string) *Mock {
+	return &Mock{
+		webhookSecret: webhookSecret,
+		intents:       map[string]*Intent{},
+		refunded:      map[string]int64{},
+		intentKeys:    map[string]*Intent{},
+		refundKeys:    map[string]*Refund{},
+	}
+}
+
+func (m *Mock) Name() string { return "mock" }
+
+func (m *Mock) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("mock: amount must be positive")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if params.IdempotencyKey != "" {
+		if intent, ok := m.intentKeys[params.IdempotencyKey]; ok {
+			out := *intent
+			return &out, nil
+		}
+	}
+
+	id := "pi_mock_" + compactID()
+	intent := &Intent{
+		ID:           id,
+		ClientSecret: id + "_secret_" + compactID(),
+		Status:       IntentRequiresConfirmation,
+		Amount:       params.Amount,
+		Currency:     params.Currency,
+	}
+	m.intents[id] = intent
+	if params.IdempotencyKey != "" {
+		m.intentKeys[params.IdempotencyKey] = intent
+	}
+	out := *intent
+	return &out, nil
+}
+
+func (m *Mock) ConfirmIntent(ctx context.Context, intentID string, params ConfirmParams) (*Intent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intent, ok := m.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	// Confirming again is harmless, as it is with Stripe
+	if intent.Status == IntentSucceeded {
+		out := *intent
+		return &out, nil
+	}
+	if intent.Status == IntentCanceled {
+		return nil, fmt.Errorf("mock: intent %s is canceled", intentID)
+	}
+
+	switch params.PaymentMethod {
+	case MockCardDeclined:
+		intent.Status = IntentRequiresPaymentMethod
+		intent.FailureReason = "Your card was declined."
+		return nil, &DeclineError{Code: "card_declined", Message: intent.FailureReason}
+	case MockCardNoFunds:
+		intent.Status = IntentRequiresPaymentMethod
+		intent.FailureReason = "Your card has insufficient funds."
+		return nil, &DeclineError{Code: "insufficient_funds", Message: intent.FailureReason}
+	}
+
+	intent.Status = IntentSucceeded
+	intent.FailureReason = ""
+	out := *intent
+	return &out, nil
+}
+
+func (m *Mock) Refund(ctx context.Context, params RefundParams) (*Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if params.IdempotencyKey != "" {
+		if refund, ok := m.refundKeys[params.IdempotencyKey]; ok {
+			out := *refund
+			return &out, nil
+		}
+	}
+
+	intent, ok := m.intents[params.IntentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if intent.Status != IntentSucceeded {
+		return nil, fmt.Errorf("mock: intent %s has not succeeded", params.IntentID)
+	}
+	amount := params.Amount
+	if amount == 0 {
+		amount = intent.Amount - m.refunded[intent.ID]
+	}
+	if amount <= 0 || m.refunded[intent.ID]+amount > intent.Amount {
+		return nil, fmt.Errorf("mock: refund of %d exceeds the remaining amount", amount)
+	}
+
+	m.refunded[intent.ID] += amount
+	refund := &Refund{ID: "re_mock_" + compactID(), Status: "succeeded", Amount: amount}
+	if params.IdempotencyKey != "" {
+		m.refundKeys[params.IdempotencyKey] = refund
+	}
+	out := *refund
+	return &out, nil
+}
+
+func (m *Mock) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	if err := verifySignature(payload, signature, m.webhookSecret, time.Now()); err != nil {
+		return nil, err
+	}
+	return DecodeEvent(payload)
+}
+
+func compactID() string {
+	id := uuid.New()
+	return fmt.Sprintf("%x", id[:12])
+}
@@ -0,0 +1,126 @@
+// Package payments talks to payment gateways. Each gateway is wrapped in a
+// Provider so the booking flow does not care which one takes the money.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// IntentStatus is the state of a payment intent at the provider. The values
+// follow Stripe's naming, which the other providers map onto.
+type IntentStatus string
+
+const (
+	IntentRequiresPaymentMethod IntentStatus = "requires_payment_method"
+	IntentRequiresConfirmation  IntentStatus = "requires_confirmation"
+	IntentRequiresAction        IntentStatus = "requires_action"
+	IntentProcessing            IntentStatus = "processing"
+	IntentSucceeded             IntentStatus = "succeeded"
+	IntentCanceled              IntentStatus = "canceled"
+)
+
+// IntentParams describes the charge to prepare. Amount is in the currency's
+// minor units (cents for USD, whole francs for RWF).
+type IntentParams struct {
+	Amount         int64
+	Currency       string
+	Description    string
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// Intent is a provider's record of a charge. ClientSecret lets the customer's
+// browser finish the payment directly with the provider.
+type Intent struct {
+	ID            string
+	ClientSecret  string
+	Status        IntentStatus
+	Amount        int64
+	Currency      string
+	FailureReason string
+}
+
+// ConfirmParams confirms an intent with the payment method collected from
+// the customer.
+type ConfirmParams struct {
+	PaymentMethod  string
+	IdempotencyKey string
+}
+
+// RefundParams returns part or all of a succeeded intent to the customer.
+type RefundParams struct {
+	IntentID       string
+	Amount         int64
+	Reason         string
+	IdempotencyKey string
+}
+
+// Refund is a provider's record of money returned to the customer.
+type Refund struct {
+	ID     string
+	Status string
+	Amount int64
+}
+
+// Event is a verified webhook notification.
+type Event struct {
+	ID       string
+	Type     string
+	IntentID string
+	Status   IntentStatus
+	Amount   int64
+	Refunded int64
+	Payload  []byte
+
+	FailureReason string
+}
+
+// Provider is a payment gateway.
+type Provider interface {
+	// Name identifies the provider on stored payments.
+	Name() string
+	// CreateIntent prepares a charge. Calls with the same idempotency key
+	// return the same intent instead of creating a second one.
+	CreateIntent(ctx context.Context, params IntentParams) (*Intent, error)
+	// ConfirmIntent attempts the charge with the given payment method.
+	ConfirmIntent(ctx context.Context, intentID string, params ConfirmParams) (*Intent, error)
+	// Refund returns money from a succeeded intent.
+	Refund(ctx context.Context, params RefundParams) (*Refund, error)
+	// VerifyWebhook checks a webhook's signature and decodes it.
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+var (
+	// ErrInvalidSignature is returned for webhooks that fail verification.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrIntentNotFound is returned for intents the provider doesn't know.
+	ErrIntentNotFound = errors.New("payment intent not found")
+)
+
+// DeclineError reports that the provider refused the charge, e.g. because
+// the card was declined. The message is safe to show to the customer.
+type DeclineError struct {
+	Code    string
+	Message string
+}
+
+func (e *DeclineError) Error() string {
+	return fmt.Sprintf("payment declined (%s): %s", e.Code, e.Message)
+}
+
+// New returns the provider with the given name.
+func New(name, secretKey, webhookSecret string) (Provider, error) {
+	switch name {
+	case "", "mock":
+		return NewMock(webhookSecret), nil
+	case "stripe":
+		if secretKey == "" {
+			return nil, errors.New("stripe provider needs a secret key")
+		}
+		return NewStripe(secretKey, webhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
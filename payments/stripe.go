@@ -0,0 +1,188 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPI = "https://api.stripe.com/v1"
+
+// Stripe talks to the Stripe API (or any server that speaks it) over plain
+// HTTP, so no SDK is needed.
+type Stripe struct {
+	secretKey     string
+	webhookSecret string
+	baseURL       string
+	client        *http.Client
+}
+
+// NewStripe returns a provider using the given API secret key and webhook
+// signing secret.
+func NewStripe(secretKey, webhookSecret string) *Stripe {
+	return &Stripe{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		baseURL:       stripeAPI,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithBaseURL points the provider at a Stripe-compatible server, such as
+// stripe-mock.
+func (s *Stripe) WithBaseURL(baseURL string) *Stripe {
+	s.baseURL = strings.TrimRight(baseURL, "/")
+	return s
+}
+
+func (s *Stripe) Name() string { return "stripe" }
+
+// stripeIntent is the part of a PaymentIntent object we read.
+type stripeIntent struct {
+	ID               string       `json:"id"`
+	ClientSecret     string       `json:"client_secret"`
+	Status           IntentStatus `json:"status"`
+	Amount           int64        `json:"amount"`
+	Currency         string       `json:"currency"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+func (i stripeIntent) intent() *Intent {
+	intent := &Intent{
+		ID:           i.ID,
+		ClientSecret: i.ClientSecret,
+		Status:       i.Status,
+		Amount:       i.Amount,
+		Currency:     strings.ToUpper(i.Currency),
+	}
+	if i.LastPaymentError != nil {
+		intent.FailureReason = i.LastPaymentError.Message
+	}
+	return intent
+}
+
+// stripeError is the error body Stripe returns with 4xx and 5xx responses.
+type stripeError struct {
+	Error struct {
+		Type        string `json:"type"`
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"error"`
+}
+
+func (s *Stripe) CreateIntent(ctx context.Context, params IntentParams) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent stripeIntent
+	if err := s.post(ctx, "/payment_intents", form, params.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	return intent.intent(), nil
+}
+
+func (s *Stripe) ConfirmIntent(ctx context.Context, intentID string, params ConfirmParams) (*Intent, error) {
+	form := url.Values{}
+	if params.PaymentMethod != "" {
+		form.Set("payment_method", params.PaymentMethod)
+	}
+
+	var intent stripeIntent
+	path := "/payment_intents/" + url.PathEscape(intentID) + "/confirm"
+	if err := s.post(ctx, path, form, params.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	return intent.intent(), nil
+}
+
+func (s *Stripe) Refund(ctx context.Context, params RefundParams) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", params.IntentID)
+	if params.Amount > 0 {
+		form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	}
+	if params.Reason != "" {
+		form.Set("reason", params.Reason)
+	}
+
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Amount int64  `json:"amount"`
+	}
+	if err := s.post(ctx, "/refunds", form, params.IdempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	return &Refund{ID: refund.ID, Status: refund.Status, Amount: refund.Amount}, nil
+}
+
+func (s *Stripe) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	if err := verifySignature(payload, signature, s.webhookSecret, time.Now()); err != nil {
+		return nil, err
+	}
+	return DecodeEvent(payload)
+}
+
+// post sends a form-encoded request and decodes the JSON response into out.
+// Card errors become a DeclineError; other failures are returned as is.
+func (s *Stripe) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("stripe: read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr stripeError
+		if err := json.Unmarshal(body, &apiErr); err != nil {
+			return fmt.Errorf("stripe: %s", resp.Status)
+		}
+		if apiErr.Error.Type == "card_error" {
+			code := apiErr.Error.DeclineCode
+			if code == "" {
+				code = apiErr.Error.Code
+			}
+			return &DeclineError{Code: code, Message: apiErr.Error.Message}
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrIntentNotFound
+		}
+		return fmt.Errorf("stripe: %s: %s", resp.Status, apiErr.Error.Message)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("stripe: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package payments
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name   string
+		header string
+		secret string
+		valid  bool
+	}{
+		{
+			name:   "valid signature",
+			header: Sign(payload, "whsec_new", now),
+			secret: "whsec_new",
+			valid:  true,
+		},
+		{
+			name:   "just inside the tolerance",
+			header: Sign(payload, "whsec_new", now.Add(-SignatureTolerance)),
+			secret: "whsec_new",
+			valid:  true,
+		},
+		{
+			name:   "too old",
+			header: Sign(payload, "whsec_new", now.Add(-SignatureTolerance-time.Second)),
+			secret: "whsec_new",
+		},
+		{
+			name:   "too far in the future",
+			header: Sign(payload, "whsec_new", now.Add(SignatureTolerance+time.Second)),
+			secret: "whsec_new",
+		},
+		{
+			name:   "wrong secret",
+			header: Sign(payload, "whsec_old", now),
+			secret: "whsec_new",
+		},
+		{
+			name:   "rotation: old signature first, new one matches",
+			header: "t=" + timestamp + ",v1=" + signature(payload, "whsec_old", timestamp) + ",v1=" + signature(payload, "whsec_new", timestamp),
+			secret: "whsec_new",
+			valid:  true,
+		},
+		{
+			name:   "rotation: new signature first, old secret still configured",
+			header: "t=" + timestamp + ",v1=" + signature(payload, "whsec_new", timestamp) + ",v1=" + signature(payload, "whsec_old", timestamp),
+			secret: "whsec_old",
+			valid:  true,
+		},
+		{
+			name:   "signature for another timestamp",
+			header: "t=" + timestamp + ",v1=" + signature(payload, "whsec_new", strconv.FormatInt(now.Unix()-1, 10)),
+			secret: "whsec_new",
+		},
+		{
+			name:   "unknown scheme only",
+			header: "t=" + timestamp + ",v0=" + signature(payload, "whsec_new", timestamp),
+			secret: "whsec_new",
+		},
+		{
+			name:   "missing timestamp",
+			header: "v1=" + signature(payload, "whsec_new", timestamp),
+			secret: "whsec_new",
+		},
+		{
+			name:   "malformed timestamp",
+			header: "t=yesterday,v1=" + signature(payload, "whsec_new", "yesterday"),
+			secret: "whsec_new",
+		},
+		{
+			name:   "empty header",
+			secret: "whsec_new",
+		},
+		{
+			name:   "no secret configured",
+			header: Sign(payload, "", now),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(payload, tt.header, tt.secret, now)
+			if tt.valid && err != nil {
+				t.Fatalf("verifySignature: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("verifySignature = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureRejectsChangedPayload(t *testing.T) {
+	now := time.Now()
+	header := Sign([]byte(`{"amount":100}`), "whsec", now)
+	if err := verifySignature([]byte(`{"amount":1}`), header, "whsec", now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("verifySignature = %v, want ErrInvalidSignature", err)
+	}
+}
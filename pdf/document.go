@@ -0,0 +1,178 @@
+// Package pdf writes simple PDF documents: text in the standard Helvetica
+// fonts, lines and filled rectangles, which is all manifests, vouchers and
+// invoices need. It has no dependencies beyond the standard library.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Page sizes in points.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font is one of the standard fonts every PDF reader has.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+func (f Font) resource() string {
+	if f == HelveticaBold {
+		return "F2"
+	}
+	return "F1"
+}
+
+// Document is a PDF being drawn. Coordinates are in points from the
+// top-left corner of the page; text is placed by its baseline.
+type Document struct {
+	width, height float64
+	title         string
+	pages         []*bytes.Buffer
+}
+
+// New starts a document whose pages are width by height points. Swap the
+// A4 sizes for landscape.
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// Width returns the page width.
+func (d *Document) Width() float64 { return d.width }
+
+// Height returns the page height.
+func (d *Document) Height() float64 { return d.height }
+
+// SetTitle sets the title readers show for the document.
+func (d *Document) SetTitle(title string) { d.title = title }
+
+// AddPage starts a new page; drawing goes to it from then on.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws text in black with its baseline at y.
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(d.page(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font.resource(), num(size), num(x), num(d.height-y), escape(encode(text)))
+}
+
+// TextRight draws text in black ending at x.
+func (d *Document) TextRight(x, y float64, font Font, size float64, text string) {
+	d.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a black line width points thick.
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+// FillRect fills a rectangle in a shade of grey, from 0 (black) to 1
+// (white).
+func (d *Document) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.page(), "%s g %s %s %s %s re f 0 g\n",
+		num(gray), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// StrokeRect outlines a rectangle in black.
+func (d *Document) StrokeRect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.page(), "%s w %s %s %s %s re S\n",
+		num(width), num(x), num(d.height-y-h), num(w), num(h))
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// WriteTo renders the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	d.page()
+	out := &writer{}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed; each page then takes a page and a content
+	// object
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	out.object("<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	out.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	out.object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	out.object(fmt.Sprintf("<< /Producer (tours-backend) /Title (%s) >>", escape(encode(d.title))))
+	for i, page := range d.pages {
+		out.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(d.width), num(d.height), 7+2*i))
+
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		zw.Write(page.Bytes())
+		zw.Close()
+		out.object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.buf.Len()
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+	out.printf("trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, xref)
+	return out.buf.WriteTo(w)
+}
+
+// writer numbers objects and remembers where each starts for the xref
+// table.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&w.buf, format, args...)
+}
+
+func (w *writer) object(body string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	w.printf("%d 0 obj\n%s\nendobj\n", len(w.offsets), body)
+}
+
+// num formats a coordinate without needless digits.
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escape makes text safe inside a PDF string literal.
+func escape(text string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", "", "\n", " ")
+	return r.Replace(text)
+}
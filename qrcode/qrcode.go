@@ -0,0 +1,206 @@
+// Package qrcode encodes short texts as QR codes (ISO/IEC 18004) in byte
+// mode at error correction level M. Versions 1 to 10 are supported, which
+// holds up to 213 bytes - plenty for a booking reference or link.
+package qrcode
+
+import (
+	"errors"
+)
+
+// MaxLength is the longest text Encode accepts.
+const MaxLength = 213
+
+// Code is an encoded QR code: Size by Size modules, without the quiet zone
+// of four light modules readers expect around it.
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module in row y, column x is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Level M error correction, indexed by version: codewords per block and
+// number of blocks.
+var (
+	eccPerBlock = [11]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	eccBlocks   = [11]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+	// Centres of the alignment patterns along each axis
+	alignment = [11][]int{
+		nil, nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+		{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+	}
+)
+
+// Encode returns the smallest QR code holding text.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= 10; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*dataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("qrcode: text is too long")
+	}
+
+	codewords := addECC(version, encodeData(version, data))
+	q := newMatrix(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return &Code{Size: q.size, modules: q.modules}, nil
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// rawCodewords is how many codewords fit in a symbol of the version.
+func rawCodewords(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		aligns := version/7 + 2
+		modules -= (25*aligns-10)*aligns - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules / 8
+}
+
+func dataCodewords(version int) int {
+	return rawCodewords(version) - eccPerBlock[version]*eccBlocks[version]
+}
+
+// encodeData lays out the byte-mode segment, terminator and padding.
+func encodeData(version int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * dataCodewords(version)
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// addECC splits data into blocks, computes each block's Reed-Solomon
+// codewords and interleaves the lot.
+func addECC(version int, data []byte) []byte {
+	numBlocks := eccBlocks[version]
+	eccLen := eccPerBlock[version]
+	raw := rawCodewords(version)
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		size := shortLen - eccLen
+		if i >= numShort {
+			size++
+		}
+		block := append([]byte{}, data[k:k+size]...)
+		k += size
+		ecc := rsRemainder(block, divisor)
+		if i < numShort {
+			// Pad short blocks so every block lines up when interleaving
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i < len(blocks[0]); i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the generator polynomial of the given degree, highest
+// coefficient first and the leading 1 left out.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
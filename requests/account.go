@@ -0,0 +1,13 @@
+package requests
+
+// DeleteAccountRequest confirms the deletion of the logged-in user's
+// account. Accounts created through Google have no password to give.
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"max=72"`
+}
+
+// AdminDeleteAccountRequest deletes a user's account, after the usual grace
+// period or right away.
+type AdminDeleteAccountRequest struct {
+	Immediate bool `json:"immediate"`
+}
@@ -0,0 +1,58 @@
+package requests
+
+import "time"
+
+// CreateBookingRequest books a departure of a tour, or tickets for an
+// event, for the logged-in user's party; exactly one of tourId and eventId
+// is given. A tour's departure is given by ID or by travel date; without
+// either the next one is booked. Older clients send only travelers, who are
+// then all booked as adults.
+type CreateBookingRequest struct {
+	TourID      string     `json:"tourId" validate:"required_without=EventID,uuid"`
+	EventID     string     `json:"eventId" validate:"required_without=TourID,uuid"`
+	DepartureID string     `json:"departureId" validate:"uuid"`
+	Travelers   int        `json:"travelers" validate:"min=1,max=50"`
+	Adults      int        `json:"adults" validate:"required_without=Travelers,min=1,max=50"`
+	Children    int        `json:"children" validate:"min=0,max=50"`
+	Infants     int        `json:"infants" validate:"min=0,max=50"`
+	TravelDate  *time.Time `json:"travelDate"`
+	PromoCode   string     `json:"promoCode" validate:"max=50"`
+}
+
+// QuoteRequest describes the party and departure to price a tour for, with
+// an optional promotion code. The departure is given by ID or by its date
+// (YYYY-MM-DD) and defaults to the tour's next departure.
+type QuoteRequest struct {
+	DepartureID string `json:"departureId" query:"departureId" validate:"uuid"`
+	Adults      int    `json:"adults" query:"adults" validate:"required,min=1,max=50"`
+	Children    int    `json:"children" query:"children" validate:"min=0,max=50"`
+	Infants     int    `json:"infants" query:"infants" validate:"min=0,max=50"`
+	Date        string `json:"date" query:"date"`
+	PromoCode   string `json:"promoCode" query:"promoCode" validate:"max=50"`
+}
+
+// ValidatePromotionRequest checks a promotion code against the tour and
+// party it would be used for.
+type ValidatePromotionRequest struct {
+	PromoCode   string     `json:"promoCode" validate:"required,max=50"`
+	TourID      string     `json:"tourId" validate:"required,uuid"`
+	DepartureID string     `json:"departureId" validate:"uuid"`
+	Adults      int        `json:"adults" validate:"required,min=1,max=50"`
+	Children    int        `json:"children" validate:"min=0,max=50"`
+	Infants     int        `json:"infants" validate:"min=0,max=50"`
+	Date        *time.Time `json:"date"`
+}
+
+// ConfirmPaymentRequest completes a payment with the payment method the
+// customer entered at the provider (for the mock provider, e.g.
+// "pm_card_visa" or "pm_card_chargeDeclined").
+type ConfirmPaymentRequest struct {
+	PaymentMethod string `json:"paymentMethod" validate:"required,max=255"`
+}
+
+// RefundPaymentRequest refunds part or all of a payment. Amount is in the
+// currency's minor units; leave it out to refund everything left.
+type RefundPaymentRequest struct {
+	Amount int64  `json:"amount" validate:"min=1"`
+	Reason string `json:"reason" validate:"max=255"`
+}
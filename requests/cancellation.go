@@ -0,0 +1,25 @@
+package requests
+
+import "github.com/Twisac-Solutions/tours-backend/models"
+
+// CancellationPolicyRequest creates or replaces a cancellation policy. Each
+// tier refunds refundPercent when cancelling at least daysBefore days before
+// departure; cancelling later than every tier is not refunded, and a policy
+// without tiers is non-refundable.
+type CancellationPolicyRequest struct {
+	Name        string                    `json:"name" validate:"required,max=100"`
+	Description string                    `json:"description" validate:"max=5000"`
+	Tiers       []models.CancellationTier `json:"tiers" validate:"max=10"`
+}
+
+// CancelBookingRequest cancels one of the customer's bookings.
+type CancelBookingRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// AdminCancelBookingRequest cancels a booking for a customer. RefundPercent
+// replaces the share the tour's cancellation policy would refund.
+type AdminCancelBookingRequest struct {
+	Reason        string `json:"reason" validate:"required,max=500"`
+	RefundPercent *int   `json:"refundPercent" validate:"min=0,max=100"`
+}
@@ -0,0 +1,33 @@
+package requests
+
+import "github.com/Twisac-Solutions/tours-backend/models"
+
+type CreateCategoryRequest struct {
+	Name        string `json:"name" form:"name" validate:"required,max=100"`
+	Description string `json:"description" form:"description" validate:"max=1000"`
+	Icon        string `json:"icon" form:"icon" validate:"required,max=255"`
+}
+
+// UpdateCategoryRequest only changes the fields that are sent.
+type UpdateCategoryRequest struct {
+	Name        string `json:"name" form:"name" validate:"max=100"`
+	Description string `json:"description" form:"description" validate:"max=1000"`
+	Icon        string `json:"icon" form:"icon" validate:"max=255"`
+}
+
+// PatchCategoryRequest is the JSON Merge Patch view of a category.
+type PatchCategoryRequest struct {
+	Name        string `json:"name" column:"name" validate:"required,max=100"`
+	Description string `json:"description" column:"description" validate:"max=1000"`
+	Icon        string `json:"icon" column:"icon" validate:"required,max=255"`
+}
+
+// NewPatchCategoryRequest returns the patch DTO holding the category's
+// current state.
+func NewPatchCategoryRequest(category models.Category) PatchCategoryRequest {
+	return PatchCategoryRequest{
+		Name:        category.Name,
+		Description: category.Description,
+		Icon:        category.Icon,
+	}
+}
@@ -0,0 +1,7 @@
+package requests
+
+// SetExchangeRateRequest sets how many units of a currency one unit of the
+// base currency buys.
+type SetExchangeRateRequest struct {
+	Rate float64 `json:"rate" validate:"required,min=0"`
+}
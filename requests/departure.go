@@ -0,0 +1,28 @@
+package requests
+
+import "time"
+
+// DepartureRequest creates or replaces a departure of a tour. EndDate
+// defaults to the tour's duration after StartDate, Capacity to the tour's
+// default capacity. PriceOverride is in major units of the tour's currency
+// and replaces its adult fare on this departure.
+type DepartureRequest struct {
+	StartDate     time.Time  `json:"startDate" validate:"required"`
+	EndDate       *time.Time `json:"endDate" validate:"gtefield=StartDate"`
+	Capacity      int        `json:"capacity" validate:"min=1,max=1000"`
+	PriceOverride *float64   `json:"priceOverride" validate:"min=0"`
+	Status        string     `json:"status" validate:"oneof=scheduled guaranteed cancelled"`
+}
+
+// GenerateDeparturesRequest creates departures from an RFC 5545 recurrence
+// rule such as "FREQ=WEEKLY;BYDAY=SA,SU". StartDate is the first departure,
+// as DTSTART is in RFC 5545, and the rest follow at its time of day; Until
+// (or the rule's own COUNT or UNTIL) ends the schedule.
+type GenerateDeparturesRequest struct {
+	RRule         string     `json:"rrule" validate:"required,max=500"`
+	StartDate     time.Time  `json:"startDate" validate:"required"`
+	Until         *time.Time `json:"until" validate:"gtefield=StartDate"`
+	Capacity      int        `json:"capacity" validate:"min=1,max=1000"`
+	PriceOverride *float64   `json:"priceOverride" validate:"min=0"`
+	Status        string     `json:"status" validate:"oneof=scheduled guaranteed"`
+}
@@ -1,19 +1,42 @@
 package requests
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
 
 type CreateDestinationRequest struct {
-	Name        string                `form:"name"`
-	Description string                `form:"description"`
-	Region      string                `form:"region"`
-	Country     string                `form:"country"`
+	Name        string                `form:"name" validate:"required,max=255"`
+	Description string                `form:"description" validate:"required,max=5000"`
+	Region      string                `form:"region" validate:"required,max=255"`
+	Country     string                `form:"country" validate:"required,max=255"`
 	CoverImage  *multipart.FileHeader `form:"coverImage"`
 }
 
 type UpdateDestinationRequest struct {
-	Name        string                `form:"name"`
-	Description string                `form:"description"`
-	Region      string                `form:"region"`
-	Country     string                `form:"country"`
+	Name        string                `form:"name" validate:"required,max=255"`
+	Description string                `form:"description" validate:"required,max=5000"`
+	Region      string                `form:"region" validate:"required,max=255"`
+	Country     string                `form:"country" validate:"required,max=255"`
 	CoverImage  *multipart.FileHeader `form:"coverImage"`
 }
+
+// PatchDestinationRequest is the JSON Merge Patch view of a destination.
+type PatchDestinationRequest struct {
+	Name        string `json:"name" column:"name" validate:"required,max=255"`
+	Description string `json:"description" column:"description" validate:"required,max=5000"`
+	Region      string `json:"region" column:"region" validate:"required,max=255"`
+	Country     string `json:"country" column:"country" validate:"required,max=255"`
+}
+
+// NewPatchDestinationRequest returns the patch DTO holding the destination's
+// current state.
+func NewPatchDestinationRequest(destination models.Destination) PatchDestinationRequest {
+	return PatchDestinationRequest{
+		Name:        destination.Name,
+		Description: destination.Description,
+		Region:      destination.Region,
+		Country:     destination.Country,
+	}
+}
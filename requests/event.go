@@ -0,0 +1,104 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+type CreateEventRequest struct {
+	Title         string    `json:"title" form:"title" validate:"required,max=255"`
+	Slug          string    `json:"slug" form:"slug" validate:"required,max=255"`
+	DestinationID string    `json:"destinationId" form:"destinationId" validate:"required,uuid"`
+	CategoryID    string    `json:"categoryId" form:"categoryId" validate:"required,uuid"`
+	ShortDesc     string    `json:"shortDescription" form:"shortDescription" validate:"max=500"`
+	FullDesc      string    `json:"fullDescription" form:"fullDescription" validate:"max=10000"`
+	EventDate     time.Time `json:"eventDate" form:"eventDate" validate:"required"`
+	DurationHours int       `json:"durationHours" form:"durationHours" validate:"min=0,max=720"`
+	TicketPrice   float64   `json:"ticketPrice" form:"ticketPrice" validate:"min=0"`
+	Currency      string    `json:"currency" form:"currency" validate:"required,currency"`
+	Capacity      int       `json:"capacity" form:"capacity" validate:"required,min=1"`
+	Availability  int       `json:"availability" form:"availability" validate:"min=0,ltefield=Capacity"`
+	IsFeatured    bool      `json:"isFeatured" form:"isFeatured"`
+	Inclusions    []string  `json:"inclusions" form:"inclusions" validate:"max=50"`
+	Exclusions    []string  `json:"exclusions" form:"exclusions" validate:"max=50"`
+	Tags          []string  `json:"tags" form:"tags" validate:"max=20"`
+	// CancellationPolicyID decides refunds; without one the event is
+	// non-refundable
+	CancellationPolicyID string `json:"cancellationPolicyId" form:"cancellationPolicyId" validate:"uuid"`
+	// MeetingPoint tells guests where to go; it is printed on tickets
+	MeetingPoint string `json:"meetingPoint" form:"meetingPoint" validate:"max=500"`
+}
+
+// UpdateEventRequest only changes the fields that are sent.
+type UpdateEventRequest struct {
+	Title         string    `json:"title" form:"title" validate:"max=255"`
+	Slug          string    `json:"slug" form:"slug" validate:"max=255"`
+	DestinationID string    `json:"destinationId" form:"destinationId" validate:"uuid"`
+	CategoryID    string    `json:"categoryId" form:"categoryId" validate:"uuid"`
+	ShortDesc     string    `json:"shortDescription" form:"shortDescription" validate:"max=500"`
+	FullDesc      string    `json:"fullDescription" form:"fullDescription" validate:"max=10000"`
+	EventDate     time.Time `json:"eventDate" form:"eventDate"`
+	DurationHours int       `json:"durationHours" form:"durationHours" validate:"min=0,max=720"`
+	TicketPrice   float64   `json:"ticketPrice" form:"ticketPrice" validate:"min=0"`
+	Currency      string    `json:"currency" form:"currency" validate:"currency"`
+	Capacity      int       `json:"capacity" form:"capacity" validate:"min=1"`
+	Availability  int       `json:"availability" form:"availability" validate:"min=0,ltefield=Capacity"`
+	IsFeatured    bool      `json:"isFeatured" form:"isFeatured"`
+	Inclusions    []string  `json:"inclusions" form:"inclusions" validate:"max=50"`
+	Exclusions    []string  `json:"exclusions" form:"exclusions" validate:"max=50"`
+	Tags          []string  `json:"tags" form:"tags" validate:"max=20"`
+	// CancellationPolicyID decides refunds; without one the event is
+	// non-refundable
+	CancellationPolicyID string `json:"cancellationPolicyId" form:"cancellationPolicyId" validate:"uuid"`
+	// MeetingPoint tells guests where to go; it is printed on tickets
+	MeetingPoint string `json:"meetingPoint" form:"meetingPoint" validate:"max=500"`
+}
+
+// PatchEventRequest is the JSON Merge Patch view of an event.
+type PatchEventRequest struct {
+	Title         string            `json:"title" column:"title" validate:"required,max=255"`
+	Slug          string            `json:"slug" column:"slug" validate:"required,max=255"`
+	DestinationID string            `json:"destinationId" column:"destination_id" validate:"required,uuid"`
+	CategoryID    string            `json:"categoryId" column:"category_id" validate:"required,uuid"`
+	ShortDesc     string            `json:"shortDescription" column:"short_desc" validate:"max=500"`
+	FullDesc      string            `json:"fullDescription" column:"full_desc" validate:"max=10000"`
+	EventDate     time.Time         `json:"eventDate" column:"event_date" validate:"required"`
+	DurationHours int               `json:"durationHours" column:"duration_hours" validate:"min=0,max=720"`
+	TicketPrice   float64           `json:"ticketPrice" column:"ticket_price_minor" validate:"min=0"`
+	Currency      string            `json:"currency" column:"currency" validate:"required,currency"`
+	Capacity      int               `json:"capacity" column:"capacity" validate:"required,min=1"`
+	Availability  int               `json:"availability" column:"availability" validate:"min=0,ltefield=Capacity"`
+	IsFeatured    bool              `json:"isFeatured" column:"is_featured"`
+	Inclusions    models.StringList `json:"inclusions" column:"inclusions" validate:"max=50"`
+	Exclusions    models.StringList `json:"exclusions" column:"exclusions" validate:"max=50"`
+	Tags          models.StringList `json:"tags" column:"tags" validate:"max=20"`
+
+	CancellationPolicyID string `json:"cancellationPolicyId" column:"cancellation_policy_id" validate:"uuid"`
+	MeetingPoint         string `json:"meetingPoint" column:"meeting_point" validate:"max=500"`
+}
+
+// NewPatchEventRequest returns the patch DTO holding the event's current state.
+func NewPatchEventRequest(event models.Event) PatchEventRequest {
+	return PatchEventRequest{
+		Title:         event.Title,
+		Slug:          event.Slug,
+		DestinationID: event.DestinationID.String(),
+		CategoryID:    event.CategoryID.String(),
+		ShortDesc:     event.ShortDesc,
+		FullDesc:      event.FullDesc,
+		EventDate:     event.EventDate,
+		DurationHours: event.DurationHours,
+		TicketPrice:   event.TicketPrice,
+		Currency:      event.Currency,
+		Capacity:      event.Capacity,
+		Availability:  event.Availability,
+		IsFeatured:    event.IsFeatured,
+		Inclusions:    event.Inclusions,
+		Exclusions:    event.Exclusions,
+		Tags:          event.Tags,
+
+		CancellationPolicyID: optionalUUIDString(event.CancellationPolicyID),
+		MeetingPoint:         event.MeetingPoint,
+	}
+}
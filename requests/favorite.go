@@ -0,0 +1,8 @@
+package requests
+
+// AddFavoriteRequest saves a tour, event or destination for the logged-in
+// user.
+type AddFavoriteRequest struct {
+	TargetType string `json:"targetType" validate:"required,oneof=tour event destination"`
+	TargetID   string `json:"targetId" validate:"required,uuid"`
+}
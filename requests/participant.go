@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+// ParticipantRequest describes one traveller. Dates are YYYY-MM-DD; which
+// of the other details are required depends on the tour.
+type ParticipantRequest struct {
+	FullName              string `json:"fullName" validate:"required,max=200"`
+	DateOfBirth           string `json:"dateOfBirth" validate:"max=10"`
+	Nationality           string `json:"nationality" validate:"max=100"`
+	PassportNumber        string `json:"passportNumber" validate:"max=50"`
+	PassportExpiry        string `json:"passportExpiry" validate:"max=10"`
+	DietaryRequirements   string `json:"dietaryRequirements" validate:"max=500"`
+	EmergencyContactName  string `json:"emergencyContactName" validate:"max=200"`
+	EmergencyContactPhone string `json:"emergencyContactPhone" validate:"max=50"`
+}
+
+// ParticipantsRequest replaces a booking's travellers, one per member of
+// the party.
+type ParticipantsRequest struct {
+	Participants []ParticipantRequest `json:"participants" validate:"required,max=100"`
+}
+
+// ValidateParticipants validates the request and every participant in it,
+// reporting fields as participants[i].name.
+func ValidateParticipants(req *ParticipantsRequest) error {
+	if err := Validate(req); err != nil {
+		return err
+	}
+	var fields []models.FieldError
+	for i := range req.Participants {
+		err := Validate(&req.Participants[i])
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		for _, field := range appErr.Fields {
+			field.Field = fmt.Sprintf("participants[%d].%s", i, field.Field)
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+)
+
+// MergePatchContentType is the media type of an RFC 7386 JSON Merge Patch.
+const MergePatchContentType = "application/merge-patch+json"
+
+// ApplyMergePatch applies an RFC 7386 JSON Merge Patch document to dst, a
+// pointer to a patch DTO pre-filled with the current state of the resource.
+//
+// Only fields carrying a `column` tag can be patched; their `json` tag names
+// the member. A member set to null resets the field to its zero value, any
+// other value replaces it, and absent members are left alone. The merged DTO
+// is then validated as a whole, so `required` fields can't be nulled and
+// cross-field rules see the final values. Only failures caused by the patch
+// are reported, so legacy data in untouched fields doesn't block an update.
+//
+// The returned map holds the database columns of the members present in the
+// patch and is meant to be passed to gorm's Updates, which unlike a struct
+// update also writes false, 0 and "".
+func ApplyMergePatch(body []byte, dst interface{}) (map[string]interface{}, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, apperrors.BadRequest("Request body must be a JSON merge patch object")
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	columns := make(map[string]interface{}, len(patch))
+	var fields []models.FieldError
+	known := make(map[string]bool, t.NumField())
+	touched := make(map[string]bool, len(patch))
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		column := sf.Tag.Get("column")
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if column == "" || name == "" || name == "-" {
+			continue
+		}
+		known[name] = true
+
+		raw, ok := patch[name]
+		if !ok {
+			continue
+		}
+		touched[name] = true
+		touched[sf.Name] = true
+		field := v.Field(i)
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			field.Set(reflect.Zero(field.Type()))
+		} else if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+			fields = append(fields, models.FieldError{Field: name, Message: "has an invalid value"})
+			continue
+		}
+		columns[column] = field.Interface()
+	}
+
+	var unknown []string
+	for name := range patch {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		fields = append(fields, models.FieldError{Field: name, Message: "cannot be patched"})
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Validation failed", fields...)
+	}
+
+	for _, e := range validator.Validate(dst).Errors {
+		if touched[e.Field] || refersTo(t, e.Field, touched) {
+			fields = append(fields, models.FieldError{Field: e.Field, Message: e.Message})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Validation failed", fields...)
+	}
+	return columns, nil
+}
+
+// refersTo reports whether the validate rules of the field with the given
+// json name compare it against one of the touched fields.
+func refersTo(t reflect.Type, name string, touched map[string]bool) bool {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if strings.Split(sf.Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+		for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+			_, param, ok := strings.Cut(rule, "=")
+			if params := strings.Fields(param); ok && len(params) > 0 && touched[params[0]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// optionalUUIDString is the patch DTO form of a nullable UUID column.
+func optionalUUIDString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
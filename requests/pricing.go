@@ -0,0 +1,18 @@
+package requests
+
+import "time"
+
+// PricingRuleRequest creates or replaces a pricing rule of a tour. Which
+// fields apply depends on kind (see models.PricingRule); set either amount,
+// in major units of the tour's currency, or percent.
+type PricingRuleRequest struct {
+	Kind         string     `json:"kind" validate:"required,oneof=fare season group early_bird"`
+	Name         string     `json:"name" validate:"max=100"`
+	TravelerType string     `json:"travelerType" validate:"required_if=Kind fare,oneof=child infant"`
+	MinTravelers int        `json:"minTravelers" validate:"required_if=Kind group,min=2,max=50"`
+	DaysBefore   int        `json:"daysBefore" validate:"required_if=Kind early_bird,min=1,max=730"`
+	StartDate    *time.Time `json:"startDate" validate:"required_if=Kind season"`
+	EndDate      *time.Time `json:"endDate" validate:"required_if=Kind season,gtefield=StartDate"`
+	Amount       *float64   `json:"amount" validate:"min=0"`
+	Percent      *float64   `json:"percent" validate:"min=-100,max=1000"`
+}
@@ -0,0 +1,193 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone names are checked without relying on the host
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9._]*[a-z0-9])?$`)
+	phonePattern    = regexp.MustCompile(`^\+?[0-9][0-9 ()-]*[0-9]$`)
+)
+
+// SocialLinksRequest holds the links shown on a user's public profile.
+type SocialLinksRequest struct {
+	Instagram string `json:"instagram" column:"instagram" validate:"url,max=255"`
+	Facebook  string `json:"facebook" column:"facebook" validate:"url,max=255"`
+	Website   string `json:"website" column:"website" validate:"url,max=255"`
+}
+
+// UpdateProfileRequest replaces the logged-in user's profile. Usernames are
+// lowercased before they are checked.
+type UpdateProfileRequest struct {
+	Name        string             `json:"name" validate:"required,max=100"`
+	Username    string             `json:"username" validate:"required,min=3,max=30" validator:"username"`
+	Phone       string             `json:"phone" validate:"max=32" validator:"phone"`
+	Bio         string             `json:"bio" validate:"max=1000"`
+	Country     string             `json:"country" validate:"max=100"`
+	City        string             `json:"city" validate:"max=100"`
+	Language    string             `json:"language" validate:"max=35"`
+	Timezone    string             `json:"timezone" validate:"max=64" validator:"timezone"`
+	SocialLinks SocialLinksRequest `json:"socialLinks"`
+}
+
+// PatchProfileRequest is the JSON Merge Patch view of the logged-in user's
+// profile. socialLinks is merged member by member, see ApplyProfilePatch.
+type PatchProfileRequest struct {
+	Name     string `json:"name" column:"name" validate:"required,max=100"`
+	Username string `json:"username" column:"username" validate:"required,min=3,max=30" validator:"username"`
+	Phone    string `json:"phone" column:"phone" validate:"max=32" validator:"phone"`
+	Bio      string `json:"bio" column:"bio" validate:"max=1000"`
+	Country  string `json:"country" column:"country" validate:"max=100"`
+	City     string `json:"city" column:"city" validate:"max=100"`
+	Language string `json:"language" column:"language" validate:"max=35"`
+	Timezone string `json:"timezone" column:"timezone" validate:"max=64" validator:"timezone"`
+}
+
+// NewPatchProfileRequest returns the patch DTOs holding the user's current
+// profile and social links.
+func NewPatchProfileRequest(user models.User) (PatchProfileRequest, SocialLinksRequest) {
+	profile := PatchProfileRequest{
+		Name:     user.Name,
+		Username: user.Username,
+		Phone:    user.Phone,
+		Bio:      user.Bio,
+		Country:  user.Country,
+		City:     user.City,
+		Language: user.Language,
+		Timezone: user.Timezone,
+	}
+	links := SocialLinksRequest{
+		Instagram: user.SocialLinks.Instagram,
+		Facebook:  user.SocialLinks.Facebook,
+		Website:   user.SocialLinks.Website,
+	}
+	return profile, links
+}
+
+// Normalize lowercases the username and trims the free-text fields.
+func (r *UpdateProfileRequest) Normalize() {
+	r.Username = strings.ToLower(strings.TrimSpace(r.Username))
+	r.Name = strings.TrimSpace(r.Name)
+	r.Phone = strings.TrimSpace(r.Phone)
+}
+
+// Columns returns the user columns the request writes.
+func (r *UpdateProfileRequest) Columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":      r.Name,
+		"username":  r.Username,
+		"phone":     r.Phone,
+		"bio":       r.Bio,
+		"country":   r.Country,
+		"city":      r.City,
+		"language":  r.Language,
+		"timezone":  r.Timezone,
+		"instagram": r.SocialLinks.Instagram,
+		"facebook":  r.SocialLinks.Facebook,
+		"website":   r.SocialLinks.Website,
+	}
+}
+
+// ValidateProfile validates an UpdateProfileRequest, including its social
+// links, which are reported as socialLinks.x.
+func ValidateProfile(req *UpdateProfileRequest) error {
+	fields := validationFields(Validate(req), "")
+	fields = append(fields, validationFields(Validate(&req.SocialLinks), "socialLinks.")...)
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
+
+// ApplyProfilePatch applies a JSON Merge Patch to the profile and social
+// links DTOs and returns the user columns to update. socialLinks is a
+// nested merge patch: null clears every link, an object changes only the
+// links it names.
+func ApplyProfilePatch(body []byte, profile *PatchProfileRequest, links *SocialLinksRequest) (map[string]interface{}, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, apperrors.BadRequest("Request body must be a JSON merge patch object")
+	}
+	rawLinks, hasLinks := patch["socialLinks"]
+	delete(patch, "socialLinks")
+	if username, ok := patch["username"]; ok {
+		var value string
+		if json.Unmarshal(username, &value) == nil {
+			patch["username"], _ = json.Marshal(strings.ToLower(strings.TrimSpace(value)))
+		}
+	}
+	rest, _ := json.Marshal(patch)
+
+	var fields []models.FieldError
+	columns, err := ApplyMergePatch(rest, profile)
+	if err != nil {
+		if fields = validationFields(err, ""); fields == nil {
+			return nil, err
+		}
+	}
+	if hasLinks {
+		if string(rawLinks) == "null" {
+			rawLinks = []byte(`{"instagram":null,"facebook":null,"website":null}`)
+		}
+		linkColumns, err := ApplyMergePatch(rawLinks, links)
+		if err != nil {
+			linkFields := validationFields(err, "socialLinks.")
+			if linkFields == nil {
+				linkFields = []models.FieldError{{Field: "socialLinks", Message: "must be an object"}}
+			}
+			fields = append(fields, linkFields...)
+		}
+		for column, value := range linkColumns {
+			if columns != nil {
+				columns[column] = value
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation("Validation failed", fields...)
+	}
+	return columns, nil
+}
+
+// validationFields returns the field errors of a validation error with
+// their names prefixed, or nil for any other error.
+func validationFields(err error, prefix string) []models.FieldError {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Kind != apperrors.KindValidation {
+		return nil
+	}
+	fields := make([]models.FieldError, len(appErr.Fields))
+	for i, f := range appErr.Fields {
+		fields[i] = models.FieldError{Field: prefix + f.Field, Message: f.Message}
+	}
+	return fields
+}
+
+func validateUsername(value interface{}) error {
+	if !usernamePattern.MatchString(value.(string)) {
+		return errors.New("may only contain lowercase letters, digits, dots and underscores, and must start and end with a letter or digit")
+	}
+	return nil
+}
+
+func validatePhone(value interface{}) error {
+	if !phonePattern.MatchString(value.(string)) {
+		return errors.New("must be a phone number of digits, spaces, dashes and brackets, optionally starting with +")
+	}
+	return nil
+}
+
+func validateTimezone(value interface{}) error {
+	if _, err := time.LoadLocation(value.(string)); err != nil {
+		return errors.New("must be an IANA time zone such as Africa/Kigali")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package requests
+
+import "time"
+
+// PromotionRequest creates or replaces a promotion. Amount and minSpend are
+// in major units of currency, which fixed discounts and minimum spends need.
+// Zero limits mean unlimited; empty scopes mean every tour.
+type PromotionRequest struct {
+	Code           string     `json:"code" validate:"required,min=3,max=50"`
+	Description    string     `json:"description" validate:"max=255"`
+	Type           string     `json:"type" validate:"required,oneof=percent fixed"`
+	Percent        float64    `json:"percent" validate:"required_if=Type percent,min=0,max=100"`
+	Amount         float64    `json:"amount" validate:"required_if=Type fixed,min=0"`
+	Currency       string     `json:"currency" validate:"required_if=Type fixed,currency"`
+	MinSpend       float64    `json:"minSpend" validate:"min=0"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	MaxRedemptions int        `json:"maxRedemptions" validate:"min=0"`
+	MaxPerUser     int        `json:"maxPerUser" validate:"min=0"`
+	TourIDs        []string   `json:"tourIds" validate:"max=100"`
+	CategoryIDs    []string   `json:"categoryIds" validate:"max=100"`
+	DestinationIDs []string   `json:"destinationIds" validate:"max=100"`
+	Active         *bool      `json:"active"`
+}
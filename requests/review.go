@@ -0,0 +1,63 @@
+package requests
+
+// CreateReviewRequest is the body a customer sends to review a tour. The
+// aspect ratings are optional.
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=2000"`
+
+	GuideRating        *int `json:"guideRating" validate:"min=1,max=5"`
+	ValueRating        *int `json:"valueRating" validate:"min=1,max=5"`
+	OrganisationRating *int `json:"organisationRating" validate:"min=1,max=5"`
+	SafetyRating       *int `json:"safetyRating" validate:"min=1,max=5"`
+}
+
+// AdminReviewRequest is used by admins to create or replace a review. The
+// target is given by targetType and targetId; tourId alone still works for
+// tour reviews.
+type AdminReviewRequest struct {
+	UserID     string `json:"userId" validate:"required,uuid"`
+	TargetType string `json:"targetType" validate:"required_with=TargetID,oneof=tour event destination"`
+	TargetID   string `json:"targetId" validate:"required_without=TourID,uuid"`
+	TourID     string `json:"tourId" validate:"required_without=TargetID,uuid"`
+	Rating     int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment    string `json:"comment" validate:"max=2000"`
+
+	GuideRating        *int `json:"guideRating" validate:"min=1,max=5"`
+	ValueRating        *int `json:"valueRating" validate:"min=1,max=5"`
+	OrganisationRating *int `json:"organisationRating" validate:"min=1,max=5"`
+	SafetyRating       *int `json:"safetyRating" validate:"min=1,max=5"`
+}
+
+// UpdateReviewRequest is the body a customer sends to edit their review.
+type UpdateReviewRequest struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=2000"`
+
+	GuideRating        *int `json:"guideRating" validate:"min=1,max=5"`
+	ValueRating        *int `json:"valueRating" validate:"min=1,max=5"`
+	OrganisationRating *int `json:"organisationRating" validate:"min=1,max=5"`
+	SafetyRating       *int `json:"safetyRating" validate:"min=1,max=5"`
+}
+
+// ReportReviewRequest is the body of a "report this review" submission.
+type ReportReviewRequest struct {
+	Reason  string `json:"reason" validate:"required,oneof=spam offensive fake off-topic other"`
+	Details string `json:"details" validate:"max=1000"`
+}
+
+// ModerateReviewsRequest approves or rejects several reviews at once.
+type ModerateReviewsRequest struct {
+	IDs  []string `json:"ids" validate:"required,min=1,max=100"`
+	Note string   `json:"note" validate:"max=1000"`
+}
+
+// ReviewReplyRequest is the owner's public reply to a review.
+type ReviewReplyRequest struct {
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+// ReviewVoteRequest says whether the voter found a review helpful.
+type ReviewVoteRequest struct {
+	Helpful *bool `json:"helpful" validate:"required"`
+}
@@ -3,58 +3,136 @@ package requests
 import (
 	"fmt"
 	"mime/multipart"
+	"strings"
 	"time"
+	"unicode/utf8"
 
-	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
 )
 
 type CreateTourRequest struct {
-	Title          string                `json:"title" form:"title" required:"true"`
-	DestinationID  string                `json:"destinationId" form:"destinationId" required:"true"`
-	CategoryID     string                `json:"categoryId" form:"categoryId" required:"true"`
-	Description    string                `json:"description" form:"description" required:"true"`
-	About          string                `json:"about" form:"about" required:"true"`
-	CoverImage     *multipart.FileHeader `json:"coverImage" form:"coverImage"`
-	StartDate      time.Time             `json:"startDate" form:"startDate" required:"true"`
-	EndDate        time.Time             `json:"endDate" form:"endDate" required:"true"`
-	PricePerPerson float64               `json:"pricePerPerson" form:"pricePerPerson" required:"true" min:"0"`
-	Currency       string                `json:"currency" form:"currency" required:"true"`
-	IsFeatured     bool                  `json:"isFeatured" form:"isFeatured"`
+	Title           string                `json:"title" form:"title" validate:"required,max=255"`
+	DestinationID   string                `json:"destinationId" form:"destinationId" validate:"required,uuid"`
+	CategoryID      string                `json:"categoryId" form:"categoryId" validate:"required,uuid"`
+	Description     string                `json:"description" form:"description" validate:"required,max=10000"`
+	About           string                `json:"about" form:"about" validate:"required,max=10000"`
+	CoverImage      *multipart.FileHeader `json:"coverImage" form:"coverImage"`
+	DurationDays    int                   `json:"durationDays" form:"durationDays" validate:"required_without=StartDate,min=1,max=365"`
+	DefaultCapacity int                   `json:"defaultCapacity" form:"defaultCapacity" validate:"min=1,max=1000"`
+	// Deprecated: create departures instead. When given, the tour lasts from
+	// StartDate to EndDate and gets a first departure on StartDate.
+	StartDate      *time.Time `json:"startDate" form:"startDate" validate:"required_with=EndDate"`
+	EndDate        *time.Time `json:"endDate" form:"endDate" validate:"required_with=StartDate,gtefield=StartDate"`
+	PricePerPerson float64    `json:"pricePerPerson" form:"pricePerPerson" validate:"required,min=0"`
+	Currency       string     `json:"currency" form:"currency" validate:"required,currency"`
+	IsFeatured     bool       `json:"isFeatured" form:"isFeatured"`
+	// CancellationPolicyID decides refunds; without one the tour is
+	// non-refundable
+	CancellationPolicyID string `json:"cancellationPolicyId" form:"cancellationPolicyId" validate:"uuid"`
+	// VendorID is the vendor running the tour, who may export its manifests
+	VendorID string `json:"vendorId" form:"vendorId" validate:"uuid"`
+	// ParticipantFields lists the traveller details bookings must give (see
+	// models.ParticipantFields)
+	ParticipantFields []string `json:"participantFields" form:"participantFields" validate:"max=5"`
+	// MeetingPoint and Itinerary are printed on booking vouchers; the
+	// itinerary can only be sent as JSON
+	MeetingPoint string             `json:"meetingPoint" form:"meetingPoint" validate:"max=500"`
+	Itinerary    models.Itineraries `json:"itinerary" form:"-" validate:"max=60"`
 }
 
 type UpdateTourRequest struct {
-	Title          string                `form:"title"`
-	DestinationID  string                `form:"destinationId"`
-	CategoryID     string                `form:"categoryId"`
-	Description    string                `form:"description"`
-	About          string                `form:"about"`
-	StartDate      time.Time             `form:"startDate"`
-	EndDate        time.Time             `form:"endDate"`
-	PricePerPerson float64               `form:"pricePerPerson"`
-	Currency       string                `form:"currency"`
-	IsFeatured     bool                  `form:"isFeatured"`
-	CoverImage     *multipart.FileHeader `form:"coverImage"`
+	Title           string                `form:"title" validate:"required,max=255"`
+	DestinationID   string                `form:"destinationId" validate:"required,uuid"`
+	CategoryID      string                `form:"categoryId" validate:"required,uuid"`
+	Description     string                `form:"description" validate:"required,max=10000"`
+	About           string                `form:"about" validate:"required,max=10000"`
+	DurationDays    int                   `form:"durationDays" validate:"min=1,max=365"`
+	DefaultCapacity int                   `form:"defaultCapacity" validate:"min=1,max=1000"`
+	PricePerPerson  float64               `form:"pricePerPerson" validate:"required,min=0"`
+	Currency        string                `form:"currency" validate:"required,currency"`
+	IsFeatured      bool                  `form:"isFeatured"`
+	CoverImage      *multipart.FileHeader `form:"coverImage"`
+	// CancellationPolicyID replaces the tour's cancellation policy when
+	// given; PATCH it to null to remove it
+	CancellationPolicyID string `form:"cancellationPolicyId" validate:"uuid"`
+	// VendorID and ParticipantFields replace the tour's when given; PATCH
+	// them to null to remove them
+	VendorID          string   `form:"vendorId" validate:"uuid"`
+	ParticipantFields []string `form:"participantFields" validate:"max=5"`
+	// MeetingPoint replaces the tour's when given; PATCH the itinerary
+	MeetingPoint string `form:"meetingPoint" validate:"max=500"`
 }
 
-type ValidationError struct {
-	Field string `json:"field"`
-	Error string `json:"error"`
+// PatchTourRequest is the JSON Merge Patch view of a tour.
+type PatchTourRequest struct {
+	Title           string  `json:"title" column:"title" validate:"required,max=255"`
+	DestinationID   string  `json:"destinationId" column:"destination_id" validate:"required,uuid"`
+	CategoryID      string  `json:"categoryId" column:"category" validate:"required,uuid"`
+	Description     string  `json:"description" column:"description" validate:"required,max=10000"`
+	About           string  `json:"about" column:"about" validate:"required,max=10000"`
+	DurationDays    int     `json:"durationDays" column:"duration_days" validate:"required,min=1,max=365"`
+	DefaultCapacity int     `json:"defaultCapacity" column:"default_capacity" validate:"required,min=1,max=1000"`
+	PricePerPerson  float64 `json:"pricePerPerson" column:"price_per_person_minor" validate:"min=0"`
+	Currency        string  `json:"currency" column:"currency" validate:"required,currency"`
+	IsFeatured      bool    `json:"isFeatured" column:"is_featured"`
+
+	CancellationPolicyID string   `json:"cancellationPolicyId" column:"cancellation_policy_id" validate:"uuid"`
+	VendorID             string   `json:"vendorId" column:"vendor_id" validate:"uuid"`
+	ParticipantFields    []string `json:"participantFields" column:"participant_fields" validate:"max=5"`
+
+	MeetingPoint string             `json:"meetingPoint" column:"meeting_point" validate:"max=500"`
+	Itinerary    models.Itineraries `json:"itinerary" column:"itinerary" validate:"max=60"`
 }
 
-func ValidateCreateTourRequest(req CreateTourRequest) utils.ValidationResult {
-	validator := utils.NewValidator()
+// NewPatchTourRequest returns the patch DTO holding the tour's current state.
+func NewPatchTourRequest(tour models.Tour) PatchTourRequest {
+	return PatchTourRequest{
+		Title:           tour.Title,
+		DestinationID:   tour.DestinationID.String(),
+		CategoryID:      tour.Category.String(),
+		Description:     tour.Description,
+		About:           tour.About,
+		DurationDays:    tour.DurationDays,
+		DefaultCapacity: tour.DefaultCapacity,
+		PricePerPerson:  tour.PricePerPerson,
+		Currency:        tour.Currency,
+		IsFeatured:      tour.IsFeatured,
+
+		CancellationPolicyID: optionalUUIDString(tour.CancellationPolicyID),
+		VendorID:             optionalUUIDString(tour.VendorID),
+		ParticipantFields:    tour.ParticipantFields,
+
+		MeetingPoint: tour.MeetingPoint,
+		Itinerary:    tour.Itinerary,
+	}
+}
 
-	// Add custom validators
-	validator.AddCustomValidator("validateDates", func(i interface{}) error {
-		req, ok := i.(CreateTourRequest)
-		if !ok {
-			return fmt.Errorf("invalid type for date validation")
+// ValidateItinerary checks each day of an itinerary, reporting fields as
+// itinerary[i].name.
+func ValidateItinerary(itinerary models.Itineraries) error {
+	var fields []models.FieldError
+	for i, day := range itinerary {
+		prefix := fmt.Sprintf("itinerary[%d].", i)
+		if day.Day < 1 {
+			fields = append(fields, models.FieldError{Field: prefix + "day", Message: "must be at least 1"})
+		} else if day.Day > 365 {
+			fields = append(fields, models.FieldError{Field: prefix + "day", Message: "must be at most 365"})
 		}
-		if req.EndDate.Before(req.StartDate) {
-			return fmt.Errorf("end date must be after start date")
+		if strings.TrimSpace(day.Title) == "" {
+			fields = append(fields, models.FieldError{Field: prefix + "title", Message: "This field is required"})
+		} else if utf8.RuneCountInString(day.Title) > 255 {
+			fields = append(fields, models.FieldError{Field: prefix + "title", Message: "must be at most 255 characters"})
 		}
-		return nil
-	})
-
-	return validator.Validate(req)
+		if utf8.RuneCountInString(day.Description) > 5000 {
+			fields = append(fields, models.FieldError{Field: prefix + "description", Message: "must be at most 5000 characters"})
+		}
+		if utf8.RuneCountInString(day.Image) > 500 {
+			fields = append(fields, models.FieldError{Field: prefix + "image", Message: "must be at most 500 characters"})
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
 }
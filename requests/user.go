@@ -0,0 +1,60 @@
+package requests
+
+import "github.com/Twisac-Solutions/tours-backend/models"
+
+type CreateUserRequest struct {
+	Email    string  `json:"email" validate:"required,email,max=255"`
+	Name     string  `json:"name"  validate:"required,max=100"`
+	Password string  `json:"password" validate:"required,min=6,max=72"`
+	Role     string  `json:"role"  validate:"required,oneof=user vendor admin superadmin"`
+	Username *string `json:"username,omitempty" validate:"min=3,max=30"`
+}
+
+type UpdateUserRequest struct {
+	Email *string `json:"email,omitempty" validate:"email,max=255"` // pointer = optional
+	Name  *string `json:"name,omitempty" validate:"min=1,max=100"`
+	Role  *string `json:"role,omitempty" validate:"oneof=user vendor admin superadmin"`
+}
+
+type CreateAdminRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+type UpdateAdminRequest struct {
+	Name  *string `json:"name,omitempty" validate:"min=1,max=100"`
+	Email *string `json:"email,omitempty" validate:"email,max=255"`
+}
+
+// PatchUserRequest is the JSON Merge Patch view of a user.
+type PatchUserRequest struct {
+	Name       string `json:"name" column:"name" validate:"required,max=100"`
+	Username   string `json:"username" column:"username" validate:"required,min=3,max=30"`
+	Email      string `json:"email" column:"email" validate:"required,email,max=255"`
+	Phone      string `json:"phone" column:"phone" validate:"max=32"`
+	Role       string `json:"role" column:"role" validate:"required,oneof=user vendor admin superadmin"`
+	Bio        string `json:"bio" column:"bio" validate:"max=1000"`
+	Country    string `json:"country" column:"country" validate:"max=100"`
+	City       string `json:"city" column:"city" validate:"max=100"`
+	Language   string `json:"language" column:"language" validate:"max=35"`
+	Timezone   string `json:"timezone" column:"timezone" validate:"max=64"`
+	IsVerified bool   `json:"isVerified" column:"is_verified"`
+}
+
+// NewPatchUserRequest returns the patch DTO holding the user's current state.
+func NewPatchUserRequest(user models.User) PatchUserRequest {
+	return PatchUserRequest{
+		Name:       user.Name,
+		Username:   user.Username,
+		Email:      user.Email,
+		Phone:      user.Phone,
+		Role:       user.Role,
+		Bio:        user.Bio,
+		Country:    user.Country,
+		City:       user.City,
+		Language:   user.Language,
+		Timezone:   user.Timezone,
+		IsVerified: user.IsVerified,
+	}
+}
@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+)
+
+var validator = utils.NewValidator()
+
+func init() {
+	validator.AddCustomValidator("username", validateUsername)
+	validator.AddCustomValidator("phone", validatePhone)
+	validator.AddCustomValidator("timezone", validateTimezone)
+}
+
+// Validate runs the declarative `validate` rules of a request DTO and
+// returns a validation error listing every offending field, or nil.
+func Validate(req interface{}) error {
+	result := validator.Validate(req)
+	if result.Valid {
+		return nil
+	}
+	fields := make([]models.FieldError, len(result.Errors))
+	for i, e := range result.Errors {
+		fields[i] = models.FieldError{Field: e.Field, Message: e.Message}
+	}
+	return apperrors.Validation("Validation failed", fields...)
+}
@@ -0,0 +1,11 @@
+package requests
+
+// JoinWaitlistRequest puts the logged-in user's party in line for a
+// sold-out departure or event; exactly one of the two is given.
+type JoinWaitlistRequest struct {
+	DepartureID string `json:"departureId" validate:"required_without=EventID,uuid"`
+	EventID     string `json:"eventId" validate:"required_without=DepartureID,uuid"`
+	Adults      int    `json:"adults" validate:"required,min=1,max=50"`
+	Children    int    `json:"children" validate:"min=0,max=50"`
+	Infants     int    `json:"infants" validate:"min=0,max=50"`
+}
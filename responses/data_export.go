@@ -0,0 +1,20 @@
+package responses
+
+import "github.com/Twisac-Solutions/tours-backend/models"
+
+// DataExportResponse is a data export with the link to download it once it
+// is ready.
+type DataExportResponse struct {
+	models.DataExport
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+// ToDataExportResponse converts a models.DataExport; downloadURL is only
+// shown while the export is ready.
+func ToDataExportResponse(export models.DataExport, downloadURL string) DataExportResponse {
+	response := DataExportResponse{DataExport: export}
+	if export.Status == models.DataExportReady {
+		response.DownloadURL = downloadURL
+	}
+	return response
+}
@@ -13,23 +13,34 @@ type DestinationResponse struct {
 	Region      string `json:"region"`
 	Country     string `json:"country"`
 	CoverImage  string `json:"coverImage"`
-	User        struct {
+	// Average over the approved reviews of the destination's tours and events
+	AverageRating float64 `json:"averageRating"`
+	ReviewCount   int     `json:"reviewCount"`
+	User          struct {
 		ID   string `json:"id"`
 		Name string `json:"name"`
 	} `json:"user"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Only sent to logged-in users: whether they saved the destination
+	IsFavorite *bool `json:"isFavorite,omitempty"`
 }
 
 func ToDestinationResponse(destination models.Destination) DestinationResponse {
 	response := DestinationResponse{
-		ID:          destination.ID.String(),
-		Name:        destination.Name,
-		Description: destination.Description,
-		Region:      destination.Region,
-		Country:     destination.Country,
-		CreatedAt:   destination.CreatedAt,
-		UpdatedAt:   destination.UpdatedAt,
+		ID:            destination.ID.String(),
+		Name:          destination.Name,
+		Description:   destination.Description,
+		Region:        destination.Region,
+		Country:       destination.Country,
+		AverageRating: destination.AverageRating,
+		ReviewCount:   destination.ReviewCount,
+		Version:       destination.Version,
+		CreatedAt:     destination.CreatedAt,
+		UpdatedAt:     destination.UpdatedAt,
 	}
 
 	response.CoverImage = destination.CoverImage.URL
@@ -37,5 +48,9 @@ func ToDestinationResponse(destination models.Destination) DestinationResponse {
 	response.User.ID = destination.User.ID.String()
 	response.User.Name = destination.User.Name
 
+	if destination.UpdatedBy != nil {
+		response.UpdatedBy = destination.UpdatedBy.String()
+	}
+
 	return response
 }
@@ -0,0 +1,41 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+// FavoriteResponse is a saved tour, event or destination. The field named
+// by TargetType holds the item; it is missing if the item was removed.
+type FavoriteResponse struct {
+	ID          string                `json:"id"`
+	TargetType  models.FavoriteTarget `json:"targetType"`
+	TargetID    string                `json:"targetId"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	Tour        *TourResponse         `json:"tour,omitempty"`
+	Event       *models.Event         `json:"event,omitempty"`
+	Destination *DestinationResponse  `json:"destination,omitempty"`
+}
+
+func ToFavoriteResponse(favorite models.Favorite) FavoriteResponse {
+	response := FavoriteResponse{
+		ID:         favorite.ID.String(),
+		TargetType: favorite.TargetType,
+		TargetID:   favorite.TargetID.String(),
+		CreatedAt:  favorite.CreatedAt,
+		Event:      favorite.Event,
+	}
+	saved := true
+	if favorite.Tour != nil {
+		tour := ToTourResponse(*favorite.Tour)
+		tour.IsFavorite = &saved
+		response.Tour = &tour
+	}
+	if favorite.Destination != nil {
+		destination := ToDestinationResponse(*favorite.Destination)
+		destination.IsFavorite = &saved
+		response.Destination = &destination
+	}
+	return response
+}
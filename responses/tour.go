@@ -4,25 +4,56 @@ import (
 	"time"
 
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
 )
 
 // TourResponse represents the API response format for a tour
 type TourResponse struct {
-	ID             string    `json:"id"`
-	Title          string    `json:"title"`
-	CategoryID     string    `json:"categoryId"`
-	Description    string    `json:"description"`
-	About          string    `json:"about"`
-	StartDate      time.Time `json:"startDate"`
-	EndDate        time.Time `json:"endDate"`
-	PricePerPerson float64   `json:"pricePerPerson"`
-	Currency       string    `json:"currency"`
-	IsFeatured     bool      `json:"isFeatured"`
+	ID                  string  `json:"id"`
+	Title               string  `json:"title"`
+	CategoryID          string  `json:"categoryId"`
+	Description         string  `json:"description"`
+	About               string  `json:"about"`
+	DurationDays        int     `json:"durationDays"`
+	DefaultCapacity     int     `json:"defaultCapacity"`
+	PricePerPerson      float64 `json:"pricePerPerson"`
+	PricePerPersonMinor int64   `json:"pricePerPersonMinor"`
+	Currency            string  `json:"currency"`
+	IsFeatured          bool    `json:"isFeatured"`
+
+	// Set when the price was converted for ?currency=
+	OriginalPricePerPerson *float64 `json:"originalPricePerPerson,omitempty"`
+	OriginalCurrency       string   `json:"originalCurrency,omitempty"`
+
+	// The next bookable departure; StartDate and EndDate repeat its dates
+	// for clients written before tours had departures
+	NextDeparture *models.TourDeparture `json:"nextDeparture"`
+	StartDate     *time.Time            `json:"startDate"`
+	EndDate       *time.Time            `json:"endDate"`
+
+	// Refunds on cancellation; no policy means the tour is non-refundable.
+	// The policy itself is only loaded for a single tour
+	CancellationPolicyID *string                    `json:"cancellationPolicyId"`
+	CancellationPolicy   *models.CancellationPolicy `json:"cancellationPolicy,omitempty"`
+
+	// The vendor running the tour and the traveller details bookings must
+	// give (see PUT /api/user/bookings/{id}/participants)
+	VendorID          *string  `json:"vendorId"`
+	ParticipantFields []string `json:"participantFields"`
+
+	// Printed on booking vouchers
+	MeetingPoint string             `json:"meetingPoint"`
+	Itinerary    models.Itineraries `json:"itinerary"`
 
 	// Rating fields
 	AverageRating float64 `json:"averageRating"`
 	ReviewCount   int     `json:"reviewCount"`
 
+	// How many users saved the tour; IsFavorite is only sent to logged-in
+	// users and says whether they saved it
+	FavoriteCount int   `json:"favoriteCount"`
+	IsFavorite    *bool `json:"isFavorite,omitempty"`
+
 	CoverImage  string `json:"coverImage"`
 	Destination struct {
 		ID   string `json:"id"`
@@ -35,26 +66,55 @@ type TourResponse struct {
 		ProfileImage string `json:"profileImage"`
 		Role         string `json:"role"`
 	} `json:"user"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 func ToTourResponse(tour models.Tour) TourResponse {
 	response := TourResponse{
-		ID:             tour.ID.String(),
-		Title:          tour.Title,
-		CategoryID:     tour.Category.String(),
-		Description:    tour.Description,
-		About:          tour.About,
-		StartDate:      tour.StartDate,
-		EndDate:        tour.EndDate,
-		PricePerPerson: tour.PricePerPerson,
-		Currency:       tour.Currency,
-		IsFeatured:     tour.IsFeatured,
-		AverageRating:  tour.AverageRating,
-		ReviewCount:    tour.ReviewCount,
-		CreatedAt:      tour.CreatedAt,
-		UpdatedAt:      tour.UpdatedAt,
+		ID:                  tour.ID.String(),
+		Title:               tour.Title,
+		CategoryID:          tour.Category.String(),
+		Description:         tour.Description,
+		About:               tour.About,
+		DurationDays:        tour.DurationDays,
+		DefaultCapacity:     tour.DefaultCapacity,
+		PricePerPerson:      tour.PricePerPerson,
+		PricePerPersonMinor: tour.PricePerPersonMinor,
+		Currency:            tour.Currency,
+		IsFeatured:          tour.IsFeatured,
+		AverageRating:       tour.AverageRating,
+		ReviewCount:         tour.ReviewCount,
+		FavoriteCount:       tour.FavoriteCount,
+		Version:             tour.Version,
+		CreatedAt:           tour.CreatedAt,
+		UpdatedAt:           tour.UpdatedAt,
+	}
+
+	if tour.CancellationPolicyID != nil {
+		id := tour.CancellationPolicyID.String()
+		response.CancellationPolicyID = &id
+		response.CancellationPolicy = tour.CancellationPolicy
+	}
+	if tour.VendorID != nil {
+		id := tour.VendorID.String()
+		response.VendorID = &id
+	}
+	response.ParticipantFields = tour.ParticipantFields
+	if response.ParticipantFields == nil {
+		response.ParticipantFields = []string{}
+	}
+	response.MeetingPoint = tour.MeetingPoint
+	response.Itinerary = tour.Itinerary
+	if response.Itinerary == nil {
+		response.Itinerary = models.Itineraries{}
+	}
+	if tour.NextDeparture != nil {
+		response.NextDeparture = tour.NextDeparture
+		response.StartDate = &tour.NextDeparture.StartDate
+		response.EndDate = &tour.NextDeparture.EndDate
 	}
 
 	// Set the cover image URL
@@ -71,5 +131,20 @@ func ToTourResponse(tour models.Tour) TourResponse {
 	response.User.ProfileImage = tour.User.ProfileImage.URL
 	response.User.Role = tour.User.Role
 
+	if tour.UpdatedBy != nil {
+		response.UpdatedBy = tour.UpdatedBy.String()
+	}
+
 	return response
 }
+
+// ConvertPrice shows the price as amount minor units of currency, keeping
+// the stored price as the original.
+func (r *TourResponse) ConvertPrice(amount int64, currency string) {
+	original := r.PricePerPerson
+	r.OriginalPricePerPerson = &original
+	r.OriginalCurrency = r.Currency
+	r.PricePerPerson = utils.FromMinorUnits(amount, currency)
+	r.PricePerPersonMinor = amount
+	r.Currency = currency
+}
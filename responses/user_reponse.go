@@ -21,6 +21,28 @@ type UserResponse struct {
 	IsVerified     bool      `json:"is_verified"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	ProfileThumbnail  string             `json:"profile_thumbnail"`
+	Timezone          string             `json:"timezone"`
+	SocialLinks       models.SocialLinks `json:"social_links"`
+	UsernameChangedAt *time.Time         `json:"username_changed_at,omitempty"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	AnonymizedAt        *time.Time `json:"anonymized_at,omitempty"`
+}
+
+// PublicProfileResponse is what anyone can see of a user: no contact
+// details.
+type PublicProfileResponse struct {
+	Username     string              `json:"username"`
+	Name         string              `json:"name"`
+	ProfileImage models.ProfileImage `json:"profileImage"`
+	Bio          string              `json:"bio"`
+	Country      string              `json:"country"`
+	City         string              `json:"city"`
+	SocialLinks  models.SocialLinks  `json:"socialLinks"`
+	ReviewCount  int64               `json:"reviewCount"`
+	MemberSince  time.Time           `json:"memberSince"`
 }
 
 // Converts a models.User into the public response.
@@ -40,5 +62,29 @@ func ToUserResponse(u models.User) UserResponse {
 		IsVerified:     u.IsVerified,
 		CreatedAt:      u.CreatedAt,
 		UpdatedAt:      u.UpdatedAt,
+
+		ProfileThumbnail:  u.ProfileImage.ThumbnailURL,
+		Timezone:          u.Timezone,
+		SocialLinks:       u.SocialLinks,
+		UsernameChangedAt: u.UsernameChangedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		AnonymizedAt:        u.AnonymizedAt,
+	}
+}
+
+// ToPublicProfileResponse converts a models.User and the number of their
+// published reviews into their public profile.
+func ToPublicProfileResponse(u models.User, reviewCount int64) PublicProfileResponse {
+	return PublicProfileResponse{
+		Username:     u.Username,
+		Name:         u.Name,
+		ProfileImage: u.ProfileImage,
+		Bio:          u.Bio,
+		Country:      u.Country,
+		City:         u.City,
+		SocialLinks:  u.SocialLinks,
+		ReviewCount:  reviewCount,
+		MemberSince:  u.CreatedAt,
 	}
 }
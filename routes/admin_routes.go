@@ -7,7 +7,7 @@ import (
 )
 
 func RegisterAdminRoutes(app *fiber.App) {
-	admin := app.Group("/admin", middlewares.AdminOnly)
+	admin := app.Group("/admin", middlewares.AdminOnly, middlewares.AuditLog())
 	admin.Post("/login", controllers.AdminLogin)
 
 	// Tour Routes
@@ -15,20 +15,36 @@ func RegisterAdminRoutes(app *fiber.App) {
 	admin.Get("/tours/:id", controllers.GetTourByID)
 	admin.Post("/tours", controllers.CreateTour)
 	admin.Put("/tours/:id", controllers.UpdateTour)
+	admin.Patch("/tours/:id", controllers.PatchTour)
 	admin.Delete("/tours/:id", controllers.DeleteTour)
+	admin.Get("/tours/:id/pricing-rules", controllers.GetPricingRules)
+	admin.Post("/tours/:id/pricing-rules", controllers.CreatePricingRule)
+	admin.Put("/tours/:id/pricing-rules/:ruleId", controllers.UpdatePricingRule)
+	admin.Delete("/tours/:id/pricing-rules/:ruleId", controllers.DeletePricingRule)
+	admin.Get("/tours/:id/departures", controllers.GetAdminTourDepartures)
+	admin.Post("/tours/:id/departures", controllers.CreateDeparture)
+	admin.Post("/tours/:id/departures/generate", controllers.GenerateDepartures)
+	admin.Get("/tours/:id/departures/:departureId", controllers.GetDeparture)
+	admin.Put("/tours/:id/departures/:departureId", controllers.UpdateDeparture)
+	admin.Delete("/tours/:id/departures/:departureId", controllers.DeleteDeparture)
+	admin.Get("/tours/:id/departures/:departureId/waitlist", controllers.GetDepartureWaitlist)
+	admin.Get("/tours/:id/departures/:departureId/manifest", controllers.GetDepartureManifest)
 
 	//Events Routes
 	admin.Get("/events", controllers.GetAllEvents)
 	admin.Get("/events/:id", controllers.GetEventByID)
 	admin.Post("/events", controllers.CreateEvent)
 	admin.Put("/events/:id", controllers.UpdateEvent)
+	admin.Patch("/events/:id", controllers.PatchEvent)
 	admin.Delete("/events/:id", controllers.DeleteEvent)
+	admin.Get("/events/:id/waitlist", controllers.GetEventWaitlist)
 
 	// Destination Routes
 	admin.Get("/destinations", controllers.GetAllDestinations)
 	admin.Get("/destinations/:id", controllers.GetDestinationByID)
 	admin.Post("/destinations", controllers.CreateDestination)
 	admin.Put("/destinations/:id", controllers.UpdateDestination)
+	admin.Patch("/destinations/:id", controllers.PatchDestination)
 	admin.Delete("/destinations/:id", controllers.DeleteDestination)
 
 	// Category Routes
@@ -36,14 +52,51 @@ func RegisterAdminRoutes(app *fiber.App) {
 	admin.Get("/categories/:id", controllers.GetCategoryByID)
 	admin.Post("/categories", controllers.CreateCategory)
 	admin.Put("/categories/:id", controllers.UpdateCategory)
+	admin.Patch("/categories/:id", controllers.PatchCategory)
 	admin.Delete("/categories/:id", controllers.DeleteCategory)
 
 	// Review Routes
 	admin.Get("/reviews", controllers.GetAllReviews)
+	admin.Get("/reviews/moderation", controllers.GetModerationQueue)
+	admin.Post("/reviews/moderation/approve", controllers.ApproveReviews)
+	admin.Post("/reviews/moderation/reject", controllers.RejectReviews)
 	admin.Get("/reviews/:id", controllers.GetReviewByID)
 	admin.Post("/reviews", controllers.CreateReview)
 	admin.Put("/reviews/:id", controllers.UpdateReview)
 	admin.Delete("/reviews/:id", controllers.DeleteReview)
+	admin.Put("/reviews/:id/reply", controllers.ReplyToReview)
+	admin.Delete("/reviews/:id/reply", controllers.DeleteReviewReply)
+
+	// Booking Routes
+	admin.Post("/bookings/:id/cancel", controllers.AdminCancelBooking)
+
+	// Payment Routes
+	admin.Get("/payments", controllers.GetAllPayments)
+	admin.Get("/payments/:id", controllers.GetPaymentByID)
+	admin.Post("/payments/:id/refund", controllers.RefundPayment)
+	admin.Get("/webhooks", controllers.GetWebhookEvents)
+	admin.Get("/webhooks/:id", controllers.GetWebhookEventByID)
+	admin.Post("/webhooks/:id/replay", controllers.ReplayWebhookEvent)
+
+	// Promotion Routes
+	admin.Get("/promotions", controllers.GetPromotions)
+	admin.Get("/promotions/:id", controllers.GetPromotionByID)
+	admin.Post("/promotions", controllers.CreatePromotion)
+	admin.Put("/promotions/:id", controllers.UpdatePromotion)
+	admin.Delete("/promotions/:id", controllers.DeletePromotion)
+
+	// Cancellation Policy Routes
+	admin.Get("/cancellation-policies", controllers.GetCancellationPolicies)
+	admin.Get("/cancellation-policies/:id", controllers.GetCancellationPolicy)
+	admin.Post("/cancellation-policies", controllers.CreateCancellationPolicy)
+	admin.Put("/cancellation-policies/:id", controllers.UpdateCancellationPolicy)
+	admin.Delete("/cancellation-policies/:id", controllers.DeleteCancellationPolicy)
+
+	// Exchange Rate Routes
+	admin.Get("/exchange-rates", controllers.GetExchangeRates)
+	admin.Post("/exchange-rates/import", controllers.ImportExchangeRates)
+	admin.Put("/exchange-rates/:currency", controllers.SetExchangeRate)
+	admin.Delete("/exchange-rates/:currency", controllers.DeleteExchangeRate)
 
 	admin.Put("/me/password", controllers.UpdateAdminPassword)
 	admin.Get("/user/me", controllers.GetCurrentAdminProfile)
@@ -53,7 +106,18 @@ func RegisterAdminRoutes(app *fiber.App) {
 	userAdmin.Get("/:id", controllers.GetUserByID)
 	userAdmin.Post("/", controllers.CreateUser)
 	userAdmin.Put("/:id", controllers.UpdateUser)
+	userAdmin.Patch("/:id", controllers.PatchUser)
 	userAdmin.Delete("/:id", controllers.DeleteUser)
+	userAdmin.Post("/:id/data-exports", controllers.RequestUserDataExport)
+	userAdmin.Get("/:id/data-exports", controllers.GetUserDataExports)
+	userAdmin.Get("/:id/data-exports/:exportId/download", controllers.DownloadUserDataExport)
+	userAdmin.Post("/:id/deletion", controllers.DeleteUserAccount)
+	userAdmin.Delete("/:id/deletion", controllers.CancelUserAccountDeletion)
+
+	// Audit Log Routes
+	audit := admin.Group("/audit-logs", middlewares.RequireRole("superadmin"))
+	audit.Get("/", controllers.GetAuditLogs)
+	audit.Get("/export", controllers.ExportAuditLogs)
 
 	adminUsers := admin.Group("/managers", middlewares.SuperAdminOnly())
 	adminUsers.Get("/", controllers.ListAdmins)
@@ -15,19 +15,88 @@ func SetupRoutes(app *fiber.App) {
 	auth.Post("/google", controllers.GoogleSSO)
 	auth.Post("/logout", controllers.Logout)
 
+	// Registered before the /user group, whose middleware matches any path
+	// starting with /api/user, including /api/users
+	api.Get("/users/:username", controllers.GetPublicProfile)
+
 	user := api.Group("/user", middlewares.JWTProtected())
 	user.Get("/profile", controllers.GetUserProfile)
+	user.Put("/profile", controllers.UpdateMyProfile)
+	user.Patch("/profile", controllers.PatchMyProfile)
+	user.Post("/profile/avatar", controllers.UploadMyAvatar)
+	user.Delete("/profile/avatar", controllers.DeleteMyAvatar)
+	user.Post("/account/deletion", controllers.DeleteMyAccount)
+	user.Delete("/account/deletion", controllers.CancelMyAccountDeletion)
+	user.Get("/data-exports", controllers.GetMyDataExports)
+	user.Post("/data-exports", controllers.RequestMyDataExport)
+	user.Get("/data-exports/:id", controllers.GetMyDataExport)
+	user.Get("/data-exports/:id/download", controllers.DownloadMyDataExport)
+	user.Get("/reviews", controllers.GetMyReviews)
+	user.Put("/reviews/:id", controllers.UpdateMyReview)
+	user.Delete("/reviews/:id", controllers.DeleteMyReview)
+	user.Post("/reviews/:id/photos", controllers.AddMyReviewPhotos)
+	user.Delete("/reviews/:id/photos/:photoId", controllers.DeleteMyReviewPhoto)
+	user.Get("/bookings", controllers.GetMyBookings)
+	user.Post("/bookings", controllers.CreateBooking)
+	user.Get("/bookings/:id", controllers.GetMyBooking)
+	user.Post("/bookings/:id/payments", controllers.PayBooking)
+	user.Get("/bookings/:id/cancellation", controllers.GetMyBookingCancellation)
+	user.Post("/bookings/:id/cancel", controllers.CancelMyBooking)
+	user.Get("/bookings/:id/participants", controllers.GetMyBookingParticipants)
+	user.Put("/bookings/:id/participants", controllers.SetMyBookingParticipants)
+	user.Get("/bookings/:id/voucher", controllers.GetMyBookingVoucher)
+	user.Get("/bookings/:id/invoice", controllers.GetMyBookingInvoice)
+	user.Post("/payments/:id/confirm", controllers.ConfirmMyPayment)
+	user.Get("/waitlist", controllers.GetMyWaitlist)
+	user.Post("/waitlist", controllers.JoinWaitlist)
+	user.Get("/waitlist/:id", controllers.GetMyWaitlistEntry)
+	user.Delete("/waitlist/:id", controllers.LeaveWaitlist)
+	user.Post("/waitlist/:id/claim", controllers.ClaimWaitlistOffer)
+	user.Get("/waitlist/:id/ticket", controllers.GetMyEventTicket)
+	user.Get("/favorites", controllers.GetMyFavorites)
+	user.Post("/favorites", controllers.AddFavorite)
+	user.Delete("/favorites/:type/:targetId", controllers.RemoveFavorite)
+	user.Get("/notifications", controllers.GetMyNotifications)
+	user.Post("/notifications/read-all", controllers.MarkAllNotificationsRead)
+	user.Post("/notifications/:id/read", controllers.MarkNotificationRead)
+
+	vendor := api.Group("/vendor", middlewares.JWTProtected(), middlewares.VendorOnly)
+	vendor.Get("/tours", controllers.GetVendorTours)
+	vendor.Get("/tours/:id/departures/:departureId/manifest", controllers.GetVendorDepartureManifest)
 
 	// Tour Routes
-	api.Get("/tours", controllers.GetAllTours)
-	api.Get("/tours/:id", controllers.GetTourByID)
-	api.Get("/tours/featured", controllers.GetFeaturedTours)
-	api.Get("/tours/filter", controllers.GetFilteredTours)
+	api.Get("/tours", middlewares.OptionalJWT(), controllers.GetAllTours)
+	api.Get("/tours/featured", middlewares.OptionalJWT(), controllers.GetFeaturedTours)
+	api.Get("/tours/filter", middlewares.OptionalJWT(), controllers.GetFilteredTours)
+	api.Get("/tours/:id", middlewares.OptionalJWT(), controllers.GetTourByID)
+	api.Get("/tours/:id/quote", middlewares.OptionalJWT(), controllers.GetTourQuote)
+	api.Get("/tours/:id/departures", controllers.GetTourDepartures)
+	api.Get("/tours/:id/availability", controllers.GetTourAvailability)
 	api.Get("/tours/:id/reviews", controllers.GetTourReviews)
+	api.Get("/tours/:id/reviews/summary", controllers.GetTourReviewSummary)
 	api.Post("/tours/:id/reviews", middlewares.JWTProtected(), controllers.CreateTourReview)
+	api.Get("/departures", controllers.GetUpcomingDepartures)
+	api.Post("/reviews/:id/report", middlewares.JWTProtected(), controllers.ReportReview)
+	api.Post("/reviews/:id/vote", middlewares.JWTProtected(), controllers.VoteReview)
+	api.Delete("/reviews/:id/vote", middlewares.JWTProtected(), controllers.DeleteReviewVote)
+
+	api.Get("/destinations", middlewares.OptionalJWT(), controllers.GetAllDestinations)
+	api.Get("/destinations/:id", middlewares.OptionalJWT(), controllers.GetDestinationByID)
+	api.Get("/destinations/:id/availability", controllers.GetDestinationAvailability)
+	api.Get("/destinations/:id/reviews", controllers.GetDestinationReviews)
+	api.Get("/destinations/:id/reviews/summary", controllers.GetDestinationReviewSummary)
+	api.Post("/destinations/:id/reviews", middlewares.JWTProtected(), controllers.CreateDestinationReview)
+
+	api.Get("/events/:id/reviews", controllers.GetEventReviews)
+	api.Get("/events/:id/reviews/summary", controllers.GetEventReviewSummary)
+	api.Post("/events/:id/reviews", middlewares.JWTProtected(), controllers.CreateEventReview)
+
+	api.Post("/webhooks/payments", controllers.PaymentWebhook)
+
+	api.Post("/promotions/validate", middlewares.OptionalJWT(), controllers.ValidatePromotion)
 
-	api.Get("/destinations", controllers.GetAllDestinations)
-	api.Get("/destinations/:id", controllers.GetDestinationByID)
+	// Exchange Rate Routes
+	api.Get("/exchange-rates", controllers.GetExchangeRates)
 
 	api.Get("/categories", controllers.GetAllCategories)
 	api.Get("/categories/:id", controllers.GetCategoryByID)
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// accountPurgeBatch caps how many accounts one run of the purger deletes.
+const accountPurgeBatch = 20
+
+// ScheduleAccountDeletion deletes the user's account after a grace period,
+// during which CancelAccountDeletion restores it. The user is logged out
+// everywhere. Accounts with unfinished bookings can't be deleted.
+func ScheduleAccountDeletion(userID uuid.UUID, grace time.Duration) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := loadDeletableUser(tx, userID, &user); err != nil {
+			return err
+		}
+		if user.DeletionScheduledAt != nil {
+			return apperrors.Conflict("This account is already scheduled for deletion")
+		}
+		now := time.Now()
+		scheduledAt := now.Add(grace)
+		err := tx.Model(&user).Updates(map[string]interface{}{
+			"deletion_requested_at": now,
+			"deletion_scheduled_at": scheduledAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := revokeUserSessions(tx, userID); err != nil {
+			return err
+		}
+		body := fmt.Sprintf("Your account will be deleted on %s. Until then you can log in and cancel the deletion. "+
+			"Afterwards your personal data is erased; your reviews stay up anonymously and your booking and payment "+
+			"records are kept as the law requires.", scheduledAt.UTC().Format("2 January 2006"))
+		return notify(tx, userID, models.NotificationAccountDeletion, "Your account is scheduled for deletion", body, "/api/user/account/deletion")
+	})
+	if err != nil {
+		return nil, apperrors.FromDB(err, "User")
+	}
+	return &user, nil
+}
+
+// CancelAccountDeletion keeps an account that is scheduled for deletion.
+func CancelAccountDeletion(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.AnonymizedAt != nil {
+			return apperrors.Conflict("This account has been deleted")
+		}
+		if user.DeletionScheduledAt == nil {
+			return apperrors.Conflict("This account is not scheduled for deletion")
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"deletion_requested_at": nil,
+			"deletion_scheduled_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, apperrors.FromDB(err, "User")
+	}
+	return &user, nil
+}
+
+// loadDeletableUser loads a user whose account may be deleted: not deleted
+// already and without bookings still waiting for payment or travel.
+func loadDeletableUser(tx *gorm.DB, userID uuid.UUID, user *models.User) error {
+	if err := tx.First(user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	if user.AnonymizedAt != nil {
+		return apperrors.Conflict("This account has been deleted")
+	}
+	now := time.Now()
+	var open int64
+	err := tx.Model(&models.Booking{}).
+		Where("user_id = ?", userID).
+		Where(tx.Where("status = ?", models.BookingPending).
+			Or("status = ? AND travel_date > ?", models.BookingConfirmed, now).
+			Or("status = ? AND departure_id IN (?)", models.BookingConfirmed,
+				tx.Model(&models.TourDeparture{}).Select("id").Where("start_date > ?", now))).
+		Count(&open).Error
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return apperrors.Conflict("Cancel or complete your upcoming bookings before deleting the account")
+	}
+	return nil
+}
+
+// StartAccountPurger deletes the accounts whose grace period ended every
+// interval.
+func StartAccountPurger(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purged, err := PurgeDueAccounts()
+			if err != nil {
+				log.Printf("account purger: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("account purger: deleted %d accounts", purged)
+			}
+		}
+	}()
+}
+
+// PurgeDueAccounts deletes the accounts whose grace period ended. Accounts
+// that took a booking in the meantime are skipped until it is over. It
+// returns how many accounts were deleted.
+func PurgeDueAccounts() (int, error) {
+	var due []uuid.UUID
+	err := database.DB.Model(&models.User{}).
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", time.Now()).
+		Order("deletion_scheduled_at").
+		Limit(accountPurgeBatch).
+		Pluck("id", &due).Error
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, userID := range due {
+		if err := PurgeAccount(userID); err != nil {
+			log.Printf("account purger: user %s: %v", userID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeAccount erases the user's personal data. The user row stays, as an
+// anonymous "Deleted user", so their reviews stay up and their bookings,
+// payments and invoices are kept as financial records; the travellers named
+// on bookings, favourites, sessions, notifications, data exports and the
+// avatar uploaded to Cloudinary go.
+func PurgeAccount(userID uuid.UUID) error {
+	var files []string
+	var avatar models.ProfileImage
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := loadDeletableUser(tx, userID, &user); err != nil {
+			return err
+		}
+		avatar = user.ProfileImage
+		if err := leaveWaitlists(tx, userID); err != nil {
+			return err
+		}
+
+		var tourIDs []uuid.UUID
+		err := tx.Model(&models.Favorite{}).
+			Where("user_id = ? AND target_type = ?", userID, models.FavoriteTour).
+			Pluck("target_id", &tourIDs).Error
+		if err != nil {
+			return err
+		}
+		for _, tourID := range tourIDs {
+			// Only favourites this purge deletes come off the count; one
+			// removed concurrently already took itself off.
+			result := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, models.FavoriteTour, tourID).
+				Delete(&models.Favorite{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := adjustFavoriteCount(tx, models.FavoriteTour, tourID, -1); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Favorite{}).Error; err != nil {
+			return err
+		}
+
+		bookings := tx.Model(&models.Booking{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("booking_id IN (?)", bookings).Delete(&models.Participant{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.Session{}, &models.Notification{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if files, err = deleteDataExports(tx, userID); err != nil {
+			return err
+		}
+
+		id := userID.String()
+		now := time.Now()
+		return tx.Model(&user).Select("*").Omit("id", "role", "created_at", "deletion_requested_at").
+			Updates(models.User{
+				Name:                "Deleted user",
+				Username:            "deleted_" + id[:8] + id[9:13],
+				Email:               "deleted+" + id + "@invalid",
+				UpdatedBy:           &userID,
+				DeletionScheduledAt: user.DeletionScheduledAt,
+				AnonymizedAt:        &now,
+			}).Error
+	})
+	if err != nil {
+		return apperrors.FromDB(err, "User")
+	}
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("account purger: %v", err)
+		}
+	}
+	deleteAvatar(avatar)
+	return nil
+}
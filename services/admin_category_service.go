@@ -3,6 +3,7 @@ package services
 import (
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"gorm.io/gorm"
 )
 
 func GetAllCategories() ([]models.Category, error) {
@@ -21,10 +22,26 @@ func CreateCategory(category *models.Category) error {
 	return database.DB.Create(category).Error
 }
 
-func UpdateCategory(id string, updated *models.Category) error {
-	return database.DB.Model(&models.Category{}).Where("id = ?", id).Updates(updated).Error
+func UpdateCategory(id string, version int, updated *models.Category) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Category{}, id, version); err != nil {
+			return err
+		}
+		return tx.Model(&models.Category{}).Where("id = ?", id).Updates(updated).Error
+	})
 }
 
-func DeleteCategory(id string) error {
-	return database.DB.Delete(&models.Category{}, "id = ?", id).Error
+// PatchCategory writes the columns produced by a merge patch if the category
+// is still at the given version.
+func PatchCategory(id string, version int, columns map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Category{}, id, version); err != nil {
+			return err
+		}
+		return tx.Model(&models.Category{}).Where("id = ?", id).Updates(columns).Error
+	})
+}
+
+func DeleteCategory(id string, version int) error {
+	return deleteVersioned(database.DB, &models.Category{}, id, version)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/garrettladley/fiberpaginate/v2"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 func GetAllDestinations(c *fiber.Ctx) ([]models.Destination, int64, error) {
@@ -38,16 +39,22 @@ func CreateDestination(destination *models.Destination) error {
 	return database.DB.Create(destination).Error
 }
 
-func UpdateDestination(id string, updated *models.Destination) error {
+func UpdateDestination(id string, version int, updated *models.Destination) error {
 	// Start a transaction
 	tx := database.DB.Begin()
 
+	if err := bumpVersion(tx, &models.Destination{}, id, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Update the destination
 	if err := tx.Model(&models.Destination{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"name":        updated.Name,
 		"description": updated.Description,
 		"region":      updated.Region,
 		"country":     updated.Country,
+		"updated_by":  updated.UpdatedBy,
 	}).Error; err != nil {
 		tx.Rollback()
 		return err
@@ -72,6 +79,20 @@ func UpdateDestination(id string, updated *models.Destination) error {
 	return tx.Commit().Error
 }
 
-func DeleteDestination(id string) error {
-	return database.DB.Delete(&models.Destination{}, "id = ?", id).Error
+// PatchDestination writes the columns produced by a merge patch if the
+// destination is still at the given version.
+func PatchDestination(id string, version int, columns map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Destination{}, id, version); err != nil {
+			return err
+		}
+		return tx.Model(&models.Destination{}).Where("id = ?", id).Updates(columns).Error
+	})
+}
+
+func DeleteDestination(id string, version int) error {
+	if err := deleteVersioned(database.DB, &models.Destination{}, id, version); err != nil {
+		return err
+	}
+	return deleteFavoritesOf(models.FavoriteDestination, id)
 }
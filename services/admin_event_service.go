@@ -3,17 +3,19 @@ package services
 import (
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func GetAllEvents() ([]models.Event, error) {
 	var events []models.Event
-	err := database.DB.Preload("Gallery").Preload("Schedule").Find(&events).Error
+	err := database.DB.Find(&events).Error
 	return events, err
 }
 
 func GetEventByID(id string) (*models.Event, error) {
 	var event models.Event
-	err := database.DB.Preload("Gallery").Preload("Schedule").First(&event, "id = ?", id).Error
+	err := database.DB.Preload("CancellationPolicy").First(&event, "id = ?", id).Error
 	return &event, err
 }
 
@@ -21,10 +23,45 @@ func CreateEvent(event *models.Event) error {
 	return database.DB.Create(event).Error
 }
 
-func UpdateEvent(id string, updated *models.Event) error {
-	return database.DB.Model(&models.Event{}).Where("id = ?", id).Updates(updated).Error
+func UpdateEvent(id string, version int, updated *models.Event) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Event{}, id, version); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Event{}).Where("id = ?", id).Updates(updated).Error; err != nil {
+			return err
+		}
+		return offerEventWaitlist(tx, id)
+	})
 }
 
-func DeleteEvent(id string) error {
-	return database.DB.Delete(&models.Event{}, "id = ?", id).Error
+// PatchEvent writes the columns produced by a merge patch if the event is
+// still at the given version.
+func PatchEvent(id string, version int, columns map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Event{}, id, version); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Event{}).Where("id = ?", id).Updates(columns).Error; err != nil {
+			return err
+		}
+		return offerEventWaitlist(tx, id)
+	})
+}
+
+// offerEventWaitlist offers tickets an update made available to the
+// event's waitlist.
+func offerEventWaitlist(tx *gorm.DB, id string) error {
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	return offerWaitlist(tx, models.WaitlistEvent, eventID)
+}
+
+func DeleteEvent(id string, version int) error {
+	if err := deleteVersioned(database.DB, &models.Event{}, id, version); err != nil {
+		return err
+	}
+	return deleteFavoritesOf(models.FavoriteEvent, id)
 }
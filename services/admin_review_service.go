@@ -1,8 +1,10 @@
 package services
 
 import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"gorm.io/gorm"
 )
 
 func GetAllReviews() ([]models.Review, error) {
@@ -13,7 +15,7 @@ func GetAllReviews() ([]models.Review, error) {
 
 func GetReviewByID(id string) (*models.Review, error) {
 	var review models.Review
-	err := database.DB.First(&review, "id = ?", id).Error
+	err := database.DB.Preload("Reply").Preload("Photos").First(&review, "id = ?", id).Error
 	return &review, err
 }
 
@@ -29,86 +31,108 @@ func DeleteReview(id string) error {
 	return database.DB.Delete(&models.Review{}, "id = ?", id).Error
 }
 
-// CreateTourReview creates a review for a tour and updates the tour's rating
-func CreateTourReview(review *models.Review) error {
-	// Start a transaction
-	tx := database.DB.Begin()
-
-	// Create the review
-	if err := tx.Create(review).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Update tour rating
-	if err := UpdateTourRating(review.TourID); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return tx.Commit().Error
+// CreateTargetReview creates a review of a tour, event or destination and
+// updates the affected ratings. A user can review a target only once; a tour
+// review is marked verified when the user has a completed booking for it.
+func CreateTargetReview(review *models.Review) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		targets := ratingTargets{}
+		targets.addReview(*review)
+		return withRatings(tx, &targets, func() error {
+			var existing int64
+			if err := tx.Model(&models.Review{}).
+				Where("user_id = ? AND target_type = ? AND target_id = ?", review.UserID, review.TargetType, review.TargetID).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return apperrors.Conflict("You have already reviewed this " + string(review.TargetType))
+			}
+
+			verified, err := isVerifiedReview(tx, *review)
+			if err != nil {
+				return err
+			}
+			review.Verified = verified
+
+			if err := tx.Create(review).Error; err != nil {
+				return apperrors.FromDB(err, "Review")
+			}
+			return nil
+		})
+	})
 }
 
-// UpdateTourReview updates a review and recalculates the tour's rating
+// UpdateTourReview updates a review and recalculates the rating of the
+// target it belongs to, and of the target it was moved away from if that
+// changed.
 func UpdateTourReview(id string, updated *models.Review) error {
-	// Start a transaction
-	tx := database.DB.Begin()
-
-	// Get the existing review to get the tour ID
-	var existingReview models.Review
-	if err := tx.First(&existingReview, "id = ?", id).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Update the review
-	if err := tx.Model(&models.Review{}).Where("id = ?", id).Updates(updated).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Update tour rating
-	if err := UpdateTourRating(existingReview.TourID); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	return tx.Commit().Error
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var existingReview models.Review
+		if err := tx.First(&existingReview, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		targets := ratingTargets{}
+		targets.addReview(existingReview)
+		targets.addReview(*updated)
+		return withRatings(tx, &targets, func() error {
+			if err := tx.Model(&models.Review{}).Where("id = ?", id).Updates(updated).Error; err != nil {
+				return apperrors.FromDB(err, "Review")
+			}
+			return nil
+		})
+	})
 }
 
-// DeleteTourReview deletes a review and updates the tour's rating
+// DeleteTourReview deletes a review and updates the target's rating
 func DeleteTourReview(id string) error {
-	// Start a transaction
-	tx := database.DB.Begin()
-
-	// Get the existing review to get the tour ID
-	var existingReview models.Review
-	if err := tx.First(&existingReview, "id = ?", id).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var existingReview models.Review
+		if err := tx.First(&existingReview, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		targets := ratingTargets{}
+		targets.addReview(existingReview)
+		return withRatings(tx, &targets, func() error {
+			if err := deleteReviewDependents(tx, existingReview.ID); err != nil {
+				return err
+			}
+			return tx.Delete(&models.Review{}, "id = ?", id).Error
+		})
+	})
+}
 
-	// Delete the review
-	if err := tx.Delete(&models.Review{}, "id = ?", id).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
+// ReviewSortOrders maps the sort options of the public review list to
+// their ORDER BY clauses.
+var ReviewSortOrders = map[string]string{
+	"newest":     "created_at DESC",
+	"helpful":    "helpful_count DESC, created_at DESC",
+	"rating":     "rating DESC, created_at DESC",
+	"rating_asc": "rating ASC, created_at DESC",
+}
 
-	// Update tour rating
-	if err := UpdateTourRating(existingReview.TourID); err != nil {
-		tx.Rollback()
-		return err
+// GetTargetReviews returns the approved reviews of a tour, event or
+// destination in the given sort order, newest first by default.
+func GetTargetReviews(targetType models.ReviewTargetType, targetID string, sort string) ([]models.Review, error) {
+	if sort == "" {
+		sort = "newest"
+	}
+	order, ok := ReviewSortOrders[sort]
+	if !ok {
+		return nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "sort",
+			Message: "must be one of: newest, helpful, rating, rating_asc",
+		})
 	}
 
-	return tx.Commit().Error
-}
-
-// GetTourReviews returns all reviews for a specific tour
-func GetTourReviews(tourID string) ([]models.Review, error) {
 	var reviews []models.Review
-	err := database.DB.Where("tour_id = ?", tourID).
+	err := database.DB.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReviewApproved).
 		Preload("User").
-		Order("created_at DESC").
+		Preload("Reply").
+		Preload("Photos").
+		Order(order).
 		Find(&reviews).Error
 	return reviews, err
 }
@@ -1,16 +1,24 @@
 package services
 
 import (
-	"time"
+	"math"
+	"strconv"
+	"strings"
 
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/garrettladley/fiberpaginate/v2"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func GetAllTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
+	order, err := tourOrder(c)
+	if err != nil {
+		return nil, 0, err
+	}
 	var tours []models.Tour
 	var totalCount int64
 
@@ -25,31 +33,114 @@ func GetAllTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
 	}
 	database.DB.Model(&models.Tour{}).Count(&totalCount)
 	// err := database.DB.Preload("Gallery").Preload("Itinerary").Find(&tours).Error
-	err := database.DB.Offset(pageInfo.Start()).
-		Limit(pageInfo.Limit).Preload("User").Preload("Destination").Preload("CoverImage").Order("created_at DESC").Find(&tours).Error
-	return tours, totalCount, err
+	err = database.DB.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).Preload("User").Preload("Destination").Preload("CoverImage").Order(order).Find(&tours).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return tours, totalCount, loadNextDepartures(tours)
 }
 
 func GetTourByID(id string) (*models.Tour, error) {
 	var tour models.Tour
-	err := database.DB.Preload("User").Preload("Destination").Preload("CoverImage").First(&tour, "id = ?", id).Error
-	return &tour, err
+	err := database.DB.Preload("User").Preload("Destination").Preload("CoverImage").Preload("CancellationPolicy").
+		First(&tour, "id = ?", id).Error
+	if err != nil {
+		return &tour, err
+	}
+	tours := []models.Tour{tour}
+	err = loadNextDepartures(tours)
+	return &tours[0], err
 }
 
-func CreateTour(tour *models.Tour) error {
-	return database.DB.Create(tour).Error
+// CreateTour creates a tour, with its first departure when one is given.
+func CreateTour(tour *models.Tour, firstDeparture *models.TourDeparture) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tour).Error; err != nil {
+			return err
+		}
+		if firstDeparture == nil {
+			return nil
+		}
+		return tx.Create(firstDeparture).Error
+	})
 }
 
-func UpdateTour(id string, updated *models.Tour) error {
-	return database.DB.Model(&models.Tour{}).Where("id = ?", id).Updates(updated).Error
+// UpdateTour replaces the editable fields of a tour if it is still at the
+// given version. The columns are selected explicitly so zero values such as
+// IsFeatured=false are written too.
+func UpdateTour(id string, version int, updated *models.Tour) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Tour{}, id, version); err != nil {
+			return err
+		}
+		return tx.Model(&models.Tour{}).Where("id = ?", id).
+			Select("title", "destination_id", "category", "description", "about", "duration_days", "default_capacity",
+				"price_per_person_minor", "currency", "is_featured", "cancellation_policy_id", "vendor_id", "participant_fields",
+				"meeting_point", "updated_by", "CoverImage").
+			Updates(updated).Error
+	})
 }
 
-func DeleteTour(id string) error {
-	return database.DB.Delete(&models.Tour{}, "id = ?", id).Error
+// PatchTour writes the columns produced by a merge patch if the tour is still
+// at the given version.
+func PatchTour(id string, version int, columns map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := bumpVersion(tx, &models.Tour{}, id, version); err != nil {
+			return err
+		}
+		return tx.Model(&models.Tour{}).Where("id = ?", id).Updates(columns).Error
+	})
+}
+
+// DeleteTour removes a tour nobody holds a booking for; its departures go
+// with it, so booked departures must be cancelled first.
+func DeleteTour(id string, version int) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var bookings int64
+		err := tx.Model(&models.Booking{}).
+			Where("tour_id = ? AND status NOT IN ?", id, []models.BookingStatus{models.BookingCancelled, models.BookingExpired}).
+			Count(&bookings).Error
+		if err != nil {
+			return err
+		}
+		if bookings > 0 {
+			return apperrors.Conflict("Tour has bookings; cancel its departures instead")
+		}
+		return deleteVersioned(tx, &models.Tour{}, id, version)
+	})
+	if err != nil {
+		return err
+	}
+	return deleteFavoritesOf(models.FavoriteTour, id)
+}
+
+// TourSortOrders maps the sort options of the public tour lists to their
+// ORDER BY clauses.
+var TourSortOrders = map[string]string{
+	"newest":  "created_at DESC",
+	"popular": "favorite_count DESC, review_count DESC, created_at DESC",
+}
+
+// tourOrder returns the ORDER BY clause for ?sort=, newest first by
+// default.
+func tourOrder(c *fiber.Ctx) (string, error) {
+	order, ok := TourSortOrders[c.Query("sort", "newest")]
+	if !ok {
+		return "", apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "sort",
+			Message: "must be one of: newest, popular",
+		})
+	}
+	return order, nil
 }
 
 // GetFeaturedTours returns tours marked as featured (paginated)
 func GetFeaturedTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
+	order, err := tourOrder(c)
+	if err != nil {
+		return nil, 0, err
+	}
 	var tours []models.Tour
 	var totalCount int64
 
@@ -67,35 +158,27 @@ func GetFeaturedTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
 	database.DB.Model(&models.Tour{}).Where("is_featured = ?", true).Count(&totalCount)
 
 	// Get featured tours with pagination
-	err := database.DB.Where("is_featured = ?", true).
+	err = database.DB.Where("is_featured = ?", true).
 		Offset(pageInfo.Start()).
 		Limit(pageInfo.Limit).
 		Preload("User").
 		Preload("Destination").
 		Preload("CoverImage").
-		Order("created_at DESC").
+		Order(order).
 		Find(&tours).Error
 
-	return tours, totalCount, err
+	if err != nil {
+		return nil, 0, err
+	}
+	return tours, totalCount, loadNextDepartures(tours)
 }
 
-// func UpdateTour(id string, updated *models.Tour) error {
-// 	return database.DB.Model(&models.Tour{}).Where("id = ?", id).Updates(map[string]interface{}{
-// 		"title":            updated.Title,
-// 		"destination_id":   updated.DestinationID,
-// 		"category":         updated.Category,
-// 		"description":      updated.Description,
-// 		"about":            updated.About,
-// 		"start_date":       updated.StartDate,
-// 		"end_date":         updated.EndDate,
-// 		"price_per_person": updated.PricePerPerson,
-// 		"currency":         updated.Currency,
-// 		"is_featured":      updated.IsFeatured,
-// 	}).Error
-// }
-
 // GetFilteredTours returns tours based on various filter criteria
 func GetFilteredTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
+	order, err := tourOrder(c)
+	if err != nil {
+		return nil, 0, err
+	}
 	var tours []models.Tour
 	var totalCount int64
 
@@ -111,9 +194,10 @@ func GetFilteredTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
 	// Build query with filters
 	query := database.DB.Model(&models.Tour{})
 
-	// Filter by upcoming tours if requested
+	// Filter by tours with a bookable departure if requested
 	if c.Query("upcoming") == "true" {
-		query = query.Where("start_date > ?", time.Now())
+		query = query.Where("EXISTS (?)", bookableDepartures(database.DB.Model(&models.TourDeparture{})).
+			Select("1").Where("tour_departures.tour_id = tours.id"))
 	}
 
 	// Filter by featured tours if requested
@@ -131,53 +215,40 @@ func GetFilteredTours(c *fiber.Ctx) ([]models.Tour, int64, error) {
 		query = query.Where("category = ?", categoryID)
 	}
 
-	// Filter by price range if provided
-	if minPrice := c.Query("min_price"); minPrice != "" {
-		query = query.Where("price_per_person >= ?", minPrice)
+	// Filter by price range if provided, in the requested currency
+	minPrice, err := priceQuery(c, "min_price")
+	if err != nil {
+		return nil, 0, err
+	}
+	maxPrice, err := priceQuery(c, "max_price")
+	if err != nil {
+		return nil, 0, err
 	}
-	if maxPrice := c.Query("max_price"); maxPrice != "" {
-		query = query.Where("price_per_person <= ?", maxPrice)
+	if minPrice != nil || maxPrice != nil {
+		currency := strings.ToUpper(c.Query("currency", config.BaseCurrency))
+		condition, args, err := priceRangeFilter(currency, minPrice, maxPrice)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where(condition, args...)
 	}
 
 	// Count total records matching filters
 	query.Count(&totalCount)
 
 	// Get filtered tours with pagination
-	err := query.Offset(pageInfo.Start()).
+	err = query.Offset(pageInfo.Start()).
 		Limit(pageInfo.Limit).
 		Preload("User").
 		Preload("Destination").
 		Preload("CoverImage").
-		Order("created_at DESC").
+		Order(order).
 		Find(&tours).Error
 
-	return tours, totalCount, err
-}
-
-// UpdateTourRating calculates and updates the average rating and review count for a tour
-func UpdateTourRating(tourID uuid.UUID) error {
-	var result struct {
-		AverageRating float64
-		ReviewCount   int64
-	}
-
-	// Calculate average rating and count from reviews
-	err := database.DB.Model(&models.Review{}).
-		Select("AVG(rating) as average_rating, COUNT(*) as review_count").
-		Where("tour_id = ?", tourID).
-		Scan(&result).Error
-
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
-
-	// Update the tour with the new rating information
-	return database.DB.Model(&models.Tour{}).
-		Where("id = ?", tourID).
-		Updates(map[string]interface{}{
-			"average_rating": result.AverageRating,
-			"review_count":   result.ReviewCount,
-		}).Error
+	return tours, totalCount, loadNextDepartures(tours)
 }
 
 // CreateTourWithReview creates a tour and optionally adds a review
@@ -193,14 +264,13 @@ func CreateTourWithReview(tour *models.Tour, review *models.Review) error {
 
 	// If review is provided, create it
 	if review != nil {
-		review.TourID = tour.ID
-		if err := tx.Create(review).Error; err != nil {
-			tx.Rollback()
-			return err
-		}
-
-		// Update tour rating
-		if err := UpdateTourRating(tour.ID); err != nil {
+		review.TargetType = models.ReviewTargetTour
+		review.TargetID = tour.ID
+		targets := ratingTargets{}
+		targets.addReview(*review)
+		if err := withRatings(tx, &targets, func() error {
+			return tx.Create(review).Error
+		}); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -208,3 +278,19 @@ func CreateTourWithReview(tour *models.Tour, review *models.Review) error {
 
 	return tx.Commit().Error
 }
+
+// priceQuery parses an optional non-negative decimal query parameter.
+func priceQuery(c *fiber.Ctx, name string) (*float64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   name,
+			Message: "must be a non-negative number",
+		})
+	}
+	return &value, nil
+}
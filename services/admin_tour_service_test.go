@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDeleteTourWithBookingsConflicts(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t)
+	tour, departure := createTestDeparture(t, time.Now().AddDate(0, 1, 0))
+	booking := createTestBooking(t, user, tour, departure)
+
+	err := DeleteTour(tour.ID.String(), tour.Version)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Status != fiber.StatusConflict {
+		t.Fatalf("DeleteTour with a pending booking = %v, want a conflict", err)
+	}
+	var departures int64
+	database.DB.Model(&models.TourDeparture{}).Where("tour_id = ?", tour.ID).Count(&departures)
+	if departures != 1 {
+		t.Fatalf("departures left = %d, want 1", departures)
+	}
+
+	if _, err := CancelBooking(user.ID, booking.ID, "Changed plans"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteTour(tour.ID.String(), tour.Version); err != nil {
+		t.Fatalf("DeleteTour after cancelling = %v", err)
+	}
+}
@@ -46,6 +46,11 @@ func UpdateUser(id string, updates *models.User) error {
 		Updates(updates).Error
 }
 
+// PatchUser writes the columns produced by a merge patch.
+func PatchUser(id string, columns map[string]interface{}) error {
+	return database.DB.Model(&models.User{}).Where("id = ?", id).Updates(columns).Error
+}
+
 func DeleteUser(id string) error {
 	return database.DB.Delete(&models.User{}, "id = ?", id).Error
 }
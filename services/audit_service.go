@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// auditEntity is a model snapshotted before and after an audited write,
+// looked up by column (the primary key "id" unless set).
+type auditEntity struct {
+	model  func() interface{}
+	column string
+}
+
+// auditEntities maps the audited entity type to the model that is
+// snapshotted before and after a write.
+var auditEntities = map[string]auditEntity{
+	"tours":                 {model: func() interface{} { return &models.Tour{} }},
+	"departures":            {model: func() interface{} { return &models.TourDeparture{} }},
+	"pricing-rules":         {model: func() interface{} { return &models.PricingRule{} }},
+	"events":                {model: func() interface{} { return &models.Event{} }},
+	"destinations":          {model: func() interface{} { return &models.Destination{} }},
+	"categories":            {model: func() interface{} { return &models.Category{} }},
+	"reviews":               {model: func() interface{} { return &models.Review{} }},
+	"review-replies":        {model: func() interface{} { return &models.ReviewReply{} }, column: "review_id"},
+	"bookings":              {model: func() interface{} { return &models.Booking{} }},
+	"payments":              {model: func() interface{} { return &models.Payment{} }},
+	"refunds":               {model: func() interface{} { return &models.PaymentRefund{} }},
+	"webhooks":              {model: func() interface{} { return &models.WebhookEvent{} }},
+	"promotions":            {model: func() interface{} { return &models.Promotion{} }},
+	"cancellation-policies": {model: func() interface{} { return &models.CancellationPolicy{} }},
+	"exchange-rates":        {model: func() interface{} { return &models.ExchangeRate{} }, column: "currency"},
+	"users":                 {model: func() interface{} { return &models.User{} }},
+	"data-exports":          {model: func() interface{} { return &models.DataExport{} }},
+}
+
+// SnapshotEntity loads an entity and returns its JSON representation as a map.
+// Unknown entity types and missing rows yield a nil snapshot.
+func SnapshotEntity(entityType, id string) (map[string]interface{}, error) {
+	audited, ok := auditEntities[entityType]
+	if !ok || id == "" {
+		return nil, nil
+	}
+	column := audited.column
+	if column == "" {
+		column = "id"
+	}
+
+	entity := audited.model()
+	if err := database.DB.First(entity, column+" = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot map[string]interface{}
+	err = json.Unmarshal(raw, &snapshot)
+	return snapshot, err
+}
+
+// DiffSnapshots returns the fields whose values differ between two snapshots.
+func DiffSnapshots(before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := make(map[string]models.AuditChange)
+	for key, old := range before {
+		if updated, ok := after[key]; !ok || !reflect.DeepEqual(old, updated) {
+			changes[key] = models.AuditChange{Before: old, After: after[key]}
+		}
+	}
+	for key, updated := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = models.AuditChange{Before: nil, After: updated}
+		}
+	}
+	// Bookkeeping columns change on every write and only add noise
+	delete(changes, "updatedAt")
+	return changes
+}
+
+// RecordAudit stores an audit entry with the given changes.
+func RecordAudit(entry *models.AuditLog, changes map[string]models.AuditChange) error {
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	entry.Changes = string(raw)
+	return database.DB.Create(entry).Error
+}
+
+// GetAuditLogs returns audit entries matching the query filters (paginated)
+func GetAuditLogs(c *fiber.Ctx) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := filterAuditLogs(c, database.DB.Model(&models.AuditLog{}))
+	query.Count(&totalCount)
+
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&logs).Error
+	return logs, totalCount, err
+}
+
+// ExportAuditLogs returns every audit entry matching the query filters
+func ExportAuditLogs(c *fiber.Ctx) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := filterAuditLogs(c, database.DB.Model(&models.AuditLog{})).
+		Order("created_at DESC").
+		Find(&logs).Error
+	return logs, err
+}
+
+func filterAuditLogs(c *fiber.Ctx, query *gorm.DB) *gorm.DB {
+	if actorID := c.Query("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if from, ok := parseAuditTime(c.Query("from"), false); ok {
+		query = query.Where("created_at >= ?", from)
+	}
+	if to, ok := parseAuditTime(c.Query("to"), true); ok {
+		query = query.Where("created_at <= ?", to)
+	}
+	return query
+}
+
+// parseAuditTime accepts either RFC 3339 timestamps or plain dates. A plain
+// date used as an upper bound covers the whole day.
+func parseAuditTime(value string, endOfDay bool) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
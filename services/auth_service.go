@@ -4,14 +4,13 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
 	"github.com/Twisac-Solutions/tours-backend/blacklist"
-	"github.com/Twisac-Solutions/tours-backend/config"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/requests"
 	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -35,40 +34,32 @@ type UserResponse struct {
 	ProfilePicture string `json:"profile_picture,omitempty"`
 }
 
-type RegisterRequest struct {
-	Email    string `json:"email"`
-	Name     string `json:"name"`
-	Password string `json:"password"`
-}
-
-type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-}
-
 // Register godoc
 // @Summary Register a new user
 // @Description Create a new user account with auto-generated username
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param registerRequest body RegisterRequest true "Register Request"
+// @Param registerRequest body requests.RegisterRequest true "Register Request"
 // @Success 201 {object} AuthResponse
-// @Failure 400 {object} AuthResponse
-// @Failure 500 {object} AuthResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/register [post]
 func Register(c *fiber.Ctx) error {
-	var req RegisterRequest
+	var req requests.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+		return apperrors.BadRequest("Invalid payload")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
 	// Check email uniqueness
 	var existing models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&existing).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "email already exists"})
+		return apperrors.Conflict("Email already exists")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "db error"})
+		return apperrors.Internal("Failed to check email", err)
 	}
 
 	// Create user
@@ -82,10 +73,13 @@ func Register(c *fiber.Ctx) error {
 		IsVerified: false,
 	}
 	if err := database.DB.Create(&newUser).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not create user"})
+		return apperrors.FromDB(err, "User")
 	}
 
-	token, _ := utils.GenerateJWT(newUser.ID.String())
+	token, err := startSession(c, newUser.ID)
+	if err != nil {
+		return apperrors.Internal("Could not create token", err)
+	}
 	return c.Status(201).JSON(fiber.Map{"token": token, "user": &UserResponse{
 		ID:       newUser.ID.String(),
 		Email:    newUser.Email,
@@ -101,31 +95,31 @@ func Register(c *fiber.Ctx) error {
 // @Tags Auth
 // @Accept json
 // @Produce json
-// @Param loginRequest body LoginRequest true "Login Request"
+// @Param loginRequest body requests.LoginRequest true "Login Request"
 // @Success 200 {object} AuthResponse
-// @Failure 400 {object} AuthResponse
-// @Failure 401 {object} AuthResponse
-// @Failure 500 {object} AuthResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /api/login [post]
 func Login(c *fiber.Ctx) error {
-	var req LoginRequest
+	var req requests.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid input",
-		})
+		return apperrors.BadRequest("Invalid input")
+	}
+	if err := requests.Validate(&req); err != nil {
+		return err
 	}
 
 	user, err := FindUserByEmail(req.Email)
 
 	if err != nil || !utils.CheckPasswordHash(req.Password, user.Password) {
-		return c.Status(fiber.StatusUnauthorized).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid credentials",
-		})
+		return apperrors.Unauthorized("Invalid credentials")
 	}
 
-	token, _ := utils.GenerateJWTRole(user.ID.String(), "User")
+	token, err := startSession(c, user.ID)
+	if err != nil {
+		return apperrors.Internal("Could not create token", err)
+	}
 	return c.JSON(AuthResponse{
 		Status:  "success",
 		Message: "Login successful",
@@ -141,15 +135,6 @@ func Login(c *fiber.Ctx) error {
 	})
 }
 
-func generateJWT(userID uuid.UUID) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.JWTSecret))
-}
-
 func GoogleLogin(c *fiber.Ctx) error {
 	url := utils.GetGoogleOAuthURL()
 	return c.Redirect(url, fiber.StatusTemporaryRedirect)
@@ -159,13 +144,13 @@ func GoogleLogin(c *fiber.Ctx) error {
 func GoogleCallback(c *fiber.Ctx) error {
 	code := c.Query("code")
 	if code == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Code not found"})
+		return apperrors.BadRequest("Code not found")
 	}
 
 	// Exchange the code for an access token and fetch user info.
 	userInfo, err := utils.GetGoogleUserInfo(code)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return apperrors.Internal("Failed to fetch Google user info", err)
 	}
 
 	// Check if a user with this email exists.
@@ -181,9 +166,9 @@ func GoogleCallback(c *fiber.Ctx) error {
 		database.DB.Create(&user)
 	}
 
-	token, err := generateJWT(user.ID)
+	token, err := startSession(c, user.ID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create token"})
+		return apperrors.Internal("Could not create token", err)
 	}
 
 	return c.JSON(fiber.Map{"token": token, "user": user})
@@ -196,56 +181,39 @@ func GoogleCallback(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Success 200 {object} AuthResponse
-// @Failure 401 {object} AuthResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Router /api/logout [post]
 func Logout(c *fiber.Ctx) error {
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Authorization header not found",
-		})
+		return apperrors.BadRequest("Authorization header not found")
 	}
 
 	// Expect token in format "Bearer <token>"
 	const bearerPrefix = "Bearer "
 	if len(authHeader) <= len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid authorization header",
-		})
+		return apperrors.BadRequest("Invalid authorization header")
 	}
 	tokenStr := authHeader[len(bearerPrefix):]
 
-	// Parse token to extract expiration (using the same secret).
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.JWTSecret), nil
-	})
-	if err != nil || !token.Valid {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid token",
-		})
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid token claims",
-		})
+	// Parse token to extract expiration (with the secret it was signed with).
+	claims, err := utils.ParseJWT(tokenStr)
+	if err != nil {
+		return apperrors.BadRequest(err.Error())
 	}
 	expFloat, ok := claims["exp"].(float64)
 	if !ok {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Status:  "error",
-			Message: "Invalid expiration time",
-		})
+		return apperrors.BadRequest("Invalid expiration time")
 	}
 	expirationTime := time.Unix(int64(expFloat), 0)
 
 	// Add token to blacklist.
 	blacklist.Add(tokenStr, expirationTime)
+	if sessionID, ok := claims["sid"].(string); ok {
+		if err := revokeSession(sessionID); err != nil {
+			return apperrors.Internal("Failed to end session", err)
+		}
+	}
 
 	return c.JSON(AuthResponse{
 		Status:  "success",
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxAvailabilityDays is the longest calendar one request can ask for.
+	maxAvailabilityDays = 366
+	// defaultAvailabilityDays is the calendar length when to isn't given.
+	defaultAvailabilityDays = 31
+	// limitedSeats is how few seats left make a day "limited".
+	limitedSeats = 5
+)
+
+// calendarDeparture is the slice of a departure and its tour a calendar
+// needs.
+type calendarDeparture struct {
+	ID                  uuid.UUID
+	TourID              uuid.UUID
+	StartDate           time.Time
+	Capacity            int
+	SeatsBooked         int
+	PriceOverrideMinor  *int64
+	Status              models.DepartureStatus
+	Currency            string
+	PricePerPersonMinor int64
+}
+
+// GetTourAvailability returns the day-by-day calendar of a tour from from to
+// to (both calendar days, defaulting to today and a month later), with each
+// day's departures. Seats come from the departures' booked-seat counters,
+// so the calendar costs two indexed queries whatever its length.
+func GetTourAvailability(tourID uuid.UUID, from, to *time.Time) (*models.Availability, error) {
+	start, end, err := availabilityRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var tour models.Tour
+	if err := database.DB.Select("id", "currency", "price_per_person_minor").First(&tour, "id = ?", tourID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Tour")
+	}
+
+	departures, err := calendarDepartures(start, end, "tour_departures.tour_id = ?", tourID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := seasonRules([]uuid.UUID{tourID})
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := newAvailabilityCalendar(start, end, true)
+	for _, departure := range departures {
+		fare := calendarFare(departure, rules)
+		calendar.add(departure, &fare)
+	}
+	return &models.Availability{
+		TourID:   &tourID,
+		Currency: tour.Currency,
+		From:     start.Format(time.DateOnly),
+		To:       end.Format(time.DateOnly),
+		Days:     calendar.days(tour.Currency),
+	}, nil
+}
+
+// GetDestinationAvailability returns the day-by-day calendar of all tours
+// to a destination, with prices converted to currency (default: the base
+// currency). Departures priced in a currency without an exchange rate count
+// towards seats but not prices.
+func GetDestinationAvailability(destinationID uuid.UUID, from, to *time.Time, currency string) (*models.Availability, error) {
+	start, end, err := availabilityRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		currency = config.BaseCurrency
+	}
+	converter, err := NewPriceConverter(currency)
+	if err != nil {
+		return nil, err
+	}
+	var destination models.Destination
+	if err := database.DB.Select("id").First(&destination, "id = ?", destinationID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Destination")
+	}
+
+	departures, err := calendarDepartures(start, end, "tours.destination_id = ?", destinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tourIDs []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, departure := range departures {
+		if !seen[departure.TourID] {
+			seen[departure.TourID] = true
+			tourIDs = append(tourIDs, departure.TourID)
+		}
+	}
+	rules, err := seasonRules(tourIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := newAvailabilityCalendar(start, end, false)
+	for _, departure := range departures {
+		var price *int64
+		if amount, ok := converter.Convert(calendarFare(departure, rules), departure.Currency); ok {
+			price = &amount
+		}
+		calendar.add(departure, price)
+	}
+	return &models.Availability{
+		DestinationID: &destinationID,
+		Currency:      converter.Currency,
+		From:          start.Format(time.DateOnly),
+		To:            end.Format(time.DateOnly),
+		Days:          calendar.days(converter.Currency),
+	}, nil
+}
+
+// availabilityRange checks a calendar range and fills in its defaults.
+func availabilityRange(from, to *time.Time) (time.Time, time.Time, error) {
+	start := calendarDay(time.Now())
+	if from != nil {
+		start = calendarDay(*from)
+	}
+	end := start.AddDate(0, 0, defaultAvailabilityDays-1)
+	if to != nil {
+		end = calendarDay(*to)
+	}
+	if end.Before(start) {
+		return start, end, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "to",
+			Message: "must not be before from",
+		})
+	}
+	if end.Sub(start) >= maxAvailabilityDays*24*time.Hour {
+		return start, end, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "to",
+			Message: fmt.Sprintf("must be at most %d days after from", maxAvailabilityDays-1),
+		})
+	}
+	return start, end, nil
+}
+
+// calendarDepartures loads the departures matching a condition that start
+// between two calendar days, using the start date index.
+func calendarDepartures(start, end time.Time, condition string, args ...interface{}) ([]calendarDeparture, error) {
+	var departures []calendarDeparture
+	err := database.DB.Model(&models.TourDeparture{}).
+		Select("tour_departures.id, tour_departures.tour_id, tour_departures.start_date, tour_departures.capacity, "+
+			"tour_departures.seats_booked, tour_departures.price_override_minor, tour_departures.status, "+
+			"tours.currency, tours.price_per_person_minor").
+		Joins("JOIN tours ON tours.id = tour_departures.tour_id").
+		Where(condition, args...).
+		Where("tour_departures.start_date >= ? AND tour_departures.start_date < ?", start, end.AddDate(0, 0, 1)).
+		Order("tour_departures.start_date").
+		Scan(&departures).Error
+	return departures, err
+}
+
+// seasonRules loads the season pricing rules of tours, by tour.
+func seasonRules(tourIDs []uuid.UUID) (map[uuid.UUID][]models.PricingRule, error) {
+	byTour := map[uuid.UUID][]models.PricingRule{}
+	if len(tourIDs) == 0 {
+		return byTour, nil
+	}
+	var rules []models.PricingRule
+	err := database.DB.Where("tour_id IN ? AND kind = ?", tourIDs, models.PricingSeason).Find(&rules).Error
+	for _, rule := range rules {
+		byTour[rule.TourID] = append(byTour[rule.TourID], rule)
+	}
+	return byTour, err
+}
+
+// calendarFare is the adult fare on a departure, as a quote would give it.
+func calendarFare(departure calendarDeparture, rules map[uuid.UUID][]models.PricingRule) int64 {
+	fare, _ := departureFare(
+		&models.Tour{PricePerPersonMinor: departure.PricePerPersonMinor, Currency: departure.Currency},
+		&models.TourDeparture{StartDate: departure.StartDate, PriceOverrideMinor: departure.PriceOverrideMinor},
+		rules[departure.TourID])
+	return fare
+}
+
+// availabilityCalendar sums up departures by day. Tour calendars also list
+// the departures of each day.
+type availabilityCalendar struct {
+	start, end     time.Time
+	today          time.Time
+	listDepartures bool
+	byDay          map[string]*dayTotals
+}
+
+type dayTotals struct {
+	models.DayAvailability
+	active, cancelled int
+	tours             map[uuid.UUID]bool
+	// cheapestFull is the lowest fare among full departures, shown when
+	// every departure of the day is full
+	cheapestFull *int64
+}
+
+func newAvailabilityCalendar(start, end time.Time, listDepartures bool) *availabilityCalendar {
+	return &availabilityCalendar{
+		start:          start,
+		end:            end,
+		today:          calendarDay(time.Now()),
+		listDepartures: listDepartures,
+		byDay:          map[string]*dayTotals{},
+	}
+}
+
+// add counts a departure, whose adult fare is price (nil when unknown), on
+// its day. Departures of past days are left out.
+func (c *availabilityCalendar) add(departure calendarDeparture, price *int64) {
+	day := calendarDay(departure.StartDate)
+	if day.Before(c.today) {
+		return
+	}
+	key := day.Format(time.DateOnly)
+	totals := c.byDay[key]
+	if totals == nil {
+		totals = &dayTotals{tours: map[uuid.UUID]bool{}}
+		c.byDay[key] = totals
+	}
+
+	seatsLeft := max(0, departure.Capacity-departure.SeatsBooked)
+	if departure.Status == models.DepartureCancelled {
+		seatsLeft = 0
+		totals.cancelled++
+	} else {
+		totals.active++
+		totals.SeatsLeft += seatsLeft
+		totals.Capacity += departure.Capacity
+		totals.tours[departure.TourID] = true
+		if departure.Status == models.DepartureGuaranteed {
+			totals.Guaranteed = true
+		}
+		if price != nil {
+			if seatsLeft > 0 && (totals.PriceMinor == nil || *price < *totals.PriceMinor) {
+				totals.PriceMinor = price
+			}
+			if seatsLeft == 0 && (totals.cheapestFull == nil || *price < *totals.cheapestFull) {
+				totals.cheapestFull = price
+			}
+		}
+	}
+	if c.listDepartures {
+		totals.Departures = append(totals.Departures, models.DepartureAvailability{
+			ID:         departure.ID,
+			StartDate:  departure.StartDate,
+			Status:     departure.Status,
+			SeatsLeft:  seatsLeft,
+			PriceMinor: price,
+		})
+	}
+}
+
+// days returns one entry per day of the calendar.
+func (c *availabilityCalendar) days(currency string) []models.DayAvailability {
+	days := make([]models.DayAvailability, 0, int(c.end.Sub(c.start).Hours()/24)+1)
+	for day := c.start; !day.After(c.end); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		totals := c.byDay[key]
+		if totals == nil {
+			days = append(days, models.DayAvailability{Date: key, Status: models.AvailabilityUnavailable})
+			continue
+		}
+
+		entry := totals.DayAvailability
+		entry.Date = key
+		if entry.PriceMinor == nil {
+			entry.PriceMinor = totals.cheapestFull
+		}
+		if entry.PriceMinor != nil {
+			price := utils.FromMinorUnits(*entry.PriceMinor, currency)
+			entry.Price = &price
+		}
+		entry.Tours = len(totals.tours)
+		switch {
+		case totals.active == 0:
+			entry.Status = models.AvailabilityCancelled
+		case entry.SeatsLeft == 0:
+			entry.Status = models.AvailabilitySoldOut
+		case entry.SeatsLeft <= limitedSeats:
+			entry.Status = models.AvailabilityLimited
+		default:
+			entry.Status = models.AvailabilityAvailable
+		}
+		days = append(days, entry)
+	}
+	return days
+}
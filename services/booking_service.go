@@ -0,0 +1,237 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// HasCompletedBooking reports whether the user has a completed booking for
+// the tour.
+func HasCompletedBooking(tx *gorm.DB, userID, tourID uuid.UUID) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Booking{}).
+		Where("user_id = ? AND tour_id = ? AND status = ?", userID, tourID, models.BookingCompleted).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// isVerifiedReview reports whether a review comes from a customer who
+// completed the reviewed tour. Only tour reviews are verified; reviews of
+// events and destinations never are.
+func isVerifiedReview(tx *gorm.DB, review models.Review) (bool, error) {
+	if review.TargetType != models.ReviewTargetTour {
+		return false, nil
+	}
+	return HasCompletedBooking(tx, review.UserID, review.TargetID)
+}
+
+// CreateBooking books a departure of a tour, or tickets for an event, for
+// the user's party. A tour's price comes from its pricing rules and the
+// booking's promotion code, as QuoteTour would give it, and the quote's
+// lines are kept on the booking; the booking waits for payment. The party's
+// seats are reserved and the promotion is redeemed in the same transaction;
+// the seats are held for config.SeatHoldDuration, after which an unpaid
+// booking expires.
+func CreateBooking(booking *models.Booking) error {
+	if booking.EventID != nil {
+		var event models.Event
+		if err := database.DB.First(&event, "id = ?", *booking.EventID).Error; err != nil {
+			return apperrors.FromDB(err, "Event")
+		}
+		return database.DB.Transaction(func(tx *gorm.DB) error {
+			return bookEvent(tx, &event, booking)
+		})
+	}
+
+	var tour models.Tour
+	if err := database.DB.First(&tour, "id = ?", booking.TourID).Error; err != nil {
+		return apperrors.FromDB(err, "Tour")
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return bookTour(tx, &tour, booking)
+	})
+}
+
+// bookTour prices, reserves and stores a booking of the tour in the
+// caller's transaction.
+func bookTour(tx *gorm.DB, tour *models.Tour, booking *models.Booking) error {
+	if !utils.IsCurrencyCode(tour.Currency) {
+		return apperrors.Conflict("Tour has no valid currency and can't be booked")
+	}
+	quote, promotion, err := quoteTour(tx, tour, booking.Party(), booking.DepartureID, booking.TravelDate, booking.PromotionCode, &booking.UserID)
+	if err != nil {
+		return err
+	}
+
+	departure := models.TourDeparture{ID: quote.DepartureID}
+	if err := reserveSeats(tx, &departure, quote.Party.Seats()); err != nil {
+		return err
+	}
+
+	holdExpiresAt := time.Now().Add(config.SeatHoldDuration)
+	booking.Status = models.BookingPending
+	booking.HoldExpiresAt = &holdExpiresAt
+	booking.TourID = &tour.ID
+	booking.DepartureID = &quote.DepartureID
+	booking.Travelers = quote.Party.Travelers()
+	booking.TravelDate = &quote.Date
+	booking.TotalPriceMinor = quote.TotalMinor
+	booking.Currency = quote.Currency
+	booking.PriceLines = quote.Lines
+	booking.PromotionCode = quote.PromotionCode
+	if err := tx.Create(booking).Error; err != nil {
+		return err
+	}
+	if promotion == nil {
+		return nil
+	}
+	discount := -quote.Lines[len(quote.Lines)-1].AmountMinor
+	return redeemPromotion(tx, promotion, booking, discount)
+}
+
+// bookEvent prices, reserves and stores a booking of tickets for the event
+// in the caller's transaction. Adults and children each pay the ticket
+// price; infants come free and take no place.
+func bookEvent(tx *gorm.DB, event *models.Event, booking *models.Booking) error {
+	if !utils.IsCurrencyCode(event.Currency) {
+		return apperrors.Conflict("Event has no valid currency and can't be booked")
+	}
+	party := booking.Party()
+	if err := checkParty(party); err != nil {
+		return err
+	}
+	if event.EventDate.Before(time.Now()) {
+		return apperrors.Conflict("Event has already taken place")
+	}
+	held, err := holdSeats(tx, models.WaitlistEvent, event.ID, party.Seats())
+	if err != nil {
+		return err
+	}
+	if !held {
+		return apperrors.Conflict("Not enough tickets left for your party")
+	}
+
+	holdExpiresAt := time.Now().Add(config.SeatHoldDuration)
+	booking.Status = models.BookingPending
+	booking.HoldExpiresAt = &holdExpiresAt
+	booking.TourID = nil
+	booking.DepartureID = nil
+	booking.EventID = &event.ID
+	booking.Travelers = party.Travelers()
+	booking.TravelDate = &event.EventDate
+	booking.TotalPriceMinor = event.TicketPriceMinor * int64(party.Seats())
+	booking.Currency = event.Currency
+	booking.PriceLines = models.QuoteLines{{
+		Kind:        string(models.PricingFare),
+		Description: "Ticket",
+		Quantity:    party.Seats(),
+		UnitMinor:   event.TicketPriceMinor,
+		AmountMinor: booking.TotalPriceMinor,
+	}}
+	booking.PromotionCode = ""
+	return tx.Create(booking).Error
+}
+
+// completionBatch is how many bookings one completion run handles.
+const completionBatch = 100
+
+// StartBookingCompleter completes the bookings whose trip is over every
+// interval.
+func StartBookingCompleter(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			completed, err := CompleteBookings()
+			if err != nil {
+				log.Printf("booking completer: %v", err)
+				continue
+			}
+			if completed > 0 {
+				log.Printf("booking completer: completed %d bookings", completed)
+			}
+		}
+	}()
+}
+
+// CompleteBookings marks confirmed bookings completed once their departure
+// has ended, or their travel date has passed for bookings without one. The
+// customer's existing review of a completed tour becomes verified. It
+// returns how many bookings were completed.
+func CompleteBookings() (int, error) {
+	now := time.Now()
+	ended := database.DB.Model(&models.TourDeparture{}).Select("id").Where("end_date <= ?", now)
+	var over []models.Booking
+	err := database.DB.
+		Where("status = ?", models.BookingConfirmed).
+		Where(database.DB.Where("departure_id IN (?)", ended).
+			Or("departure_id IS NULL AND travel_date <= ?", now)).
+		Order("travel_date").
+		Limit(completionBatch).
+		Find(&over).Error
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for i := range over {
+		booking := &over[i]
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// A cancellation at the same time wins or loses here.
+			result := tx.Model(&models.Booking{}).
+				Where("id = ? AND status = ?", booking.ID, models.BookingConfirmed).
+				Update("status", models.BookingCompleted)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			completed++
+			if booking.TourID == nil {
+				return nil
+			}
+			return tx.Model(&models.Review{}).
+				Where("user_id = ? AND target_type = ? AND target_id = ? AND NOT verified",
+					booking.UserID, models.ReviewTargetTour, *booking.TourID).
+				Update("verified", true).Error
+		})
+		if err != nil {
+			return completed, err
+		}
+	}
+	return completed, nil
+}
+
+// GetUserBookings returns the user's bookings, newest first.
+func GetUserBookings(userID uuid.UUID) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := database.DB.Where("user_id = ?", userID).
+		Preload("Tour").
+		Preload("Event").
+		Order("created_at DESC").
+		Find(&bookings).Error
+	return bookings, err
+}
+
+// GetUserBooking returns one of the user's bookings with its payments.
+func GetUserBooking(userID, id uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	err := database.DB.Preload("Tour").
+		Preload("Event").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&booking, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Booking")
+	}
+	return &booking, nil
+}
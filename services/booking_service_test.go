@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+)
+
+func TestCompleteBookingsVerifiesReviews(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t)
+	tour, departure := createTestDeparture(t, time.Now().AddDate(0, 0, 7))
+	booking := createTestBooking(t, user, tour, departure)
+	payment, _, err := CreateBookingPayment(user.ID, booking.ID, "pay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConfirmPayment(user.ID, payment.ID, payments.MockCardOK); err != nil {
+		t.Fatal(err)
+	}
+
+	review := &models.Review{UserID: user.ID, TargetType: models.ReviewTargetTour, TargetID: tour.ID, Rating: 5, Comment: "Wonderful trip"}
+	if err := CreateTargetReview(review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Verified {
+		t.Fatal("review of a trip not yet taken is verified")
+	}
+
+	if completed, err := CompleteBookings(); err != nil || completed != 0 {
+		t.Fatalf("CompleteBookings before the trip = %d, %v; want 0", completed, err)
+	}
+
+	// The trip takes place.
+	start := time.Now().AddDate(0, 0, -3)
+	err = database.DB.Model(departure).Updates(map[string]any{"start_date": start, "end_date": start.AddDate(0, 0, 2)}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed, err := CompleteBookings(); err != nil || completed != 1 {
+		t.Fatalf("CompleteBookings after the trip = %d, %v; want 1", completed, err)
+	}
+
+	if got := reloadBooking(t, booking.ID).Status; got != models.BookingCompleted {
+		t.Errorf("booking status = %s, want completed", got)
+	}
+	var reloaded models.Review
+	if err := database.DB.First(&reloaded, "id = ?", review.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Verified {
+		t.Error("existing review was not verified")
+	}
+	if ok, err := HasCompletedBooking(database.DB, user.ID, tour.ID); err != nil || !ok {
+		t.Errorf("HasCompletedBooking = %v, %v; want true", ok, err)
+	}
+}
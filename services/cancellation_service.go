@@ -0,0 +1,406 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCancellationTiers = 10
+	maxCancellationDays  = 365
+)
+
+// GetCancellationPolicies lists cancellation policies by name, optionally
+// only those whose name contains ?name=.
+func GetCancellationPolicies(c *fiber.Ctx) ([]models.CancellationPolicy, int64, error) {
+	var policies []models.CancellationPolicy
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.CancellationPolicy{})
+	if name := c.Query("name"); name != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(name)+"%")
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("name").
+		Find(&policies).Error
+	return policies, totalCount, err
+}
+
+// GetCancellationPolicy returns one cancellation policy.
+func GetCancellationPolicy(id uuid.UUID) (*models.CancellationPolicy, error) {
+	var policy models.CancellationPolicy
+	if err := database.DB.First(&policy, "id = ?", id).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Cancellation policy")
+	}
+	return &policy, nil
+}
+
+// CheckCancellationPolicy makes sure a policy being attached to a tour or
+// event exists.
+func CheckCancellationPolicy(id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	if err := database.DB.Model(&models.CancellationPolicy{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "cancellationPolicyId",
+			Message: "no such cancellation policy",
+		})
+	}
+	return nil
+}
+
+// CreateCancellationPolicy adds a cancellation policy.
+func CreateCancellationPolicy(policy *models.CancellationPolicy) error {
+	if err := checkCancellationTiers(policy); err != nil {
+		return err
+	}
+	return database.DB.Create(policy).Error
+}
+
+// UpdateCancellationPolicy replaces a cancellation policy. Bookings already
+// cancelled keep the refund they got.
+func UpdateCancellationPolicy(policy *models.CancellationPolicy) error {
+	if err := checkCancellationTiers(policy); err != nil {
+		return err
+	}
+	result := database.DB.Model(policy).
+		Where("id = ?", policy.ID).
+		Select("name", "description", "tiers", "updated_by").
+		Updates(policy)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Cancellation policy")
+	}
+	return database.DB.First(policy, "id = ?", policy.ID).Error
+}
+
+// DeleteCancellationPolicy removes a policy no tour or event uses.
+func DeleteCancellationPolicy(id uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var tours, events int64
+		if err := tx.Model(&models.Tour{}).Where("cancellation_policy_id = ?", id).Count(&tours).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Event{}).Where("cancellation_policy_id = ?", id).Count(&events).Error; err != nil {
+			return err
+		}
+		if tours+events > 0 {
+			return apperrors.Conflict(fmt.Sprintf("Cancellation policy is used by %d tours and %d events", tours, events))
+		}
+		result := tx.Delete(&models.CancellationPolicy{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Cancellation policy")
+		}
+		return nil
+	})
+}
+
+// checkCancellationTiers sorts a policy's tiers and enforces the rules the
+// request DTO can't express: each tier has its own number of days, and the
+// refund never grows as departure gets closer.
+func checkCancellationTiers(policy *models.CancellationPolicy) error {
+	var fields []models.FieldError
+	fail := func(i int, field, message string) {
+		fields = append(fields, models.FieldError{Field: fmt.Sprintf("tiers[%d].%s", i, field), Message: message})
+	}
+
+	if len(policy.Tiers) > maxCancellationTiers {
+		fields = append(fields, models.FieldError{
+			Field:   "tiers",
+			Message: fmt.Sprintf("a policy can have at most %d tiers", maxCancellationTiers),
+		})
+	}
+	for i, tier := range policy.Tiers {
+		if tier.DaysBefore < 0 || tier.DaysBefore > maxCancellationDays {
+			fail(i, "daysBefore", fmt.Sprintf("must be between 0 and %d", maxCancellationDays))
+		}
+		if tier.RefundPercent < 0 || tier.RefundPercent > 100 {
+			fail(i, "refundPercent", "must be between 0 and 100")
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+
+	policy.SortTiers()
+	for i := 1; i < len(policy.Tiers); i++ {
+		if policy.Tiers[i].DaysBefore == policy.Tiers[i-1].DaysBefore {
+			fail(i, "daysBefore", "another tier has the same number of days")
+		} else if policy.Tiers[i].RefundPercent > policy.Tiers[i-1].RefundPercent {
+			fail(i, "refundPercent", "can't be more than the refund for cancelling earlier")
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
+
+// QuoteCancellation returns what cancelling one of the user's bookings now
+// would refund.
+func QuoteCancellation(userID, bookingID uuid.UUID) (*models.CancellationQuote, error) {
+	var booking models.Booking
+	if err := database.DB.First(&booking, "id = ? AND user_id = ?", bookingID, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Booking")
+	}
+	if err := checkCancellable(&booking); err != nil {
+		return nil, err
+	}
+	return quoteCancellation(database.DB, &booking, time.Now())
+}
+
+// CancelBooking cancels one of the user's bookings before departure and
+// refunds it as its tour's or event's cancellation policy says.
+func CancelBooking(userID, bookingID uuid.UUID, reason string) (*models.Booking, error) {
+	return cancelBooking(bookingID, &userID, userID, reason, nil)
+}
+
+// AdminCancelBooking cancels any booking that isn't completed, also after
+// departure. The refund follows the tour's or event's cancellation policy
+// unless refundPercent overrides it, e.g. when the operator cancels the
+// departure.
+func AdminCancelBooking(adminID, bookingID uuid.UUID, reason string, refundPercent *int) (*models.Booking, error) {
+	return cancelBooking(bookingID, nil, adminID, reason, refundPercent)
+}
+
+// cancelBooking cancels a booking (the customer's own when customerID is
+// given), frees its seats for the departure's or event's waitlist and
+// refunds it. The cancellation is committed before the refunds are made; a
+// refund the provider refuses is recorded as failed on the payment for an
+// admin to retry, and doesn't undo the cancellation.
+func cancelBooking(bookingID uuid.UUID, customerID *uuid.UUID, actorID uuid.UUID, reason string, refundPercent *int) (*models.Booking, error) {
+	var booking models.Booking
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("id = ?", bookingID)
+		if customerID != nil {
+			query = query.Where("user_id = ?", *customerID)
+		}
+		if err := query.First(&booking).Error; err != nil {
+			return apperrors.FromDB(err, "Booking")
+		}
+		if err := checkCancellable(&booking); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		quote, err := quoteCancellation(tx, &booking, now)
+		if err != nil {
+			return err
+		}
+		if customerID != nil && quote.DaysBeforeDeparture < 0 {
+			return apperrors.Conflict("Departure has already left; contact us to cancel")
+		}
+		refund := quote.RefundMinor
+		if refundPercent != nil {
+			refund = refundShare(quote.PaidMinor, *refundPercent, quote.PaidMinor-quote.RefundedMinor)
+		}
+
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND status IN ?", booking.ID, []models.BookingStatus{models.BookingPending, models.BookingConfirmed}).
+			Updates(map[string]interface{}{
+				"status":              models.BookingCancelled,
+				"cancelled_at":        now,
+				"cancelled_by":        actorID,
+				"cancellation_reason": reason,
+				"refund_minor":        refund,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.Conflict("Booking was changed by another request; try again")
+		}
+
+		// Unfinished payments can no longer be completed.
+		err = tx.Model(&models.Payment{}).
+			Where("booking_id = ? AND status IN ?", booking.ID, []models.PaymentStatus{models.PaymentPending, models.PaymentFailed}).
+			Update("status", models.PaymentCancelled).Error
+		if err != nil {
+			return err
+		}
+		if err := releasePromotion(tx, booking.ID); err != nil {
+			return err
+		}
+		if err := releaseBookingSeats(tx, &booking); err != nil {
+			return err
+		}
+
+		body := "Your booking has been cancelled."
+		if refund > 0 {
+			digits, _ := utils.CurrencyMinorUnits(booking.Currency)
+			body = fmt.Sprintf("Your booking has been cancelled. We are refunding %.*f %s to your original payment method.",
+				digits, utils.FromMinorUnits(refund, booking.Currency), booking.Currency)
+		}
+		return notify(tx, booking.UserID, models.NotificationBookingCancelled,
+			"Booking cancelled", body, "/api/user/bookings/"+booking.ID.String())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var refundedBy *uuid.UUID
+	if customerID == nil {
+		refundedBy = &actorID
+	}
+	if err := refundCancellation(booking.ID, refundedBy); err != nil {
+		log.Printf("booking %s: cancellation refund failed: %v", booking.ID, err)
+	}
+
+	err = database.DB.Preload("Tour").
+		Preload("Event").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Payments.Refunds").
+		First(&booking, "id = ?", booking.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// checkCancellable rejects bookings that are already over.
+func checkCancellable(booking *models.Booking) error {
+	switch booking.Status {
+	case models.BookingCancelled:
+		return apperrors.Conflict("Booking is already cancelled")
+	case models.BookingCompleted:
+		return apperrors.Conflict("Completed bookings can't be cancelled")
+	case models.BookingExpired:
+		return apperrors.Conflict("Booking hold has expired")
+	}
+	return nil
+}
+
+// quoteCancellation works out the refund for cancelling a booking at now:
+// the policy's share of what was paid, less anything already refunded.
+func quoteCancellation(tx *gorm.DB, booking *models.Booking, now time.Time) (*models.CancellationQuote, error) {
+	quote := models.CancellationQuote{
+		BookingID: booking.ID,
+		Currency:  booking.Currency,
+	}
+	if booking.EventID != nil {
+		var event models.Event
+		if err := tx.Preload("CancellationPolicy").First(&event, "id = ?", *booking.EventID).Error; err != nil {
+			return nil, apperrors.FromDB(err, "Event")
+		}
+		quote.Policy = event.CancellationPolicy
+	} else {
+		var tour models.Tour
+		if err := tx.Preload("CancellationPolicy").First(&tour, "id = ?", booking.TourID).Error; err != nil {
+			return nil, apperrors.FromDB(err, "Tour")
+		}
+		quote.Policy = tour.CancellationPolicy
+	}
+	if booking.DepartureID != nil {
+		var departure models.TourDeparture
+		if err := tx.Select("id", "start_date").First(&departure, "id = ?", *booking.DepartureID).Error; err != nil {
+			return nil, apperrors.FromDB(err, "Departure")
+		}
+		quote.DepartureDate = departure.StartDate
+	} else if booking.TravelDate != nil {
+		quote.DepartureDate = *booking.TravelDate
+	}
+	quote.DaysBeforeDeparture = int(calendarDay(quote.DepartureDate).Sub(calendarDay(now)).Hours() / 24)
+
+	policy := quote.Policy
+	if policy == nil {
+		policy = &models.CancellationPolicy{}
+	}
+	quote.PolicyText = policy.Summary()
+	quote.RefundPercent = policy.RefundPercent(quote.DaysBeforeDeparture)
+
+	var paid struct{ Amount, Refunded int64 }
+	err := tx.Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0) AS amount, COALESCE(SUM(refunded_amount), 0) AS refunded").
+		Where("booking_id = ? AND status IN ?", booking.ID, paidStatuses).
+		Scan(&paid).Error
+	if err != nil {
+		return nil, err
+	}
+	quote.PaidMinor = paid.Amount
+	quote.RefundedMinor = paid.Refunded
+	quote.RefundMinor = refundShare(paid.Amount, quote.RefundPercent, paid.Amount-paid.Refunded)
+	quote.Refund = utils.FromMinorUnits(quote.RefundMinor, quote.Currency)
+	return &quote, nil
+}
+
+// refundShare is percent of paid, rounded half up, but no more than left.
+func refundShare(paid int64, percent int, left int64) int64 {
+	share := (paid*int64(percent) + 50) / 100
+	if share > left {
+		share = left
+	}
+	if share < 0 {
+		share = 0
+	}
+	return share
+}
+
+// refundCancellation pays out a cancelled booking's refund across its
+// payments, oldest first. The idempotency key makes a retry return the
+// refunds already made instead of refunding twice.
+func refundCancellation(bookingID uuid.UUID, adminID *uuid.UUID) error {
+	var booking models.Booking
+	if err := database.DB.First(&booking, "id = ?", bookingID).Error; err != nil {
+		return err
+	}
+	var paid []models.Payment
+	err := database.DB.Where("booking_id = ? AND status IN ?", bookingID, paidStatuses).
+		Order("created_at").
+		Find(&paid).Error
+	if err != nil {
+		return err
+	}
+
+	left := booking.RefundMinor
+	for _, payment := range paid {
+		if left <= 0 {
+			break
+		}
+		amount := payment.Amount - payment.RefundedAmount
+		if amount > left {
+			amount = left
+		}
+		if amount <= 0 {
+			continue
+		}
+		if _, err := RefundPayment(payment.ID, amount, "Booking cancelled", "cancellation-"+bookingID.String(), adminID); err != nil {
+			return err
+		}
+		left -= amount
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PriceConverter converts prices into the currency a client asked for. It
+// holds a snapshot of the exchange rates, so one request converts all its
+// prices consistently.
+type PriceConverter struct {
+	Currency string
+	rates    map[string]float64
+}
+
+// NewPriceConverter returns a converter into currency, or nil when currency
+// is empty and prices should be shown as stored.
+func NewPriceConverter(currency string) (*PriceConverter, error) {
+	if currency == "" {
+		return nil, nil
+	}
+	currency = strings.ToUpper(currency)
+	if !utils.IsCurrencyCode(currency) {
+		return nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "currency",
+			Message: "must be an ISO 4217 currency code",
+		})
+	}
+	rates, err := loadExchangeRates()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rates[currency]; !ok {
+		return nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "currency",
+			Message: "has no exchange rate",
+		})
+	}
+	return &PriceConverter{Currency: currency, rates: rates}, nil
+}
+
+// Convert converts an amount in minor units of from into minor units of the
+// converter's currency. It reports false when from has no exchange rate.
+func (p *PriceConverter) Convert(amount int64, from string) (int64, bool) {
+	major, ok := convertMajor(p.rates, utils.FromMinorUnits(amount, from), from, p.Currency)
+	if !ok {
+		return 0, false
+	}
+	return utils.ToMinorUnits(major, p.Currency), true
+}
+
+// convertMajor converts an amount in major units between two currencies
+// through the base currency.
+func convertMajor(rates map[string]float64, amount float64, from, to string) (float64, bool) {
+	fromRate, ok := rates[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := rates[to]
+	if !ok {
+		return 0, false
+	}
+	return amount / fromRate * toRate, true
+}
+
+// loadExchangeRates returns every known rate keyed by currency, including
+// the base currency at 1.
+func loadExchangeRates() (map[string]float64, error) {
+	var rows []models.ExchangeRate
+	if err := database.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(rows)+1)
+	for _, row := range rows {
+		rates[row.Currency] = row.Rate
+	}
+	rates[config.BaseCurrency] = 1
+	return rates, nil
+}
+
+// priceRangeFilter returns a condition matching tours whose price lies
+// between min and max (either may be nil), both given in major units of
+// currency. Each bound is converted into every currency with a known rate,
+// so the comparison uses the price index; tours priced in a currency
+// without a rate can't be compared and don't match.
+func priceRangeFilter(currency string, min, max *float64) (string, []interface{}, error) {
+	rates, err := loadExchangeRates()
+	if err != nil {
+		return "", nil, err
+	}
+	if _, ok := rates[currency]; !ok {
+		return "", nil, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "currency",
+			Message: "has no exchange rate",
+		})
+	}
+
+	currencies := make([]string, 0, len(rates))
+	for code := range rates {
+		currencies = append(currencies, code)
+	}
+	sort.Strings(currencies)
+
+	var conditions []string
+	var args []interface{}
+	for _, code := range currencies {
+		condition := "(currency = ?"
+		args = append(args, code)
+		digits, _ := utils.CurrencyMinorUnits(code)
+		scale := math.Pow10(digits)
+		if min != nil {
+			amount, _ := convertMajor(rates, *min, currency, code)
+			condition += " AND price_per_person_minor >= ?"
+			args = append(args, int64(math.Ceil(amount*scale-1e-6)))
+		}
+		if max != nil {
+			amount, _ := convertMajor(rates, *max, currency, code)
+			condition += " AND price_per_person_minor <= ?"
+			args = append(args, int64(math.Floor(amount*scale+1e-6)))
+		}
+		conditions = append(conditions, condition+")")
+	}
+	return strings.Join(conditions, " OR "), args, nil
+}
+
+// GetExchangeRates lists the stored exchange rates by currency code.
+func GetExchangeRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	err := database.DB.Order("currency").Find(&rates).Error
+	return rates, err
+}
+
+// SetExchangeRate creates or replaces the rate of a currency.
+func SetExchangeRate(currency string, rate float64, adminID *uuid.UUID) (*models.ExchangeRate, error) {
+	currency = strings.ToUpper(currency)
+	if err := checkRateCurrency(currency, "currency"); err != nil {
+		return nil, err
+	}
+	row := models.ExchangeRate{Currency: currency, Rate: rate, Source: "manual", UpdatedBy: adminID}
+	err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+	return &row, err
+}
+
+// DeleteExchangeRate removes the rate of a currency, after which prices can
+// no longer be converted to or from it.
+func DeleteExchangeRate(currency string) error {
+	result := database.DB.Delete(&models.ExchangeRate{}, "currency = ?", strings.ToUpper(currency))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Exchange rate")
+	}
+	return nil
+}
+
+func checkRateCurrency(currency, field string) error {
+	if !utils.IsCurrencyCode(currency) {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   field,
+			Message: "must be an ISO 4217 currency code",
+		})
+	}
+	if currency == config.BaseCurrency {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   field,
+			Message: "is the base currency, whose rate is always 1",
+		})
+	}
+	return nil
+}
+
+// ImportExchangeRates creates or replaces rates from an uploaded file and
+// returns how many were imported. JSON files look like
+// {"base": "EUR", "rates": {"USD": 1.08}}; rates quoted against another base
+// are converted to ours. CSV files hold "currency,rate" rows against our
+// base, optionally with a header. The import is all or nothing.
+func ImportExchangeRates(filename string, data []byte, adminID *uuid.UUID) (int, error) {
+	var rates map[string]float64
+	var err error
+	if strings.EqualFold(filepath.Ext(filename), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		rates, err = parseJSONRates(data)
+	} else {
+		rates, err = parseCSVRates(data)
+	}
+	if err != nil {
+		return 0, apperrors.Validation("Invalid exchange rate file", models.FieldError{
+			Field:   "file",
+			Message: err.Error(),
+		})
+	}
+	if len(rates) == 0 {
+		return 0, apperrors.Validation("Invalid exchange rate file", models.FieldError{
+			Field:   "file",
+			Message: "contains no rates",
+		})
+	}
+
+	rows := make([]models.ExchangeRate, 0, len(rates))
+	for currency, rate := range rates {
+		if currency == config.BaseCurrency {
+			continue
+		}
+		rows = append(rows, models.ExchangeRate{Currency: currency, Rate: rate, Source: "import", UpdatedBy: adminID})
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error
+	})
+	return len(rows), err
+}
+
+func parseJSONRates(data []byte) (map[string]float64, error) {
+	var file struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("is not valid JSON: %v", err)
+	}
+
+	base := strings.ToUpper(file.Base)
+	if base == "" {
+		base = config.BaseCurrency
+	}
+	if !utils.IsCurrencyCode(base) {
+		return nil, fmt.Errorf("base %q is not an ISO 4217 currency code", file.Base)
+	}
+
+	rates := make(map[string]float64, len(file.Rates)+1)
+	for code, rate := range file.Rates {
+		currency := strings.ToUpper(code)
+		if err := checkImportedRate(currency, rate); err != nil {
+			return nil, err
+		}
+		rates[currency] = rate
+	}
+	rates[base] = 1
+
+	// Rebase onto our base currency
+	ours, ok := rates[config.BaseCurrency]
+	if !ok {
+		return nil, fmt.Errorf("rates are quoted against %s but have no rate for %s", base, config.BaseCurrency)
+	}
+	for currency, rate := range rates {
+		rates[currency] = rate / ours
+	}
+	return rates, nil
+}
+
+func parseCSVRates(data []byte) (map[string]float64, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	rates := map[string]float64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(record[0]))
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: rate %q is not a number", line, record[1])
+		}
+		if err := checkImportedRate(currency, rate); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+func checkImportedRate(currency string, rate float64) error {
+	if !utils.IsCurrencyCode(currency) {
+		return fmt.Errorf("%q is not an ISO 4217 currency code", currency)
+	}
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return fmt.Errorf("rate for %s must be a positive number", currency)
+	}
+	return nil
+}
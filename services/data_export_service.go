@@ -0,0 +1,396 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// dataExportBatch caps how many exports one run of the exporter builds.
+	dataExportBatch = 5
+	// dataExportAttempts is how often building an export is tried before
+	// it is marked failed.
+	dataExportAttempts = 3
+)
+
+// exportedBooking is a booking as it appears in a data export, with its
+// payments, travellers and invoice.
+type exportedBooking struct {
+	models.Booking
+	TourTitle  string          `json:"tourTitle,omitempty"`
+	EventTitle string          `json:"eventTitle,omitempty"`
+	Invoice    *models.Invoice `json:"invoice,omitempty"`
+}
+
+// RequestDataExport asks for a ZIP of everything stored about the user,
+// which the exporter builds in the background. Only one export per user is
+// prepared at a time.
+func RequestDataExport(userID, requestedBy uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.AnonymizedAt != nil {
+			return apperrors.Conflict("This account has been deleted")
+		}
+		var pending int64
+		err := tx.Model(&models.DataExport{}).
+			Where("user_id = ? AND status = ?", userID, models.DataExportPending).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return apperrors.Conflict("A data export is already being prepared")
+		}
+		export = models.DataExport{UserID: userID, RequestedBy: requestedBy}
+		return tx.Create(&export).Error
+	})
+	if err != nil {
+		return nil, apperrors.FromDB(err, "User")
+	}
+	return &export, nil
+}
+
+// GetUserDataExports lists the user's data exports, newest first.
+func GetUserDataExports(c *fiber.Ctx, userID uuid.UUID) ([]models.DataExport, int64, error) {
+	var exports []models.DataExport
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.DataExport{}).Where("user_id = ?", userID)
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&exports).Error
+	return exports, totalCount, err
+}
+
+// GetUserDataExport loads one of the user's data exports.
+func GetUserDataExport(userID, id uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	if err := database.DB.First(&export, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Data export")
+	}
+	return &export, nil
+}
+
+// GetDataExportDownload loads one of the user's data exports for download,
+// which is only possible while it is ready.
+func GetDataExportDownload(userID, id uuid.UUID) (*models.DataExport, error) {
+	export, err := GetUserDataExport(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch export.Status {
+	case models.DataExportReady:
+		return export, nil
+	case models.DataExportPending:
+		return nil, apperrors.Conflict("The data export is not ready yet")
+	case models.DataExportExpired:
+		return nil, apperrors.Conflict("The data export has expired; request a new one")
+	default:
+		return nil, apperrors.Conflict("The data export failed; request a new one")
+	}
+}
+
+// DataExportFilename is the name a data export is downloaded under.
+func DataExportFilename(export *models.DataExport) string {
+	return "data-export-" + export.CreatedAt.Format("2006-01-02") + ".zip"
+}
+
+// StartDataExporter builds requested data exports and removes lapsed ones
+// every interval.
+func StartDataExporter(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			built, err := BuildDataExports()
+			if err != nil {
+				log.Printf("data exporter: %v", err)
+			}
+			if built > 0 {
+				log.Printf("data exporter: built %d data exports", built)
+			}
+			expired, err := ExpireDataExports()
+			if err != nil {
+				log.Printf("data exporter: %v", err)
+			}
+			if expired > 0 {
+				log.Printf("data exporter: removed %d lapsed data exports", expired)
+			}
+		}
+	}()
+}
+
+// BuildDataExports builds the oldest requested data exports and tells
+// their users where to download them. It returns how many were built.
+func BuildDataExports() (int, error) {
+	var pending []models.DataExport
+	err := database.DB.Where("status = ?", models.DataExportPending).
+		Order("created_at").
+		Limit(dataExportBatch).
+		Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	built := 0
+	for i := range pending {
+		export := &pending[i]
+		path, size, err := writeDataExport(export)
+		if err == nil {
+			err = finishDataExport(export, path, size)
+		}
+		if err != nil {
+			log.Printf("data exporter: export %s: %v", export.ID, err)
+			if err := failDataExport(export, err); err != nil {
+				return built, err
+			}
+			continue
+		}
+		built++
+	}
+	return built, nil
+}
+
+// writeDataExport writes the ZIP of a data export and returns its path and
+// size. The file only appears under its final name once complete.
+func writeDataExport(export *models.DataExport) (string, int64, error) {
+	files, err := collectUserData(export.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(config.DataExportDir, 0o700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(config.DataExportDir, export.ID.String()+".zip")
+	tmp, err := os.CreateTemp(config.DataExportDir, ".export-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	archive := zip.NewWriter(tmp)
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			tmp.Close()
+			return "", 0, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			tmp.Close()
+			return "", 0, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		tmp.Close()
+		return "", 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+type dataExportFile struct {
+	name string
+	data interface{}
+}
+
+// collectUserData gathers what a data export holds: the user's profile,
+// bookings, reviews, favourites and login sessions.
+func collectUserData(userID uuid.UUID) ([]dataExportFile, error) {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var bookings []models.Booking
+	err := database.DB.
+		Preload("Departure").
+		Preload("Payments").
+		Preload("Payments.Refunds").
+		Preload("Participants", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Order("created_at").
+		Find(&bookings, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]exportedBooking, len(bookings))
+	var tourIDs, eventIDs []uuid.UUID
+	for i := range bookings {
+		exported[i].Booking = bookings[i]
+		if bookings[i].TourID != nil {
+			tourIDs = append(tourIDs, *bookings[i].TourID)
+		}
+		if bookings[i].EventID != nil {
+			eventIDs = append(eventIDs, *bookings[i].EventID)
+		}
+	}
+	var tours []models.Tour
+	if err := database.DB.Select("id", "title").Find(&tours, "id IN ?", tourIDs).Error; err != nil {
+		return nil, err
+	}
+	var events []models.Event
+	if err := database.DB.Select("id", "title").Find(&events, "id IN ?", eventIDs).Error; err != nil {
+		return nil, err
+	}
+	var invoices []models.Invoice
+	if err := database.DB.Find(&invoices, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	for i := range exported {
+		for _, tour := range tours {
+			if exported[i].TourID != nil && tour.ID == *exported[i].TourID {
+				exported[i].TourTitle = tour.Title
+			}
+		}
+		for _, event := range events {
+			if exported[i].EventID != nil && event.ID == *exported[i].EventID {
+				exported[i].EventTitle = event.Title
+			}
+		}
+		for j := range invoices {
+			if invoices[j].BookingID == exported[i].ID {
+				exported[i].Invoice = &invoices[j]
+			}
+		}
+	}
+
+	var reviews []models.Review
+	err = database.DB.Preload("Photos").Preload("Reply").
+		Order("created_at").
+		Find(&reviews, "user_id = ?", userID).Error
+	if err != nil {
+		return nil, err
+	}
+	var favorites []models.Favorite
+	if err := database.DB.Order("created_at").Find(&favorites, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	var sessions []models.Session
+	if err := database.DB.Order("created_at").Find(&sessions, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	return []dataExportFile{
+		{"profile.json", user},
+		{"bookings.json", exported},
+		{"reviews.json", reviews},
+		{"favorites.json", favorites},
+		{"sessions.json", sessions},
+	}, nil
+}
+
+// finishDataExport marks a built export ready and tells its user.
+func finishDataExport(export *models.DataExport, path string, size int64) error {
+	now := time.Now()
+	expiresAt := now.Add(config.DataExportTTL)
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(export).Updates(map[string]interface{}{
+			"status":       models.DataExportReady,
+			"file_path":    path,
+			"size_bytes":   size,
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		body := fmt.Sprintf("The copy of your data you asked for is ready. You can download it until %s.",
+			expiresAt.UTC().Format("2 January 2006 15:04 MST"))
+		return notify(tx, export.UserID, models.NotificationDataExportReady, "Your data export is ready", body,
+			"/api/user/data-exports/"+export.ID.String()+"/download")
+	})
+}
+
+// failDataExport records a failed build, giving up after a few attempts.
+func failDataExport(export *models.DataExport, cause error) error {
+	updates := map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": cause.Error(),
+	}
+	if export.Attempts+1 >= dataExportAttempts {
+		updates["status"] = models.DataExportFailed
+	}
+	return database.DB.Model(export).Updates(updates).Error
+}
+
+// ExpireDataExports removes the files of exports past their expiry. It
+// returns how many exports expired.
+func ExpireDataExports() (int, error) {
+	var lapsed []models.DataExport
+	err := database.DB.Where("status = ? AND expires_at <= ?", models.DataExportReady, time.Now()).
+		Limit(dataExportBatch).
+		Find(&lapsed).Error
+	if err != nil {
+		return 0, err
+	}
+	for i, export := range lapsed {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			return i, err
+		}
+		err := database.DB.Model(&export).Updates(map[string]interface{}{
+			"status":    models.DataExportExpired,
+			"file_path": "",
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(lapsed), nil
+}
+
+// deleteDataExports removes the user's data exports in the caller's
+// transaction and returns the files to remove once it commits.
+func deleteDataExports(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	var paths []string
+	err := tx.Model(&models.DataExport{}).
+		Where("user_id = ? AND file_path <> ''", userID).
+		Pluck("file_path", &paths).Error
+	if err != nil {
+		return nil, err
+	}
+	return paths, tx.Where("user_id = ?", userID).Delete(&models.DataExport{}).Error
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+	"github.com/google/uuid"
+)
+
+// setupTestDB points the services at a fresh, migrated SQLite database and
+// the mock payment provider for the length of the test.
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "test.db"))
+	config.InitConfig()
+	database.ConnectDB()
+	paymentProvider = payments.NewMock("whsec_test")
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+func createTestUser(t *testing.T) *models.User {
+	t.Helper()
+	id := uuid.New()
+	user := &models.User{ID: id, Name: "Test user", Username: "user_" + id.String()[:8], Email: id.String() + "@example.com", Role: "USER"}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestDeparture creates a tour at 100.00 EUR a person with a
+// departure of ten seats starting at start.
+func createTestDeparture(t *testing.T, start time.Time) (*models.Tour, *models.TourDeparture) {
+	t.Helper()
+	destination := &models.Destination{Name: "Test destination"}
+	if err := database.DB.Create(destination).Error; err != nil {
+		t.Fatal(err)
+	}
+	tour := &models.Tour{Title: "Test tour", DestinationID: destination.ID, PricePerPersonMinor: 10000, Currency: "EUR"}
+	if err := database.DB.Create(tour).Error; err != nil {
+		t.Fatal(err)
+	}
+	departure := &models.TourDeparture{TourID: tour.ID, StartDate: start, EndDate: start.Add(48 * time.Hour), Capacity: 10}
+	if err := database.DB.Create(departure).Error; err != nil {
+		t.Fatal(err)
+	}
+	return tour, departure
+}
+
+// createTestBooking books two adults on the departure for the user.
+func createTestBooking(t *testing.T, user *models.User, tour *models.Tour, departure *models.TourDeparture) *models.Booking {
+	t.Helper()
+	booking := &models.Booking{UserID: user.ID, TourID: &tour.ID, DepartureID: &departure.ID, Adults: 2}
+	if err := CreateBooking(booking); err != nil {
+		t.Fatal(err)
+	}
+	return booking
+}
+
+func reloadBooking(t *testing.T, id uuid.UUID) *models.Booking {
+	t.Helper()
+	var booking models.Booking
+	if err := database.DB.First(&booking, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &booking
+}
+
+func reloadPayment(t *testing.T, id uuid.UUID) *models.Payment {
+	t.Helper()
+	var payment models.Payment
+	if err := database.DB.First(&payment, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &payment
+}
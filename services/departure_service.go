@@ -0,0 +1,409 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxGeneratedDepartures caps how many departures one recurrence rule
+	// creates.
+	maxGeneratedDepartures = 366
+	// departureHorizon is how far ahead a recurrence rule is expanded.
+	departureHorizon = 2 * 365 * 24 * time.Hour
+)
+
+// DepartureSchedule describes departures to generate from a recurrence rule.
+type DepartureSchedule struct {
+	RRule              string
+	Start              time.Time
+	Until              *time.Time
+	Capacity           int
+	PriceOverrideMinor *int64
+	Status             models.DepartureStatus
+}
+
+// bookableDepartures limits a query to departures that haven't left and
+// aren't cancelled.
+func bookableDepartures(db *gorm.DB) *gorm.DB {
+	return db.Where("tour_departures.start_date >= ? AND tour_departures.status <> ?",
+		calendarDay(time.Now()), models.DepartureCancelled)
+}
+
+// loadNextDepartures sets NextDeparture on each tour to its first bookable
+// departure, in one query.
+func loadNextDepartures(tours []models.Tour) error {
+	if len(tours) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(tours))
+	for i, tour := range tours {
+		ids[i] = tour.ID
+	}
+
+	today := calendarDay(time.Now())
+	var departures []models.TourDeparture
+	err := bookableDepartures(database.DB.Model(&models.TourDeparture{})).
+		Where("tour_departures.tour_id IN ?", ids).
+		Where(`tour_departures.start_date = (SELECT MIN(d.start_date) FROM tour_departures d
+			WHERE d.tour_id = tour_departures.tour_id AND d.start_date >= ? AND d.status <> ?)`,
+			today, models.DepartureCancelled).
+		Find(&departures).Error
+	if err != nil {
+		return err
+	}
+
+	next := make(map[uuid.UUID]*models.TourDeparture, len(departures))
+	for i := range departures {
+		next[departures[i].TourID] = &departures[i]
+	}
+	for i := range tours {
+		if departure, ok := next[tours[i].ID]; ok {
+			departure.SetPriceOverride(tours[i].Currency)
+			tours[i].NextDeparture = departure
+		}
+	}
+	return nil
+}
+
+// GetTourDepartures lists the departures of a tour by start date. Public
+// listings only show bookable departures; admins see all of them and can
+// filter by status. Both can limit the range with from and to (YYYY-MM-DD).
+func GetTourDepartures(c *fiber.Ctx, tourID uuid.UUID, admin bool) ([]models.TourDeparture, int64, error) {
+	var tour models.Tour
+	if err := database.DB.Select("id", "currency").First(&tour, "id = ?", tourID).Error; err != nil {
+		return nil, 0, apperrors.FromDB(err, "Tour")
+	}
+
+	query := database.DB.Model(&models.TourDeparture{}).Where("tour_departures.tour_id = ?", tourID)
+	if admin {
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+	} else {
+		query = bookableDepartures(query)
+	}
+	query, err := departureDateRange(c, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	departures, totalCount, err := pageDepartures(c, query)
+	for i := range departures {
+		departures[i].SetPriceOverride(tour.Currency)
+	}
+	return departures, totalCount, err
+}
+
+// GetUpcomingDepartures lists bookable departures of all tours by start
+// date, optionally for one destination or category.
+func GetUpcomingDepartures(c *fiber.Ctx) ([]models.TourDeparture, int64, error) {
+	query := bookableDepartures(database.DB.Model(&models.TourDeparture{})).
+		Joins("JOIN tours ON tours.id = tour_departures.tour_id")
+	if destinationID := c.Query("destination_id"); destinationID != "" {
+		query = query.Where("tours.destination_id = ?", destinationID)
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("tours.category = ?", categoryID)
+	}
+	query, err := departureDateRange(c, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	departures, totalCount, err := pageDepartures(c, query.Preload("Tour"))
+	for i := range departures {
+		if departures[i].Tour != nil {
+			departures[i].SetPriceOverride(departures[i].Tour.Currency)
+		}
+	}
+	return departures, totalCount, err
+}
+
+// departureDateRange applies the from and to query parameters.
+func departureDateRange(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	for _, param := range []string{"from", "to"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, apperrors.Validation("Validation failed", models.FieldError{
+				Field:   param,
+				Message: "must be a date as YYYY-MM-DD",
+			})
+		}
+		if param == "from" {
+			query = query.Where("tour_departures.start_date >= ?", day)
+		} else {
+			query = query.Where("tour_departures.start_date < ?", day.AddDate(0, 0, 1))
+		}
+	}
+	return query, nil
+}
+
+func pageDepartures(c *fiber.Ctx, query *gorm.DB) ([]models.TourDeparture, int64, error) {
+	var departures []models.TourDeparture
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("tour_departures.start_date").
+		Find(&departures).Error
+	return departures, totalCount, err
+}
+
+// GetDeparture returns one departure of a tour.
+func GetDeparture(tourID, id uuid.UUID) (*models.TourDeparture, error) {
+	var departure models.TourDeparture
+	err := database.DB.Preload("Tour").First(&departure, "id = ? AND tour_id = ?", id, tourID).Error
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Departure")
+	}
+	departure.SetPriceOverride(departure.Tour.Currency)
+	return &departure, nil
+}
+
+// CreateDeparture adds a departure to a tour. A tour can't have two
+// departures starting at the same time.
+func CreateDeparture(departure *models.TourDeparture) error {
+	if err := checkDeparture(departure); err != nil {
+		return err
+	}
+	return database.DB.Create(departure).Error
+}
+
+// UpdateDeparture replaces the dates, capacity, price and status of a
+// departure. The capacity can't drop below the seats already booked.
+// Cancelling the departure cancels its bookings with a full refund.
+func UpdateDeparture(departure *models.TourDeparture, actorID uuid.UUID) error {
+	if err := checkDeparture(departure); err != nil {
+		return err
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TourDeparture{}).
+			Where("id = ? AND tour_id = ? AND seats_booked <= ?", departure.ID, departure.TourID, departure.Capacity).
+			Select("start_date", "end_date", "capacity", "price_override_minor", "status").
+			Updates(departure)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var current models.TourDeparture
+			if err := tx.First(&current, "id = ? AND tour_id = ?", departure.ID, departure.TourID).Error; err != nil {
+				return apperrors.FromDB(err, "Departure")
+			}
+			return apperrors.Conflict(fmt.Sprintf("%d seats are already booked; the capacity can't be lower", current.SeatsBooked))
+		}
+		// Seats added to the departure go to its waitlist first.
+		return offerWaitlist(tx, models.WaitlistDeparture, departure.ID)
+	})
+	if err != nil {
+		return err
+	}
+	if departure.Status == models.DepartureCancelled {
+		if err := cancelDepartureBookings(departure.ID, actorID); err != nil {
+			return err
+		}
+	}
+	return database.DB.First(departure, "id = ?", departure.ID).Error
+}
+
+// cancelDepartureBookings cancels the pending and confirmed bookings of a
+// cancelled departure, refunding them in full and notifying the customers.
+// Each booking is cancelled in its own transaction; if one fails, saving the
+// departure again picks up the rest.
+func cancelDepartureBookings(departureID, actorID uuid.UUID) error {
+	var ids []uuid.UUID
+	err := database.DB.Model(&models.Booking{}).
+		Where("departure_id = ? AND status IN ?", departureID, []models.BookingStatus{models.BookingPending, models.BookingConfirmed}).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	fullRefund := 100
+	for _, id := range ids {
+		if _, err := cancelBooking(id, nil, actorID, "The departure was cancelled", &fullRefund); err != nil {
+			return fmt.Errorf("cancel booking %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// DeleteDeparture removes a departure nobody has booked; booked departures
+// can only be cancelled.
+func DeleteDeparture(tourID, id uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var bookings int64
+		if err := tx.Model(&models.Booking{}).Where("departure_id = ?", id).Count(&bookings).Error; err != nil {
+			return err
+		}
+		if bookings > 0 {
+			return apperrors.Conflict("Departure has bookings; cancel it instead")
+		}
+		result := tx.Delete(&models.TourDeparture{}, "id = ? AND tour_id = ?", id, tourID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Departure")
+		}
+		return nil
+	})
+}
+
+func checkDeparture(departure *models.TourDeparture) error {
+	if departure.EndDate.Before(departure.StartDate) {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "endDate",
+			Message: "must not be before startDate",
+		})
+	}
+	return nil
+}
+
+// GenerateDepartures creates the departures of a tour produced by a
+// recurrence rule, up to two years ahead and at most 366 at a time. Each
+// lasts the tour's duration. Dates that already have a departure are
+// skipped, so a schedule can be extended by running it again.
+func GenerateDepartures(tour *models.Tour, schedule DepartureSchedule) ([]models.TourDeparture, int, error) {
+	rule, err := utils.ParseRRule(schedule.RRule)
+	if err != nil {
+		return nil, 0, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "rrule",
+			Message: err.Error(),
+		})
+	}
+	end := schedule.Start.Add(departureHorizon)
+	if schedule.Until != nil && schedule.Until.Before(end) {
+		end = *schedule.Until
+	}
+	starts := rule.Occurrences(schedule.Start, end, maxGeneratedDepartures)
+	if len(starts) == 0 {
+		return nil, 0, apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "rrule",
+			Message: "produces no departures in the given range",
+		})
+	}
+
+	capacity := schedule.Capacity
+	if capacity == 0 {
+		capacity = tour.DefaultCapacity
+	}
+	var created []models.TourDeparture
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, start := range starts {
+			departure := models.TourDeparture{
+				TourID:             tour.ID,
+				StartDate:          start,
+				EndDate:            departureEnd(start, tour.DurationDays),
+				Capacity:           capacity,
+				PriceOverrideMinor: schedule.PriceOverrideMinor,
+				Status:             schedule.Status,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&departure)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				departure.SetPriceOverride(tour.Currency)
+				created = append(created, departure)
+			}
+		}
+		return nil
+	})
+	return created, len(starts) - len(created), err
+}
+
+// departureEnd is when a departure starting at start ends: on the last of
+// its days.
+func departureEnd(start time.Time, durationDays int) time.Time {
+	return start.AddDate(0, 0, max(durationDays, 1)-1)
+}
+
+// resolveDeparture finds the departure a party books: the one given by ID,
+// else the one starting on date, else the tour's next bookable departure.
+func resolveDeparture(tx *gorm.DB, tour *models.Tour, departureID *uuid.UUID, date *time.Time) (*models.TourDeparture, error) {
+	var departure models.TourDeparture
+	query := tx.Where("tour_id = ?", tour.ID)
+	var err error
+	switch {
+	case departureID != nil:
+		err = query.First(&departure, "id = ?", *departureID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("Departure")
+		}
+	case date != nil:
+		day := calendarDay(*date)
+		err = query.Where("start_date >= ? AND start_date < ? AND status <> ?",
+			day, day.AddDate(0, 0, 1), models.DepartureCancelled).
+			Order("start_date").First(&departure).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.Validation("Validation failed", models.FieldError{
+				Field:   "date",
+				Message: "the tour has no departure on this date",
+			})
+		}
+	default:
+		err = bookableDepartures(query).Order("start_date").First(&departure).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.Conflict("Tour has no upcoming departures")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if departure.Status == models.DepartureCancelled {
+		return nil, apperrors.Conflict("Departure is cancelled")
+	}
+	if calendarDay(departure.StartDate).Before(calendarDay(time.Now())) {
+		return nil, apperrors.Conflict("Departure has already left")
+	}
+	return &departure, nil
+}
+
+// reserveSeats books seats on a departure. The conditional update can't
+// overbook, however many bookings race for the last seats.
+func reserveSeats(tx *gorm.DB, departure *models.TourDeparture, seats int) error {
+	result := tx.Model(&models.TourDeparture{}).
+		Where("id = ? AND status <> ? AND seats_booked + ? <= capacity", departure.ID, models.DepartureCancelled, seats).
+		Update("seats_booked", gorm.Expr("seats_booked + ?", seats))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var current models.TourDeparture
+		if err := tx.First(&current, "id = ?", departure.ID).Error; err != nil {
+			return err
+		}
+		if current.Status == models.DepartureCancelled {
+			return apperrors.Conflict("Departure is cancelled")
+		}
+		return apperrors.Conflict(fmt.Sprintf("Only %d seats are left on this departure", current.SeatsLeft()))
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/pdf"
+	"github.com/Twisac-Solutions/tours-backend/qrcode"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+)
+
+const (
+	docMargin     = 50.0
+	docLabelWidth = 110.0
+	docQRSize     = 130.0
+)
+
+// VoucherFilename is the name a booking's voucher is downloaded as; an
+// event booking's voucher is its ticket.
+func VoucherFilename(booking *models.Booking) string {
+	if booking.EventID != nil {
+		return "ticket-" + booking.Reference() + ".pdf"
+	}
+	return "voucher-" + booking.Reference() + ".pdf"
+}
+
+// InvoiceFilename is the name an invoice is downloaded as.
+func InvoiceFilename(invoice *models.Invoice) string {
+	return "invoice-" + invoice.Number + ".pdf"
+}
+
+// RenderBookingVoucher lays out the voucher of a booking: its reference and
+// a QR code identifying it, the trip, the travellers, the meeting point and
+// the itinerary. An event booking gets its ticket instead.
+func RenderBookingVoucher(booking *models.Booking) []byte {
+	if booking.EventID != nil {
+		return renderTicket(booking)
+	}
+	l := newDocumentLayout(config.InvoiceSellerName, "Booking voucher", "Voucher "+booking.Reference())
+	l.reference("Booking reference", booking.Reference(), "booking:"+booking.ID.String())
+
+	tour := booking.Tour
+	if tour == nil {
+		tour = &models.Tour{Title: "Tour no longer available"}
+	}
+	l.field("Tour", tour.Title)
+	l.field("Destination", tour.Destination.Name)
+	if booking.Departure != nil {
+		l.field("Dates", fmt.Sprintf("%s to %s", booking.Departure.StartDate.Format("Mon 2 January 2006"),
+			booking.Departure.EndDate.Format("Mon 2 January 2006")))
+	} else if booking.TravelDate != nil {
+		l.field("Date", booking.TravelDate.Format("Mon 2 January 2006"))
+	}
+	l.field("Travellers", partyText(booking.Party()))
+	if booking.User != nil {
+		l.field("Booked by", fmt.Sprintf("%s <%s>", booking.User.Name, booking.User.Email))
+	}
+	l.field("Status", strings.ToUpper(string(booking.Status)))
+	l.endReference()
+
+	l.heading("Meeting point")
+	if tour.MeetingPoint != "" {
+		l.paragraph(pdf.Helvetica, 10, tour.MeetingPoint)
+	} else {
+		l.paragraph(pdf.Helvetica, 10, "Your guide will confirm where to meet before departure.")
+	}
+
+	if len(booking.Participants) > 0 {
+		l.heading("Travellers")
+		for _, p := range booking.Participants {
+			l.paragraph(pdf.Helvetica, 10, fmt.Sprintf("%d. %s", p.Position, p.FullName))
+		}
+	}
+
+	if len(tour.Itinerary) > 0 {
+		l.heading("Itinerary")
+		for _, day := range tour.Itinerary {
+			l.gap(4)
+			l.paragraph(pdf.HelveticaBold, 10, fmt.Sprintf("Day %d: %s", day.Day, day.Title))
+			l.paragraph(pdf.Helvetica, 10, day.Description)
+		}
+	}
+
+	l.footer("Show this voucher, printed or on your phone, when you check in. The QR code identifies your booking.")
+	return l.doc.Bytes()
+}
+
+// renderTicket lays out the ticket of an event booking: its reference and
+// QR code, the event, the guests, the meeting point and the schedule.
+func renderTicket(booking *models.Booking) []byte {
+	event := booking.Event
+	if event == nil {
+		event = &models.Event{Title: "Event no longer available"}
+	}
+	l := newDocumentLayout(config.InvoiceSellerName, "Event ticket", "Ticket "+booking.Reference())
+	l.reference("Ticket reference", booking.Reference(), "booking:"+booking.ID.String())
+
+	l.field("Event", event.Title)
+	l.field("Date", event.EventDate.UTC().Format("Mon 2 January 2006, 15:04 MST"))
+	if event.DurationHours > 0 {
+		l.field("Duration", fmt.Sprintf("%d hours", event.DurationHours))
+	}
+	l.field("Guests", partyText(booking.Party()))
+	if booking.User != nil {
+		l.field("Ticket holder", fmt.Sprintf("%s <%s>", booking.User.Name, booking.User.Email))
+	}
+	l.endReference()
+
+	l.heading("Meeting point")
+	if event.MeetingPoint != "" {
+		l.paragraph(pdf.Helvetica, 10, event.MeetingPoint)
+	} else {
+		l.paragraph(pdf.Helvetica, 10, "The organiser will confirm where to go before the event.")
+	}
+	if len(event.Schedule) > 0 {
+		l.heading("Schedule")
+		for _, item := range event.Schedule {
+			l.paragraph(pdf.Helvetica, 10, item)
+		}
+	}
+
+	l.footer("Show this ticket, printed or on your phone, at the entrance. Every guest must be in your party.")
+	return l.doc.Bytes()
+}
+
+// RenderInvoice lays out an invoice: seller and buyer, the priced lines
+// and the tax included in the total.
+func RenderInvoice(invoice *models.Invoice) []byte {
+	l := newDocumentLayout(invoice.SellerName, "Invoice", "Invoice "+invoice.Number)
+	width := l.doc.Width()
+
+	top := l.y
+	for _, line := range pdf.Wrap(pdf.Helvetica, 9, invoice.SellerAddress, 220) {
+		l.doc.Text(docMargin, l.y, pdf.Helvetica, 9, line)
+		l.y += 12
+	}
+	if invoice.SellerTaxID != "" {
+		l.doc.Text(docMargin, l.y, pdf.Helvetica, 9, "Tax ID: "+invoice.SellerTaxID)
+		l.y += 12
+	}
+	sellerBottom := l.y
+
+	l.y = top
+	for _, row := range [][2]string{
+		{"Invoice number", invoice.Number},
+		{"Date of issue", invoice.IssuedAt.Format("2 January 2006")},
+		{"Booking", models.BookingReference(invoice.BookingID)},
+		{"Currency", invoice.Currency},
+	} {
+		l.doc.Text(width-docMargin-200, l.y, pdf.HelveticaBold, 9, row[0])
+		l.doc.TextRight(width-docMargin, l.y, pdf.Helvetica, 9, row[1])
+		l.y += 13
+	}
+	l.y = max(l.y, sellerBottom) + 10
+
+	l.heading("Bill to")
+	l.paragraph(pdf.Helvetica, 10, invoice.BuyerName)
+	l.paragraph(pdf.Helvetica, 10, invoice.BuyerEmail)
+
+	l.heading(invoice.Description)
+	columns := []struct {
+		title string
+		right float64
+	}{
+		{"Description", 0},
+		{"Qty", width - docMargin - 190},
+		{"Unit price", width - docMargin - 95},
+		{"Amount", width - docMargin - 5},
+	}
+	l.need(20)
+	l.doc.FillRect(docMargin, l.y, width-2*docMargin, 18, 0.9)
+	for i, column := range columns {
+		if i == 0 {
+			l.doc.Text(docMargin+5, l.y+12, pdf.HelveticaBold, 9, column.title)
+			continue
+		}
+		l.doc.TextRight(column.right, l.y+12, pdf.HelveticaBold, 9, column.title)
+	}
+	l.y += 18
+	for _, line := range invoice.Lines {
+		l.need(18)
+		description := pdf.Truncate(pdf.Helvetica, 9, line.Description, columns[1].right-docMargin-40)
+		l.doc.Text(docMargin+5, l.y+12, pdf.Helvetica, 9, description)
+		if line.Quantity > 0 {
+			l.doc.TextRight(columns[1].right, l.y+12, pdf.Helvetica, 9, fmt.Sprint(line.Quantity))
+		}
+		if line.UnitMinor != 0 {
+			l.doc.TextRight(columns[2].right, l.y+12, pdf.Helvetica, 9, formatMoney(line.UnitMinor, invoice.Currency))
+		}
+		l.doc.TextRight(columns[3].right, l.y+12, pdf.Helvetica, 9, formatMoney(line.AmountMinor, invoice.Currency))
+		l.y += 18
+		l.doc.Line(docMargin, l.y, width-docMargin, l.y, 0.25)
+	}
+
+	l.gap(10)
+	for _, row := range []struct {
+		label  string
+		amount int64
+		font   pdf.Font
+	}{
+		{"Net amount", invoice.NetMinor, pdf.Helvetica},
+		{fmt.Sprintf("Tax (%s%%)", strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", invoice.TaxRate), "0"), ".")), invoice.TaxMinor, pdf.Helvetica},
+		{"Total", invoice.TotalMinor, pdf.HelveticaBold},
+	} {
+		l.need(16)
+		l.doc.Text(columns[2].right-60, l.y+11, row.font, 10, row.label)
+		l.doc.TextRight(columns[3].right, l.y+11, row.font, 10, formatMoney(row.amount, invoice.Currency))
+		l.y += 16
+	}
+
+	l.footer("All prices include tax. This invoice was issued electronically and is valid without a signature.")
+	return l.doc.Bytes()
+}
+
+// documentLayout places blocks one below the other on portrait A4 pages,
+// starting a new page when the current one is full.
+type documentLayout struct {
+	doc   *pdf.Document
+	y     float64
+	right float64
+	// Bottom of the QR code beside the reference block
+	qrBottom float64
+}
+
+// newDocumentLayout starts a document under a header with the seller's
+// name and the kind of document.
+func newDocumentLayout(seller, kind, title string) *documentLayout {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	doc.SetTitle(title)
+	doc.AddPage()
+	doc.Text(docMargin, docMargin+18, pdf.HelveticaBold, 18, pdf.Truncate(pdf.HelveticaBold, 18, seller, 330))
+	doc.TextRight(doc.Width()-docMargin, docMargin+18, pdf.HelveticaBold, 12, strings.ToUpper(kind))
+	doc.Line(docMargin, docMargin+30, doc.Width()-docMargin, docMargin+30, 1)
+	return &documentLayout{doc: doc, y: docMargin + 52, right: doc.Width() - docMargin}
+}
+
+// reference shows the document's reference in large type with a QR code
+// of payload beside it; the fields that follow go to the left of the code
+// until endReference.
+func (l *documentLayout) reference(label, reference, payload string) {
+	top := l.y - 12
+	l.doc.Text(docMargin, l.y, pdf.Helvetica, 9, label)
+	l.doc.Text(docMargin, l.y+24, pdf.HelveticaBold, 24, reference)
+	l.y += 44
+	l.right = l.doc.Width() - docMargin - docQRSize - 15
+	l.qrBottom = top + docQRSize
+	if code, err := qrcode.Encode(payload); err == nil {
+		drawQRCode(l.doc, code, l.doc.Width()-docMargin-docQRSize, top, docQRSize)
+	}
+}
+
+func (l *documentLayout) endReference() {
+	l.y = max(l.y, l.qrBottom) + 6
+	l.right = l.doc.Width() - docMargin
+}
+
+// field shows a label and its value, wrapping long values.
+func (l *documentLayout) field(label, value string) {
+	if value == "" {
+		return
+	}
+	lines := pdf.Wrap(pdf.Helvetica, 10, value, l.right-docMargin-docLabelWidth)
+	l.need(14 * float64(len(lines)))
+	l.doc.Text(docMargin, l.y, pdf.HelveticaBold, 10, label)
+	for _, line := range lines {
+		l.doc.Text(docMargin+docLabelWidth, l.y, pdf.Helvetica, 10, line)
+		l.y += 14
+	}
+}
+
+func (l *documentLayout) heading(text string) {
+	l.gap(12)
+	l.need(40)
+	l.doc.Text(docMargin, l.y, pdf.HelveticaBold, 12, pdf.Truncate(pdf.HelveticaBold, 12, text, l.right-docMargin))
+	l.doc.Line(docMargin, l.y+5, l.right, l.y+5, 0.5)
+	l.y += 20
+}
+
+func (l *documentLayout) paragraph(font pdf.Font, size float64, text string) {
+	for _, line := range pdf.Wrap(font, size, text, l.right-docMargin) {
+		l.need(size * 1.4)
+		l.doc.Text(docMargin, l.y, font, size, line)
+		l.y += size * 1.4
+	}
+}
+
+func (l *documentLayout) gap(h float64) {
+	l.y += h
+}
+
+// need starts a new page unless h more points fit on this one.
+func (l *documentLayout) need(h float64) {
+	if l.y+h > l.doc.Height()-docMargin-30 {
+		l.doc.AddPage()
+		l.y = docMargin + 12
+	}
+}
+
+// footer closes the document with a note under a rule.
+func (l *documentLayout) footer(note string) {
+	l.gap(20)
+	lines := pdf.Wrap(pdf.Helvetica, 8, note, l.right-docMargin)
+	l.need(12 + 11*float64(len(lines)))
+	l.doc.Line(docMargin, l.y, l.right, l.y, 0.5)
+	l.y += 14
+	for _, line := range lines {
+		l.doc.Text(docMargin, l.y, pdf.Helvetica, 8, line)
+		l.y += 11
+	}
+}
+
+// drawQRCode draws code size points wide with its top-left corner at x, y,
+// including the quiet zone. Runs of dark modules in a row are drawn as one
+// rectangle so no seams show between them.
+func drawQRCode(doc *pdf.Document, code *qrcode.Code, x, y, size float64) {
+	module := size / float64(code.Size+8)
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; {
+			if !code.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Dark(col, row) {
+				col++
+			}
+			doc.FillRect(x+float64(start+4)*module, y+float64(row+4)*module, float64(col-start)*module, module, 0)
+		}
+	}
+}
+
+// partyText describes a party, e.g. "2 adults, 1 child".
+func partyText(party models.Party) string {
+	var parts []string
+	for _, group := range []struct {
+		count            int
+		singular, plural string
+	}{
+		{party.Adults, "adult", "adults"},
+		{party.Children, "child", "children"},
+		{party.Infants, "infant", "infants"},
+	} {
+		switch {
+		case group.count == 1:
+			parts = append(parts, "1 "+group.singular)
+		case group.count > 1:
+			parts = append(parts, fmt.Sprintf("%d %s", group.count, group.plural))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatMoney shows an amount in minor units with its currency's decimals.
+func formatMoney(amount int64, currency string) string {
+	digits, _ := utils.CurrencyMinorUnits(currency)
+	return fmt.Sprintf("%.*f %s", digits, utils.FromMinorUnits(amount, currency), currency)
+}
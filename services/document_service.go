@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/notifications"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// invoiceNumberRetries is how often issuing an invoice picks the next
+// number again after another request took it.
+const invoiceNumberRetries = 5
+
+// GetBookingVoucher loads one of the user's bookings with everything its
+// voucher shows. Only confirmed and completed bookings have a voucher.
+func GetBookingVoucher(userID, id uuid.UUID) (*models.Booking, error) {
+	booking, err := loadDocumentBooking(id)
+	if err != nil || booking.UserID != userID {
+		return nil, apperrors.FromDB(errOrNotFound(err), "Booking")
+	}
+	if !hasVoucher(booking) {
+		return nil, apperrors.Conflict("Vouchers are only available for confirmed bookings")
+	}
+	return booking, nil
+}
+
+// GetBookingInvoice returns the invoice of one of the user's bookings,
+// issuing it the first time it is asked for. Invoices are issued for
+// confirmed and completed bookings and stay available once issued, even if
+// the booking is cancelled later.
+func GetBookingInvoice(userID, id uuid.UUID) (*models.Invoice, error) {
+	booking, err := loadDocumentBooking(id)
+	if err != nil || booking.UserID != userID {
+		return nil, apperrors.FromDB(errOrNotFound(err), "Booking")
+	}
+	return bookingInvoice(booking)
+}
+
+// GetEventTicket loads the booking one of the user's claimed event
+// waitlist entries became, with everything its ticket shows. Tickets are
+// only issued once the booking is paid.
+func GetEventTicket(userID, id uuid.UUID) (*models.Booking, error) {
+	var entry models.WaitlistEntry
+	if err := database.DB.First(&entry, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Waitlist entry")
+	}
+	if entry.TargetType != models.WaitlistEvent || entry.BookingID == nil {
+		return nil, apperrors.Conflict("Tickets are only available for claimed event places")
+	}
+	booking, err := loadDocumentBooking(*entry.BookingID)
+	if err != nil || booking.UserID != userID {
+		return nil, apperrors.FromDB(errOrNotFound(err), "Booking")
+	}
+	if !hasVoucher(booking) {
+		return nil, apperrors.Conflict("Tickets are only available once the booking is paid")
+	}
+	return booking, nil
+}
+
+func loadDocumentBooking(id uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	err := database.DB.
+		Preload("Tour").
+		Preload("Tour.Destination").
+		Preload("Departure").
+		Preload("Event").
+		Preload("User").
+		Preload("Participants", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&booking, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+func errOrNotFound(err error) error {
+	if err == nil {
+		return gorm.ErrRecordNotFound
+	}
+	return err
+}
+
+func hasVoucher(booking *models.Booking) bool {
+	return booking.Status == models.BookingConfirmed || booking.Status == models.BookingCompleted
+}
+
+// bookingInvoice returns the booking's invoice, issuing it if the booking
+// has none yet.
+func bookingInvoice(booking *models.Booking) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := database.DB.First(&invoice, "booking_id = ?", booking.ID).Error
+	if err == nil {
+		return &invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if !hasVoucher(booking) {
+		return nil, apperrors.Conflict("Invoices are only available for confirmed bookings")
+	}
+	return issueInvoice(booking)
+}
+
+// issueInvoice numbers and stores the invoice of a booking. Two requests
+// may pick the same number; the loser retries with the next one, or takes
+// the winner's invoice if both were for this booking.
+func issueInvoice(booking *models.Booking) (*models.Invoice, error) {
+	for attempt := 0; ; attempt++ {
+		invoice := newInvoice(booking)
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			prefix := fmt.Sprintf("INV-%d-", invoice.IssuedAt.Year())
+			var last models.Invoice
+			err := tx.Select("number").
+				Where("number LIKE ?", prefix+"%").
+				Order("number DESC").
+				Limit(1).
+				Find(&last).Error
+			if err != nil {
+				return err
+			}
+			next := 1
+			if last.Number != "" {
+				n, _ := strconv.Atoi(strings.TrimPrefix(last.Number, prefix))
+				next = n + 1
+			}
+			invoice.Number = fmt.Sprintf("%s%06d", prefix, next)
+			return tx.Create(invoice).Error
+		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) && attempt < invoiceNumberRetries {
+			var existing models.Invoice
+			if err := database.DB.First(&existing, "booking_id = ?", booking.ID).Error; err == nil {
+				return &existing, nil
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return invoice, nil
+	}
+}
+
+// newInvoice copies the booking, its buyer and the seller into an
+// unnumbered invoice and works out the tax included in the total.
+func newInvoice(booking *models.Booking) *models.Invoice {
+	invoice := &models.Invoice{
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		IssuedAt:      time.Now().UTC(),
+		Currency:      booking.Currency,
+		Description:   "Booking " + booking.Reference(),
+		Lines:         booking.PriceLines,
+		TaxRate:       config.InvoiceTaxRate,
+		TotalMinor:    booking.TotalPriceMinor,
+		SellerName:    config.InvoiceSellerName,
+		SellerAddress: config.InvoiceSellerAddress,
+		SellerTaxID:   config.InvoiceSellerTaxID,
+	}
+	if booking.Tour != nil {
+		invoice.Description = booking.Tour.Title
+		if booking.Departure != nil {
+			invoice.Description += ", departing " + booking.Departure.StartDate.Format("2 January 2006")
+		}
+	}
+	if booking.Event != nil {
+		invoice.Description = booking.Event.Title + ", " + booking.Event.EventDate.UTC().Format("2 January 2006")
+	}
+	if len(invoice.Lines) == 0 {
+		// Bookings made before itemised pricing get a single line
+		invoice.Lines = models.QuoteLines{{
+			Kind:        "fare",
+			Description: "Travellers",
+			Quantity:    booking.Travelers,
+			AmountMinor: booking.TotalPriceMinor,
+		}}
+	}
+	if booking.User != nil {
+		invoice.BuyerName = booking.User.Name
+		invoice.BuyerEmail = booking.User.Email
+	}
+	invoice.TaxMinor = int64(math.Round(float64(invoice.TotalMinor) * invoice.TaxRate / (100 + invoice.TaxRate)))
+	invoice.NetMinor = invoice.TotalMinor - invoice.TaxMinor
+	return invoice
+}
+
+// bookingAttachments renders the voucher and invoice a booking
+// confirmation email carries. A booking cancelled before the email goes
+// out gets neither.
+func bookingAttachments(bookingID uuid.UUID) ([]notifications.Attachment, error) {
+	booking, err := loadDocumentBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasVoucher(booking) {
+		return nil, nil
+	}
+	invoice, err := bookingInvoice(booking)
+	if err != nil {
+		return nil, err
+	}
+	return []notifications.Attachment{
+		{
+			Filename:    VoucherFilename(booking),
+			ContentType: "application/pdf",
+			Data:        RenderBookingVoucher(booking),
+		},
+		{
+			Filename:    InvoiceFilename(invoice),
+			ContentType: "application/pdf",
+			Data:        RenderInvoice(invoice),
+		},
+	}, nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// favoriteTarget returns the model and resource name of a favourite target.
+func favoriteTarget(targetType models.FavoriteTarget) (interface{}, string) {
+	switch targetType {
+	case models.FavoriteEvent:
+		return &models.Event{}, "Event"
+	case models.FavoriteDestination:
+		return &models.Destination{}, "Destination"
+	default:
+		return &models.Tour{}, "Tour"
+	}
+}
+
+// ParseFavoriteTarget checks the type of a favourite target.
+func ParseFavoriteTarget(value, field string) (models.FavoriteTarget, error) {
+	switch targetType := models.FavoriteTarget(value); targetType {
+	case models.FavoriteTour, models.FavoriteEvent, models.FavoriteDestination:
+		return targetType, nil
+	}
+	return "", apperrors.Validation("Validation failed", models.FieldError{
+		Field:   field,
+		Message: "must be one of: tour, event, destination",
+	})
+}
+
+// AddFavorite saves a tour, event or destination for the user. Saving it
+// again returns the existing favourite; created reports which happened.
+func AddFavorite(userID uuid.UUID, targetType models.FavoriteTarget, targetID uuid.UUID) (favorite *models.Favorite, created bool, err error) {
+	model, resource := favoriteTarget(targetType)
+	var exists int64
+	if err := database.DB.Model(model).Where("id = ?", targetID).Count(&exists).Error; err != nil {
+		return nil, false, err
+	}
+	if exists == 0 {
+		return nil, false, apperrors.NotFound(resource)
+	}
+
+	favorite = &models.Favorite{UserID: userID, TargetType: targetType, TargetID: targetID}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(favorite).Error; err != nil {
+			return err
+		}
+		return adjustFavoriteCount(tx, targetType, targetID, 1)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		var existing models.Favorite
+		err = database.DB.First(&existing, "user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).Error
+		return &existing, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return favorite, true, nil
+}
+
+// RemoveFavorite removes a tour, event or destination from the user's
+// favourites.
+func RemoveFavorite(userID uuid.UUID, targetType models.FavoriteTarget, targetID uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Delete(&models.Favorite{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Favorite")
+		}
+		return adjustFavoriteCount(tx, targetType, targetID, -1)
+	})
+}
+
+// adjustFavoriteCount moves a tour's favourite count by delta in the
+// caller's transaction, together with the favourite being added or
+// removed. The count is changed in one UPDATE of the tour's row rather than
+// read and written back, so concurrent favourites of the same tour each
+// count exactly once. Only tours keep a count.
+func adjustFavoriteCount(tx *gorm.DB, targetType models.FavoriteTarget, targetID uuid.UUID, delta int) error {
+	if targetType != models.FavoriteTour {
+		return nil
+	}
+	return tx.Model(&models.Tour{}).Where("id = ?", targetID).
+		UpdateColumn("favorite_count", gorm.Expr("CASE WHEN favorite_count + ? > 0 THEN favorite_count + ? ELSE 0 END", delta, delta)).Error
+}
+
+// deleteFavoritesOf removes every user's favourite of a deleted target.
+func deleteFavoritesOf(targetType models.FavoriteTarget, targetID string) error {
+	return database.DB.Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Delete(&models.Favorite{}).Error
+}
+
+// GetUserFavorites lists the user's favourites, newest first, optionally of
+// one type only, with the saved tours, events and destinations filled in.
+func GetUserFavorites(c *fiber.Ctx, userID uuid.UUID) ([]models.Favorite, int64, error) {
+	var favorites []models.Favorite
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.Favorite{}).Where("user_id = ?", userID)
+	if value := c.Query("type"); value != "" {
+		targetType, err := ParseFavoriteTarget(value, "type")
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&favorites).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return favorites, totalCount, loadFavoriteTargets(favorites)
+}
+
+// loadFavoriteTargets fills in the tours, events and destinations the
+// favourites point at, with a query per type.
+func loadFavoriteTargets(favorites []models.Favorite) error {
+	ids := map[models.FavoriteTarget][]uuid.UUID{}
+	for _, f := range favorites {
+		ids[f.TargetType] = append(ids[f.TargetType], f.TargetID)
+	}
+
+	var tours []models.Tour
+	if len(ids[models.FavoriteTour]) > 0 {
+		err := database.DB.Preload("User").Preload("Destination").Preload("CoverImage").
+			Find(&tours, "id IN ?", ids[models.FavoriteTour]).Error
+		if err != nil {
+			return err
+		}
+		if err := loadNextDepartures(tours); err != nil {
+			return err
+		}
+	}
+	var events []models.Event
+	if len(ids[models.FavoriteEvent]) > 0 {
+		if err := database.DB.Find(&events, "id IN ?", ids[models.FavoriteEvent]).Error; err != nil {
+			return err
+		}
+	}
+	var destinations []models.Destination
+	if len(ids[models.FavoriteDestination]) > 0 {
+		err := database.DB.Preload("User").Preload("CoverImage").
+			Find(&destinations, "id IN ?", ids[models.FavoriteDestination]).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range favorites {
+		f := &favorites[i]
+		switch f.TargetType {
+		case models.FavoriteTour:
+			for j := range tours {
+				if tours[j].ID == f.TargetID {
+					f.Tour = &tours[j]
+				}
+			}
+		case models.FavoriteEvent:
+			for j := range events {
+				if events[j].ID == f.TargetID {
+					f.Event = &events[j]
+				}
+			}
+		case models.FavoriteDestination:
+			for j := range destinations {
+				if destinations[j].ID == f.TargetID {
+					f.Destination = &destinations[j]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// FavoriteSet returns which of the given targets the user has saved.
+func FavoriteSet(userID uuid.UUID, targetType models.FavoriteTarget, targetIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	saved := make(map[uuid.UUID]bool, len(targetIDs))
+	if len(targetIDs) == 0 {
+		return saved, nil
+	}
+	var ids []uuid.UUID
+	err := database.DB.Model(&models.Favorite{}).
+		Where("user_id = ? AND target_type = ? AND target_id IN ?", userID, targetType, targetIDs).
+		Pluck("target_id", &ids).Error
+	for _, id := range ids {
+		saved[id] = true
+	}
+	return saved, err
+}
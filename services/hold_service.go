@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// holdSweepBatch is how many expired holds one sweep releases.
+const holdSweepBatch = 100
+
+// StartHoldSweeper releases expired seat holds every interval.
+func StartHoldSweeper(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := ExpireHolds()
+			if err != nil {
+				log.Printf("hold sweeper: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("hold sweeper: released %d expired holds", expired)
+			}
+		}
+	}()
+}
+
+// ExpireHolds expires the unpaid bookings whose seat hold has run out and
+// releases their seats to the departure's or event's waitlist. It returns how many
+// bookings expired.
+func ExpireHolds() (int, error) {
+	var lapsed []models.Booking
+	err := database.DB.Where("status = ? AND hold_expires_at <= ?", models.BookingPending, time.Now()).
+		Order("hold_expires_at").
+		Limit(holdSweepBatch).
+		Find(&lapsed).Error
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range lapsed {
+		booking := &lapsed[i]
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// A payment succeeding at the same time wins or loses here.
+			result := tx.Model(&models.Booking{}).
+				Where("id = ? AND status = ? AND hold_expires_at <= ?", booking.ID, models.BookingPending, time.Now()).
+				Update("status", models.BookingExpired)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			expired++
+			err := tx.Model(&models.Payment{}).
+				Where("booking_id = ? AND status IN ?", booking.ID, []models.PaymentStatus{models.PaymentPending, models.PaymentFailed}).
+				Update("status", models.PaymentCancelled).Error
+			if err != nil {
+				return err
+			}
+			if err := releasePromotion(tx, booking.ID); err != nil {
+				return err
+			}
+			return releaseBookingSeats(tx, booking)
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// checkHold rejects paying for a booking whose seat hold has run out, even
+// if the sweeper hasn't released it yet.
+func checkHold(booking *models.Booking) error {
+	if booking.Status == models.BookingExpired ||
+		booking.Status == models.BookingPending && booking.HoldExpiresAt != nil && !booking.HoldExpiresAt.After(time.Now()) {
+		return apperrors.Conflict("Seat hold has expired; book again")
+	}
+	return nil
+}
+
+// confirmBooking confirms the booking a succeeded payment paid for. It
+// returns why the payment has to be refunded instead, or "" when this
+// payment confirmed the booking. A booking that is cancelled, or that another
+// payment already paid for, keeps its status and the late payment is
+// refunded. A booking whose hold expired while the customer was paying gets
+// its seats back if they are still free; otherwise it stays expired.
+func confirmBooking(tx *gorm.DB, bookingID, paymentID uuid.UUID) (string, error) {
+	confirm := map[string]any{"status": models.BookingConfirmed, "hold_expires_at": nil}
+	result := tx.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", bookingID, models.BookingPending).
+		Updates(confirm)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected > 0 {
+		return "", notifyBookingConfirmed(tx, bookingID)
+	}
+
+	var booking models.Booking
+	if err := tx.First(&booking, "id = ?", bookingID).Error; err != nil {
+		return "", err
+	}
+	switch booking.Status {
+	case models.BookingCancelled:
+		return "Booking was cancelled before the payment went through", nil
+	case models.BookingConfirmed, models.BookingCompleted:
+		var others int64
+		err := tx.Model(&models.Payment{}).
+			Where("booking_id = ? AND id <> ? AND status IN ?", bookingID, paymentID, paidStatuses).
+			Count(&others).Error
+		if err != nil || others == 0 {
+			return "", err
+		}
+		return "Booking was already paid by another payment", nil
+	}
+	if booking.Status != models.BookingExpired {
+		return "", nil
+	}
+
+	const lapsed = "Seat hold expired before payment"
+	if targetType, targetID, ok := bookingSeatTarget(&booking); ok {
+		held, err := holdSeats(tx, targetType, targetID, booking.Party().Seats())
+		if err != nil || !held {
+			return lapsed, err
+		}
+	}
+	result = tx.Model(&models.Booking{}).
+		Where("id = ? AND status = ?", bookingID, models.BookingExpired).
+		Updates(confirm)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", apperrors.Conflict("Booking was changed by another request; try again")
+	}
+	if err := reinstatePromotion(tx, &booking); err != nil {
+		return "", err
+	}
+	return "", notifyBookingConfirmed(tx, bookingID)
+}
+
+// notifyBookingConfirmed queues the confirmation email; the dispatcher
+// attaches the booking's voucher, or its ticket for an event, and invoice
+// to it.
+func notifyBookingConfirmed(tx *gorm.DB, bookingID uuid.UUID) error {
+	var booking models.Booking
+	if err := tx.Preload("Tour").Preload("Departure").Preload("Event").First(&booking, "id = ?", bookingID).Error; err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Your booking %s is confirmed.", booking.Reference())
+	if booking.Tour != nil {
+		body = fmt.Sprintf("Your booking %s for %s is confirmed.", booking.Reference(), booking.Tour.Title)
+	}
+	if booking.Departure != nil {
+		body += fmt.Sprintf(" The tour departs on %s.", booking.Departure.StartDate.Format("2 January 2006"))
+	}
+	if booking.Event != nil {
+		body = fmt.Sprintf("Your booking %s for %s is confirmed. The event starts on %s.", booking.Reference(),
+			booking.Event.Title, booking.Event.EventDate.UTC().Format("2 January 2006, 15:04 MST"))
+		body += " Your ticket and invoice are attached; show the ticket at the entrance."
+	} else {
+		body += " Your voucher and invoice are attached; bring the voucher with you."
+	}
+	return tx.Create(&models.Notification{
+		UserID:    booking.UserID,
+		Type:      models.NotificationBookingConfirmed,
+		Title:     "Booking confirmed",
+		Body:      body,
+		Link:      "/api/user/bookings/" + booking.ID.String(),
+		BookingID: &booking.ID,
+	}).Error
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetDepartureManifest lists the travellers on a departure's confirmed
+// bookings. A vendor only sees departures of the tours they run; for
+// anyone else the departure doesn't exist.
+func GetDepartureManifest(tourID, departureID uuid.UUID, vendorID *uuid.UUID) (*models.Manifest, error) {
+	departure, err := GetDeparture(tourID, departureID)
+	if err != nil {
+		return nil, err
+	}
+	tour := departure.Tour
+	if vendorID != nil && (tour.VendorID == nil || *tour.VendorID != *vendorID) {
+		return nil, apperrors.NotFound("Departure")
+	}
+
+	var bookings []models.Booking
+	err = database.DB.
+		Preload("User").
+		Preload("Participants", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("departure_id = ? AND status IN ?", departureID, []models.BookingStatus{models.BookingConfirmed, models.BookingCompleted}).
+		Order("created_at").
+		Find(&bookings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := models.Manifest{
+		TourID:            tour.ID,
+		TourTitle:         tour.Title,
+		DepartureID:       departure.ID,
+		StartDate:         departure.StartDate,
+		EndDate:           departure.EndDate,
+		ParticipantFields: tour.ParticipantFields,
+		Rows:              []models.ManifestRow{},
+		GeneratedAt:       time.Now().UTC(),
+	}
+	if manifest.ParticipantFields == nil {
+		manifest.ParticipantFields = []string{}
+	}
+	// Travellers the customer hasn't described yet still take a row, so
+	// the head count is right and the gaps are visible
+	undescribed := append([]string{"fullName"}, tour.ParticipantFields...)
+	for _, booking := range bookings {
+		party := booking.Adults + booking.Children + booking.Infants
+		for i := 0; i < party || i < len(booking.Participants); i++ {
+			row := models.ManifestRow{
+				BookingID:     booking.ID,
+				BookedBy:      booking.User.Name,
+				BookedByEmail: booking.User.Email,
+				Missing:       undescribed,
+			}
+			if i < len(booking.Participants) {
+				row.Participant = &booking.Participants[i]
+				row.Missing = row.Participant.Missing(tour.ParticipantFields)
+			}
+			if row.Missing == nil {
+				row.Missing = []string{}
+			}
+			manifest.Rows = append(manifest.Rows, row)
+		}
+	}
+	manifest.Travelers = len(manifest.Rows)
+	return &manifest, nil
+}
+
+// GetVendorTours returns a page of the tours a vendor runs, newest first.
+func GetVendorTours(c *fiber.Ctx, vendorID uuid.UUID) ([]models.Tour, int64, error) {
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{Page: 1, Limit: 10}
+	}
+	query := database.DB.Model(&models.Tour{}).Where("vendor_id = ?", vendorID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var tours []models.Tour
+	err := query.Preload("User").Preload("Destination").Preload("CoverImage").
+		Order("created_at DESC").
+		Offset(pageInfo.Start()).Limit(pageInfo.Limit).
+		Find(&tours).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return tours, total, loadNextDepartures(tours)
+}
+
+// CheckVendor checks that the user a tour is assigned to is a vendor.
+func CheckVendor(id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	err := database.DB.Model(&models.User{}).Where("id = ? AND role = ?", *id, "vendor").Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "vendorId",
+			Message: "no such vendor",
+		})
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/notifications"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationBatch is how many notifications one dispatch sends.
+	notificationBatch = 50
+	// maxNotificationAttempts is how often delivery is tried before a
+	// notification is marked failed.
+	maxNotificationAttempts = 5
+	// notificationTimeout bounds one delivery.
+	notificationTimeout = 30 * time.Second
+)
+
+var notificationSender notifications.Sender = notifications.LogSender{}
+
+// InitNotifications sets up the configured notification channel.
+func InitNotifications() error {
+	sender, err := notifications.New(config.NotificationChannel, notifications.SMTPConfig{
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		From:     config.MailFrom,
+	})
+	if err != nil {
+		return err
+	}
+	notificationSender = sender
+	return nil
+}
+
+// notify queues a notification for a user in the caller's transaction, so
+// it is only sent if the change it reports is committed.
+func notify(tx *gorm.DB, userID uuid.UUID, kind models.NotificationType, title, body, link string) error {
+	return tx.Create(&models.Notification{
+		UserID: userID,
+		Type:   kind,
+		Title:  title,
+		Body:   body,
+		Link:   link,
+	}).Error
+}
+
+// StartNotificationDispatcher delivers pending notifications every interval.
+func StartNotificationDispatcher(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			sent, err := DispatchNotifications()
+			if err != nil {
+				log.Printf("notification dispatcher: %v", err)
+				continue
+			}
+			if sent > 0 {
+				log.Printf("notification dispatcher: delivered %d notifications", sent)
+			}
+		}
+	}()
+}
+
+// DispatchNotifications delivers the oldest pending notifications and
+// returns how many were delivered. A failed delivery is retried on later
+// runs until it has been tried maxNotificationAttempts times.
+func DispatchNotifications() (int, error) {
+	var pending []models.Notification
+	err := database.DB.Where("delivery = ?", models.DeliveryPending).
+		Order("created_at").
+		Limit(notificationBatch).
+		Find(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range pending {
+		var user models.User
+		if err := database.DB.Select("id", "name", "email").First(&user, "id = ?", notification.UserID).Error; err != nil {
+			markNotification(&notification, err)
+			continue
+		}
+		message := notifications.Message{
+			To:      user.Email,
+			Name:    user.Name,
+			Subject: notification.Title,
+			Body:    notification.Body,
+		}
+		if notification.Type == models.NotificationBookingConfirmed && notification.BookingID != nil {
+			attachments, err := bookingAttachments(*notification.BookingID)
+			if err != nil {
+				markNotification(&notification, err)
+				continue
+			}
+			message.Attachments = attachments
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		err := notificationSender.Send(ctx, message)
+		cancel()
+		markNotification(&notification, err)
+		if err == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// markNotification records the outcome of a delivery attempt.
+func markNotification(notification *models.Notification, sendErr error) {
+	updates := map[string]interface{}{"attempts": notification.Attempts + 1}
+	if sendErr == nil {
+		now := time.Now()
+		updates["delivery"] = models.DeliverySent
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+	} else {
+		log.Printf("notification %s: delivery via %s failed: %v", notification.ID, notificationSender.Name(), sendErr)
+		updates["last_error"] = sendErr.Error()
+		if notification.Attempts+1 >= maxNotificationAttempts {
+			updates["delivery"] = models.DeliveryFailed
+		}
+	}
+	if err := database.DB.Model(&models.Notification{}).Where("id = ?", notification.ID).Updates(updates).Error; err != nil {
+		log.Printf("notification %s: %v", notification.ID, err)
+	}
+}
+
+// GetUserNotifications lists the user's notifications, newest first,
+// optionally only the unread ones.
+func GetUserNotifications(c *fiber.Ctx, userID uuid.UUID) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&notifications).Error
+	return notifications, totalCount, err
+}
+
+// MarkNotificationRead marks one of the user's notifications as read.
+func MarkNotificationRead(userID, id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	if err := database.DB.First(&notification, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Notification")
+	}
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := database.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &notification, nil
+}
+
+// MarkAllNotificationsRead marks all of the user's notifications as read and
+// returns how many were unread.
+func MarkAllNotificationsRead(userID uuid.UUID) (int64, error) {
+	result := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetBookingParticipants returns the travellers listed on one of the user's
+// bookings.
+func GetBookingParticipants(userID, bookingID uuid.UUID) ([]models.Participant, error) {
+	var booking models.Booking
+	if err := database.DB.First(&booking, "id = ? AND user_id = ?", bookingID, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Booking")
+	}
+	return loadParticipants(database.DB, bookingID)
+}
+
+// SetBookingParticipants replaces the travellers listed on one of the user's
+// bookings. There must be one per member of the party, each giving the
+// details the tour requires.
+func SetBookingParticipants(userID, bookingID uuid.UUID, participants []models.Participant) ([]models.Participant, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		err := tx.Preload("Tour").First(&booking, "id = ? AND user_id = ?", bookingID, userID).Error
+		if err != nil {
+			return apperrors.FromDB(err, "Booking")
+		}
+		if booking.Status != models.BookingPending && booking.Status != models.BookingConfirmed {
+			return apperrors.Conflict("Participants can only be given for pending or confirmed bookings")
+		}
+		if err := checkParticipants(&booking, participants); err != nil {
+			return err
+		}
+
+		if err := tx.Where("booking_id = ?", bookingID).Delete(&models.Participant{}).Error; err != nil {
+			return err
+		}
+		for i := range participants {
+			participants[i].ID = uuid.Nil
+			participants[i].BookingID = bookingID
+			participants[i].Position = i + 1
+		}
+		return tx.Create(&participants).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loadParticipants(database.DB, bookingID)
+}
+
+// checkParticipants checks that the participants cover the booking's party
+// and give every detail its tour requires.
+func checkParticipants(booking *models.Booking, participants []models.Participant) error {
+	party := booking.Adults + booking.Children + booking.Infants
+	if len(participants) != party {
+		return apperrors.Validation("Validation failed", models.FieldError{
+			Field:   "participants",
+			Message: fmt.Sprintf("must list all %d travellers on the booking", party),
+		})
+	}
+	// Event tickets ask for names only.
+	var required models.StringList
+	if booking.Tour != nil {
+		required = booking.Tour.ParticipantFields
+	}
+	var fields []models.FieldError
+	for i := range participants {
+		for _, field := range participants[i].Missing(required) {
+			fields = append(fields, models.FieldError{
+				Field:   fmt.Sprintf("participants[%d].%s", i, field),
+				Message: "is required for this tour",
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
+
+func loadParticipants(db *gorm.DB, bookingID uuid.UUID) ([]models.Participant, error) {
+	participants := []models.Participant{}
+	err := db.Where("booking_id = ?", bookingID).Order("position").Find(&participants).Error
+	return participants, err
+}
+
+// CheckParticipantFields checks a tour's list of required participant
+// details and drops duplicates.
+func CheckParticipantFields(fields []string) (models.StringList, error) {
+	known := make(map[string]bool, len(models.ParticipantFields))
+	for _, field := range models.ParticipantFields {
+		known[field] = true
+	}
+	list := models.StringList{}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !known[field] {
+			return nil, apperrors.Validation("Validation failed", models.FieldError{
+				Field:   "participantFields",
+				Message: fmt.Sprintf("%q is not a participant detail", field),
+			})
+		}
+		if !seen[field] {
+			seen[field] = true
+			list = append(list, field)
+		}
+	}
+	return list, nil
+}
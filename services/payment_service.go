@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// providerTimeout bounds each call to the payment provider.
+const providerTimeout = 30 * time.Second
+
+var paymentProvider payments.Provider
+
+// paidStatuses are the states in which a payment holds the customer's money.
+var paidStatuses = []models.PaymentStatus{
+	models.PaymentSucceeded,
+	models.PaymentPartiallyRefunded,
+	models.PaymentRefunded,
+}
+
+// InitPayments sets up the payment provider chosen in the configuration.
+func InitPayments() error {
+	provider, err := payments.New(config.PaymentProvider, config.StripeSecretKey, config.PaymentWebhookSecret)
+	if err != nil {
+		return err
+	}
+	if stripe, ok := provider.(*payments.Stripe); ok && config.StripeAPIURL != "" {
+		stripe.WithBaseURL(config.StripeAPIURL)
+	}
+	paymentProvider = provider
+	return nil
+}
+
+// CreateBookingPayment starts paying for one of the user's bookings and
+// returns the payment with the client secret needed to complete it. A
+// request repeating an earlier idempotency key gets the original payment back
+// (created is false) instead of a second charge. Starting a new payment
+// cancels any earlier unfinished one for the booking, so only one can ever
+// be confirmed.
+func CreateBookingPayment(userID, bookingID uuid.UUID, idempotencyKey string) (payment *models.Payment, created bool, err error) {
+	payment, err = findIdempotentPayment(userID, bookingID, idempotencyKey)
+	if err != nil || payment != nil {
+		return payment, false, err
+	}
+
+	payment = &models.Payment{
+		BookingID:      bookingID,
+		UserID:         userID,
+		IdempotencyKey: idempotencyKey,
+		Provider:       paymentProvider.Name(),
+		Status:         models.PaymentPending,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.First(&booking, "id = ? AND user_id = ?", bookingID, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Booking")
+		}
+		if err := checkHold(&booking); err != nil {
+			return err
+		}
+		if booking.Status != models.BookingPending {
+			return apperrors.Conflict("Booking is not awaiting payment")
+		}
+
+		var paid int64
+		err := tx.Model(&models.Payment{}).
+			Where("booking_id = ? AND status IN ?", bookingID, paidStatuses).
+			Count(&paid).Error
+		if err != nil {
+			return err
+		}
+		if paid > 0 {
+			return apperrors.Conflict("Booking is already paid")
+		}
+
+		payment.Amount = booking.TotalPriceMinor
+		payment.Currency = booking.Currency
+		if payment.Amount <= 0 {
+			return apperrors.Conflict("Booking has nothing to pay")
+		}
+
+		err = tx.Model(&models.Payment{}).
+			Where("booking_id = ? AND status IN ?", bookingID, []models.PaymentStatus{models.PaymentPending, models.PaymentFailed}).
+			Update("status", models.PaymentCancelled).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(payment).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// A concurrent request with the same key got there first
+		payment, err = findIdempotentPayment(userID, bookingID, idempotencyKey)
+		return payment, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := attachIntent(payment); err != nil {
+		return nil, false, err
+	}
+	return payment, true, nil
+}
+
+// findIdempotentPayment returns the payment the user created earlier with
+// the same idempotency key, or nil. A payment whose intent could not be
+// created at the time is retried now.
+func findIdempotentPayment(userID, bookingID uuid.UUID, idempotencyKey string) (*models.Payment, error) {
+	var payment models.Payment
+	err := database.DB.Where("user_id = ? AND idempotency_key = ?", userID, idempotencyKey).
+		Take(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if payment.BookingID != bookingID {
+		return nil, apperrors.Conflict("Idempotency-Key was already used for another booking")
+	}
+	if payment.ProviderRef == nil && payment.Status == models.PaymentPending {
+		if err := attachIntent(&payment); err != nil {
+			return nil, err
+		}
+	}
+	return &payment, nil
+}
+
+// attachIntent creates the provider's intent for a new payment. The payment
+// ID doubles as the provider's idempotency key, so retrying never creates a
+// second intent.
+func attachIntent(payment *models.Payment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	intent, err := paymentProvider.CreateIntent(ctx, payments.IntentParams{
+		Amount:      payment.Amount,
+		Currency:    payment.Currency,
+		Description: "Booking " + payment.BookingID.String(),
+		Metadata: map[string]string{
+			"booking_id": payment.BookingID.String(),
+			"payment_id": payment.ID.String(),
+		},
+		IdempotencyKey: payment.ID.String(),
+	})
+	if err != nil {
+		return apperrors.Internal("Failed to reach the payment provider", err)
+	}
+
+	payment.ProviderRef = &intent.ID
+	payment.ClientSecret = intent.ClientSecret
+	return database.DB.Model(payment).Updates(map[string]any{
+		"provider_ref":  intent.ID,
+		"client_secret": intent.ClientSecret,
+	}).Error
+}
+
+// ConfirmPayment charges the user's payment with the given payment method.
+// Confirming a payment that already succeeded returns it unchanged. A
+// declined card leaves the payment failed; it can be confirmed again with
+// another method.
+func ConfirmPayment(userID, paymentID uuid.UUID, paymentMethod string) (*models.Payment, error) {
+	var payment models.Payment
+	if err := database.DB.First(&payment, "id = ? AND user_id = ?", paymentID, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Payment")
+	}
+	switch payment.Status {
+	case models.PaymentSucceeded, models.PaymentPartiallyRefunded, models.PaymentRefunded:
+		return &payment, nil
+	}
+	var booking models.Booking
+	if err := database.DB.First(&booking, "id = ?", payment.BookingID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Booking")
+	}
+	if err := checkHold(&booking); err != nil {
+		return nil, err
+	}
+	if payment.Status == models.PaymentCancelled {
+		return nil, apperrors.Conflict("Payment was cancelled; start a new payment for the booking")
+	}
+	if payment.ProviderRef == nil {
+		if err := attachIntent(&payment); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	intent, err := paymentProvider.ConfirmIntent(ctx, *payment.ProviderRef, payments.ConfirmParams{
+		PaymentMethod: paymentMethod,
+	})
+	var decline *payments.DeclineError
+	if errors.As(err, &decline) {
+		if err := syncPayment(&payment, payments.IntentRequiresPaymentMethod, decline.Message); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.PaymentFailed(decline.Message)
+	}
+	if err != nil {
+		return nil, apperrors.Internal("Failed to reach the payment provider", err)
+	}
+
+	if err := syncPayment(&payment, intent.Status, intent.FailureReason); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// syncPayment records the provider's view of a payment's intent. A payment
+// that succeeds confirms its booking. Only unfinished payments change, so a
+// late or repeated update can't undo a success or a refund. A cancelled
+// payment can still succeed, because the customer may have completed the
+// old intent at the provider directly and the money has then moved. Such a
+// payment is refunded in full when it can't confirm the booking: the booking
+// was cancelled, another payment already paid for it, or its seat hold
+// expired and the seats are gone.
+func syncPayment(payment *models.Payment, status payments.IntentStatus, failureReason string) error {
+	from := []models.PaymentStatus{models.PaymentPending, models.PaymentFailed}
+	updates := map[string]any{"failure_reason": failureReason}
+	switch status {
+	case payments.IntentSucceeded:
+		now := time.Now()
+		updates["status"] = models.PaymentSucceeded
+		updates["paid_at"] = now
+		updates["failure_reason"] = ""
+		from = append(from, models.PaymentCancelled)
+	case payments.IntentCanceled:
+		updates["status"] = models.PaymentCancelled
+	case payments.IntentRequiresPaymentMethod:
+		if failureReason == "" {
+			return nil
+		}
+		updates["status"] = models.PaymentFailed
+	default:
+		updates["status"] = models.PaymentPending
+	}
+
+	refundReason := ""
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND status IN ?", payment.ID, from).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && status == payments.IntentSucceeded {
+			var err error
+			if refundReason, err = confirmBooking(tx, payment.BookingID, payment.ID); err != nil {
+				return err
+			}
+		}
+		return tx.First(payment, "id = ?", payment.ID).Error
+	})
+	if err != nil || refundReason == "" {
+		return err
+	}
+
+	if _, err := RefundPayment(payment.ID, 0, refundReason, "unconfirmed-payment", nil); err != nil {
+		log.Printf("payment %s: refund of a payment that didn't confirm its booking failed: %v", payment.ID, err)
+		return nil
+	}
+	return database.DB.First(payment, "id = ?", payment.ID).Error
+}
+
+// RefundPayment returns amount (in minor units; 0 means everything left) of
+// a payment to the customer. The amount is reserved on the payment before
+// the provider is called and released again if the refund fails. Repeating
+// an idempotency key returns the refund made the first time.
+func RefundPayment(paymentID uuid.UUID, amount int64, reason, idempotencyKey string, adminID *uuid.UUID) (*models.PaymentRefund, error) {
+	var refund models.PaymentRefund
+	err := database.DB.Where("payment_id = ? AND idempotency_key = ?", paymentID, idempotencyKey).
+		Take(&refund).Error
+	if err == nil {
+		return &refund, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var payment models.Payment
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&payment, "id = ?", paymentID).Error; err != nil {
+			return apperrors.FromDB(err, "Payment")
+		}
+		if payment.Status != models.PaymentSucceeded && payment.Status != models.PaymentPartiallyRefunded {
+			return apperrors.Conflict("Only succeeded payments can be refunded")
+		}
+		if amount == 0 {
+			amount = payment.Amount - payment.RefundedAmount
+		}
+		if amount <= 0 {
+			return apperrors.Conflict("Payment is already fully refunded")
+		}
+
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND refunded_amount + ? <= amount", paymentID, amount).
+			Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.Conflict("Refund exceeds the amount left on the payment")
+		}
+
+		refund = models.PaymentRefund{
+			PaymentID:      paymentID,
+			IdempotencyKey: idempotencyKey,
+			Amount:         amount,
+			Reason:         reason,
+			Status:         models.RefundPending,
+			CreatedBy:      adminID,
+		}
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Refund")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	result, err := paymentProvider.Refund(ctx, payments.RefundParams{
+		IntentID:       *payment.ProviderRef,
+		Amount:         amount,
+		IdempotencyKey: refund.ID.String(),
+	})
+	if err != nil {
+		if releaseErr := releaseRefund(&refund, err.Error()); releaseErr != nil {
+			return nil, releaseErr
+		}
+		return nil, apperrors.Internal("The payment provider refused the refund", err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&refund).Updates(map[string]any{
+			"provider_ref": result.ID,
+			"status":       models.RefundSucceeded,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Payment{}).Where("id = ?", paymentID).
+			Update("status", gorm.Expr("CASE WHEN refunded_amount >= amount THEN ? ELSE ? END",
+				models.PaymentRefunded, models.PaymentPartiallyRefunded)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	refund.ProviderRef = &result.ID
+	refund.Status = models.RefundSucceeded
+	return &refund, nil
+}
+
+// releaseRefund gives a failed refund's reserved amount back to the payment.
+func releaseRefund(refund *models.PaymentRefund, failureReason string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(refund).Updates(map[string]any{
+			"status":         models.RefundFailed,
+			"failure_reason": failureReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Payment{}).Where("id = ?", refund.PaymentID).
+			Update("refunded_amount", gorm.Expr("refunded_amount - ?", refund.Amount)).Error
+	})
+}
+
+// GetAllPayments lists payments for admins, newest first, optionally
+// filtered by status, booking or user.
+func GetAllPayments(c *fiber.Ctx) ([]models.Payment, int64, error) {
+	var paymentList []models.Payment
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.Payment{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if bookingID := c.Query("booking_id"); bookingID != "" {
+		query = query.Where("booking_id = ?", bookingID)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&paymentList).Error
+	return paymentList, totalCount, err
+}
+
+// GetPaymentByID returns a payment with its refunds.
+func GetPaymentByID(id uuid.UUID) (*models.Payment, error) {
+	var payment models.Payment
+	err := database.DB.Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&payment, "id = ?", id).Error
+	if err != nil {
+		return nil, apperrors.FromDB(err, "Payment")
+	}
+	return &payment, nil
+}
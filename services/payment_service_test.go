@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+)
+
+// completeIntentLate completes a payment's intent at the provider, as a
+// customer finishing an old checkout would, and delivers the success.
+func completeIntentLate(t *testing.T, payment *models.Payment) {
+	t.Helper()
+	if _, err := paymentProvider.ConfirmIntent(context.Background(), *payment.ProviderRef, payments.ConfirmParams{PaymentMethod: payments.MockCardOK}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncPayment(payment, payments.IntentSucceeded, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLateSuccessOnPaidBookingIsRefunded(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t)
+	tour, departure := createTestDeparture(t, time.Now().AddDate(0, 1, 0))
+	booking := createTestBooking(t, user, tour, departure)
+
+	superseded, _, err := CreateBookingPayment(user.ID, booking.ID, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, _, err := CreateBookingPayment(user.ID, booking.ID, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConfirmPayment(user.ID, current.ID, payments.MockCardOK); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloadBooking(t, booking.ID).Status; got != models.BookingConfirmed {
+		t.Fatalf("booking status = %s, want confirmed", got)
+	}
+
+	completeIntentLate(t, reloadPayment(t, superseded.ID))
+
+	if got := reloadPayment(t, superseded.ID); got.Status != models.PaymentRefunded || got.RefundedAmount != got.Amount {
+		t.Errorf("superseded payment = %s with %d of %d refunded, want fully refunded", got.Status, got.RefundedAmount, got.Amount)
+	}
+	if got := reloadPayment(t, current.ID).Status; got != models.PaymentSucceeded {
+		t.Errorf("current payment status = %s, want succeeded", got)
+	}
+	if got := reloadBooking(t, booking.ID).Status; got != models.BookingConfirmed {
+		t.Errorf("booking status = %s, want confirmed", got)
+	}
+}
+
+func TestLateSuccessOnCancelledBookingIsRefunded(t *testing.T) {
+	setupTestDB(t)
+	user := createTestUser(t)
+	tour, departure := createTestDeparture(t, time.Now().AddDate(0, 1, 0))
+	booking := createTestBooking(t, user, tour, departure)
+
+	payment, _, err := CreateBookingPayment(user.ID, booking.ID, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CancelBooking(user.ID, booking.ID, "Changed plans"); err != nil {
+		t.Fatal(err)
+	}
+
+	completeIntentLate(t, reloadPayment(t, payment.ID))
+
+	if got := reloadPayment(t, payment.ID); got.Status != models.PaymentRefunded || got.RefundedAmount != got.Amount {
+		t.Errorf("payment = %s with %d of %d refunded, want fully refunded", got.Status, got.RefundedAmount, got.Amount)
+	}
+	if got := reloadBooking(t, booking.ID).Status; got != models.BookingCancelled {
+		t.Errorf("booking status = %s, want cancelled", got)
+	}
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxPartySize is the most travelers one booking can hold.
+const maxPartySize = 50
+
+// QuoteTour prices a party on a departure of a tour, with an optional
+// promotion code. The departure is picked by ID, else by its start date,
+// else the tour's next bookable departure is used. The promotion's per-user
+// limit is only checked when userID is given.
+func QuoteTour(tourID uuid.UUID, party models.Party, departureID *uuid.UUID, date *time.Time, promoCode string, userID *uuid.UUID) (*models.Quote, error) {
+	var tour models.Tour
+	if err := database.DB.First(&tour, "id = ?", tourID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Tour")
+	}
+	quote, _, err := quoteTour(database.DB, &tour, party, departureID, date, promoCode, userID)
+	return quote, err
+}
+
+// quoteTour prices a party on a departure and returns the promotion it
+// applied, if any. It fails when the departure doesn't have enough seats
+// left for the party.
+func quoteTour(tx *gorm.DB, tour *models.Tour, party models.Party, departureID *uuid.UUID, date *time.Time, promoCode string, userID *uuid.UUID) (*models.Quote, *models.Promotion, error) {
+	if err := checkParty(party); err != nil {
+		return nil, nil, err
+	}
+	departure, err := resolveDeparture(tx, tour, departureID, date)
+	if err != nil {
+		return nil, nil, err
+	}
+	if party.Seats() > departure.SeatsLeft() {
+		return nil, nil, apperrors.Conflict(fmt.Sprintf("Only %d seats are left on this departure", departure.SeatsLeft()))
+	}
+
+	var rules []models.PricingRule
+	if err := tx.Where("tour_id = ?", tour.ID).Order("created_at").Find(&rules).Error; err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	quote := priceParty(tour, departure, rules, party, now)
+	if promoCode == "" {
+		return quote, nil, nil
+	}
+
+	promotion, err := findPromotion(tx, promoCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkPromotion(tx, promotion, tour, quote, userID, now); err != nil {
+		return nil, nil, err
+	}
+	applyPromotion(quote, promotion)
+	return quote, promotion, nil
+}
+
+func checkParty(party models.Party) error {
+	var fields []models.FieldError
+	if party.Adults < 1 {
+		fields = append(fields, models.FieldError{Field: "adults", Message: "must be at least 1"})
+	}
+	if party.Children < 0 {
+		fields = append(fields, models.FieldError{Field: "children", Message: "must be at least 0"})
+	}
+	if party.Infants < 0 {
+		fields = append(fields, models.FieldError{Field: "infants", Message: "must be at least 0"})
+	}
+	if party.Travelers() > maxPartySize {
+		fields = append(fields, models.FieldError{
+			Field:   "adults",
+			Message: fmt.Sprintf("a party can have at most %d travelers", maxPartySize),
+		})
+	}
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
+
+// priceParty is the pricing engine. The adult fare is the departure's
+// price override if it has one; otherwise it is the tour's price, changed by
+// the season covering the departure date (the most recently started one if
+// several do). Children and infants pay the adult fare unless a fare rule
+// says otherwise. The best group discount for the headcount (infants don't
+// count) and the best early-bird discount for how far ahead the booking is
+// made are then each taken off the fares, never below zero.
+func priceParty(tour *models.Tour, departure *models.TourDeparture, rules []models.PricingRule, party models.Party, now time.Time) *models.Quote {
+	date := calendarDay(departure.StartDate)
+	quote := &models.Quote{
+		TourID:      tour.ID,
+		DepartureID: departure.ID,
+		Date:        departure.StartDate,
+		Party:       party,
+		Currency:    tour.Currency,
+		Lines:       models.QuoteLines{},
+	}
+
+	var group, earlyBird *models.PricingRule
+	fares := map[models.TravelerType]*models.PricingRule{}
+	headcount := party.Seats()
+	daysAhead := int(date.Sub(calendarDay(now)).Hours() / 24)
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Kind {
+		case models.PricingFare:
+			fares[rule.TravelerType] = rule
+		case models.PricingGroup:
+			if rule.MinTravelers <= headcount && (group == nil || rule.MinTravelers > group.MinTravelers) {
+				group = rule
+			}
+		case models.PricingEarlyBird:
+			if rule.DaysBefore <= daysAhead && (earlyBird == nil || rule.DaysBefore > earlyBird.DaysBefore) {
+				earlyBird = rule
+			}
+		}
+	}
+
+	adultFare, season := departureFare(tour, departure, rules)
+	suffix := ""
+	if season != nil {
+		suffix = " (" + pricingRuleName(season) + ")"
+	}
+
+	travelers := []struct {
+		kind     models.TravelerType
+		label    string
+		quantity int
+	}{
+		{models.TravelerAdult, "Adult fare", party.Adults},
+		{models.TravelerChild, "Child fare", party.Children},
+		{models.TravelerInfant, "Infant fare", party.Infants},
+	}
+	for _, traveler := range travelers {
+		if traveler.quantity == 0 {
+			continue
+		}
+		unit := adultFare
+		ruleID := pricingRuleID(season)
+		if fare := fares[traveler.kind]; fare != nil {
+			if fare.AmountMinor != nil {
+				unit = *fare.AmountMinor
+			} else {
+				unit = percentOf(adultFare, *fare.Percent)
+			}
+			ruleID = &fare.ID
+		}
+		amount := unit * int64(traveler.quantity)
+		quote.Lines = append(quote.Lines, models.QuoteLine{
+			Kind:         string(models.PricingFare),
+			Description:  traveler.label + suffix,
+			TravelerType: traveler.kind,
+			Quantity:     traveler.quantity,
+			UnitMinor:    unit,
+			AmountMinor:  amount,
+			RuleID:       ruleID,
+		})
+		quote.SubtotalMinor += amount
+	}
+
+	for _, rule := range []*models.PricingRule{group, earlyBird} {
+		if rule == nil {
+			continue
+		}
+		line := models.QuoteLine{
+			Kind:        string(rule.Kind),
+			Description: pricingRuleName(rule),
+			Quantity:    1,
+			RuleID:      &rule.ID,
+		}
+		var discount int64
+		if rule.AmountMinor != nil {
+			line.Quantity = headcount
+			line.UnitMinor = -*rule.AmountMinor
+			discount = *rule.AmountMinor * int64(headcount)
+		} else {
+			discount = percentOf(quote.SubtotalMinor, *rule.Percent)
+		}
+		discount = min(discount, quote.SubtotalMinor-quote.DiscountMinor)
+		if discount <= 0 {
+			continue
+		}
+		if rule.AmountMinor == nil {
+			line.UnitMinor = -discount
+		}
+		line.AmountMinor = -discount
+		quote.Lines = append(quote.Lines, line)
+		quote.DiscountMinor += discount
+	}
+
+	quote.TotalMinor = quote.SubtotalMinor - quote.DiscountMinor
+	return quote
+}
+
+// departureFare returns the adult fare on a departure and the season rule
+// that set it, if any: the departure's price override, else the tour's price
+// changed by the season covering the departure date (the most recently
+// started one if several do).
+func departureFare(tour *models.Tour, departure *models.TourDeparture, rules []models.PricingRule) (int64, *models.PricingRule) {
+	if departure.PriceOverrideMinor != nil {
+		return *departure.PriceOverrideMinor, nil
+	}
+
+	date := calendarDay(departure.StartDate)
+	var season *models.PricingRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind == models.PricingSeason && seasonCovers(rule, date) &&
+			(season == nil || rule.StartDate.After(*season.StartDate)) {
+			season = rule
+		}
+	}
+	fare := tour.PricePerPersonMinor
+	if season == nil {
+		return fare, nil
+	}
+	if season.AmountMinor != nil {
+		return *season.AmountMinor, season
+	}
+	return max(0, fare+percentOf(fare, *season.Percent)), season
+}
+
+// percentOf returns percent % of amount, rounded to the nearest minor unit.
+func percentOf(amount int64, percent float64) int64 {
+	return int64(math.Round(float64(amount) * percent / 100))
+}
+
+// calendarDay truncates a time to midnight UTC of its day.
+func calendarDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func seasonCovers(rule *models.PricingRule, day time.Time) bool {
+	if rule.StartDate == nil || rule.EndDate == nil {
+		return false
+	}
+	return !day.Before(calendarDay(*rule.StartDate)) && !day.After(calendarDay(*rule.EndDate))
+}
+
+func pricingRuleID(rule *models.PricingRule) *uuid.UUID {
+	if rule == nil {
+		return nil
+	}
+	return &rule.ID
+}
+
+// pricingRuleName is how a rule is described on a quote.
+func pricingRuleName(rule *models.PricingRule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	switch rule.Kind {
+	case models.PricingSeason:
+		return "Seasonal price"
+	case models.PricingGroup:
+		return fmt.Sprintf("Group discount (%d+ travelers)", rule.MinTravelers)
+	case models.PricingEarlyBird:
+		return fmt.Sprintf("Early-bird discount (%d+ days ahead)", rule.DaysBefore)
+	default:
+		return string(rule.TravelerType) + " fare"
+	}
+}
+
+// GetPricingRules lists the pricing rules of a tour.
+func GetPricingRules(tourID uuid.UUID) ([]models.PricingRule, error) {
+	if err := database.DB.Select("id").First(&models.Tour{}, "id = ?", tourID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Tour")
+	}
+	var rules []models.PricingRule
+	err := database.DB.Where("tour_id = ?", tourID).Order("kind, created_at").Find(&rules).Error
+	return rules, err
+}
+
+// CreatePricingRule adds a pricing rule to a tour.
+func CreatePricingRule(rule *models.PricingRule) error {
+	if err := checkPricingRule(rule); err != nil {
+		return err
+	}
+	return database.DB.Create(rule).Error
+}
+
+// UpdatePricingRule replaces a pricing rule of a tour.
+func UpdatePricingRule(rule *models.PricingRule) error {
+	if err := checkPricingRule(rule); err != nil {
+		return err
+	}
+	result := database.DB.Model(&models.PricingRule{}).
+		Where("id = ? AND tour_id = ?", rule.ID, rule.TourID).
+		Select("kind", "name", "traveler_type", "min_travelers", "days_before",
+			"start_date", "end_date", "amount_minor", "percent").
+		Updates(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Pricing rule")
+	}
+	return database.DB.First(rule, "id = ?", rule.ID).Error
+}
+
+// DeletePricingRule removes a pricing rule from a tour.
+func DeletePricingRule(tourID, id uuid.UUID) error {
+	result := database.DB.Delete(&models.PricingRule{}, "id = ? AND tour_id = ?", id, tourID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Pricing rule")
+	}
+	return nil
+}
+
+// checkPricingRule enforces the fields each kind of rule needs and clears
+// the ones it doesn't use.
+func checkPricingRule(rule *models.PricingRule) error {
+	var fields []models.FieldError
+	fail := func(field, message string) {
+		fields = append(fields, models.FieldError{Field: field, Message: message})
+	}
+
+	if (rule.AmountMinor == nil) == (rule.Percent == nil) {
+		fail("amount", "set either amount or percent")
+	}
+	if rule.Kind != models.PricingFare {
+		rule.TravelerType = ""
+	}
+	if rule.Kind != models.PricingGroup {
+		rule.MinTravelers = 0
+	}
+	if rule.Kind != models.PricingEarlyBird {
+		rule.DaysBefore = 0
+	}
+	if rule.Kind != models.PricingSeason {
+		rule.StartDate, rule.EndDate = nil, nil
+	}
+
+	switch rule.Kind {
+	case models.PricingFare:
+		if rule.TravelerType != models.TravelerChild && rule.TravelerType != models.TravelerInfant {
+			fail("travelerType", "must be child or infant")
+		}
+		if rule.Percent != nil && *rule.Percent < 0 {
+			fail("percent", "must be at least 0")
+		}
+	case models.PricingSeason:
+		if rule.StartDate == nil || rule.EndDate == nil {
+			fail("startDate", "a season needs a start and end date")
+		} else if calendarDay(*rule.EndDate).Before(calendarDay(*rule.StartDate)) {
+			fail("endDate", "must not be before startDate")
+		}
+	case models.PricingGroup, models.PricingEarlyBird:
+		if rule.Kind == models.PricingGroup && rule.MinTravelers < 2 {
+			fail("minTravelers", "must be at least 2")
+		}
+		if rule.Kind == models.PricingEarlyBird && rule.DaysBefore < 1 {
+			fail("daysBefore", "must be at least 1")
+		}
+		if rule.Percent != nil && (*rule.Percent <= 0 || *rule.Percent > 100) {
+			fail("percent", "must be a discount above 0 and at most 100")
+		}
+		if rule.AmountMinor != nil && *rule.AmountMinor <= 0 {
+			fail("amount", "must be above 0")
+		}
+	default:
+		fail("kind", "must be one of fare, season, group, early_bird")
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
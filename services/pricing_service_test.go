@@ -0,0 +1,199 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+func minorPtr(v int64) *int64       { return &v }
+func percentPtr(v float64) *float64 { return &v }
+func utcDay(year int, month time.Month, d int) *time.Time {
+	t := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestPriceParty(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 15, 0, 0, 0, time.UTC)
+	startDate := time.Date(2026, time.May, 1, 9, 0, 0, 0, time.UTC) // 61 days ahead
+
+	tests := []struct {
+		name     string
+		override *int64
+		rules    []models.PricingRule
+		party    models.Party
+		subtotal int64
+		discount int64
+		lines    int
+	}{
+		{
+			name:     "adults at the tour price",
+			party:    models.Party{Adults: 2},
+			subtotal: 20000,
+			lines:    1,
+		},
+		{
+			name:     "departure override wins over seasons",
+			override: minorPtr(8000),
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.April, 1), EndDate: utcDay(2026, time.June, 1), AmountMinor: minorPtr(15000)},
+			},
+			party:    models.Party{Adults: 1},
+			subtotal: 8000,
+			lines:    1,
+		},
+		{
+			name: "season percent changes the adult fare",
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.April, 1), EndDate: utcDay(2026, time.May, 1), Percent: percentPtr(-20)},
+			},
+			party:    models.Party{Adults: 2},
+			subtotal: 16000,
+			lines:    1,
+		},
+		{
+			name: "season outside the departure date is ignored",
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.May, 2), EndDate: utcDay(2026, time.June, 1), AmountMinor: minorPtr(5000)},
+			},
+			party:    models.Party{Adults: 1},
+			subtotal: 10000,
+			lines:    1,
+		},
+		{
+			name: "most recently started season wins",
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.January, 1), EndDate: utcDay(2026, time.December, 31), AmountMinor: minorPtr(9000)},
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.April, 15), EndDate: utcDay(2026, time.May, 15), AmountMinor: minorPtr(12000)},
+			},
+			party:    models.Party{Adults: 1},
+			subtotal: 12000,
+			lines:    1,
+		},
+		{
+			name: "season discount never goes below zero",
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.April, 1), EndDate: utcDay(2026, time.June, 1), Percent: percentPtr(-150)},
+			},
+			party:    models.Party{Adults: 1},
+			subtotal: 0,
+			lines:    1,
+		},
+		{
+			name: "child and infant fares",
+			rules: []models.PricingRule{
+				{Kind: models.PricingFare, TravelerType: models.TravelerChild, Percent: percentPtr(50)},
+				{Kind: models.PricingFare, TravelerType: models.TravelerInfant, AmountMinor: minorPtr(0)},
+			},
+			party:    models.Party{Adults: 2, Children: 1, Infants: 1},
+			subtotal: 25000,
+			lines:    3,
+		},
+		{
+			name: "child fare follows the seasonal adult fare",
+			rules: []models.PricingRule{
+				{Kind: models.PricingSeason, StartDate: utcDay(2026, time.April, 1), EndDate: utcDay(2026, time.June, 1), AmountMinor: minorPtr(12000)},
+				{Kind: models.PricingFare, TravelerType: models.TravelerChild, Percent: percentPtr(50)},
+			},
+			party:    models.Party{Adults: 1, Children: 1},
+			subtotal: 18000,
+			lines:    2,
+		},
+		{
+			name: "largest group discount reached applies",
+			rules: []models.PricingRule{
+				{Kind: models.PricingGroup, MinTravelers: 3, Percent: percentPtr(5)},
+				{Kind: models.PricingGroup, MinTravelers: 5, Percent: percentPtr(15)},
+				{Kind: models.PricingGroup, MinTravelers: 8, Percent: percentPtr(25)},
+			},
+			party:    models.Party{Adults: 5},
+			subtotal: 50000,
+			discount: 7500,
+			lines:    2,
+		},
+		{
+			name: "infants don't count toward a group",
+			rules: []models.PricingRule{
+				{Kind: models.PricingGroup, MinTravelers: 3, Percent: percentPtr(10)},
+			},
+			party:    models.Party{Adults: 2, Infants: 1},
+			subtotal: 30000,
+			lines:    2,
+		},
+		{
+			name: "early bird by days ahead",
+			rules: []models.PricingRule{
+				{Kind: models.PricingEarlyBird, DaysBefore: 30, Percent: percentPtr(10)},
+				{Kind: models.PricingEarlyBird, DaysBefore: 60, Percent: percentPtr(20)},
+				{Kind: models.PricingEarlyBird, DaysBefore: 90, Percent: percentPtr(30)},
+			},
+			party:    models.Party{Adults: 1},
+			subtotal: 10000,
+			discount: 2000,
+			lines:    2,
+		},
+		{
+			name: "group and early bird both come off the subtotal",
+			rules: []models.PricingRule{
+				{Kind: models.PricingGroup, MinTravelers: 2, Percent: percentPtr(10)},
+				{Kind: models.PricingEarlyBird, DaysBefore: 30, AmountMinor: minorPtr(500)},
+			},
+			party:    models.Party{Adults: 2},
+			subtotal: 20000,
+			discount: 3000,
+			lines:    3,
+		},
+		{
+			name: "discounts are capped at the subtotal",
+			rules: []models.PricingRule{
+				{Kind: models.PricingGroup, MinTravelers: 2, AmountMinor: minorPtr(6000)},
+				{Kind: models.PricingEarlyBird, DaysBefore: 30, AmountMinor: minorPtr(6000)},
+			},
+			party:    models.Party{Adults: 1, Children: 1},
+			subtotal: 20000,
+			discount: 20000,
+			lines:    4,
+		},
+		{
+			name: "discount left after the cap is dropped",
+			rules: []models.PricingRule{
+				{Kind: models.PricingGroup, MinTravelers: 2, AmountMinor: minorPtr(12000)},
+				{Kind: models.PricingEarlyBird, DaysBefore: 30, Percent: percentPtr(10)},
+			},
+			party:    models.Party{Adults: 2},
+			subtotal: 20000,
+			discount: 20000,
+			lines:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tour := &models.Tour{PricePerPersonMinor: 10000, Currency: "EUR"}
+			departure := &models.TourDeparture{StartDate: startDate, PriceOverrideMinor: tt.override}
+
+			quote := priceParty(tour, departure, tt.rules, tt.party, now)
+
+			if quote.SubtotalMinor != tt.subtotal {
+				t.Errorf("subtotal = %d, want %d", quote.SubtotalMinor, tt.subtotal)
+			}
+			if quote.DiscountMinor != tt.discount {
+				t.Errorf("discount = %d, want %d", quote.DiscountMinor, tt.discount)
+			}
+			if want := tt.subtotal - tt.discount; quote.TotalMinor != want {
+				t.Errorf("total = %d, want %d", quote.TotalMinor, want)
+			}
+			if len(quote.Lines) != tt.lines {
+				t.Errorf("got %d lines, want %d: %+v", len(quote.Lines), tt.lines, quote.Lines)
+			}
+			var sum int64
+			for _, line := range quote.Lines {
+				sum += line.AmountMinor
+			}
+			if sum != quote.TotalMinor {
+				t.Errorf("lines add up to %d, want the total %d", sum, quote.TotalMinor)
+			}
+		})
+	}
+}
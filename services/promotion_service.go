@@ -0,0 +1,350 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var promotionCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]*$`)
+
+// promotionError rejects a promotion code for the given reason.
+func promotionError(reason string) error {
+	return apperrors.Validation("Validation failed", models.FieldError{
+		Field:   "promoCode",
+		Message: reason,
+	})
+}
+
+// findPromotion looks up an active promotion by its code, in any case.
+func findPromotion(tx *gorm.DB, code string) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := tx.Where("code = ? AND active = ?", strings.ToUpper(strings.TrimSpace(code)), true).
+		Take(&promotion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, promotionError("is not a valid promotion code")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// checkPromotion reports why a promotion can't be applied to a quote for a
+// tour, or nil if it can. The per-user limit is only checked when the user
+// is known.
+func checkPromotion(tx *gorm.DB, promotion *models.Promotion, tour *models.Tour, quote *models.Quote, userID *uuid.UUID, now time.Time) error {
+	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
+		return promotionError("is not valid yet")
+	}
+	if promotion.EndsAt != nil && !now.Before(*promotion.EndsAt) {
+		return promotionError("has expired")
+	}
+	if promotion.MaxRedemptions > 0 && promotion.RedemptionCount >= promotion.MaxRedemptions {
+		return promotionError("has been fully redeemed")
+	}
+	if !promotionCovers(promotion, tour) {
+		return promotionError("does not apply to this tour")
+	}
+	if promotion.Currency != "" && promotion.Currency != quote.Currency {
+		return promotionError("is only valid for prices in " + promotion.Currency)
+	}
+	if quote.TotalMinor < promotion.MinSpendMinor {
+		return promotionError(fmt.Sprintf("needs a minimum spend of %s %.*f",
+			promotion.Currency, minorDigits(promotion.Currency),
+			utils.FromMinorUnits(promotion.MinSpendMinor, promotion.Currency)))
+	}
+	if userID != nil && promotion.MaxPerUser > 0 {
+		used, err := countRedemptions(tx, promotion.ID, *userID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(promotion.MaxPerUser) {
+			return promotionError("has already been used the maximum number of times")
+		}
+	}
+	return nil
+}
+
+func minorDigits(currency string) int {
+	digits, _ := utils.CurrencyMinorUnits(currency)
+	return digits
+}
+
+func countRedemptions(tx *gorm.DB, promotionID, userID uuid.UUID) (int64, error) {
+	var used int64
+	err := tx.Model(&models.PromotionRedemption{}).
+		Where("promotion_id = ? AND user_id = ?", promotionID, userID).
+		Count(&used).Error
+	return used, err
+}
+
+// promotionCovers reports whether a tour is in the promotion's scope.
+func promotionCovers(promotion *models.Promotion, tour *models.Tour) bool {
+	if len(promotion.TourIDs) == 0 && len(promotion.CategoryIDs) == 0 && len(promotion.DestinationIDs) == 0 {
+		return true
+	}
+	return slices.Contains(promotion.TourIDs, tour.ID.String()) ||
+		slices.Contains(promotion.CategoryIDs, tour.Category.String()) ||
+		slices.Contains(promotion.DestinationIDs, tour.DestinationID.String())
+}
+
+// applyPromotion takes the promotion's discount off what is left of the
+// quote after the pricing rules.
+func applyPromotion(quote *models.Quote, promotion *models.Promotion) {
+	var discount int64
+	if promotion.Type == models.PromotionPercent {
+		discount = percentOf(quote.TotalMinor, promotion.Percent)
+	} else {
+		discount = promotion.AmountMinor
+	}
+	discount = min(discount, quote.TotalMinor)
+
+	description := "Promotion " + promotion.Code
+	if promotion.Description != "" {
+		description += ": " + promotion.Description
+	}
+	quote.PromotionCode = promotion.Code
+	quote.Lines = append(quote.Lines, models.QuoteLine{
+		Kind:        "promotion",
+		Description: description,
+		Quantity:    1,
+		UnitMinor:   -discount,
+		AmountMinor: -discount,
+	})
+	quote.DiscountMinor += discount
+	quote.TotalMinor -= discount
+}
+
+// redeemPromotion counts a promotion as used by a booking. The counter is
+// raised with a conditional update, so concurrent bookings can't exceed the
+// global limit; the update also locks the promotion until the transaction
+// ends, which serialises the per-user check.
+func redeemPromotion(tx *gorm.DB, promotion *models.Promotion, booking *models.Booking, discount int64) error {
+	result := tx.Model(&models.Promotion{}).
+		Where("id = ? AND active = ? AND (max_redemptions = 0 OR redemption_count < max_redemptions)", promotion.ID, true).
+		Update("redemption_count", gorm.Expr("redemption_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return promotionError("has been fully redeemed")
+	}
+
+	if promotion.MaxPerUser > 0 {
+		used, err := countRedemptions(tx, promotion.ID, booking.UserID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(promotion.MaxPerUser) {
+			return promotionError("has already been used the maximum number of times")
+		}
+	}
+
+	return tx.Create(&models.PromotionRedemption{
+		PromotionID:   promotion.ID,
+		UserID:        booking.UserID,
+		BookingID:     booking.ID,
+		DiscountMinor: discount,
+		Currency:      booking.Currency,
+	}).Error
+}
+
+// releasePromotion gives back the redemption of a booking that expired or
+// was cancelled, so it no longer counts toward the promotion's limits.
+func releasePromotion(tx *gorm.DB, bookingID uuid.UUID) error {
+	var redemption models.PromotionRedemption
+	err := tx.Where("booking_id = ?", bookingID).Take(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result := tx.Delete(&redemption)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	return tx.Model(&models.Promotion{}).
+		Where("id = ? AND redemption_count > 0", redemption.PromotionID).
+		Update("redemption_count", gorm.Expr("redemption_count - 1")).Error
+}
+
+// reinstatePromotion counts the promotion of an expired booking again when
+// a late payment revives it. The customer paid the discounted price, so the
+// redemption is recorded even if the promotion has reached its limit since.
+func reinstatePromotion(tx *gorm.DB, booking *models.Booking) error {
+	if booking.PromotionCode == "" {
+		return nil
+	}
+	var promotion models.Promotion
+	if err := tx.Where("code = ?", booking.PromotionCode).Take(&promotion).Error; err != nil {
+		return err
+	}
+	var discount int64
+	for _, line := range booking.PriceLines {
+		if line.Kind == "promotion" {
+			discount = -line.AmountMinor
+		}
+	}
+	err := tx.Model(&models.Promotion{}).Where("id = ?", promotion.ID).
+		Update("redemption_count", gorm.Expr("redemption_count + 1")).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.PromotionRedemption{
+		PromotionID:   promotion.ID,
+		UserID:        booking.UserID,
+		BookingID:     booking.ID,
+		DiscountMinor: discount,
+		Currency:      booking.Currency,
+	}).Error
+}
+
+// GetPromotions lists promotions for admins, newest first, optionally
+// filtered by active state and by part of the code.
+func GetPromotions(c *fiber.Ctx) ([]models.Promotion, int64, error) {
+	var promotions []models.Promotion
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.Promotion{})
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active == "true")
+	}
+	if code := c.Query("code"); code != "" {
+		query = query.Where("code LIKE ?", "%"+strings.ToUpper(code)+"%")
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&promotions).Error
+	return promotions, totalCount, err
+}
+
+// GetPromotionByID returns one promotion.
+func GetPromotionByID(id uuid.UUID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	if err := database.DB.First(&promotion, "id = ?", id).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Promotion")
+	}
+	return &promotion, nil
+}
+
+// CreatePromotion adds a promotion. Codes are stored in upper case.
+func CreatePromotion(promotion *models.Promotion) error {
+	if err := checkPromotionFields(promotion); err != nil {
+		return err
+	}
+	return database.DB.Create(promotion).Error
+}
+
+// UpdatePromotion replaces the terms of a promotion, keeping its
+// redemption count.
+func UpdatePromotion(promotion *models.Promotion) error {
+	if err := checkPromotionFields(promotion); err != nil {
+		return err
+	}
+	result := database.DB.Model(&models.Promotion{}).
+		Where("id = ?", promotion.ID).
+		Select("code", "description", "type", "percent", "amount_minor", "currency", "min_spend_minor",
+			"starts_at", "ends_at", "max_redemptions", "max_per_user", "tour_ids", "category_ids",
+			"destination_ids", "active", "updated_by").
+		Updates(promotion)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Promotion")
+	}
+	return database.DB.First(promotion, "id = ?", promotion.ID).Error
+}
+
+// DeletePromotion removes a promotion that was never redeemed; redeemed
+// promotions are kept for the bookings that used them and can only be
+// deactivated.
+func DeletePromotion(id uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var redeemed int64
+		if err := tx.Model(&models.PromotionRedemption{}).Where("promotion_id = ?", id).Count(&redeemed).Error; err != nil {
+			return err
+		}
+		if redeemed > 0 {
+			return apperrors.Conflict("Promotion has been redeemed; deactivate it instead")
+		}
+		result := tx.Delete(&models.Promotion{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Promotion")
+		}
+		return nil
+	})
+}
+
+// checkPromotionFields normalises a promotion and enforces the rules the
+// request DTO can't express.
+func checkPromotionFields(promotion *models.Promotion) error {
+	var fields []models.FieldError
+	fail := func(field, message string) {
+		fields = append(fields, models.FieldError{Field: field, Message: message})
+	}
+
+	promotion.Code = strings.ToUpper(strings.TrimSpace(promotion.Code))
+	promotion.Currency = strings.ToUpper(promotion.Currency)
+	if !promotionCodePattern.MatchString(promotion.Code) {
+		fail("code", "may only contain letters, digits, - and _")
+	}
+	switch promotion.Type {
+	case models.PromotionPercent:
+		promotion.AmountMinor = 0
+		if promotion.Percent <= 0 || promotion.Percent > 100 {
+			fail("percent", "must be above 0 and at most 100")
+		}
+	case models.PromotionFixed:
+		promotion.Percent = 0
+		if promotion.AmountMinor <= 0 {
+			fail("amount", "must be above 0")
+		}
+		if promotion.Currency == "" {
+			fail("currency", "is required for a fixed discount")
+		}
+	default:
+		fail("type", "must be percent or fixed")
+	}
+	if promotion.MinSpendMinor > 0 && promotion.Currency == "" {
+		fail("currency", "is required with a minimum spend")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		fail("endsAt", "must be after startsAt")
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation("Validation failed", fields...)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StartRatingReconciler runs ReconcileRatings in the background every
+// interval. A zero interval disables it.
+func StartRatingReconciler(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			repaired, err := ReconcileRatings()
+			if err != nil {
+				log.Printf("rating reconciler: %v", err)
+				continue
+			}
+			if repaired > 0 {
+				log.Printf("rating reconciler: repaired %d ratings", repaired)
+			}
+		}
+	}()
+}
+
+// ReconcileRatings recomputes the stored rating of every tour, event and
+// destination from its approved reviews and repairs the ones that drifted,
+// e.g. after reviews were edited directly in the database. Each row is
+// locked and fixed in its own transaction so live review writes are only
+// held up briefly. It returns the number of rows repaired.
+func ReconcileRatings() (int, error) {
+	kinds := []struct {
+		model   interface{}
+		reviews func(tx *gorm.DB, id uuid.UUID) *gorm.DB
+	}{
+		{&models.Tour{}, tourReviews},
+		{&models.Event{}, eventReviews},
+		{&models.Destination{}, destinationReviews},
+	}
+
+	repaired := 0
+	for _, kind := range kinds {
+		var ids []uuid.UUID
+		if err := database.DB.Model(kind.model).Pluck("id", &ids).Error; err != nil {
+			return repaired, err
+		}
+		for _, id := range ids {
+			var drifted bool
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := lockRows(tx, kind.model, []uuid.UUID{id}, "Row"); err != nil {
+					var appErr *apperrors.Error
+					if errors.As(err, &appErr) && appErr.Kind == apperrors.KindNotFound {
+						// Deleted since the scan, nothing left to repair
+						return nil
+					}
+					return err
+				}
+				var err error
+				drifted, err = refreshRating(tx, kind.model, id, kind.reviews(tx, id))
+				return err
+			})
+			if err != nil {
+				return repaired, err
+			}
+			if drifted {
+				repaired++
+			}
+		}
+	}
+	return repaired, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ratingTargets names the rating aggregates a review write affects: the
+// reviewed tours, events and destinations, and the destinations the tours
+// and events belong to.
+type ratingTargets struct {
+	tours        []uuid.UUID
+	events       []uuid.UUID
+	destinations []uuid.UUID
+}
+
+// addReview adds the target a review is about.
+func (t *ratingTargets) addReview(review models.Review) {
+	switch review.TargetType {
+	case models.ReviewTargetTour:
+		t.tours = appendUnique(t.tours, review.TargetID)
+	case models.ReviewTargetEvent:
+		t.events = appendUnique(t.events, review.TargetID)
+	case models.ReviewTargetDestination:
+		t.destinations = appendUnique(t.destinations, review.TargetID)
+	}
+}
+
+func appendUnique(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// withRatings runs a review write inside tx and brings the affected rating
+// aggregates up to date in the same transaction.
+//
+// The aggregate rows are locked before write runs, so concurrent review
+// writes on the same tour, event or destination queue up and each recompute
+// sees every review committed before it. Without the lock two transactions
+// could each compute an average that misses the other's review.
+func withRatings(tx *gorm.DB, targets *ratingTargets, write func() error) error {
+	if err := lockRatings(tx, targets); err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	return refreshRatings(tx, targets)
+}
+
+// lockRatings resolves the destinations of the targets and write-locks
+// every aggregate row. A no-op UPDATE takes a row lock on Postgres and the
+// database write lock on SQLite. Rows are locked in a fixed order (tours,
+// events, destinations, each by ID) so that writers never deadlock.
+func lockRatings(tx *gorm.DB, targets *ratingTargets) error {
+	var destinations []uuid.UUID
+	if len(targets.tours) > 0 {
+		if err := tx.Model(&models.Tour{}).Where("id IN ?", targets.tours).
+			Distinct().Pluck("destination_id", &destinations).Error; err != nil {
+			return err
+		}
+	}
+	if len(targets.events) > 0 {
+		var eventDestinations []uuid.UUID
+		if err := tx.Model(&models.Event{}).Where("id IN ?", targets.events).
+			Distinct().Pluck("destination_id", &eventDestinations).Error; err != nil {
+			return err
+		}
+		destinations = append(destinations, eventDestinations...)
+	}
+	for _, id := range destinations {
+		targets.destinations = appendUnique(targets.destinations, id)
+	}
+
+	if err := lockRows(tx, &models.Tour{}, targets.tours, "Tour"); err != nil {
+		return err
+	}
+	if err := lockRows(tx, &models.Event{}, targets.events, "Event"); err != nil {
+		return err
+	}
+	return lockRows(tx, &models.Destination{}, targets.destinations, "Destination")
+}
+
+func lockRows(tx *gorm.DB, model interface{}, ids []uuid.UUID, resource string) error {
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	for _, id := range ids {
+		result := tx.Model(model).Where("id = ?", id).UpdateColumn("review_count", gorm.Expr("review_count"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound(resource)
+		}
+	}
+	return nil
+}
+
+// refreshRatings recomputes the average rating and review count of every
+// target from its approved reviews.
+func refreshRatings(tx *gorm.DB, targets *ratingTargets) error {
+	for _, id := range targets.tours {
+		if _, err := refreshRating(tx, &models.Tour{}, id, tourReviews(tx, id)); err != nil {
+			return err
+		}
+	}
+	for _, id := range targets.events {
+		if _, err := refreshRating(tx, &models.Event{}, id, eventReviews(tx, id)); err != nil {
+			return err
+		}
+	}
+	for _, id := range targets.destinations {
+		if _, err := refreshRating(tx, &models.Destination{}, id, destinationReviews(tx, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ratingAggregate is the stored or computed rating of a tour, event or
+// destination.
+type ratingAggregate struct {
+	AverageRating float64
+	ReviewCount   int64
+}
+
+// drifted reports whether a stored aggregate differs from the computed one.
+// Averages are stored with two decimals.
+func (a ratingAggregate) drifted(actual ratingAggregate) bool {
+	diff := a.AverageRating - actual.AverageRating
+	return a.ReviewCount != actual.ReviewCount || diff > 0.005 || diff < -0.005
+}
+
+// refreshRating stores the aggregate of the given reviews on the row and
+// reports whether the stored value had drifted.
+func refreshRating(tx *gorm.DB, model interface{}, id uuid.UUID, reviews *gorm.DB) (bool, error) {
+	var actual ratingAggregate
+	if err := reviews.Select("COALESCE(AVG(rating), 0) as average_rating, COUNT(*) as review_count").
+		Scan(&actual).Error; err != nil {
+		return false, err
+	}
+
+	var stored ratingAggregate
+	if err := tx.Model(model).Select("average_rating, review_count").Where("id = ?", id).
+		Scan(&stored).Error; err != nil {
+		return false, err
+	}
+	if !stored.drifted(actual) {
+		return false, nil
+	}
+	return true, tx.Model(model).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"average_rating": actual.AverageRating,
+		"review_count":   actual.ReviewCount,
+	}).Error
+}
+
+func approvedReviews(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&models.Review{}).Where("status = ?", models.ReviewApproved)
+}
+
+func tourReviews(tx *gorm.DB, tourID uuid.UUID) *gorm.DB {
+	return approvedReviews(tx).Where("target_type = ? AND target_id = ?", models.ReviewTargetTour, tourID)
+}
+
+func eventReviews(tx *gorm.DB, eventID uuid.UUID) *gorm.DB {
+	return approvedReviews(tx).Where("target_type = ? AND target_id = ?", models.ReviewTargetEvent, eventID)
+}
+
+// destinationReviews covers the reviews of the destination itself and of
+// every tour and event held there.
+func destinationReviews(tx *gorm.DB, destinationID uuid.UUID) *gorm.DB {
+	return approvedReviews(tx).Where(
+		"(target_type = ? AND target_id = ?) OR (target_type = ? AND target_id IN (?)) OR (target_type = ? AND target_id IN (?))",
+		models.ReviewTargetDestination, destinationID,
+		models.ReviewTargetTour, tx.Model(&models.Tour{}).Select("id").Where("destination_id = ?", destinationID),
+		models.ReviewTargetEvent, tx.Model(&models.Event{}).Select("id").Where("destination_id = ?", destinationID),
+	)
+}
+
+// reviewTarget returns the model a review target type is stored as and its
+// name for error messages.
+func reviewTarget(targetType models.ReviewTargetType) (interface{}, string) {
+	switch targetType {
+	case models.ReviewTargetEvent:
+		return &models.Event{}, "Event"
+	case models.ReviewTargetDestination:
+		return &models.Destination{}, "Destination"
+	default:
+		return &models.Tour{}, "Tour"
+	}
+}
+
+// targetReviews returns the approved reviews that make up the rating of a
+// target.
+func targetReviews(tx *gorm.DB, targetType models.ReviewTargetType, id uuid.UUID) *gorm.DB {
+	switch targetType {
+	case models.ReviewTargetEvent:
+		return eventReviews(tx, id)
+	case models.ReviewTargetDestination:
+		return destinationReviews(tx, id)
+	default:
+		return tourReviews(tx, id)
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxReviewPhotos is how many photos a single review can carry.
+const MaxReviewPhotos = 5
+
+// mostHelpfulLimit is how many reviews the summary highlights.
+const mostHelpfulLimit = 3
+
+// GetReviewSummary returns the rating histogram, average aspect ratings and
+// most helpful reviews of a tour, event or destination. It covers the same
+// approved reviews as the target's stored rating, so a destination's
+// summary includes the reviews of its tours and events.
+func GetReviewSummary(targetType models.ReviewTargetType, targetID uuid.UUID) (*models.ReviewSummary, error) {
+	model, resource := reviewTarget(targetType)
+	var exists int64
+	if err := database.DB.Model(model).Where("id = ?", targetID).Count(&exists).Error; err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, apperrors.NotFound(resource)
+	}
+
+	approved := targetReviews(database.DB, targetType, targetID)
+
+	var buckets []struct {
+		Rating int
+		Count  int64
+	}
+	if err := approved.Session(&gorm.Session{}).
+		Select("rating, COUNT(*) as count").
+		Group("rating").
+		Scan(&buckets).Error; err != nil {
+		return nil, err
+	}
+
+	summary := models.ReviewSummary{
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Histogram:   map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+		MostHelpful: []models.Review{},
+	}
+	var total int64
+	for _, b := range buckets {
+		summary.Histogram[b.Rating] += b.Count
+		summary.ReviewCount += b.Count
+		total += int64(b.Rating) * b.Count
+	}
+	if summary.ReviewCount > 0 {
+		summary.AverageRating = float64(total) / float64(summary.ReviewCount)
+	}
+
+	// AVG skips NULLs, so unrated aspects don't drag the averages down
+	if err := approved.Session(&gorm.Session{}).
+		Select("AVG(guide_rating) as guide, AVG(value_rating) as value, " +
+			"AVG(organisation_rating) as organisation, AVG(safety_rating) as safety").
+		Scan(&summary.SubRatings).Error; err != nil {
+		return nil, err
+	}
+
+	if err := approved.Session(&gorm.Session{}).
+		Where("helpful_count > 0").
+		Preload("User").
+		Preload("Reply").
+		Preload("Photos").
+		Order("helpful_count DESC, created_at DESC").
+		Limit(mostHelpfulLimit).
+		Find(&summary.MostHelpful).Error; err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// VoteReview records whether a user found an approved review helpful,
+// replacing any earlier vote of theirs, and returns the updated review.
+// Reviewers can't vote on their own reviews.
+func VoteReview(reviewID, userID uuid.UUID, helpful bool) (*models.Review, error) {
+	var review models.Review
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, "id = ? AND status = ?", reviewID, models.ReviewApproved).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+		if review.UserID == userID {
+			return apperrors.Forbidden("You cannot vote on your own review")
+		}
+
+		vote := models.ReviewVote{ReviewID: reviewID, UserID: userID, Helpful: helpful}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "review_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"helpful", "updated_at"}),
+		}).Create(&vote).Error; err != nil {
+			return err
+		}
+		return recountReviewVotes(tx, &review)
+	})
+	return &review, err
+}
+
+// DeleteReviewVote withdraws a user's vote on a review.
+func DeleteReviewVote(reviewID, userID uuid.UUID) (*models.Review, error) {
+	var review models.Review
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, "id = ?", reviewID).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+		result := tx.Delete(&models.ReviewVote{}, "review_id = ? AND user_id = ?", reviewID, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Vote")
+		}
+		return recountReviewVotes(tx, &review)
+	})
+	return &review, err
+}
+
+// recountReviewVotes recomputes the vote counters of a review from the votes
+// table inside the caller's transaction, so concurrent votes can't drift.
+func recountReviewVotes(tx *gorm.DB, review *models.Review) error {
+	votes := tx.Model(&models.ReviewVote{}).Select("COUNT(*)")
+	if err := tx.Model(review).UpdateColumns(map[string]interface{}{
+		"helpful_count":   votes.Session(&gorm.Session{}).Where("review_id = ? AND helpful = ?", review.ID, true),
+		"unhelpful_count": votes.Session(&gorm.Session{}).Where("review_id = ? AND helpful = ?", review.ID, false),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Select("helpful_count", "unhelpful_count").First(review, "id = ?", review.ID).Error
+}
+
+// GetUserReview returns one of a user's own reviews with its photos.
+func GetUserReview(userID uuid.UUID, id string) (*models.Review, error) {
+	var review models.Review
+	err := database.DB.Preload("Photos").First(&review, "id = ? AND user_id = ?", id, userID).Error
+	return &review, err
+}
+
+// AddReviewPhotos attaches uploaded photos to a user's review. Like an edit,
+// new photos send the review back to moderation.
+func AddReviewPhotos(userID uuid.UUID, id string, urls []string) (*models.Review, error) {
+	var review models.Review
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+		var existing int64
+		if err := tx.Model(&models.ReviewPhoto{}).Where("review_id = ?", review.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if int(existing)+len(urls) > MaxReviewPhotos {
+			return apperrors.BadRequest(fmt.Sprintf("A review can have at most %d photos", MaxReviewPhotos))
+		}
+
+		targets := ratingTargets{}
+		targets.addReview(review)
+		if err := withRatings(tx, &targets, func() error {
+			photos := make([]models.ReviewPhoto, len(urls))
+			for i, url := range urls {
+				photos[i] = models.ReviewPhoto{ReviewID: review.ID, UserID: userID, URL: url}
+			}
+			if err := tx.Create(&photos).Error; err != nil {
+				return err
+			}
+			return tx.Model(&review).Update("status", models.ReviewPending).Error
+		}); err != nil {
+			return err
+		}
+		return tx.Preload("Photos").First(&review, "id = ?", review.ID).Error
+	})
+	return &review, err
+}
+
+// DeleteReviewPhoto removes a photo from a user's own review.
+func DeleteReviewPhoto(userID uuid.UUID, id string, photoID uint) error {
+	var review models.Review
+	if err := database.DB.Select("id").First(&review, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	result := database.DB.Delete(&models.ReviewPhoto{}, "id = ? AND review_id = ?", photoID, review.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("Photo")
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReportReview records a user's report against an approved review. Reports
+// never hide a review by themselves: once a review collects
+// config.ReviewFlagThreshold reports it joins the moderation queue, and stays
+// visible until a moderator decides.
+func ReportReview(report *models.ReviewReport) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.First(&review, "id = ? AND status = ?", report.ReviewID, models.ReviewApproved).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+		if err := tx.Create(report).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return apperrors.Conflict("You have already reported this review")
+			}
+			return err
+		}
+		return tx.Model(&review).UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error
+	})
+}
+
+// GetModerationQueue returns the reviews awaiting a moderator, most reported
+// first. status narrows the queue to one state; by default it holds pending
+// and flagged reviews and approved ones that reached the report threshold.
+func GetModerationQueue(c *fiber.Ctx) ([]models.Review, int64, error) {
+	var reviews []models.Review
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.Review{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ? OR (status = ? AND report_count >= ?)",
+			[]models.ReviewStatus{models.ReviewPending, models.ReviewFlagged},
+			models.ReviewApproved, config.ReviewFlagThreshold)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	// Kept for clients written before reviews had other targets
+	if tourID := c.Query("tour_id"); tourID != "" {
+		query = query.Where("target_type = ? AND target_id = ?", models.ReviewTargetTour, tourID)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Preload("User").
+		Preload("Tour").
+		Preload("Event").
+		Preload("Destination").
+		Preload("Reply").
+		Preload("Photos").
+		Order("report_count DESC, created_at ASC").
+		Find(&reviews).Error
+	return reviews, totalCount, err
+}
+
+// ModerateReviews moves the given reviews to the approved or rejected state
+// and recomputes the rating of every target involved. Approving clears the
+// report count so new reports start afresh. It returns the number of reviews
+// changed.
+func ModerateReviews(ids []uuid.UUID, status models.ReviewStatus, moderatorID uuid.UUID, note string) (int64, error) {
+	var changed int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var reviews []models.Review
+		if err := tx.Select("id", "target_type", "target_id").Where("id IN ?", ids).Find(&reviews).Error; err != nil {
+			return err
+		}
+		targets := ratingTargets{}
+		for _, review := range reviews {
+			targets.addReview(review)
+		}
+		if err := lockRatings(tx, &targets); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":          status,
+			"moderated_by":    moderatorID,
+			"moderated_at":    now,
+			"moderation_note": note,
+		}
+		if status == models.ReviewApproved {
+			updates["report_count"] = 0
+		}
+		result := tx.Model(&models.Review{}).Where("id IN ?", ids).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		changed = result.RowsAffected
+
+		return refreshRatings(tx, &targets)
+	})
+	return changed, err
+}
+
+// SetReviewReply creates or replaces the owner's reply to a review. Only the
+// creator of the reviewed tour, event or destination (or a super admin) may reply.
+func SetReviewReply(reviewID string, ownerID uuid.UUID, superAdmin bool, body string) (*models.ReviewReply, error) {
+	id, err := uuid.Parse(reviewID)
+	if err != nil {
+		return nil, apperrors.BadRequest("Malformed review ID")
+	}
+	var reply models.ReviewReply
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkReviewOwner(tx, reviewID, ownerID, superAdmin); err != nil {
+			return err
+		}
+
+		err := tx.First(&reply, "review_id = ?", reviewID).Error
+		switch {
+		case err == nil:
+			reply.Body = body
+			reply.UserID = ownerID
+			return tx.Save(&reply).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			reply = models.ReviewReply{
+				ReviewID: id,
+				UserID:   ownerID,
+				Body:     body,
+			}
+			return tx.Create(&reply).Error
+		default:
+			return err
+		}
+	})
+	return &reply, err
+}
+
+// DeleteReviewReply removes the owner's reply to a review.
+func DeleteReviewReply(reviewID string, ownerID uuid.UUID, superAdmin bool) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkReviewOwner(tx, reviewID, ownerID, superAdmin); err != nil {
+			return err
+		}
+		result := tx.Where("review_id = ?", reviewID).Delete(&models.ReviewReply{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("Reply")
+		}
+		return nil
+	})
+}
+
+// checkReviewOwner makes sure userID created the tour, event or destination
+// the review is about.
+func checkReviewOwner(tx *gorm.DB, reviewID string, userID uuid.UUID, superAdmin bool) error {
+	var review models.Review
+	if err := tx.First(&review, "id = ?", reviewID).Error; err != nil {
+		return apperrors.FromDB(err, "Review")
+	}
+	if superAdmin {
+		return nil
+	}
+
+	var owner uuid.UUID
+	model, _ := reviewTarget(review.TargetType)
+	if err := tx.Model(model).Where("id = ?", review.TargetID).Pluck("created_by", &owner).Error; err != nil {
+		return err
+	}
+	if owner != userID {
+		return apperrors.Forbidden("Only the owner of the " + string(review.TargetType) + " can reply to its reviews")
+	}
+	return nil
+}
+
+// deleteReviewDependents removes the reply, reports, photos and votes of a
+// review that is about to be deleted; sqlite doesn't enforce the cascade.
+func deleteReviewDependents(tx *gorm.DB, reviewID uuid.UUID) error {
+	for _, model := range []interface{}{
+		&models.ReviewReply{},
+		&models.ReviewReport{},
+		&models.ReviewPhoto{},
+		&models.ReviewVote{},
+	} {
+		if err := tx.Delete(model, "review_id = ?", reviewID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
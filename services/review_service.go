@@ -0,0 +1,76 @@
+package services
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetUserReviews returns the reviews written by a user, newest first.
+func GetUserReviews(userID uuid.UUID) ([]models.Review, error) {
+	var reviews []models.Review
+	err := database.DB.Where("user_id = ?", userID).
+		Preload("Tour").
+		Preload("Event").
+		Preload("Destination").
+		Preload("Reply").
+		Preload("Photos").
+		Order("created_at DESC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+// UpdateUserReview lets a user change the ratings and comment of their own
+// review. The edit goes back to moderation, the verified flag is refreshed
+// and the target's rating recomputed.
+func UpdateUserReview(userID uuid.UUID, id string, changes models.Review) (*models.Review, error) {
+	var review models.Review
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+
+		targets := ratingTargets{}
+		targets.addReview(review)
+		return withRatings(tx, &targets, func() error {
+			verified, err := isVerifiedReview(tx, review)
+			if err != nil {
+				return err
+			}
+			review.Rating = changes.Rating
+			review.Comment = changes.Comment
+			review.GuideRating = changes.GuideRating
+			review.ValueRating = changes.ValueRating
+			review.OrganisationRating = changes.OrganisationRating
+			review.SafetyRating = changes.SafetyRating
+			review.Verified = verified
+			review.Status = models.ReviewPending
+
+			return tx.Model(&review).Select(
+				"rating", "comment", "guide_rating", "value_rating", "organisation_rating", "safety_rating",
+				"verified", "status",
+			).Updates(&review).Error
+		})
+	})
+	return &review, err
+}
+
+// DeleteUserReview deletes a user's own review and updates the target's rating.
+func DeleteUserReview(userID uuid.UUID, id string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.First(&review, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Review")
+		}
+		targets := ratingTargets{}
+		targets.addReview(review)
+		return withRatings(tx, &targets, func() error {
+			if err := deleteReviewDependents(tx, review.ID); err != nil {
+				return err
+			}
+			return tx.Delete(&review).Error
+		})
+	})
+}
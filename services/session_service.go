@@ -0,0 +1,59 @@
+package services
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionLifetime is how long a login lasts.
+const sessionLifetime = 72 * time.Hour
+
+// startSession records a login from the request and issues its token.
+func startSession(c *fiber.Ctx, userID uuid.UUID) (string, error) {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	for len(userAgent) > 500 {
+		_, size := utf8.DecodeLastRuneInString(userAgent)
+		userAgent = userAgent[:len(userAgent)-size]
+	}
+	session := models.Session{
+		UserID:    userID,
+		IPAddress: c.IP(),
+		UserAgent: userAgent,
+		ExpiresAt: time.Now().Add(sessionLifetime),
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return utils.GenerateSessionJWT(userID.String(), session.ID.String(), session.ExpiresAt)
+}
+
+// SessionActive reports whether the user's login session has not been
+// revoked.
+func SessionActive(userID, sessionID string) bool {
+	var active int64
+	err := database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Count(&active).Error
+	return err == nil && active > 0
+}
+
+// revokeSession ends a login session, as logging out does.
+func revokeSession(sessionID string) error {
+	return database.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions logs the user out everywhere.
+func revokeUserSessions(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
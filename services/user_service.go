@@ -1,10 +1,20 @@
 package services
 
 import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
 	"github.com/Twisac-Solutions/tours-backend/database"
 	"github.com/Twisac-Solutions/tours-backend/models"
 	"github.com/Twisac-Solutions/tours-backend/responses"
+	"github.com/Twisac-Solutions/tours-backend/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func GetUserProfile(c *fiber.Ctx) error {
@@ -12,23 +22,9 @@ func GetUserProfile(c *fiber.Ctx) error {
 	var user models.User
 
 	if err := database.DB.First(&user, "id = ?", userId).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
-	}
-	return c.JSON(fiber.Map{"user": &responses.UserResponse{
-		ID:             user.ID.String(),
-		Email:          user.Email,
-		Name:           user.Name,
-		Username:       user.Username,
-		Role:           user.Role,
-		ProfilePicture: user.ProfileImage.URL,
-		Bio:            user.Bio,
-		Phone:          user.Phone,
-		Country:        user.Country,
-		City:           user.City,
-		Language:       user.Language,
-		IsVerified:     user.IsVerified,
-		CreatedAt:      user.CreatedAt,
-	}})
+		return apperrors.FromDB(err, "User")
+	}
+	return c.JSON(fiber.Map{"user": responses.ToUserResponse(user)})
 }
 
 func GetUserByID(id string) (*models.User, error) {
@@ -36,3 +32,109 @@ func GetUserByID(id string) (*models.User, error) {
 	err := database.DB.First(&user, "id = ?", id).Error
 	return &user, err
 }
+
+// UpdateProfile writes columns of the user's own profile and returns the
+// updated user. A new username must not be taken, and users wait
+// config.UsernameChangeCooldown between username changes.
+func UpdateProfile(userID uuid.UUID, columns map[string]interface{}) (*models.User, error) {
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if username, ok := columns["username"].(string); ok && username != user.Username {
+			if err := checkUsernameChange(tx, &user, username); err != nil {
+				return err
+			}
+			columns["username_changed_at"] = time.Now().UTC()
+		}
+		columns["updated_by"] = userID
+		if err := tx.Model(&user).Updates(columns).Error; err != nil {
+			return err
+		}
+		return tx.First(&user, "id = ?", userID).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// Another user took the username since it was checked
+		return nil, apperrors.Conflict("Username is already taken")
+	}
+	if err != nil {
+		return nil, apperrors.FromDB(err, "User")
+	}
+	return &user, nil
+}
+
+// checkUsernameChange reports whether the user may switch to username now.
+func checkUsernameChange(tx *gorm.DB, user *models.User, username string) error {
+	if user.UsernameChangedAt != nil {
+		next := user.UsernameChangedAt.Add(config.UsernameChangeCooldown)
+		if time.Now().Before(next) {
+			return apperrors.Conflict("Your username can be changed again after " + next.UTC().Format(time.RFC3339))
+		}
+	}
+	var taken int64
+	err := tx.Model(&models.User{}).
+		Where("LOWER(username) = ? AND id <> ?", strings.ToLower(username), user.ID).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return apperrors.Conflict("Username is already taken")
+	}
+	return nil
+}
+
+// SetProfileImage replaces the user's avatar; empty URLs remove it. The
+// picture it replaces is deleted from Cloudinary.
+func SetProfileImage(userID uuid.UUID, image models.ProfileImage) (*models.User, error) {
+	var previous models.User
+	if err := database.DB.Select("id", "url", "thumbnail_url", "public_id").First(&previous, "id = ?", userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "User")
+	}
+	user, err := UpdateProfile(userID, map[string]interface{}{
+		"url":           image.URL,
+		"thumbnail_url": image.ThumbnailURL,
+		"public_id":     image.PublicID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if previous.ProfileImage.URL != image.URL {
+		deleteAvatar(previous.ProfileImage)
+	}
+	return user, nil
+}
+
+// deleteAvatar deletes an avatar the user no longer has from Cloudinary.
+// Avatars uploaded before public IDs were kept are found by their URL. A
+// failure is only logged; nothing points at the picture any more.
+func deleteAvatar(image models.ProfileImage) {
+	publicID := image.PublicID
+	if publicID == "" {
+		publicID = utils.CloudinaryPublicID(image.URL)
+	}
+	if publicID == "" {
+		return
+	}
+	if err := utils.DeleteFromCloudinary(publicID); err != nil {
+		log.Printf("delete avatar %s: %v", publicID, err)
+	}
+}
+
+// GetPublicProfile loads a user by username, ignoring case, with the number
+// of their published reviews. Deleted accounts have no public profile.
+func GetPublicProfile(username string) (*models.User, int64, error) {
+	var user models.User
+	if err := database.DB.First(&user, "LOWER(username) = ? AND anonymized_at IS NULL", strings.ToLower(username)).Error; err != nil {
+		return nil, 0, apperrors.FromDB(err, "User")
+	}
+	var reviews int64
+	err := database.DB.Model(&models.Review{}).
+		Where("user_id = ? AND status = ?", user.ID, models.ReviewApproved).
+		Count(&reviews).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return &user, reviews, nil
+}
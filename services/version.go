@@ -0,0 +1,39 @@
+package services
+
+import (
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"gorm.io/gorm"
+)
+
+func errVersionConflict() error {
+	return apperrors.PreconditionFailed("The resource was modified since it was read; reload and retry")
+}
+
+// bumpVersion increments the version of a row only if it still holds the
+// expected one. Run inside the transaction that writes the row: the
+// conditional UPDATE locks it, so a concurrent writer with the same version
+// finds zero rows and gets a 412.
+func bumpVersion(tx *gorm.DB, model interface{}, id string, version int) error {
+	result := tx.Model(model).
+		Where("id = ? AND version = ?", id, version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict()
+	}
+	return nil
+}
+
+// deleteVersioned deletes a row only if it still holds the expected version.
+func deleteVersioned(tx *gorm.DB, model interface{}, id string, version int) error {
+	result := tx.Where("id = ? AND version = ?", id, version).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict()
+	}
+	return nil
+}
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/config"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// expiryBatch is how many lapsed offers one expiry run handles.
+const expiryBatch = 100
+
+var openWaitlistStatuses = []models.WaitlistStatus{models.WaitlistWaiting, models.WaitlistOffered}
+
+// JoinWaitlist puts the user's party in line for a sold-out departure or
+// event. Departures and events that still have seats for the party must be
+// booked instead.
+func JoinWaitlist(entry *models.WaitlistEntry) error {
+	if err := checkParty(entry.Party()); err != nil {
+		return err
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		free, err := freeSeats(tx, entry.TargetType, entry.TargetID)
+		if err != nil {
+			return err
+		}
+		if free >= entry.Seats() {
+			return apperrors.Conflict("Seats are available; book instead")
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return apperrors.FromDB(err, "Waitlist entry")
+		}
+		subject, err := waitlistSubject(tx, entry)
+		if err != nil {
+			return err
+		}
+		if err := setWaitlistPosition(tx, entry); err != nil {
+			return err
+		}
+		return notify(tx, entry.UserID, models.NotificationWaitlistJoined,
+			"You're on the waitlist for "+subject,
+			fmt.Sprintf("You are number %d in line for %s. We'll let you know as soon as seats free up.", entry.Position, subject),
+			waitlistLink(entry))
+	})
+}
+
+// GetUserWaitlist lists the user's waitlist entries, newest first, with
+// their place in line.
+func GetUserWaitlist(c *fiber.Ctx, userID uuid.UUID) ([]models.WaitlistEntry, int64, error) {
+	query := database.DB.Model(&models.WaitlistEntry{}).Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return pageWaitlist(c, query, "created_at DESC")
+}
+
+// GetUserWaitlistEntry returns one of the user's waitlist entries with its
+// place in line.
+func GetUserWaitlistEntry(userID, id uuid.UUID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := database.DB.First(&entry, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Waitlist entry")
+	}
+	if err := setWaitlistPosition(database.DB, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetWaitlist lists the open entries of a departure or event in line order,
+// offers first, with the customers and their places in line.
+func GetWaitlist(c *fiber.Ctx, targetType models.WaitlistTarget, targetID uuid.UUID) ([]models.WaitlistEntry, int64, error) {
+	query := database.DB.Model(&models.WaitlistEntry{}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Preload("User")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", openWaitlistStatuses)
+	}
+	return pageWaitlist(c, query, "CASE WHEN status = 'offered' THEN 0 ELSE 1 END, created_at")
+}
+
+func pageWaitlist(c *fiber.Ctx, query *gorm.DB, order string) ([]models.WaitlistEntry, int64, error) {
+	var entries []models.WaitlistEntry
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order(order).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range entries {
+		if err := setWaitlistPosition(database.DB, &entries[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return entries, totalCount, nil
+}
+
+// setWaitlistPosition sets the place in line of a waiting entry: one more
+// than the entries that joined the same line before it and still wait.
+func setWaitlistPosition(tx *gorm.DB, entry *models.WaitlistEntry) error {
+	if entry.Status != models.WaitlistWaiting {
+		entry.Position = 0
+		return nil
+	}
+	var ahead int64
+	err := tx.Model(&models.WaitlistEntry{}).
+		Where("target_type = ? AND target_id = ? AND status = ? AND created_at < ?",
+			entry.TargetType, entry.TargetID, models.WaitlistWaiting, entry.CreatedAt).
+		Count(&ahead).Error
+	entry.Position = int(ahead) + 1
+	return err
+}
+
+// LeaveWaitlist withdraws one of the user's open entries. Seats held for
+// an offer are passed on to the next in line.
+func LeaveWaitlist(userID, id uuid.UUID) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var entry models.WaitlistEntry
+		if err := tx.First(&entry, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Waitlist entry")
+		}
+		return leaveWaitlistEntry(tx, &entry)
+	})
+}
+
+// leaveWaitlistEntry takes an open entry out of line, returning the seats
+// an offer held.
+func leaveWaitlistEntry(tx *gorm.DB, entry *models.WaitlistEntry) error {
+	result := tx.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entry.ID, entry.Status).
+		Where("status IN ?", openWaitlistStatuses).
+		Update("status", models.WaitlistLeft)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.Conflict("Waitlist entry is no longer open")
+	}
+	if entry.Status != models.WaitlistOffered {
+		return nil
+	}
+	return returnHeldSeats(tx, entry)
+}
+
+// leaveWaitlists takes all of the user's open entries out of line.
+func leaveWaitlists(tx *gorm.DB, userID uuid.UUID) error {
+	var entries []models.WaitlistEntry
+	if err := tx.Find(&entries, "user_id = ? AND status IN ?", userID, openWaitlistStatuses).Error; err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := leaveWaitlistEntry(tx, &entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimWaitlistOffer turns an unexpired offer into a booking. The held
+// seats or tickets are booked and priced like any other booking, which then
+// waits for payment: the places are only the customer's once it is paid,
+// and go back on offer if its hold runs out first.
+func ClaimWaitlistOffer(userID, id uuid.UUID) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&entry, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			return apperrors.FromDB(err, "Waitlist entry")
+		}
+		now := time.Now()
+		result := tx.Model(&models.WaitlistEntry{}).
+			Where("id = ? AND status = ? AND offer_expires_at > ?", entry.ID, models.WaitlistOffered, now).
+			Update("status", models.WaitlistClaimed)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if entry.Status == models.WaitlistOffered {
+				return apperrors.Conflict("Offer has expired")
+			}
+			return apperrors.Conflict("Waitlist entry has no offer to claim")
+		}
+		entry.Status = models.WaitlistClaimed
+
+		// The held seats are handed back and booked again in the same
+		// transaction, so nobody else can take them in between.
+		if err := releaseSeats(tx, entry.TargetType, entry.TargetID, entry.Seats()); err != nil {
+			return err
+		}
+		booking := models.Booking{
+			UserID:   userID,
+			Adults:   entry.Adults,
+			Children: entry.Children,
+			Infants:  entry.Infants,
+		}
+		if entry.TargetType == models.WaitlistEvent {
+			var event models.Event
+			if err := tx.First(&event, "id = ?", entry.TargetID).Error; err != nil {
+				return apperrors.FromDB(err, "Event")
+			}
+			if err := bookEvent(tx, &event, &booking); err != nil {
+				return err
+			}
+		} else {
+			var departure models.TourDeparture
+			if err := tx.First(&departure, "id = ?", entry.TargetID).Error; err != nil {
+				return apperrors.FromDB(err, "Departure")
+			}
+			var tour models.Tour
+			if err := tx.First(&tour, "id = ?", departure.TourID).Error; err != nil {
+				return apperrors.FromDB(err, "Tour")
+			}
+			booking.DepartureID = &departure.ID
+			if err := bookTour(tx, &tour, &booking); err != nil {
+				return err
+			}
+		}
+		entry.BookingID = &booking.ID
+		return tx.Model(&entry).Update("booking_id", booking.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// StartWaitlistExpirer expires lapsed waitlist offers every interval.
+func StartWaitlistExpirer(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := ExpireWaitlistOffers()
+			if err != nil {
+				log.Printf("waitlist expirer: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("waitlist expirer: expired %d offers", expired)
+			}
+		}
+	}()
+}
+
+// ExpireWaitlistOffers expires the offers whose hold window has passed and
+// offers their seats to the next in line. It returns how many offers
+// expired.
+func ExpireWaitlistOffers() (int, error) {
+	var lapsed []models.WaitlistEntry
+	err := database.DB.Where("status = ? AND offer_expires_at <= ?", models.WaitlistOffered, time.Now()).
+		Order("offer_expires_at").
+		Limit(expiryBatch).
+		Find(&lapsed).Error
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range lapsed {
+		entry := &lapsed[i]
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			// A customer claiming at the same time wins or loses here.
+			result := tx.Model(&models.WaitlistEntry{}).
+				Where("id = ? AND status = ?", entry.ID, models.WaitlistOffered).
+				Update("status", models.WaitlistExpired)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			expired++
+			subject, err := waitlistSubject(tx, entry)
+			if err != nil {
+				return err
+			}
+			if err := notify(tx, entry.UserID, models.NotificationWaitlistExpired,
+				"Your offer for "+subject+" has expired",
+				fmt.Sprintf("The seats we held for you on %s have been released. You can join the waitlist again at any time.", subject),
+				waitlistLink(entry)); err != nil {
+				return err
+			}
+			return returnHeldSeats(tx, entry)
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// releaseBookingSeats frees the seats or tickets a booking held on its
+// departure or event and offers them to the waitlist in the caller's
+// transaction.
+func releaseBookingSeats(tx *gorm.DB, booking *models.Booking) error {
+	targetType, targetID, ok := bookingSeatTarget(booking)
+	if !ok {
+		return nil
+	}
+	if err := releaseSeats(tx, targetType, targetID, booking.Party().Seats()); err != nil {
+		return err
+	}
+	return offerWaitlist(tx, targetType, targetID)
+}
+
+// bookingSeatTarget returns what a booking takes seats on: its event or its
+// departure. ok is false for bookings made without either.
+func bookingSeatTarget(booking *models.Booking) (targetType models.WaitlistTarget, targetID uuid.UUID, ok bool) {
+	switch {
+	case booking.EventID != nil:
+		return models.WaitlistEvent, *booking.EventID, true
+	case booking.DepartureID != nil:
+		return models.WaitlistDeparture, *booking.DepartureID, true
+	}
+	return "", uuid.Nil, false
+}
+
+// returnHeldSeats frees the seats held for an offer and offers them to the
+// next in line.
+func returnHeldSeats(tx *gorm.DB, entry *models.WaitlistEntry) error {
+	if err := releaseSeats(tx, entry.TargetType, entry.TargetID, entry.Seats()); err != nil {
+		return err
+	}
+	return offerWaitlist(tx, entry.TargetType, entry.TargetID)
+}
+
+// offerWaitlist offers the seats free on a departure or event to the line:
+// entries are offered in the order they joined, skipping parties too large
+// for what is left. The seats are held for each offered entry until its
+// offer expires.
+func offerWaitlist(tx *gorm.DB, targetType models.WaitlistTarget, targetID uuid.UUID) error {
+	free, err := freeSeats(tx, targetType, targetID)
+	if err != nil || free <= 0 {
+		if errors.Is(err, errNotOffered) {
+			return nil
+		}
+		return err
+	}
+
+	var waiting []models.WaitlistEntry
+	err = tx.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.WaitlistWaiting).
+		Order("created_at").
+		Find(&waiting).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range waiting {
+		if free <= 0 {
+			break
+		}
+		entry := &waiting[i]
+		seats := entry.Seats()
+		if seats > free {
+			continue
+		}
+		held, err := holdSeats(tx, targetType, targetID, seats)
+		if err != nil {
+			return err
+		}
+		if !held {
+			// Someone else took the seats in the meantime.
+			break
+		}
+		now := time.Now()
+		expires := now.Add(config.WaitlistHoldWindow)
+		result := tx.Model(&models.WaitlistEntry{}).
+			Where("id = ? AND status = ?", entry.ID, models.WaitlistWaiting).
+			Updates(map[string]interface{}{
+				"status":           models.WaitlistOffered,
+				"offered_at":       now,
+				"offer_expires_at": expires,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := releaseSeats(tx, targetType, targetID, seats); err != nil {
+				return err
+			}
+			continue
+		}
+		free -= seats
+
+		subject, err := waitlistSubject(tx, entry)
+		if err != nil {
+			return err
+		}
+		err = notify(tx, entry.UserID, models.NotificationWaitlistOffer,
+			"Seats are available for "+subject,
+			fmt.Sprintf("We're holding %d seats on %s for you until %s UTC. Claim them before then to book.",
+				seats, subject, expires.UTC().Format("2 Jan 2006 15:04")),
+			waitlistLink(entry))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errNotOffered marks a departure or event whose seats are no longer
+// offered, because it is cancelled or has already taken place.
+var errNotOffered = apperrors.Conflict("Departure or event is no longer available")
+
+// freeSeats returns how many seats a departure or event has left. A
+// departure or event that can't be booked any more gives errNotOffered.
+func freeSeats(tx *gorm.DB, targetType models.WaitlistTarget, targetID uuid.UUID) (int, error) {
+	today := calendarDay(time.Now())
+	switch targetType {
+	case models.WaitlistDeparture:
+		var departure models.TourDeparture
+		if err := tx.First(&departure, "id = ?", targetID).Error; err != nil {
+			return 0, apperrors.FromDB(err, "Departure")
+		}
+		if departure.Status == models.DepartureCancelled || departure.StartDate.Before(today) {
+			return 0, errNotOffered
+		}
+		return departure.SeatsLeft(), nil
+	case models.WaitlistEvent:
+		var event models.Event
+		if err := tx.First(&event, "id = ?", targetID).Error; err != nil {
+			return 0, apperrors.FromDB(err, "Event")
+		}
+		if event.EventDate.Before(today) {
+			return 0, errNotOffered
+		}
+		return event.Availability, nil
+	}
+	return 0, apperrors.BadRequest("Unknown waitlist target")
+}
+
+// holdSeats takes seats on a departure or event if they are still free.
+func holdSeats(tx *gorm.DB, targetType models.WaitlistTarget, targetID uuid.UUID, seats int) (bool, error) {
+	var result *gorm.DB
+	if targetType == models.WaitlistEvent {
+		result = tx.Model(&models.Event{}).
+			Where("id = ? AND availability >= ?", targetID, seats).
+			Update("availability", gorm.Expr("availability - ?", seats))
+	} else {
+		result = tx.Model(&models.TourDeparture{}).
+			Where("id = ? AND status <> ? AND seats_booked + ? <= capacity", targetID, models.DepartureCancelled, seats).
+			Update("seats_booked", gorm.Expr("seats_booked + ?", seats))
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+// releaseSeats gives seats on a departure or event back.
+func releaseSeats(tx *gorm.DB, targetType models.WaitlistTarget, targetID uuid.UUID, seats int) error {
+	if targetType == models.WaitlistEvent {
+		return tx.Model(&models.Event{}).Where("id = ?", targetID).
+			Update("availability", gorm.Expr("availability + ?", seats)).Error
+	}
+	return tx.Model(&models.TourDeparture{}).Where("id = ?", targetID).
+		Update("seats_booked", gorm.Expr("CASE WHEN seats_booked > ? THEN seats_booked - ? ELSE 0 END", seats, seats)).Error
+}
+
+// waitlistSubject names what an entry waits for in notifications.
+func waitlistSubject(tx *gorm.DB, entry *models.WaitlistEntry) (string, error) {
+	if entry.TargetType == models.WaitlistEvent {
+		var event models.Event
+		if err := tx.Select("id", "title", "event_date").First(&event, "id = ?", entry.TargetID).Error; err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s on %s", event.Title, event.EventDate.UTC().Format("2 Jan 2006")), nil
+	}
+	var departure models.TourDeparture
+	if err := tx.Preload("Tour").First(&departure, "id = ?", entry.TargetID).Error; err != nil {
+		return "", err
+	}
+	title := "your tour"
+	if departure.Tour != nil {
+		title = departure.Tour.Title
+	}
+	return fmt.Sprintf("%s departing %s", title, departure.StartDate.UTC().Format("2 Jan 2006")), nil
+}
+
+func waitlistLink(entry *models.WaitlistEntry) string {
+	return "/api/user/waitlist/" + entry.ID.String()
+}
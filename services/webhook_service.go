@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/apperrors"
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+	"github.com/Twisac-Solutions/tours-backend/payments"
+	"github.com/garrettladley/fiberpaginate/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxWebhookAttempts is how often a failing event is tried before it is
+	// left for an admin to replay.
+	maxWebhookAttempts = 12
+	// maxWebhookBackoff caps the delay between retries.
+	maxWebhookBackoff = 6 * time.Hour
+	// webhookRetryBatch is how many due events one retry run picks up.
+	webhookRetryBatch = 100
+	// webhookPendingGrace is how long an event may stay pending before the
+	// retrier assumes the request that stored it died before processing it.
+	webhookPendingGrace = 5 * time.Minute
+	// webhookUnmatchedGrace is how long an event for an intent we hold no
+	// payment for is retried, in case the provider was quicker than our own
+	// write, before it is ignored.
+	webhookUnmatchedGrace = time.Hour
+)
+
+// webhookEventTypes are the provider events applied to payments; others are
+// ignored.
+var webhookEventTypes = map[string]bool{
+	"payment_intent.succeeded":      true,
+	"payment_intent.canceled":       true,
+	"payment_intent.processing":     true,
+	"payment_intent.payment_failed": true,
+	"charge.refunded":               true,
+}
+
+// ReceivePaymentWebhook verifies a webhook from the payment provider, stores
+// it in the inbox and processes it. An event the inbox already holds is not
+// processed again and is reported as a duplicate, unless it is still pending
+// because an earlier delivery died before processing it. Processing errors
+// don't fail the call: the event stays in the inbox and is retried later.
+func ReceivePaymentWebhook(payload []byte, signature string) (*models.WebhookEvent, bool, error) {
+	event, err := paymentProvider.VerifyWebhook(payload, signature)
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		return nil, false, apperrors.BadRequest("Invalid webhook signature")
+	}
+	if err != nil {
+		return nil, false, apperrors.BadRequest("Malformed webhook payload")
+	}
+
+	record := models.WebhookEvent{
+		Provider: paymentProvider.Name(),
+		EventID:  event.ID,
+		Type:     event.Type,
+		Payload:  string(payload),
+		Status:   models.WebhookPending,
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var existing models.WebhookEvent
+		err := database.DB.Where("provider = ? AND event_id = ?", record.Provider, record.EventID).
+			Take(&existing).Error
+		if err != nil || existing.Status != models.WebhookPending {
+			return &existing, true, err
+		}
+		record = existing
+	}
+
+	if err := processWebhookEvent(&record); err != nil {
+		return nil, false, err
+	}
+	return &record, false, nil
+}
+
+// processWebhookEvent applies a stored event and records the outcome. A
+// failure is scheduled for a retry with exponential backoff.
+func processWebhookEvent(record *models.WebhookEvent) error {
+	handled, err := applyWebhookEvent(record)
+
+	now := time.Now()
+	record.Attempts++
+	record.NextAttemptAt = nil
+	switch {
+	case err != nil:
+		log.Printf("payment webhook %s (%s): %v", record.EventID, record.Type, err)
+		record.Status = models.WebhookFailed
+		record.LastError = err.Error()
+		if record.Attempts < maxWebhookAttempts {
+			next := now.Add(webhookBackoff(record.Attempts))
+			record.NextAttemptAt = &next
+		}
+	case handled:
+		record.Status = models.WebhookProcessed
+		record.LastError = ""
+		record.ProcessedAt = &now
+	default:
+		record.Status = models.WebhookIgnored
+		record.LastError = ""
+		record.ProcessedAt = &now
+	}
+
+	return database.DB.Model(record).
+		Select("status", "attempts", "last_error", "next_attempt_at", "processed_at").
+		Updates(record).Error
+}
+
+// webhookBackoff is the delay before retry number attempts: one minute,
+// doubling each time, up to maxWebhookBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookBackoff)
+}
+
+// applyWebhookEvent moves the payment (and through it the booking) named by
+// an event to the state the provider reports. It reports false for events
+// that don't concern any of our payments. An event naming an intent we hold
+// no payment for fails until webhookUnmatchedGrace has passed, as the
+// payment may not have been stored yet. Every transition is conditional, so
+// applying an event twice, or out of order, is harmless.
+func applyWebhookEvent(record *models.WebhookEvent) (bool, error) {
+	event, err := payments.DecodeEvent([]byte(record.Payload))
+	if err != nil {
+		return false, err
+	}
+	if !webhookEventTypes[event.Type] {
+		return false, nil
+	}
+
+	var payment models.Payment
+	err = database.DB.Where("provider = ? AND provider_ref = ?", record.Provider, event.IntentID).
+		Take(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if time.Since(record.CreatedAt) < webhookUnmatchedGrace {
+			return false, fmt.Errorf("no payment for intent %s yet", event.IntentID)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch event.Type {
+	case "payment_intent.succeeded", "payment_intent.canceled", "payment_intent.processing":
+		return true, syncPayment(&payment, event.Status, event.FailureReason)
+	case "payment_intent.payment_failed":
+		reason := event.FailureReason
+		if reason == "" {
+			reason = "The payment failed"
+		}
+		return true, syncPayment(&payment, payments.IntentRequiresPaymentMethod, reason)
+	case "charge.refunded":
+		return true, recordProviderRefund(&payment, event.Refunded, record.EventID)
+	default:
+		return false, nil
+	}
+}
+
+// recordProviderRefund catches up with refunds made outside this API, e.g.
+// in the provider's dashboard. totalRefunded is the provider's running total
+// for the payment; whatever we haven't recorded yet is added as one refund.
+func recordProviderRefund(payment *models.Payment, totalRefunded int64, eventID string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(payment, "id = ?", payment.ID).Error; err != nil {
+			return err
+		}
+		missing := min(totalRefunded, payment.Amount) - payment.RefundedAmount
+		if missing <= 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.Payment{}).
+			Where("id = ? AND refunded_amount = ?", payment.ID, payment.RefundedAmount).
+			Updates(map[string]any{
+				"refunded_amount": gorm.Expr("refunded_amount + ?", missing),
+				"status": gorm.Expr("CASE WHEN refunded_amount + ? >= amount THEN ? ELSE ? END",
+					missing, models.PaymentRefunded, models.PaymentPartiallyRefunded),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("payment changed while recording the refund")
+		}
+
+		return tx.Create(&models.PaymentRefund{
+			PaymentID:      payment.ID,
+			IdempotencyKey: "webhook:" + eventID,
+			Amount:         missing,
+			Reason:         "Refunded at the payment provider",
+			Status:         models.RefundSucceeded,
+		}).Error
+	})
+}
+
+// StartWebhookRetrier runs RetryWebhookEvents in the background every
+// interval. A zero interval disables it.
+func StartWebhookRetrier(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			retried, err := RetryWebhookEvents()
+			if err != nil {
+				log.Printf("webhook retrier: %v", err)
+				continue
+			}
+			if retried > 0 {
+				log.Printf("webhook retrier: processed %d events", retried)
+			}
+		}
+	}()
+}
+
+// RetryWebhookEvents processes the failed inbox events that are due for
+// another attempt, and pending ones left behind for longer than
+// webhookPendingGrace, and returns how many of them succeeded.
+func RetryWebhookEvents() (int, error) {
+	now := time.Now()
+	var due []models.WebhookEvent
+	err := database.DB.
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND created_at <= ?)",
+			models.WebhookFailed, now, models.WebhookPending, now.Add(-webhookPendingGrace)).
+		Order("COALESCE(next_attempt_at, created_at)").
+		Limit(webhookRetryBatch).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range due {
+		if err := processWebhookEvent(&due[i]); err != nil {
+			return processed, err
+		}
+		if due[i].Status != models.WebhookFailed {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+// ReplayWebhookEvent processes a stored event again, whatever its state.
+func ReplayWebhookEvent(id uuid.UUID) (*models.WebhookEvent, error) {
+	var record models.WebhookEvent
+	if err := database.DB.First(&record, "id = ?", id).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Webhook event")
+	}
+	if err := processWebhookEvent(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetWebhookEvents lists inbox events for admins, newest first, optionally
+// filtered by status and type.
+func GetWebhookEvents(c *fiber.Ctx) ([]models.WebhookEvent, int64, error) {
+	var events []models.WebhookEvent
+	var totalCount int64
+
+	pageInfo, ok := fiberpaginate.FromContext(c)
+	if !ok {
+		pageInfo = &fiberpaginate.PageInfo{
+			Page:  1,
+			Limit: 10,
+		}
+	}
+
+	query := database.DB.Model(&models.WebhookEvent{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType := c.Query("type"); eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Offset(pageInfo.Start()).
+		Limit(pageInfo.Limit).
+		Order("created_at DESC").
+		Find(&events).Error
+	return events, totalCount, err
+}
+
+// GetWebhookEventByID returns one inbox event.
+func GetWebhookEventByID(id uuid.UUID) (*models.WebhookEvent, error) {
+	var record models.WebhookEvent
+	if err := database.DB.First(&record, "id = ?", id).Error; err != nil {
+		return nil, apperrors.FromDB(err, "Webhook event")
+	}
+	return &record, nil
+}
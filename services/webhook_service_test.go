@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Twisac-Solutions/tours-backend/database"
+	"github.com/Twisac-Solutions/tours-backend/models"
+)
+
+func TestUnmatchedWebhookIsRetriedBeforeIgnored(t *testing.T) {
+	setupTestDB(t)
+	record := &models.WebhookEvent{
+		Provider: paymentProvider.Name(),
+		EventID:  "evt_unmatched",
+		Type:     "payment_intent.succeeded",
+		Payload:  `{"id":"evt_unmatched","type":"payment_intent.succeeded","data":{"object":{"id":"pi_unknown","object":"payment_intent","status":"succeeded"}}}`,
+		Status:   models.WebhookPending,
+	}
+	if err := database.DB.Create(record).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := processWebhookEvent(record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != models.WebhookFailed || record.NextAttemptAt == nil {
+		t.Fatalf("fresh unmatched event = %s, retry at %v; want failed with a retry", record.Status, record.NextAttemptAt)
+	}
+
+	record.CreatedAt = time.Now().Add(-webhookUnmatchedGrace - time.Minute)
+	if err := processWebhookEvent(record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != models.WebhookIgnored {
+		t.Errorf("unmatched event past the grace period = %s, want ignored", record.Status)
+	}
+}
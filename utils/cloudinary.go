@@ -2,7 +2,10 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"mime/multipart"
+	"strings"
 	"time"
 
 	"github.com/Twisac-Solutions/tours-backend/config"
@@ -45,3 +48,130 @@ func UploadImageToCloudinary(file *multipart.FileHeader, folder string) (string,
 
 	return uploadResult.SecureURL, nil
 }
+
+// Sizes in pixels of the square avatar and its thumbnail.
+const (
+	avatarSize          = 400
+	avatarThumbnailSize = 96
+)
+
+// ImageCrop is the rectangle of an uploaded image to keep, in pixels of the
+// original.
+type ImageCrop struct {
+	X, Y, Width, Height int
+}
+
+// UploadAvatarToCloudinary uploads a profile picture and returns the URLs
+// of the square avatar and its thumbnail, and the public ID of the uploaded
+// picture. Both are cut from crop, or around the face when crop is nil, and
+// generated while the picture is uploaded.
+func UploadAvatarToCloudinary(file *multipart.FileHeader, crop *ImageCrop) (url, thumbnailURL, publicID string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	src, err := file.Open()
+	if err != nil {
+		return "", "", "", err
+	}
+	defer src.Close()
+
+	avatar, thumbnail := avatarTransformations(crop)
+	uploadResult, err := cld.Upload.Upload(ctx, src, uploader.UploadParams{
+		Folder:       "avatars",
+		ResourceType: "image",
+		Eager:        avatar + "|" + thumbnail,
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+	if uploadResult.Error.Message != "" {
+		return "", "", "", errors.New(uploadResult.Error.Message)
+	}
+	if url, err = imageURL(uploadResult, avatar); err != nil {
+		return "", "", "", err
+	}
+	if thumbnailURL, err = imageURL(uploadResult, thumbnail); err != nil {
+		return "", "", "", err
+	}
+	return url, thumbnailURL, uploadResult.PublicID, nil
+}
+
+// DeleteFromCloudinary permanently deletes an uploaded image, with every
+// version derived from it, and invalidates the copies cached on the CDN.
+// An image that is already gone is not an error.
+func DeleteFromCloudinary(publicID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	invalidate := true
+	result, err := cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: "image",
+		Invalidate:   &invalidate,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New(result.Error.Message)
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return fmt.Errorf("cloudinary destroy %s: %s", publicID, result.Result)
+	}
+	return nil
+}
+
+// CloudinaryPublicID returns the public ID of the image a Cloudinary
+// delivery URL shows, e.g. "avatars/abc" for
+// https://res.cloudinary.com/demo/image/upload/c_fill,w_400/v17/avatars/abc.jpg.
+// It returns "" for URLs that aren't Cloudinary uploads.
+func CloudinaryPublicID(deliveryURL string) string {
+	_, path, found := strings.Cut(deliveryURL, "/image/upload/")
+	if !found {
+		return ""
+	}
+	// Transformations come before the version; the public ID follows it.
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && segment[0] == 'v' && isDigits(segment[1:]) {
+			segments = segments[i+1:]
+			break
+		}
+	}
+	publicID := strings.Join(segments, "/")
+	if dot := strings.LastIndex(publicID, "."); dot > strings.LastIndex(publicID, "/") {
+		publicID = publicID[:dot]
+	}
+	return publicID
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func avatarTransformations(crop *ImageCrop) (avatar, thumbnail string) {
+	if crop == nil {
+		return fmt.Sprintf("c_fill,g_face,w_%d,h_%d", avatarSize, avatarSize),
+			fmt.Sprintf("c_thumb,g_face,w_%d,h_%d", avatarThumbnailSize, avatarThumbnailSize)
+	}
+	cut := fmt.Sprintf("c_crop,x_%d,y_%d,w_%d,h_%d/", crop.X, crop.Y, crop.Width, crop.Height)
+	return cut + fmt.Sprintf("c_fill,w_%d,h_%d", avatarSize, avatarSize),
+		cut + fmt.Sprintf("c_fill,w_%d,h_%d", avatarThumbnailSize, avatarThumbnailSize)
+}
+
+// imageURL returns the delivery URL of an uploaded image with a
+// transformation applied.
+func imageURL(upload *uploader.UploadResult, transformation string) (string, error) {
+	image, err := cld.Image(upload.PublicID)
+	if err != nil {
+		return "", err
+	}
+	image.Transformation = transformation
+	image.Version = upload.Version
+	return image.String()
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestCloudinaryPublicID(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			url:  "https://res.cloudinary.com/demo/image/upload/c_fill,g_face,w_400,h_400/v1712345678/avatars/abc123.jpg",
+			want: "avatars/abc123",
+		},
+		{
+			url:  "https://res.cloudinary.com/demo/image/upload/c_crop,x_1,y_2,w_3,h_4/c_fill,w_96,h_96/v17/avatars/abc123.png",
+			want: "avatars/abc123",
+		},
+		{
+			url:  "https://res.cloudinary.com/demo/image/upload/v17/avatars/abc123",
+			want: "avatars/abc123",
+		},
+		{
+			url:  "https://res.cloudinary.com/demo/image/upload/v17/avatars/v2/abc.123.webp",
+			want: "avatars/v2/abc.123",
+		},
+		{url: "https://lh3.googleusercontent.com/a/photo.jpg"},
+		{url: ""},
+	}
+	for _, tt := range tests {
+		if got := CloudinaryPublicID(tt.url); got != tt.want {
+			t.Errorf("CloudinaryPublicID(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
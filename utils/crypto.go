@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// dataCipher encrypts sensitive personal data stored in the database.
+var dataCipher cipher.AEAD
+
+// InitEncryption sets the key used by Encrypt and Decrypt: 32 bytes,
+// base64-encoded.
+func InitEncryption(key string) error {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("data encryption key is not valid base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return fmt.Errorf("data encryption key must be 32 bytes, got %d", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return err
+	}
+	dataCipher, err = cipher.NewGCM(block)
+	return err
+}
+
+// Encrypt seals plaintext with AES-256-GCM under a random nonce and returns
+// the nonce and ciphertext base64-encoded.
+func Encrypt(plaintext string) (string, error) {
+	if dataCipher == nil {
+		return "", errors.New("data encryption is not initialized")
+	}
+	nonce := make([]byte, dataCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := dataCipher.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt.
+func Decrypt(ciphertext string) (string, error) {
+	if dataCipher == nil {
+		return "", errors.New("data encryption is not initialized")
+	}
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is not valid base64: %w", err)
+	}
+	size := dataCipher.NonceSize()
+	if len(raw) < size {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := dataCipher.Open(nil, raw[:size], raw[size:], nil)
+	if err != nil {
+		return "", errors.New("encrypted value could not be decrypted")
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func TestInitEncryption(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{name: "32-byte key", key: testKey('k'), valid: true},
+		{name: "empty", key: ""},
+		{name: "not base64", key: "not a key!"},
+		{name: "too short", key: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{name: "too long", key: base64.StdEncoding.EncodeToString(make([]byte, 64))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := InitEncryption(tt.key)
+			if tt.valid != (err == nil) {
+				t.Fatalf("InitEncryption(%q) = %v, want valid=%v", tt.key, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	if err := InitEncryption(testKey('k')); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{
+		"",
+		"P1234567",
+		"Ünïcödé – 日本語 🙂",
+		strings.Repeat("long value ", 500),
+	} {
+		sealed, err := Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt: %v", err)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Errorf("ciphertext contains the plaintext")
+		}
+		opened, err := Decrypt(sealed)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if opened != plaintext {
+			t.Errorf("round trip gave %q, want %q", opened, plaintext)
+		}
+	}
+
+	first, _ := Encrypt("same")
+	second, _ := Encrypt("same")
+	if first == second {
+		t.Error("encrypting twice gave the same ciphertext; nonces must be random")
+	}
+}
+
+func TestDecryptDetectsTampering(t *testing.T) {
+	if err := InitEncryption(testKey('k')); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := Encrypt("passport P1234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+
+	flip := func(i int) string {
+		changed := append([]byte(nil), raw...)
+		changed[i] ^= 0x01
+		return base64.StdEncoding.EncodeToString(changed)
+	}
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "flipped nonce", value: flip(0)},
+		{name: "flipped ciphertext", value: flip(len(raw) / 2)},
+		{name: "flipped tag", value: flip(len(raw) - 1)},
+		{name: "truncated", value: base64.StdEncoding.EncodeToString(raw[:len(raw)-1])},
+		{name: "shorter than a nonce", value: base64.StdEncoding.EncodeToString(raw[:4])},
+		{name: "not base64", value: "%%%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.value); err == nil {
+				t.Fatal("Decrypt succeeded, want an error")
+			}
+		})
+	}
+
+	t.Run("other key", func(t *testing.T) {
+		if err := InitEncryption(testKey('o')); err != nil {
+			t.Fatal(err)
+		}
+		defer InitEncryption(testKey('k'))
+		if _, err := Decrypt(sealed); err == nil {
+			t.Fatal("Decrypt succeeded with another key, want an error")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// VersionETag formats a resource version as a strong entity tag, e.g. "3".
+func VersionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// BodyETag formats a weak entity tag for a rendered representation, e.g.
+// W/"3-1f2e...". The hash covers fields that change without a version bump
+// (ratings, converted prices, seats left); the version prefix lets the tag
+// still be sent back in If-Match.
+func BodyETag(version int, body []byte) string {
+	sum := sha256.Sum256(body)
+	return "W/" + strconv.Quote(strconv.Itoa(version)+"-"+hex.EncodeToString(sum[:8]))
+}
+
+// MatchVersion reports whether an If-Match header value names version,
+// either as a VersionETag or through the version prefix of a BodyETag.
+// "*" matches any version.
+func MatchVersion(header string, version int) bool {
+	want := strconv.Itoa(version)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" {
+			return true
+		}
+		tag, err := strconv.Unquote(candidate)
+		if err != nil {
+			continue
+		}
+		if v, _, _ := strings.Cut(tag, "-"); v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header value lists
+// etag. "*" matches any tag. With weak comparison (used for If-None-Match) a
+// W/ prefix is ignored; strong comparison (If-Match) never matches weak tags.
+func MatchETag(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -30,23 +30,51 @@ func GenerateJWTRole(userID string, role string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateSessionJWT issues a user token belonging to a login session,
+// valid until expiresAt.
+func GenerateSessionJWT(userID, sessionID string, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    "User",
+		"sid":     sessionID,
+		"exp":     expiresAt.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
 func VerifyJWT(c *fiber.Ctx) (string, error) {
-	tokenStr := c.Get("Authorization")[7:]
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims["userId"].(string), nil
+	userID, _, err := VerifySessionJWT(c)
+	return userID, err
+}
+
+// VerifySessionJWT verifies a user token and returns the user and the login
+// session it belongs to. Tokens issued before sessions were recorded have
+// no session.
+func VerifySessionJWT(c *fiber.Ctx) (userID, sessionID string, err error) {
+	tokenStr, err := bearerToken(c)
+	if err != nil {
+		return "", "", err
+	}
+	claims, err := ParseJWT(tokenStr)
+	if err != nil {
+		return "", "", err
 	}
-	return "", err
+	sessionID, _ = claims["sid"].(string)
+	// Register issues "userId" while Login issues "user_id"
+	if id, ok := claims["userId"].(string); ok {
+		return id, sessionID, nil
+	}
+	if id, ok := claims["user_id"].(string); ok {
+		return id, sessionID, nil
+	}
+	return "", "", errors.New("Invalid token data")
 }
 func VerifyJWTRole(c *fiber.Ctx) (userID string, role string, err error) {
-	authHeader := c.Get("Authorization")
-	if authHeader == "" {
-		return "", "", errors.New("Missing token")
+	tokenStr, err := bearerToken(c)
+	if err != nil {
+		return "", "", err
 	}
-
-	tokenStr := authHeader[len("Bearer "):]
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
 	})
@@ -68,3 +96,31 @@ func VerifyJWTRole(c *fiber.Ctx) (userID string, role string, err error) {
 
 	return id, roleStr, nil
 }
+
+// ParseJWT verifies a token issued by this package and returns its claims.
+func ParseJWT(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("Invalid token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("Invalid token claims")
+	}
+	return claims, nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(c *fiber.Ctx) (string, error) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return "", errors.New("Missing token")
+	}
+	const prefix = "Bearer "
+	if len(authHeader) <= len(prefix) || authHeader[:len(prefix)] != prefix {
+		return "", errors.New("Invalid authorization header")
+	}
+	return authHeader[len(prefix):], nil
+}
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRRulePeriods bounds how many days, weeks or months Occurrences walks
+// through, so a rule that rarely matches can't loop for long.
+const maxRRulePeriods = 10000
+
+// RRule is the subset of an RFC 5545 recurrence rule used to schedule tour
+// departures: FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL, COUNT, UNTIL,
+// BYDAY (with ordinals such as 1SA or -1SU for monthly rules) and
+// BYMONTHDAY.
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []RRuleDay
+	ByMonthDay []int
+}
+
+// RRuleDay is a BYDAY entry. N picks the Nth such weekday of the month,
+// counting from the end when negative; 0 means every one.
+type RRuleDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRRule parses a rule such as "FREQ=WEEKLY;BYDAY=SA;COUNT=10", with or
+// without the "RRULE:" prefix.
+func ParseRRule(value string) (*RRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("rule is empty")
+	}
+
+	rule := &RRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		name, param, ok := strings.Cut(part, "=")
+		if !ok || param == "" {
+			return nil, fmt.Errorf("malformed part %q", part)
+		}
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(param)
+			if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" && rule.Freq != "MONTHLY" {
+				return nil, fmt.Errorf("FREQ %s is not supported; use DAILY, WEEKLY or MONTHLY", param)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseRRuleTime(param)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, entry := range strings.Split(strings.ToUpper(param), ",") {
+				if len(entry) < 2 {
+					return nil, fmt.Errorf("BYDAY entry %q is malformed", entry)
+				}
+				weekday, ok := rruleWeekdays[entry[len(entry)-2:]]
+				if !ok {
+					return nil, fmt.Errorf("BYDAY entry %q is not a weekday", entry)
+				}
+				day := RRuleDay{Weekday: weekday}
+				if ordinal := entry[:len(entry)-2]; ordinal != "" {
+					n, err := strconv.Atoi(ordinal)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("BYDAY entry %q has a bad ordinal", entry)
+					}
+					day.N = n
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, entry := range strings.Split(param, ",") {
+				n, err := strconv.Atoi(entry)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY entry %q is not a day of the month", entry)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL can't be combined")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != "MONTHLY" {
+		return nil, errors.New("BYMONTHDAY needs FREQ=MONTHLY")
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != "MONTHLY" {
+			return nil, errors.New("BYDAY ordinals need FREQ=MONTHLY")
+		}
+	}
+	return rule, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes that whole day
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL %q must look like 20060102 or 20060102T150405Z", value)
+}
+
+// Occurrences returns the times the rule produces from dtstart on, at
+// dtstart's time of day, stopping after end or limit results, whichever
+// comes first. As in RFC 5545, dtstart is always the first occurrence and
+// counts toward COUNT, even when it doesn't match the rule.
+func (r *RRule) Occurrences(dtstart, end time.Time, limit int) []time.Time {
+	if dtstart.After(end) || limit <= 0 {
+		return nil
+	}
+	out := []time.Time{dtstart}
+	for period := 0; period < maxRRulePeriods; period++ {
+		for _, t := range r.candidates(dtstart, period) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if (r.Until != nil && t.After(*r.Until)) || t.After(end) {
+				return out
+			}
+			if r.Count > 0 && len(out) >= r.Count {
+				return out
+			}
+			out = append(out, t)
+			if len(out) >= limit {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// candidates returns the sorted times the rule allows in the period-th
+// day, week or month after dtstart's.
+func (r *RRule) candidates(dtstart time.Time, period int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+	step := period * r.Interval
+
+	switch r.Freq {
+	case "DAILY":
+		t := dtstart.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+
+	case "WEEKLY":
+		// Weeks start on Monday, as RFC 5545 defaults to
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := dtstart.AddDate(0, 0, step*7-offset)
+		if len(r.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, offset)}
+		}
+		var out []time.Time
+		for i := 0; i < 7; i++ {
+			if t := monday.AddDate(0, 0, i); r.hasWeekday(t.Weekday()) {
+				out = append(out, t)
+			}
+		}
+		return out
+
+	default: // MONTHLY
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, 0, dtstart.Location())
+		year, month := first.Year(), first.Month()
+		daysInMonth := first.AddDate(0, 1, -1).Day()
+
+		// BYDAY and BYMONTHDAY together keep only the days both allow
+		var days map[int]bool
+		if len(r.ByMonthDay) > 0 {
+			days = r.monthDays(daysInMonth)
+		}
+		if len(r.ByDay) > 0 {
+			byDay := r.monthWeekdays(year, month, daysInMonth)
+			if days == nil {
+				days = byDay
+			}
+			for d := range days {
+				if !byDay[d] {
+					delete(days, d)
+				}
+			}
+		}
+		if days == nil {
+			days = map[int]bool{}
+			if dtstart.Day() <= daysInMonth {
+				days[dtstart.Day()] = true
+			}
+		}
+
+		sorted := make([]int, 0, len(days))
+		for d := range days {
+			sorted = append(sorted, d)
+		}
+		sort.Ints(sorted)
+		out := make([]time.Time, len(sorted))
+		for i, d := range sorted {
+			out[i] = at(year, month, d)
+		}
+		return out
+	}
+}
+
+// monthDays returns the days of a month that BYMONTHDAY names, counting
+// negative entries from the end of the month.
+func (r *RRule) monthDays(daysInMonth int) map[int]bool {
+	days := map[int]bool{}
+	for _, n := range r.ByMonthDay {
+		if n < 0 {
+			n = daysInMonth + n + 1
+		}
+		if n >= 1 && n <= daysInMonth {
+			days[n] = true
+		}
+	}
+	return days
+}
+
+// monthWeekdays returns the days of a month that BYDAY names, honouring
+// ordinals such as 2TU or -1FR.
+func (r *RRule) monthWeekdays(year int, month time.Month, daysInMonth int) map[int]bool {
+	days := map[int]bool{}
+	for _, byDay := range r.ByDay {
+		var matches []int
+		for d := 1; d <= daysInMonth; d++ {
+			if time.Date(year, month, d, 0, 0, 0, 0, time.UTC).Weekday() == byDay.Weekday {
+				matches = append(matches, d)
+			}
+		}
+		switch {
+		case byDay.N == 0:
+			for _, d := range matches {
+				days[d] = true
+			}
+		case byDay.N > 0 && byDay.N <= len(matches):
+			days[matches[byDay.N-1]] = true
+		case byDay.N < 0 && -byDay.N <= len(matches):
+			days[matches[len(matches)+byDay.N]] = true
+		}
+	}
+	return days
+}
+
+func (r *RRule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRRuleOccurrences(t *testing.T) {
+	farEnd := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart string
+		end     string
+		limit   int
+		want    []string
+	}{
+		{
+			name:    "weekly with COUNT",
+			rule:    "FREQ=WEEKLY;BYDAY=SA;COUNT=3",
+			dtstart: "2026-01-03T10:00:00Z",
+			want:    []string{"2026-01-03T10:00:00Z", "2026-01-10T10:00:00Z", "2026-01-17T10:00:00Z"},
+		},
+		{
+			name:    "DTSTART is the first occurrence even off the rule",
+			rule:    "FREQ=WEEKLY;BYDAY=SA;COUNT=3",
+			dtstart: "2026-01-01T10:00:00Z",
+			want:    []string{"2026-01-01T10:00:00Z", "2026-01-03T10:00:00Z", "2026-01-10T10:00:00Z"},
+		},
+		{
+			name:    "weekly INTERVAL with several days",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=4",
+			dtstart: "2026-01-05T08:30:00Z",
+			want:    []string{"2026-01-05T08:30:00Z", "2026-01-07T08:30:00Z", "2026-01-19T08:30:00Z", "2026-01-21T08:30:00Z"},
+		},
+		{
+			name:    "date-only UNTIL includes that day",
+			rule:    "FREQ=DAILY;UNTIL=20260103",
+			dtstart: "2026-01-01T10:00:00Z",
+			want:    []string{"2026-01-01T10:00:00Z", "2026-01-02T10:00:00Z", "2026-01-03T10:00:00Z"},
+		},
+		{
+			name:    "UNTIL with a time is exact",
+			rule:    "FREQ=DAILY;UNTIL=20260103T090000Z",
+			dtstart: "2026-01-01T10:00:00Z",
+			want:    []string{"2026-01-01T10:00:00Z", "2026-01-02T10:00:00Z"},
+		},
+		{
+			name:    "end bounds the range",
+			rule:    "FREQ=DAILY",
+			dtstart: "2026-01-01T10:00:00Z",
+			end:     "2026-01-02T10:00:00Z",
+			want:    []string{"2026-01-01T10:00:00Z", "2026-01-02T10:00:00Z"},
+		},
+		{
+			name:    "limit bounds the results",
+			rule:    "FREQ=DAILY",
+			dtstart: "2026-01-01T10:00:00Z",
+			limit:   2,
+			want:    []string{"2026-01-01T10:00:00Z", "2026-01-02T10:00:00Z"},
+		},
+		{
+			name:    "DTSTART after end gives nothing",
+			rule:    "FREQ=DAILY",
+			dtstart: "2026-01-05T10:00:00Z",
+			end:     "2026-01-02T10:00:00Z",
+		},
+		{
+			name:    "first Saturday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=1SA;COUNT=3",
+			dtstart: "2026-01-03T09:00:00Z",
+			want:    []string{"2026-01-03T09:00:00Z", "2026-02-07T09:00:00Z", "2026-03-07T09:00:00Z"},
+		},
+		{
+			name:    "last Sunday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1SU;COUNT=3",
+			dtstart: "2026-01-25T09:00:00Z",
+			want:    []string{"2026-01-25T09:00:00Z", "2026-02-22T09:00:00Z", "2026-03-29T09:00:00Z"},
+		},
+		{
+			name:    "last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=4",
+			dtstart: "2026-01-31T09:00:00Z",
+			want:    []string{"2026-01-31T09:00:00Z", "2026-02-28T09:00:00Z", "2026-03-31T09:00:00Z", "2026-04-30T09:00:00Z"},
+		},
+		{
+			name:    "day 31 skips shorter months",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3",
+			dtstart: "2026-01-31T09:00:00Z",
+			want:    []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			name:    "monthly on DTSTART's day skips shorter months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: "2026-01-31T09:00:00Z",
+			want:    []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			name:    "BYDAY and BYMONTHDAY intersect",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;COUNT=3",
+			dtstart: "2026-02-13T18:00:00Z",
+			want:    []string{"2026-02-13T18:00:00Z", "2026-03-13T18:00:00Z", "2026-11-13T18:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rule, err)
+			}
+			end, limit := farEnd, 100
+			if tt.end != "" {
+				end = mustParseTime(t, tt.end)
+			}
+			if tt.limit > 0 {
+				limit = tt.limit
+			}
+
+			got := rule.Occurrences(mustParseTime(t, tt.dtstart), end, limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences %v, want %v", len(got), got, tt.want)
+			}
+			for i, want := range tt.want {
+				if !got[i].Equal(mustParseTime(t, want)) {
+					t.Errorf("occurrence %d = %s, want %s", i, got[i].Format(time.RFC3339), want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"BYDAY=SA",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=2026-01-01",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1SA",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=6SA",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYSETPOS=1",
+	}
+	for _, value := range tests {
+		if _, err := ParseRRule(value); err == nil {
+			t.Errorf("ParseRRule(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
@@ -2,6 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"image"
+	_ "image/gif" // decoders for ImageSize
+	_ "image/jpeg"
+	_ "image/png"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -37,3 +41,18 @@ func saveUploadedFile(file *multipart.FileHeader, dst string) error {
 	_, err = out.ReadFrom(src)
 	return err
 }
+
+// ImageSize returns the width and height of an uploaded JPEG, PNG or GIF
+// image, or an error if the file is not one.
+func ImageSize(file *multipart.FileHeader) (width, height int, err error) {
+	src, err := file.Open()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer src.Close()
+	config, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
@@ -2,9 +2,13 @@ package utils
 
 import (
 	"fmt"
+	"net/mail"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -21,7 +25,25 @@ type ValidationResult struct {
 	Errors []ValidationError `json:"errors,omitempty"`
 }
 
-// Validator provides validation functionality
+// Validator provides validation functionality.
+//
+// Rules are declared in a `validate` struct tag as a comma-separated list,
+// e.g. `validate:"required,email,max=255"`. Supported rules:
+//
+//	required              value must be non-empty
+//	email, uuid, url      format checks on strings
+//	currency              ISO 4217 currency code
+//	oneof=a b c           value must be one of the listed options
+//	min=N, max=N, len=N   length for strings and slices, value for numbers
+//	gtfield=F, gtefield=F value must be greater than (or equal to) field F
+//	ltfield=F, ltefield=F value must be less than (or equal to) field F
+//	eqfield=F, nefield=F  value must (not) equal field F
+//	required_with=F       required when field F is set
+//	required_without=F    required when field F is empty
+//	required_if=F v       required when field F equals v
+//
+// The legacy `required:"true"` tag and `form:"name,required"` are honoured
+// as well. Empty optional fields skip all other rules.
 type Validator struct {
 	// You can add custom validation functions here if needed
 	customValidators map[string]func(interface{}) error
@@ -34,11 +56,17 @@ func NewValidator() *Validator {
 	}
 }
 
-// AddCustomValidator adds a custom validation function
+// AddCustomValidator adds a custom validation function. Fields opt in with
+// a `validator:"name"` tag.
 func (v *Validator) AddCustomValidator(name string, fn func(interface{}) error) {
 	v.customValidators[name] = fn
 }
 
+type validationRule struct {
+	name  string
+	param string
+}
+
 // Validate performs validation on the given struct
 func (v *Validator) Validate(s interface{}) ValidationResult {
 	result := ValidationResult{
@@ -55,21 +83,15 @@ func (v *Validator) Validate(s interface{}) ValidationResult {
 
 	// Iterate through all fields
 	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
+		field := indirect(val.Field(i))
 		fieldType := typ.Field(i)
-
-		// Get the json tag name for the field
-		jsonTag := fieldType.Tag.Get("json")
-		if jsonTag == "" {
-			jsonTag = strings.ToLower(fieldType.Name)
-		}
-		// Remove any json tag options (like ,omitempty)
-		jsonTag = strings.Split(jsonTag, ",")[0]
+		name := fieldName(fieldType)
+		rules := parseRules(fieldType.Tag.Get("validate"))
 
 		// Check required fields
-		if v.isRequired(fieldType) && v.isEmpty(field) {
+		if v.isRequired(val, fieldType, rules) && v.isEmpty(field) {
 			result.Errors = append(result.Errors, ValidationError{
-				Field:   jsonTag,
+				Field:   name,
 				Message: "This field is required",
 			})
 			result.Valid = false
@@ -82,9 +104,9 @@ func (v *Validator) Validate(s interface{}) ValidationResult {
 		}
 
 		// Validate field based on its type
-		if err := v.validateField(field, fieldType); err != nil {
+		if err := v.validateField(val, field, fieldType, rules); err != nil {
 			result.Errors = append(result.Errors, ValidationError{
-				Field:   jsonTag,
+				Field:   name,
 				Message: err.Error(),
 			})
 			result.Valid = false
@@ -94,13 +116,65 @@ func (v *Validator) Validate(s interface{}) ValidationResult {
 	return result
 }
 
+// fieldName returns the name clients know the field by: its json tag, its
+// form tag, or the lowercased Go name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(field.Name)
+}
+
+func parseRules(tag string) []validationRule {
+	var rules []validationRule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, validationRule{name: name, param: param})
+	}
+	return rules
+}
+
+// indirect dereferences pointers, leaving nil pointers as they are
+func indirect(field reflect.Value) reflect.Value {
+	for field.Kind() == reflect.Ptr && !field.IsNil() {
+		field = field.Elem()
+	}
+	return field
+}
+
 // isRequired checks if a field is required based on struct tags
-func (v *Validator) isRequired(field reflect.StructField) bool {
+func (v *Validator) isRequired(parent reflect.Value, field reflect.StructField, rules []validationRule) bool {
 	// Check for required tag
 	if _, ok := field.Tag.Lookup("required"); ok {
 		return true
 	}
 
+	for _, rule := range rules {
+		switch rule.name {
+		case "required":
+			return true
+		case "required_with":
+			if other, ok := siblingField(parent, rule.param); ok && !v.isEmpty(other) {
+				return true
+			}
+		case "required_without":
+			if other, ok := siblingField(parent, rule.param); ok && v.isEmpty(other) {
+				return true
+			}
+		case "required_if":
+			otherName, want, _ := strings.Cut(rule.param, " ")
+			if other, ok := siblingField(parent, otherName); ok && fmt.Sprint(other.Interface()) == want {
+				return true
+			}
+		}
+	}
+
 	// Check form tag
 	formTag := field.Tag.Get("form")
 	return strings.Contains(formTag, "required")
@@ -113,14 +187,23 @@ func (v *Validator) isEmpty(field reflect.Value) bool {
 		return field.String() == ""
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return field.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.Uint() == 0
 	case reflect.Float32, reflect.Float64:
 		return field.Float() == 0
+	case reflect.Slice, reflect.Map:
+		return field.Len() == 0
+	case reflect.Array:
+		if field.Type() == reflect.TypeOf(uuid.UUID{}) {
+			return field.Interface().(uuid.UUID) == uuid.Nil
+		}
+		return false
 	case reflect.Struct:
 		if field.Type() == reflect.TypeOf(time.Time{}) {
 			return field.Interface().(time.Time).IsZero()
 		}
 		return false
-	case reflect.Ptr:
+	case reflect.Ptr, reflect.Interface:
 		return field.IsNil()
 	default:
 		return false
@@ -128,29 +211,32 @@ func (v *Validator) isEmpty(field reflect.Value) bool {
 }
 
 // validateField validates a single field based on its type and tags
-func (v *Validator) validateField(field reflect.Value, fieldType reflect.StructField) error {
-	switch field.Interface().(type) {
+func (v *Validator) validateField(parent, field reflect.Value, fieldType reflect.StructField, rules []validationRule) error {
+	switch value := field.Interface().(type) {
 	case uuid.UUID:
-		if field.Interface().(uuid.UUID) == uuid.Nil {
+		if value == uuid.Nil {
 			return fmt.Errorf("invalid UUID format")
 		}
-	case string:
-		if maxLen, ok := fieldType.Tag.Lookup("max"); ok {
-			// Add string length validation
-			_ = maxLen // You can implement this
-		}
-	case float64:
-		if min, ok := fieldType.Tag.Lookup("min"); ok {
-			// Add minimum value validation
-			_ = min // You can implement this
-		}
 	case time.Time:
 		// Add any specific time validation if needed
-		if field.Interface().(time.Time).IsZero() {
+		if value.IsZero() {
 			return fmt.Errorf("invalid date format")
 		}
 	}
 
+	// Legacy standalone min/max tags
+	for _, name := range []string{"min", "max"} {
+		if param, ok := fieldType.Tag.Lookup(name); ok {
+			rules = append(rules, validationRule{name: name, param: param})
+		}
+	}
+
+	for _, rule := range rules {
+		if err := v.applyRule(parent, field, rule); err != nil {
+			return err
+		}
+	}
+
 	// Run custom validators if any
 	if validatorName := fieldType.Tag.Get("validator"); validatorName != "" {
 		if validator, ok := v.customValidators[validatorName]; ok {
@@ -162,3 +248,172 @@ func (v *Validator) validateField(field reflect.Value, fieldType reflect.StructF
 
 	return nil
 }
+
+func (v *Validator) applyRule(parent, field reflect.Value, rule validationRule) error {
+	switch rule.name {
+	case "required", "required_with", "required_without", "required_if", "omitempty":
+		return nil
+	case "email":
+		if _, err := mail.ParseAddress(field.String()); err != nil || strings.ContainsAny(field.String(), "<> ") {
+			return fmt.Errorf("must be a valid email address")
+		}
+	case "uuid":
+		if _, err := uuid.Parse(field.String()); err != nil {
+			return fmt.Errorf("must be a valid UUID")
+		}
+	case "url":
+		u, err := url.ParseRequestURI(field.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("must be a valid http(s) URL")
+		}
+	case "currency":
+		if !IsCurrencyCode(field.String()) {
+			return fmt.Errorf("must be an ISO 4217 currency code")
+		}
+	case "oneof":
+		options := strings.Fields(rule.param)
+		value := fmt.Sprint(field.Interface())
+		for _, option := range options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(options, ", "))
+	case "min", "max", "len":
+		return checkSize(field, rule)
+	case "gtfield", "gtefield", "ltfield", "ltefield", "eqfield", "nefield":
+		other, ok := siblingField(parent, rule.param)
+		if !ok || v.isEmpty(other) {
+			return nil
+		}
+		return compareFields(field, other, rule)
+	default:
+		return fmt.Errorf("unknown validation rule %q", rule.name)
+	}
+	return nil
+}
+
+// checkSize enforces min/max/len: length for strings and slices, value for numbers
+func checkSize(field reflect.Value, rule validationRule) error {
+	limit, err := strconv.ParseFloat(rule.param, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s parameter %q", rule.name, rule.param)
+	}
+
+	var size float64
+	var unit string
+	switch field.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(field.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size, unit = float64(field.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = field.Float()
+	default:
+		return nil
+	}
+
+	switch {
+	case rule.name == "min" && size < limit:
+		if unit == "" {
+			return fmt.Errorf("must be at least %s", rule.param)
+		}
+		return fmt.Errorf("must be at least %s%s", rule.param, unit)
+	case rule.name == "max" && size > limit:
+		if unit == "" {
+			return fmt.Errorf("must be at most %s", rule.param)
+		}
+		return fmt.Errorf("must be at most %s%s", rule.param, unit)
+	case rule.name == "len" && size != limit:
+		return fmt.Errorf("must be exactly %s%s", rule.param, unit)
+	}
+	return nil
+}
+
+// siblingField looks up another field of the struct being validated by its Go name
+func siblingField(parent reflect.Value, name string) (reflect.Value, bool) {
+	field := parent.FieldByName(name)
+	if !field.IsValid() {
+		return reflect.Value{}, false
+	}
+	return indirect(field), true
+}
+
+// compareFields implements the cross-field rules for times, numbers and strings
+func compareFields(field, other reflect.Value, rule validationRule) error {
+	var cmp int
+	switch a := field.Interface().(type) {
+	case time.Time:
+		b, ok := other.Interface().(time.Time)
+		if !ok {
+			return nil
+		}
+		cmp = a.Compare(b)
+	default:
+		af, aok := toFloat(field)
+		bf, bok := toFloat(other)
+		switch {
+		case aok && bok:
+			cmp = compareFloat(af, bf)
+		case field.Kind() == reflect.String && other.Kind() == reflect.String:
+			cmp = strings.Compare(field.String(), other.String())
+		default:
+			return nil
+		}
+	}
+
+	otherName := strings.ToLower(rule.param[:1]) + rule.param[1:]
+	switch rule.name {
+	case "gtfield":
+		if cmp <= 0 {
+			return fmt.Errorf("must be after %s", otherName)
+		}
+	case "gtefield":
+		if cmp < 0 {
+			return fmt.Errorf("must not be before %s", otherName)
+		}
+	case "ltfield":
+		if cmp >= 0 {
+			return fmt.Errorf("must be before %s", otherName)
+		}
+	case "ltefield":
+		if cmp > 0 {
+			return fmt.Errorf("must not be after %s", otherName)
+		}
+	case "eqfield":
+		if cmp != 0 {
+			return fmt.Errorf("must match %s", otherName)
+		}
+	case "nefield":
+		if cmp == 0 {
+			return fmt.Errorf("must differ from %s", otherName)
+		}
+	}
+	return nil
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}